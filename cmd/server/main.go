@@ -13,6 +13,9 @@ import (
 	"ohabits/internal/database"
 	"ohabits/internal/handlers"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/reminders"
+	"ohabits/internal/services/uploadgc"
+	"ohabits/internal/storage"
 
 	"github.com/labstack/echo/v4"
 	echomw "github.com/labstack/echo/v4/middleware"
@@ -31,11 +34,27 @@ func main() {
 
 	log.Println("✅ تم الاتصال بقاعدة البيانات")
 
+	// Create upload storage
+	store, err := storage.New(storage.Config{
+		Backend:     cfg.StorageBackend,
+		LocalDir:    cfg.UploadsDir,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+		S3PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatalf("فشل إعداد التخزين: %v", err)
+	}
+	log.Printf("✅ التخزين: %s", cfg.StorageBackend)
+
 	// Create auth middleware
 	auth := middleware.NewAuthMiddleware(cfg.JWTSecret, db)
 
 	// Create handlers (AIService is created inside)
-	h := handlers.New(db, cfg, auth)
+	h := handlers.New(db, cfg, auth, store)
 
 	// Log AI service status
 	if h.AIService.IsConfigured() {
@@ -66,7 +85,6 @@ func main() {
 
 	// Static files
 	e.Static("/static", "static")
-	e.Static("/uploads", "uploads")
 	e.File("/favicon.ico", "static/images/icons/icon-192x192.png")
 
 	// Rate limiter صارم للـ Login (5 محاولات بالدقيقة)
@@ -88,11 +106,17 @@ func main() {
 	// Landing page or Dashboard (public with optional auth)
 	e.GET("/", h.LandingOrDashboard, auth.OptionalAuth)
 
+	// Uploaded media (owner only, or a signed URL from /api/media/sign)
+	e.GET("/uploads/*", h.ServeMedia, auth.OptionalAuth)
+
 	// Public pages
 	e.GET("/privacy", h.PrivacyPage)
 	e.GET("/terms", h.TermsPage)
 	e.GET("/support", h.SupportPage)
 
+	// Calendar subscription feed (public - the secret token is the credential)
+	e.GET("/calendar/feed/:token", h.CalendarFeed)
+
 	// Protected routes
 	protected := e.Group("")
 	protected.Use(auth.RequireAuth)
@@ -107,6 +131,12 @@ func main() {
 	protected.POST("/habits/:id/toggle", h.ToggleHabit)
 	protected.DELETE("/habits/:id", h.DeleteHabit)
 
+	// Prayer times (أوقات الصلاة)
+	protected.POST("/prayer/settings", h.SavePrayerSettings)
+	protected.GET("/api/prayer-times", h.GetPrayerTimesAPI)
+	protected.GET("/api/prayer/settings", h.GetPrayerSettingsAPI)
+	protected.PUT("/api/prayer/settings", h.UpdatePrayerSettingsAPI)
+
 	// Medications
 	protected.GET("/medications", h.MedicationsPage)
 	protected.POST("/medications", h.CreateMedication)
@@ -124,11 +154,40 @@ func main() {
 	protected.POST("/mood", h.SaveMood)
 	protected.GET("/daily-notes", h.DailyNotesPage)
 	protected.GET("/notes/search", h.SearchNotes)
+	protected.GET("/notes/revisions", h.NoteRevisionsPage)
+
+	// Journaling templates (التدوين)
+	protected.GET("/journal", h.JournalPage)
+	protected.POST("/journal/templates", h.CreateNoteTemplate)
+	protected.DELETE("/journal/templates/:id", h.DeleteNoteTemplate)
+	protected.POST("/journal/entries", h.SaveNoteEntries)
+	protected.GET("/api/journal/templates", h.GetNoteTemplatesAPI)
+	protected.POST("/api/journal/templates", h.CreateNoteTemplateAPI)
+	protected.PUT("/api/journal/templates/:id", h.UpdateNoteTemplateAPI)
+	protected.DELETE("/api/journal/templates/:id", h.DeleteNoteTemplateAPI)
+	protected.GET("/api/journal/entries", h.GetNoteEntriesAPI)
+	protected.POST("/api/journal/entries", h.SaveNoteEntriesAPI)
+	protected.GET("/api/journal/prompt", h.GetJournalPromptAPI)
+
+	// Memories (في مثل هذا اليوم)
+	protected.POST("/memories/hide", h.HideMemory)
+	protected.GET("/api/memories", h.GetMemoriesAPI)
+	protected.GET("/api/memories/hidden", h.GetHiddenMemoriesAPI)
+	protected.POST("/api/memories/hidden", h.HideMemoryAPI)
+	protected.DELETE("/api/memories/hidden/:date", h.UnhideMemoryAPI)
 
 	// Images
 	protected.POST("/images", h.UploadImages)
 	protected.DELETE("/images/:id", h.DeleteImage)
 
+	// Photo gallery (معرض الصور)
+	protected.GET("/gallery", h.GalleryPage)
+	protected.GET("/gallery/more", h.GalleryMore)
+	protected.POST("/gallery/download", h.DownloadImagesZip)
+	protected.GET("/api/images", h.GetGalleryAPI)
+	protected.GET("/api/images/months", h.GetImageMonthsAPI)
+	protected.POST("/api/images/download", h.DownloadImagesZip)
+
 	// Workouts
 	protected.GET("/workouts", h.WorkoutsPage)
 	protected.POST("/workouts", h.CreateWorkout)
@@ -136,12 +195,42 @@ func main() {
 	protected.DELETE("/workouts/:id", h.DeleteWorkout)
 	protected.POST("/workouts/reorder", h.ReorderWorkouts)
 	protected.POST("/workout-log", h.SaveWorkoutLog)
+	protected.POST("/workout-log/cardio/import", h.ImportCardio)
+	protected.POST("/api/workouts/cardio/import", h.ImportCardioAPI)
+	protected.GET("/api/workouts/exercises/:name/progress", h.GetExerciseProgressAPI)
+	protected.GET("/api/workouts/muscle-groups", h.GetMuscleGroupSetsAPI)
+
+	// Exercise library (مكتبة التمارين)
+	protected.GET("/exercises", h.ExerciseLibraryPage)
+	protected.POST("/exercises", h.CreateLibraryExercise)
+	protected.DELETE("/exercises/:id", h.DeleteLibraryExercise)
+	protected.GET("/api/exercises", h.GetExerciseLibraryAPI)
+	protected.POST("/api/exercises", h.CreateLibraryExerciseAPI)
+	protected.PUT("/api/exercises/:id", h.UpdateLibraryExerciseAPI)
+	protected.DELETE("/api/exercises/:id", h.DeleteLibraryExerciseAPI)
+	protected.GET("/api/exercises/:id/substitutions", h.GetExerciseSubstitutionsAPI)
+
+	// Training programs (البرامج التدريبية)
+	protected.GET("/programs", h.ProgramsPage)
+	protected.POST("/programs", h.CreateProgram)
+	protected.POST("/programs/:id/activate", h.ActivateProgram)
+	protected.DELETE("/programs/:id", h.DeleteProgram)
+	protected.GET("/api/programs", h.GetProgramsAPI)
+	protected.POST("/api/programs", h.CreateProgramAPI)
+	protected.GET("/api/programs/today", h.GetScheduledWorkoutAPI)
+	protected.POST("/api/programs/deactivate", h.DeactivateProgramsAPI)
+	protected.PUT("/api/programs/:id", h.UpdateProgramAPI)
+	protected.DELETE("/api/programs/:id", h.DeleteProgramAPI)
+	protected.POST("/api/programs/:id/activate", h.ActivateProgramAPI)
 
 	// Profile
 	protected.GET("/profile", h.ProfilePage)
 	protected.POST("/profile/info", h.UpdateProfileInfo)
 	protected.POST("/profile/password", h.UpdateProfilePassword)
 	protected.POST("/profile/avatar", h.UpdateProfileAvatar)
+	protected.POST("/profile/calendar-feed", h.SaveCalendarFeedOptions)
+	protected.POST("/profile/calendar-feed/rotate", h.RotateCalendarFeed)
+	protected.DELETE("/profile/calendar-feed", h.DeleteCalendarFeed)
 
 	// Blog
 	protected.GET("/blog", h.BlogPage)
@@ -149,6 +238,7 @@ func main() {
 	protected.GET("/blog/new", h.BlogNewPage)
 	protected.GET("/blog/:id", h.BlogViewPage)
 	protected.GET("/blog/:id/edit", h.BlogEditPage)
+	protected.GET("/blog/:id/revisions", h.BlogRevisionsPage)
 	protected.POST("/blog/:id/save", h.BlogSave)
 	protected.DELETE("/blog/:id", h.BlogDelete)
 	protected.POST("/blog/upload-image", h.BlogUploadImage)
@@ -159,11 +249,61 @@ func main() {
 	protected.POST("/api/ai/format-markdown", aiHandler.FormatMarkdown)
 	protected.POST("/api/ai/custom-prompt", aiHandler.CustomPrompt)
 
+	// Edit history for notes and blog posts (سجل التعديلات)
+	protected.POST("/revisions/:id/restore", h.RestoreRevision)
+	protected.GET("/api/revisions", h.GetRevisionsAPI)
+	protected.GET("/api/revisions/:id", h.GetRevisionAPI)
+	protected.POST("/api/revisions/:id/restore", h.RestoreRevisionAPI)
+
+	// Body weight and measurements (الوزن والقياسات)
+	protected.GET("/body", h.BodyMetricsPage)
+	protected.POST("/body", h.SaveBodyMetric)
+	protected.DELETE("/body/:id", h.DeleteBodyMetric)
+	protected.POST("/body/settings", h.SaveBodyMetricSettings)
+	protected.GET("/api/body-metrics", h.GetBodyMetricsAPI)
+	protected.POST("/api/body-metrics", h.SaveBodyMetricAPI)
+	protected.GET("/api/body-metrics/settings", h.GetBodyMetricSettingsAPI)
+	protected.PUT("/api/body-metrics/settings", h.UpdateBodyMetricSettingsAPI)
+	protected.DELETE("/api/body-metrics/:id", h.DeleteBodyMetricAPI)
+
+	// Weekly / monthly review (المراجعة)
+	protected.GET("/review", h.ReviewPage)
+	protected.GET("/api/review", h.GetReviewAPI)
+
+	// Insights (الرؤى)
+	protected.GET("/insights", h.InsightsPage)
+	protected.GET("/api/insights", h.GetInsightsAPI)
+
 	// Calendar Events (الرزنامة)
 	protected.GET("/calendar", h.CalendarPage)
 	protected.POST("/calendar", h.CreateCalendarEvent)
 	protected.PUT("/calendar/:id", h.UpdateCalendarEvent)
 	protected.DELETE("/calendar/:id", h.DeleteCalendarEvent)
+	protected.GET("/calendar/hijri", h.HijriDateHint)
+	protected.GET("/calendar/month", h.CalendarMonth)
+	protected.POST("/calendar/settings", h.SaveCalendarSettings)
+	protected.POST("/calendar/:id/occurrences/:date/cancel", h.CancelCalendarOccurrence)
+	protected.PUT("/calendar/:id/occurrences/:date", h.UpdateCalendarOccurrence)
+	protected.DELETE("/calendar/:id/occurrences/:date", h.RestoreCalendarOccurrence)
+	protected.POST("/notifications/:id/dismiss", h.DismissEventNotification)
+	protected.GET("/api/hijri", h.GetHijriDateAPI)
+	protected.GET("/api/calendar/islamic-holidays", h.GetIslamicHolidaysAPI)
+	protected.GET("/api/calendar/settings", h.GetCalendarSettingsAPI)
+	protected.PUT("/api/calendar/settings", h.UpdateCalendarSettingsAPI)
+	protected.GET("/api/calendar/occurrences", h.GetCalendarRangeAPI)
+	protected.GET("/api/calendar/:id/occurrences", h.GetCalendarOccurrencesAPI)
+	protected.PUT("/api/calendar/:id/occurrences/:date", h.UpdateCalendarOccurrenceAPI)
+	protected.DELETE("/api/calendar/:id/occurrences/:date", h.RestoreCalendarOccurrenceAPI)
+	protected.GET("/api/notifications", h.GetNotificationsAPI)
+	protected.POST("/api/notifications/:id/delivered", h.MarkNotificationDeliveredAPI)
+	protected.POST("/calendar/import", h.PreviewCalendarImport)
+	protected.POST("/calendar/import/confirm", h.ConfirmCalendarImport)
+	protected.POST("/api/calendar/import/preview", h.PreviewCalendarImportAPI)
+	protected.POST("/api/calendar/import", h.ImportCalendarAPI)
+	protected.GET("/api/calendar/feed", h.GetCalendarFeedAPI)
+	protected.PUT("/api/calendar/feed", h.UpdateCalendarFeedAPI)
+	protected.POST("/api/calendar/feed/rotate", h.RotateCalendarFeedAPI)
+	protected.DELETE("/api/calendar/feed", h.DeleteCalendarFeedAPI)
 
 	// Sync API (للتطبيق الأصلي iOS/macOS)
 	protected.GET("/api/sync/all", h.SyncAll)
@@ -176,6 +316,7 @@ func main() {
 	protected.PUT("/api/user/profile", h.UpdateProfileAPI)
 	protected.POST("/api/user/profile/image", h.UploadProfileImageAPI)
 	protected.DELETE("/api/user/profile/image", h.DeleteProfileImageAPI)
+	protected.GET("/api/user/storage", h.GetStorageUsageAPI)
 	protected.DELETE("/api/user/account", h.DeleteAccountAPI)
 
 	// User API (للتطبيق الأصلي)
@@ -191,6 +332,9 @@ func main() {
 	protected.POST("/api/blog/images", h.UploadBlogImageAPI)
 	protected.DELETE("/api/blog/images/:id", h.DeleteBlogImageAPI)
 
+	// Signed media URLs (API)
+	protected.POST("/api/media/sign", h.SignMediaURLsAPI)
+
 	// Projects API (للتطبيق الأصلي)
 	protected.GET("/api/projects", h.GetProjects)
 	protected.GET("/api/projects/:id", h.GetProject)
@@ -204,6 +348,11 @@ func main() {
 	protected.PUT("/api/tasks/:id", h.UpdateTaskAPI)
 	protected.DELETE("/api/tasks/:id", h.DeleteTaskAPI)
 
+	// Task Dependencies API
+	protected.GET("/api/tasks/:id/dependencies", h.GetTaskDependenciesAPI)
+	protected.POST("/api/tasks/:id/dependencies", h.CreateTaskDependencyAPI)
+	protected.DELETE("/api/dependencies/:id", h.DeleteTaskDependencyAPI)
+
 	// Task Comments API
 	protected.GET("/api/tasks/:id/comments", h.GetTaskComments)
 	protected.POST("/api/tasks/:id/comments", h.CreateTaskCommentAPI)
@@ -220,6 +369,28 @@ func main() {
 	admin.GET("", h.AdminDashboard)
 	admin.DELETE("/users/:id", h.DeleteUser)
 
+	// Clean up orphaned uploads in the background
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	defer stopGC()
+	if cfg.UploadGCIntervalHours > 0 {
+		gc := &uploadgc.Collector{
+			DB:          db,
+			Store:       store,
+			GracePeriod: time.Duration(cfg.UploadGCGraceHours) * time.Hour,
+		}
+		go gc.RunEvery(gcCtx, time.Duration(cfg.UploadGCIntervalHours)*time.Hour)
+		log.Printf("✅ تنظيف الملفات اليتيمة كل %d ساعة", cfg.UploadGCIntervalHours)
+	}
+
+	// Queue calendar reminders as they come due
+	remindCtx, stopReminders := context.WithCancel(context.Background())
+	defer stopReminders()
+	if cfg.ReminderIntervalMinutes > 0 {
+		scheduler := &reminders.Scheduler{DB: db}
+		go scheduler.RunEvery(remindCtx, time.Duration(cfg.ReminderIntervalMinutes)*time.Minute)
+		log.Printf("✅ تذكيرات التقويم كل %d دقيقة", cfg.ReminderIntervalMinutes)
+	}
+
 	// Start server
 	go func() {
 		addr := ":" + cfg.Port
@@ -235,6 +406,8 @@ func main() {
 	<-quit
 
 	log.Println("⏳ إيقاف السيرفر...")
+	stopGC()
+	stopReminders()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
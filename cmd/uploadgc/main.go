@@ -0,0 +1,63 @@
+// Command uploadgc runs one orphaned-upload cleanup pass.
+//
+//	go run ./cmd/uploadgc -dry-run        # list orphans only
+//	go run ./cmd/uploadgc -grace 24h      # delete files orphaned for over a day
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"ohabits/internal/config"
+	"ohabits/internal/database"
+	"ohabits/internal/services/uploadgc"
+	"ohabits/internal/storage"
+)
+
+func main() {
+	cfg := config.Load()
+
+	dryRun := flag.Bool("dry-run", false, "list orphaned files without deleting them")
+	grace := flag.Duration("grace", time.Duration(cfg.UploadGCGraceHours)*time.Hour, "only collect files orphaned for longer than this")
+	flag.Parse()
+
+	db, err := database.New(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("فشل الاتصال بقاعدة البيانات: %v", err)
+	}
+	defer db.Close()
+
+	store, err := storage.New(storage.Config{
+		Backend:     cfg.StorageBackend,
+		LocalDir:    cfg.UploadsDir,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+		S3PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatalf("فشل إعداد التخزين: %v", err)
+	}
+
+	gc := &uploadgc.Collector{DB: db, Store: store, GracePeriod: *grace, DryRun: *dryRun}
+	report, err := gc.Run(context.Background())
+	if err != nil {
+		log.Fatalf("فشل التنظيف: %v", err)
+	}
+
+	for _, obj := range report.Orphans {
+		fmt.Printf("%s\t%d\t%s\n", obj.Key, obj.Size, obj.ModTime.Format(time.RFC3339))
+	}
+	fmt.Printf("\nscanned: %d\norphans: %d (%d bytes)\nstale blog images: %d\n",
+		report.Scanned, len(report.Orphans), report.OrphanBytes, report.StaleBlogImages)
+	if *dryRun {
+		fmt.Println("dry run: nothing deleted")
+	} else {
+		fmt.Printf("deleted: %d\nfailed: %d\n", report.Deleted, report.Failed)
+	}
+}
@@ -9,11 +9,12 @@ import (
 
 // AdminStats holds general statistics for admin dashboard
 type AdminStats struct {
-	TotalUsers      int `json:"total_users"`
-	ActiveThisWeek  int `json:"active_this_week"`
-	AdminCount      int `json:"admin_count"`
-	SubscribedCount int `json:"subscribed_count"`
-	NormalCount     int `json:"normal_count"`
+	TotalUsers      int   `json:"total_users"`
+	ActiveThisWeek  int   `json:"active_this_week"`
+	AdminCount      int   `json:"admin_count"`
+	SubscribedCount int   `json:"subscribed_count"`
+	NormalCount     int   `json:"normal_count"`
+	StorageBytes    int64 `json:"storage_bytes"` // All users' uploads
 }
 
 // UserStats holds statistics for a single user
@@ -28,6 +29,8 @@ type UserStats struct {
 	BlogCount    int        `json:"blog_count"`
 	LastActivity *time.Time `json:"last_activity"`
 	CreatedAt    time.Time  `json:"created_at"`
+	StorageBytes int64      `json:"storage_bytes"`
+	QuotaBytes   int64      `json:"quota_bytes"` // Set by the handler from the role, 0 = unlimited
 }
 
 // GetAdminStats returns overall system statistics
@@ -70,6 +73,18 @@ func (db *DB) GetAdminStats(ctx context.Context) (*AdminStats, error) {
 		return nil, err
 	}
 
+	// Storage used by all uploads
+	err = db.Pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COALESCE(SUM(stored_bytes), 0) FROM daily_images WHERE deleted_at IS NULL) +
+			(SELECT COALESCE(SUM(stored_bytes), 0) FROM blog_images WHERE is_deleted = false) +
+			(SELECT COALESCE(SUM(file_size), 0) FROM task_attachments WHERE COALESCE(is_deleted, false) = false) +
+			(SELECT COALESCE(SUM(avatar_bytes), 0) FROM users)
+	`).Scan(&stats.StorageBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
@@ -90,7 +105,8 @@ func (db *DB) GetAllUsersStats(ctx context.Context) ([]UserStats, error) {
 				COALESCE(hc.last_habit, u.created_at),
 				COALESCE(nc.last_note, u.created_at),
 				COALESCE(mc.last_mood, u.created_at)
-			) as last_activity
+			) as last_activity,
+			COALESCE(s.storage_bytes, 0) + u.avatar_bytes as storage_bytes
 		FROM users u
 		LEFT JOIN (
 			SELECT user_id, COUNT(*) as habits_count
@@ -122,6 +138,17 @@ func (db *DB) GetAllUsersStats(ctx context.Context) ([]UserStats, error) {
 			FROM mood_ratings
 			GROUP BY user_id
 		) mc ON u.id = mc.user_id
+		LEFT JOIN (
+			SELECT user_id, SUM(bytes) as storage_bytes
+			FROM (
+				SELECT user_id, stored_bytes as bytes FROM daily_images WHERE deleted_at IS NULL
+				UNION ALL
+				SELECT user_id, stored_bytes FROM blog_images WHERE is_deleted = false
+				UNION ALL
+				SELECT user_id, file_size FROM task_attachments WHERE COALESCE(is_deleted, false) = false
+			) files
+			GROUP BY user_id
+		) s ON u.id = s.user_id
 		ORDER BY last_activity DESC NULLS LAST
 	`)
 	if err != nil {
@@ -143,6 +170,7 @@ func (db *DB) GetAllUsersStats(ctx context.Context) ([]UserStats, error) {
 			&u.NotesCount,
 			&u.BlogCount,
 			&u.LastActivity,
+			&u.StorageBytes,
 		)
 		if err != nil {
 			return nil, err
@@ -120,9 +120,34 @@ func (db *DB) CreateBlogPost(ctx context.Context, userID uuid.UUID, title string
 }
 
 // UpdateBlogPost updates an existing blog post
-func (db *DB) UpdateBlogPost(ctx context.Context, userID uuid.UUID, postID uuid.UUID, title, content string) (*MarkdownNote, error) {
+// The previous title and content are kept as a revision; source is one of the RevisionSource constants
+func (db *DB) UpdateBlogPost(ctx context.Context, userID uuid.UUID, postID uuid.UUID, title, content, source string) (*MarkdownNote, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var prevTitle, prevContent string
+	err = tx.QueryRow(ctx, `
+		SELECT title, content FROM markdown_notes
+		WHERE id = $1 AND user_id = $2 AND is_deleted = false
+		FOR UPDATE
+	`, postID, userID).Scan(&prevTitle, &prevContent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if prevContent != "" && (prevTitle != title || prevContent != content) {
+		if err := saveRevision(ctx, tx, userID, RevisionEntityMarkdownNote, postID, prevTitle, prevContent, source); err != nil {
+			return nil, err
+		}
+	}
+
 	var p MarkdownNote
-	err := db.Pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		UPDATE markdown_notes
 		SET title = $3, content = $4, updated_at = NOW()
 		WHERE id = $1 AND user_id = $2 AND is_deleted = false
@@ -130,9 +155,10 @@ func (db *DB) UpdateBlogPost(ctx context.Context, userID uuid.UUID, postID uuid.
 	`, postID, userID, title, content).Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsRTL, &p.IsDeleted, &p.CreatedAt, &p.UpdatedAt)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 
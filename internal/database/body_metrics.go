@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Largest accepted measurements, well inside the columns' NUMERIC precision
+const (
+	MaxWeightKg   = 700 // NUMERIC(6,2)
+	MaxBodyFatPct = 100 // NUMERIC(4,1)
+	MaxLengthCm   = 400 // NUMERIC(5,1)
+)
+
+const bodyMetricColumns = `id, user_id, date, weight_kg::float8, body_fat_pct::float8, waist_cm::float8,
+	chest_cm::float8, hips_cm::float8, arm_cm::float8, thigh_cm::float8, neck_cm::float8,
+	notes, created_at, updated_at`
+
+func scanBodyMetric(row pgx.Row) (*BodyMetric, error) {
+	var m BodyMetric
+	err := row.Scan(
+		&m.ID, &m.UserID, &m.Date, &m.WeightKg, &m.BodyFatPct, &m.WaistCm,
+		&m.ChestCm, &m.HipsCm, &m.ArmCm, &m.ThighCm, &m.NeckCm,
+		&m.Notes, &m.CreatedAt, &m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetBodyMetrics retrieves body metric entries between from and to, oldest first
+func (db *DB) GetBodyMetrics(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]BodyMetric, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+bodyMetricColumns+`
+		FROM body_metrics
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []BodyMetric
+	for rows.Next() {
+		m, err := scanBodyMetric(rows)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *m)
+	}
+
+	return metrics, rows.Err()
+}
+
+// SaveBodyMetric creates or replaces the entry for m.Date
+func (db *DB) SaveBodyMetric(ctx context.Context, userID uuid.UUID, m BodyMetric) (*BodyMetric, error) {
+	return scanBodyMetric(db.Pool.QueryRow(ctx, `
+		INSERT INTO body_metrics (user_id, date, weight_kg, body_fat_pct, waist_cm, chest_cm, hips_cm, arm_cm, thigh_cm, neck_cm, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, date) DO UPDATE SET
+			weight_kg = $3, body_fat_pct = $4, waist_cm = $5, chest_cm = $6, hips_cm = $7,
+			arm_cm = $8, thigh_cm = $9, neck_cm = $10, notes = $11, updated_at = NOW()
+		RETURNING `+bodyMetricColumns,
+		userID, m.Date.Format("2006-01-02"), m.WeightKg, m.BodyFatPct, m.WaistCm, m.ChestCm,
+		m.HipsCm, m.ArmCm, m.ThighCm, m.NeckCm, m.Notes,
+	))
+}
+
+// saveBodyWeight records only the weight for a day, keeping any measurements already logged
+func saveBodyWeight(ctx context.Context, tx pgx.Tx, userID uuid.UUID, date time.Time, weightKg float64) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO body_metrics (user_id, date, weight_kg)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, date) DO UPDATE SET weight_kg = $3, updated_at = NOW()
+	`, userID, date.Format("2006-01-02"), weightKg)
+	return err
+}
+
+// DeleteBodyMetric deletes a body metric entry owned by the user
+func (db *DB) DeleteBodyMetric(ctx context.Context, userID, id uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM body_metrics WHERE id = $1 AND user_id = $2`, id, userID)
+	return err
+}
+
+// GetBodyMetricSettings returns the unit and goal weight, defaulting to kg with no goal
+func (db *DB) GetBodyMetricSettings(ctx context.Context, userID uuid.UUID) (*BodyMetricSettings, error) {
+	settings := BodyMetricSettings{Unit: UnitKg}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT unit, goal_weight_kg::float8 FROM body_metric_settings WHERE user_id = $1
+	`, userID).Scan(&settings.Unit, &settings.GoalWeightKg)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveBodyMetricSettings stores the unit and goal weight
+func (db *DB) SaveBodyMetricSettings(ctx context.Context, userID uuid.UUID, settings BodyMetricSettings) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO body_metric_settings (user_id, unit, goal_weight_kg, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET unit = $2, goal_weight_kg = $3, updated_at = NOW()
+	`, userID, settings.Unit, settings.GoalWeightKg)
+	return err
+}
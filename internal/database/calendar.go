@@ -2,15 +2,62 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"sort"
 	"time"
 
+	"ohabits/internal/services/hijri"
+
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
-// GetCalendarEventsByUserID retrieves all calendar events for a user
+const calendarEventColumns = `id, user_id, title, event_type, event_date, end_date, is_recurring, calendar_type, rrule,
+	to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), time_zone, reminders,
+	notes, created_at, updated_at, is_deleted`
+
+// scanCalendarEvent reads a row selected with calendarEventColumns
+func scanCalendarEvent(row pgx.Row) (*CalendarEvent, error) {
+	var e CalendarEvent
+	var rule, startTime, endTime, timeZone, notes *string
+	var remindersJSON []byte
+	err := row.Scan(
+		&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &e.CalendarType, &rule,
+		&startTime, &endTime, &timeZone, &remindersJSON,
+		&notes, &e.CreatedAt, &e.UpdatedAt, &e.IsDeleted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		e.RRule = *rule
+	}
+	if startTime != nil {
+		e.StartTime = *startTime
+	}
+	if endTime != nil {
+		e.EndTime = *endTime
+	}
+	if timeZone != nil {
+		e.TimeZone = *timeZone
+	}
+	if notes != nil {
+		e.Notes = *notes
+	}
+	if len(remindersJSON) > 0 {
+		if err := json.Unmarshal(remindersJSON, &e.Reminders); err != nil {
+			return nil, err
+		}
+	}
+	return &e, nil
+}
+
+// GetCalendarEventsByUserID retrieves all calendar events for a user, with the
+// exceptions of rule series
 func (db *DB) GetCalendarEventsByUserID(ctx context.Context, userID uuid.UUID) ([]CalendarEvent, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, title, event_type, event_date, end_date, is_recurring, notes, created_at, updated_at
+		SELECT `+calendarEventColumns+`
 		FROM calendar_events
 		WHERE user_id = $1 AND is_deleted = false
 		ORDER BY EXTRACT(MONTH FROM event_date), EXTRACT(DAY FROM event_date)
@@ -22,24 +69,27 @@ func (db *DB) GetCalendarEventsByUserID(ctx context.Context, userID uuid.UUID) (
 
 	var events []CalendarEvent
 	for rows.Next() {
-		var e CalendarEvent
-		var notes *string
-		if err := rows.Scan(&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		e, err := scanCalendarEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		if notes != nil {
-			e.Notes = *notes
-		}
-		events = append(events, e)
+		events = append(events, *e)
 	}
 
-	return events, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.attachCalendarEventExceptions(ctx, userID, events); err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
 // GetCalendarEventsByType retrieves calendar events of a specific type for a user
 func (db *DB) GetCalendarEventsByType(ctx context.Context, userID uuid.UUID, eventType string) ([]CalendarEvent, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, title, event_type, event_date, end_date, is_recurring, notes, created_at, updated_at
+		SELECT `+calendarEventColumns+`
 		FROM calendar_events
 		WHERE user_id = $1 AND event_type = $2 AND is_deleted = false
 		ORDER BY EXTRACT(MONTH FROM event_date), EXTRACT(DAY FROM event_date)
@@ -51,124 +101,149 @@ func (db *DB) GetCalendarEventsByType(ctx context.Context, userID uuid.UUID, eve
 
 	var events []CalendarEvent
 	for rows.Next() {
-		var e CalendarEvent
-		var notes *string
-		if err := rows.Scan(&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		e, err := scanCalendarEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		if notes != nil {
-			e.Notes = *notes
-		}
-		events = append(events, e)
+		events = append(events, *e)
 	}
 
 	return events, rows.Err()
 }
 
-// GetCalendarEventsForDay retrieves calendar events for a specific date (including recurring yearly events and date ranges)
-func (db *DB) GetCalendarEventsForDay(ctx context.Context, userID uuid.UUID, date time.Time) ([]CalendarEventForDay, error) {
-	// For recurring events: match month and day (for start date or within range)
-	// For non-recurring events: match exact date or within date range
-	// Date range: event_date <= date <= end_date
+// GetCalendarOccurrences expands the user's events into the occurrences that
+// overlap [from, to] (inclusive civil dates), in one query: yearly events on
+// the Gregorian or Hijri calendar, rule series with their exceptions, and
+// single and multi-day events. Occurrences are in date order, all-day ones
+// before timed ones on the same day.
+func (db *DB) GetCalendarOccurrences(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]CalendarEventForDay, error) {
+	from, to = civilDate(from), civilDate(to)
+
+	// Recurring events can occur in any range after they start; the others
+	// only when their dates overlap it
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, title, event_type, event_date, end_date, is_recurring, notes, created_at, updated_at
+		SELECT `+calendarEventColumns+`
 		FROM calendar_events
-		WHERE user_id = $1 AND is_deleted = false AND (
-			-- Single day events (no end_date)
-			(end_date IS NULL AND (
-				(is_recurring = true AND EXTRACT(MONTH FROM event_date) = $2 AND EXTRACT(DAY FROM event_date) = $3)
-				OR
-				(is_recurring = false AND event_date = $4)
-			))
-			OR
-			-- Date range events (has end_date)
-			(end_date IS NOT NULL AND (
-				(is_recurring = false AND event_date <= $4 AND end_date >= $4)
-				OR
-				(is_recurring = true AND (
-					-- For recurring ranges, we need to check if current date falls within the "adjusted" range for this year
-					(EXTRACT(MONTH FROM event_date) < $2 OR (EXTRACT(MONTH FROM event_date) = $2 AND EXTRACT(DAY FROM event_date) <= $3))
-					AND
-					(EXTRACT(MONTH FROM end_date) > $2 OR (EXTRACT(MONTH FROM end_date) = $2 AND EXTRACT(DAY FROM end_date) >= $3))
-				))
-			))
-		)
-		ORDER BY event_type, title
-	`, userID, int(date.Month()), date.Day(), date.Format("2006-01-02"))
+		WHERE user_id = $1 AND is_deleted = false AND event_date <= $3
+		  AND (is_recurring = true OR rrule IS NOT NULL OR COALESCE(end_date, event_date) >= $2)
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var events []CalendarEventForDay
+	var events []CalendarEvent
 	for rows.Next() {
-		var e CalendarEventForDay
-		var notes *string
-		if err := rows.Scan(&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		e, err := scanCalendarEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		if notes != nil {
-			e.Notes = *notes
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.attachCalendarEventExceptions(ctx, userID, events); err != nil {
+		return nil, err
+	}
+
+	occurrences := []CalendarEventForDay{}
+	for _, e := range events {
+		occurrences = append(occurrences, EventOccurrences(e, from, to)...)
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		a, b := occurrences[i], occurrences[j]
+		if !a.EventDate.Equal(b.EventDate) {
+			return a.EventDate.Before(b.EventDate)
 		}
+		return lessForDay(a, b)
+	})
+	return occurrences, nil
+}
 
-		// Calculate years since the event (for birthdays)
-		e.YearsAgo = date.Year() - e.EventDate.Year()
-		e.IsToday = date.Month() == e.EventDate.Month() && date.Day() == e.EventDate.Day()
+// lessForDay orders the events of one day: all-day events first, then timed
+// ones by start time, then by type and title
+func lessForDay(a, b CalendarEventForDay) bool {
+	if a.StartTime != b.StartTime {
+		return a.StartTime < b.StartTime
+	}
+	if a.EventType != b.EventType {
+		return a.EventType < b.EventType
+	}
+	return a.Title < b.Title
+}
 
-		events = append(events, e)
+// GetCalendarEventsForDay retrieves the calendar events on a specific date
+// (including recurring events and date ranges that cover it)
+func (db *DB) GetCalendarEventsForDay(ctx context.Context, userID uuid.UUID, date time.Time) ([]CalendarEventForDay, error) {
+	day := civilDate(date)
+	events, err := db.GetCalendarOccurrences(ctx, userID, day, day)
+	if err != nil {
+		return nil, err
 	}
 
-	return events, rows.Err()
+	for i := range events {
+		events[i].IsToday = events[i].EventDate.Equal(day)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return lessForDay(events[i], events[j]) })
+	return events, nil
 }
 
-// GetDatesWithEventsForWeek returns a map of dates (YYYY-MM-DD format) that have events within the week
-func (db *DB) GetDatesWithEventsForWeek(ctx context.Context, userID uuid.UUID, weekStart time.Time) (map[string]bool, error) {
-	result := make(map[string]bool)
+// NextHijriOccurrence returns the Gregorian date of the first yearly Hijri
+// occurrence of e on or after from
+func NextHijriOccurrence(e CalendarEvent, from time.Time) time.Time {
+	origin := hijri.FromTime(e.EventDate)
+	day := civilDate(from)
+	y := hijri.FromTime(day).Year
+	start := origin.AddYears(y - origin.Year).Time()
+	if start.Before(day) {
+		start = origin.AddYears(y + 1 - origin.Year).Time()
+	}
+	return start
+}
 
-	// Check each day of the week
-	for i := 0; i < 7; i++ {
-		day := weekStart.AddDate(0, 0, i)
-		dateStr := day.Format("2006-01-02")
-
-		var count int
-		err := db.Pool.QueryRow(ctx, `
-			SELECT COUNT(*)
-			FROM calendar_events
-			WHERE user_id = $1 AND is_deleted = false AND (
-				-- Single day events
-				(end_date IS NULL AND (
-					(is_recurring = true AND EXTRACT(MONTH FROM event_date) = $2 AND EXTRACT(DAY FROM event_date) = $3)
-					OR
-					(is_recurring = false AND event_date = $4)
-				))
-				OR
-				-- Date range events
-				(end_date IS NOT NULL AND (
-					(is_recurring = false AND event_date <= $4 AND end_date >= $4)
-					OR
-					(is_recurring = true AND (
-						(EXTRACT(MONTH FROM event_date) < $2 OR (EXTRACT(MONTH FROM event_date) = $2 AND EXTRACT(DAY FROM event_date) <= $3))
-						AND
-						(EXTRACT(MONTH FROM end_date) > $2 OR (EXTRACT(MONTH FROM end_date) = $2 AND EXTRACT(DAY FROM end_date) >= $3))
-					))
-				))
-			)
-		`, userID, int(day.Month()), day.Day(), dateStr).Scan(&count)
-		if err != nil {
-			return nil, err
-		}
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
 
-		if count > 0 {
-			result[dateStr] = true
-		}
+// GetDatesWithEvents returns the dates (YYYY-MM-DD) in [from, to] that have
+// events, including each day of multi-day events
+func (db *DB) GetDatesWithEvents(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[string]bool, error) {
+	occurrences, err := db.GetCalendarOccurrences(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
 	}
 
+	result := make(map[string]bool)
+	for _, o := range occurrences {
+		end := o.EventDate
+		if o.HasDateRange() {
+			end = *o.EndDate
+		}
+		for d := o.EventDate; !d.After(end); d = d.AddDate(0, 0, 1) {
+			result[d.Format("2006-01-02")] = true
+		}
+	}
 	return result, nil
 }
 
-// CreateCalendarEvent creates a new calendar event
-func (db *DB) CreateCalendarEvent(ctx context.Context, userID uuid.UUID, title, eventType string, eventDate time.Time, endDate *time.Time, isRecurring bool, notes string) (*CalendarEvent, error) {
-	var e CalendarEvent
+// CreateCalendarEvent creates a new calendar event. calendarType is the calendar a
+// yearly event repeats on (CalendarHijri or, by default, CalendarGregorian);
+// a non-empty rule makes it a recurring series on the Gregorian calendar instead.
+func (db *DB) CreateCalendarEvent(ctx context.Context, userID uuid.UUID, title, eventType string, eventDate time.Time, endDate *time.Time, isRecurring bool, calendarType, rule, notes string) (*CalendarEvent, error) {
+	if calendarType != CalendarHijri {
+		calendarType = CalendarGregorian
+	}
+
+	rulePtr, err := normalizeRRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	if rulePtr != nil {
+		isRecurring = true
+		calendarType = CalendarGregorian
+	}
+
 	var notesPtr *string
 	if notes != "" {
 		notesPtr = &notes
@@ -180,27 +255,104 @@ func (db *DB) CreateCalendarEvent(ctx context.Context, userID uuid.UUID, title,
 		endDateStr = &s
 	}
 
-	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO calendar_events (user_id, title, event_type, event_date, end_date, is_recurring, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, user_id, title, event_type, event_date, end_date, is_recurring, notes, created_at, updated_at
-	`, userID, title, eventType, eventDate.Format("2006-01-02"), endDateStr, isRecurring, notesPtr).Scan(
-		&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &notesPtr, &e.CreatedAt, &e.UpdatedAt,
-	)
+	return scanCalendarEvent(db.Pool.QueryRow(ctx, `
+		INSERT INTO calendar_events (user_id, title, event_type, event_date, end_date, is_recurring, calendar_type, rrule, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+calendarEventColumns+`
+	`, userID, title, eventType, eventDate.Format("2006-01-02"), endDateStr, isRecurring, calendarType, rulePtr, notesPtr))
+}
 
+// ImportCalendarEvents creates events with their times, reminders and
+// exceptions in one transaction, so a failed import leaves nothing behind
+func (db *DB) ImportCalendarEvents(ctx context.Context, userID uuid.UUID, events []CalendarEvent) ([]CalendarEvent, error) {
+	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	if notesPtr != nil {
-		e.Notes = *notesPtr
+	created := make([]CalendarEvent, 0, len(events))
+	for _, e := range events {
+		if e.CalendarType != CalendarHijri {
+			e.CalendarType = CalendarGregorian
+		}
+		rulePtr, err := normalizeRRule(e.RRule)
+		if err != nil {
+			return nil, err
+		}
+		if rulePtr != nil {
+			e.IsRecurring = true
+			e.CalendarType = CalendarGregorian
+		}
+		start, end, zone, err := eventTimeArgs(e.StartTime, e.EndTime, e.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		remindersJSON, err := json.Marshal(normalizeReminders(e.Reminders))
+		if err != nil {
+			return nil, err
+		}
+
+		var notesPtr *string
+		if e.Notes != "" {
+			notesPtr = &e.Notes
+		}
+		var endDateStr *string
+		if e.EndDate != nil {
+			s := e.EndDate.Format("2006-01-02")
+			endDateStr = &s
+		}
+
+		event, err := scanCalendarEvent(tx.QueryRow(ctx, `
+			INSERT INTO calendar_events (user_id, title, event_type, event_date, end_date, is_recurring, calendar_type, rrule, notes,
+			                             start_time, end_time, time_zone, reminders)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING `+calendarEventColumns+`
+		`, userID, e.Title, e.EventType, e.EventDate.Format("2006-01-02"), endDateStr, e.IsRecurring, e.CalendarType, rulePtr, notesPtr,
+			start, end, zone, remindersJSON))
+		if err != nil {
+			return nil, err
+		}
+
+		if rulePtr != nil {
+			for _, x := range e.Exceptions {
+				x.EventID = event.ID
+				if err := saveEventException(ctx, tx, userID, &x); err != nil {
+					return nil, err
+				}
+				event.Exceptions = append(event.Exceptions, x)
+			}
+		}
+		created = append(created, *event)
 	}
 
-	return &e, nil
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-// UpdateCalendarEvent updates an existing calendar event
-func (db *DB) UpdateCalendarEvent(ctx context.Context, eventID uuid.UUID, title, eventType string, eventDate time.Time, endDate *time.Time, isRecurring bool, notes string) error {
+// UpdateCalendarEvent updates an existing calendar event. An empty calendarType
+// keeps the current one (for clients that don't know about Hijri recurrence),
+// and so does a nil rule, which also keeps a rule series recurring whatever
+// isRecurring says; an empty rule makes the event yearly again.
+func (db *DB) UpdateCalendarEvent(ctx context.Context, eventID uuid.UUID, title, eventType string, eventDate time.Time, endDate *time.Time, isRecurring bool, calendarType string, rule *string, notes string) error {
+	if calendarType != "" && calendarType != CalendarHijri {
+		calendarType = CalendarGregorian
+	}
+
+	var rulePtr *string
+	if rule != nil {
+		var err error
+		if rulePtr, err = normalizeRRule(*rule); err != nil {
+			return err
+		}
+		if rulePtr != nil {
+			isRecurring = true
+			calendarType = CalendarGregorian
+		}
+	}
+
 	var notesPtr *string
 	if notes != "" {
 		notesPtr = &notes
@@ -214,9 +366,13 @@ func (db *DB) UpdateCalendarEvent(ctx context.Context, eventID uuid.UUID, title,
 
 	_, err := db.Pool.Exec(ctx, `
 		UPDATE calendar_events
-		SET title = $2, event_type = $3, event_date = $4, end_date = $5, is_recurring = $6, notes = $7, updated_at = NOW()
+		SET title = $2, event_type = $3, event_date = $4, end_date = $5,
+		    is_recurring = $6 OR (NOT $9 AND rrule IS NOT NULL),
+		    calendar_type = COALESCE(NULLIF($8, ''), calendar_type),
+		    rrule = CASE WHEN $9 THEN $10 ELSE rrule END,
+		    notes = $7, updated_at = NOW()
 		WHERE id = $1
-	`, eventID, title, eventType, eventDate.Format("2006-01-02"), endDateStr, isRecurring, notesPtr)
+	`, eventID, title, eventType, eventDate.Format("2006-01-02"), endDateStr, isRecurring, notesPtr, calendarType, rule != nil, rulePtr)
 
 	return err
 }
@@ -226,3 +382,25 @@ func (db *DB) DeleteCalendarEvent(ctx context.Context, eventID uuid.UUID) error
 	_, err := db.Pool.Exec(ctx, `UPDATE calendar_events SET is_deleted = true, updated_at = NOW() WHERE id = $1`, eventID)
 	return err
 }
+
+// GetCalendarSettings returns the user's calendar preferences, defaulting to no overlay
+func (db *DB) GetCalendarSettings(ctx context.Context, userID uuid.UUID) (*CalendarSettings, error) {
+	var settings CalendarSettings
+	err := db.Pool.QueryRow(ctx, `
+		SELECT show_islamic_holidays FROM calendar_settings WHERE user_id = $1
+	`, userID).Scan(&settings.ShowIslamicHolidays)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveCalendarSettings stores the user's calendar preferences
+func (db *DB) SaveCalendarSettings(ctx context.Context, userID uuid.UUID, settings CalendarSettings) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO calendar_settings (user_id, show_islamic_holidays, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET show_islamic_holidays = $2, updated_at = NOW()
+	`, userID, settings.ShowIslamicHolidays)
+	return err
+}
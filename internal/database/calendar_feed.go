@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const calendarFeedColumns = `user_id, token, include_medications, include_tasks, created_at, rotated_at`
+
+func scanCalendarFeed(row pgx.Row) (*CalendarFeed, error) {
+	var f CalendarFeed
+	if err := row.Scan(&f.UserID, &f.Token, &f.IncludeMedications, &f.IncludeTasks, &f.CreatedAt, &f.RotatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetCalendarFeed returns the user's feed settings, or nil if no feed was created yet
+func (db *DB) GetCalendarFeed(ctx context.Context, userID uuid.UUID) (*CalendarFeed, error) {
+	f, err := scanCalendarFeed(db.Pool.QueryRow(ctx, `
+		SELECT `+calendarFeedColumns+` FROM calendar_feeds WHERE user_id = $1
+	`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// GetCalendarFeedByToken looks up a feed by its secret token, or nil if none matches
+func (db *DB) GetCalendarFeedByToken(ctx context.Context, token string) (*CalendarFeed, error) {
+	f, err := scanCalendarFeed(db.Pool.QueryRow(ctx, `
+		SELECT `+calendarFeedColumns+` FROM calendar_feeds WHERE token = $1
+	`, token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// RotateCalendarFeedToken creates the user's feed or replaces its token,
+// invalidating the previous URL
+func (db *DB) RotateCalendarFeedToken(ctx context.Context, userID uuid.UUID) (*CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+	return scanCalendarFeed(db.Pool.QueryRow(ctx, `
+		INSERT INTO calendar_feeds (user_id, token)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = $2, rotated_at = NOW()
+		RETURNING `+calendarFeedColumns,
+		userID, token,
+	))
+}
+
+// SaveCalendarFeedOptions sets what the feed includes besides calendar events,
+// creating the feed if needed
+func (db *DB) SaveCalendarFeedOptions(ctx context.Context, userID uuid.UUID, includeMedications, includeTasks bool) (*CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+	return scanCalendarFeed(db.Pool.QueryRow(ctx, `
+		INSERT INTO calendar_feeds (user_id, token, include_medications, include_tasks)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET include_medications = $3, include_tasks = $4
+		RETURNING `+calendarFeedColumns,
+		userID, token, includeMedications, includeTasks,
+	))
+}
+
+// DeleteCalendarFeed disables the feed; its URL stops working
+func (db *DB) DeleteCalendarFeed(ctx context.Context, userID uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	return err
+}
+
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
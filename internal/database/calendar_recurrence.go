@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ohabits/internal/services/hijri"
+	"ohabits/internal/services/rrule"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// normalizeRRule validates a recurrence rule and returns it in canonical form;
+// nil for no rule or a plain yearly rule, which is stored as the original
+// yearly repeat so it keeps working with every client
+func normalizeRRule(rule string) (*string, error) {
+	if rule == "" {
+		return nil, nil
+	}
+	r, err := rrule.Parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	s := r.String()
+	if s == "FREQ=YEARLY" {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// RuleDescription summarizes the event's recurrence rule in Arabic
+func (e *CalendarEvent) RuleDescription() string {
+	r, err := rrule.Parse(e.RRule)
+	if err != nil {
+		return ""
+	}
+	return r.Describe()
+}
+
+// attachCalendarEventExceptions loads the exceptions of the rule series among events
+func (db *DB) attachCalendarEventExceptions(ctx context.Context, userID uuid.UUID, events []CalendarEvent) error {
+	hasRule := false
+	for i := range events {
+		hasRule = hasRule || events[i].RRule != ""
+	}
+	if !hasRule {
+		return nil
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, event_id, occurrence_date, is_cancelled, title, event_date, notes, updated_at
+		FROM calendar_event_exceptions
+		WHERE user_id = $1
+		ORDER BY occurrence_date
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byEvent := make(map[uuid.UUID][]CalendarEventException)
+	for rows.Next() {
+		var x CalendarEventException
+		if err := rows.Scan(&x.ID, &x.EventID, &x.OccurrenceDate, &x.IsCancelled, &x.Title, &x.EventDate, &x.Notes, &x.UpdatedAt); err != nil {
+			return err
+		}
+		byEvent[x.EventID] = append(byEvent[x.EventID], x)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range events {
+		if events[i].RRule != "" {
+			events[i].Exceptions = byEvent[events[i].ID]
+		}
+	}
+	return nil
+}
+
+// GetCalendarEventByID returns one of the user's events, with its exceptions
+func (db *DB) GetCalendarEventByID(ctx context.Context, userID, eventID uuid.UUID) (*CalendarEvent, error) {
+	e, err := scanCalendarEvent(db.Pool.QueryRow(ctx, `
+		SELECT `+calendarEventColumns+`
+		FROM calendar_events
+		WHERE id = $1 AND user_id = $2 AND is_deleted = false
+	`, eventID, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []CalendarEvent{*e}
+	if err := db.attachCalendarEventExceptions(ctx, userID, events); err != nil {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+// RuleOccurrences expands a rule series into the occurrences that overlap
+// [from, to], applying its exceptions: cancelled occurrences are dropped and
+// overridden ones carry their new date, title and notes. Each occurrence has
+// EventDate/EndDate of that occurrence and OccurrenceDate set to the date the
+// rule puts it on.
+func RuleOccurrences(e CalendarEvent, from, to time.Time) []CalendarEventForDay {
+	if !e.HasRule() {
+		return nil
+	}
+	rule, err := rrule.Parse(e.RRule)
+	if err != nil {
+		return nil
+	}
+
+	from, to = civilDate(from), civilDate(to)
+	start := civilDate(e.EventDate)
+	length := 0
+	if e.HasDateRange() && e.EndDate.After(e.EventDate) {
+		length = int(civilDate(*e.EndDate).Sub(start).Hours() / 24)
+	}
+
+	exceptions := make(map[time.Time]CalendarEventException, len(e.Exceptions))
+	for _, x := range e.Exceptions {
+		exceptions[civilDate(x.OccurrenceDate)] = x
+	}
+
+	occurrence := func(original time.Time, x *CalendarEventException) CalendarEventForDay {
+		o := CalendarEventForDay{CalendarEvent: e}
+		o.Exceptions = nil
+		o.OccurrenceDate = &original
+		o.EventDate = original
+		if x != nil {
+			if x.EventDate != nil {
+				o.EventDate = civilDate(*x.EventDate)
+			}
+			if x.Title != nil {
+				o.Title = *x.Title
+			}
+			if x.Notes != nil {
+				o.Notes = *x.Notes
+			}
+		}
+		if e.HasDateRange() {
+			end := o.EventDate.AddDate(0, 0, length)
+			o.EndDate = &end
+		}
+		o.YearsAgo = o.EventDate.Year() - start.Year()
+		return o
+	}
+
+	var out []CalendarEventForDay
+	for _, d := range rule.Between(start, from.AddDate(0, 0, -length), to) {
+		x, ok := exceptions[d]
+		switch {
+		case !ok:
+			out = append(out, occurrence(d, nil))
+		case !x.IsCancelled && x.EventDate == nil:
+			out = append(out, occurrence(d, &x))
+		}
+	}
+
+	// Occurrences moved into the range from anywhere in the series
+	for _, x := range e.Exceptions {
+		if x.IsCancelled || x.EventDate == nil {
+			continue
+		}
+		moved := civilDate(*x.EventDate)
+		if moved.After(to) || moved.AddDate(0, 0, length).Before(from) || !rule.Occurs(start, x.OccurrenceDate) {
+			continue
+		}
+		out = append(out, occurrence(civilDate(x.OccurrenceDate), &x))
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EventDate.Before(out[j].EventDate) })
+	return out
+}
+
+// EventOccurrences returns the occurrences of any event that overlap [from,
+// to]: rule series expanded by RuleOccurrences, yearly events once per
+// (Gregorian or Hijri) year, and single events once
+func EventOccurrences(e CalendarEvent, from, to time.Time) []CalendarEventForDay {
+	if e.HasRule() {
+		return RuleOccurrences(e, from, to)
+	}
+
+	from, to = civilDate(from), civilDate(to)
+	start := civilDate(e.EventDate)
+	length := 0
+	if e.HasDateRange() && e.EndDate.After(e.EventDate) {
+		length = int(civilDate(*e.EndDate).Sub(start).Hours() / 24)
+	}
+
+	var starts []time.Time
+	switch {
+	case e.IsHijri():
+		for d := NextHijriOccurrence(e, from.AddDate(0, 0, -length)); !d.After(to); d = NextHijriOccurrence(e, d.AddDate(0, 0, 1)) {
+			starts = append(starts, d)
+		}
+	case e.IsRecurring:
+		for y := from.AddDate(0, 0, -length).Year(); y <= to.Year(); y++ {
+			d := time.Date(y, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+			if d.Day() == start.Day() && !d.Before(start) {
+				starts = append(starts, d)
+			}
+		}
+	default:
+		starts = []time.Time{start}
+	}
+
+	var out []CalendarEventForDay
+	for _, d := range starts {
+		if d.After(to) || d.AddDate(0, 0, length).Before(from) {
+			continue
+		}
+		o := CalendarEventForDay{CalendarEvent: e, YearsAgo: d.Year() - start.Year()}
+		if e.IsHijri() {
+			o.YearsAgo = hijri.FromTime(d).Year - hijri.FromTime(start).Year
+		}
+		o.EventDate = d
+		if e.HasDateRange() {
+			end := d.AddDate(0, 0, length)
+			o.EndDate = &end
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// SaveCalendarEventException cancels or overrides one occurrence of the user's
+// series, replacing any earlier exception for it. A moved date equal to the
+// occurrence date is stored as not moved.
+func (db *DB) SaveCalendarEventException(ctx context.Context, userID uuid.UUID, x CalendarEventException) (*CalendarEventException, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := saveEventException(ctx, tx, userID, &x); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &x, nil
+}
+
+// saveEventException upserts x within tx and touches its series
+func saveEventException(ctx context.Context, tx pgx.Tx, userID uuid.UUID, x *CalendarEventException) error {
+	x.OccurrenceDate = civilDate(x.OccurrenceDate)
+	var movedStr *string
+	if x.EventDate != nil {
+		if moved := civilDate(*x.EventDate); moved.Equal(x.OccurrenceDate) {
+			x.EventDate = nil
+		} else {
+			s := moved.Format("2006-01-02")
+			movedStr = &s
+			x.EventDate = &moved
+		}
+	}
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO calendar_event_exceptions (event_id, user_id, occurrence_date, is_cancelled, title, event_date, notes)
+		SELECT id, user_id, $3, $4, $5, $6, $7
+		FROM calendar_events
+		WHERE id = $1 AND user_id = $2 AND is_deleted = false
+		ON CONFLICT (event_id, occurrence_date) DO UPDATE
+		SET is_cancelled = EXCLUDED.is_cancelled, title = EXCLUDED.title, event_date = EXCLUDED.event_date,
+		    notes = EXCLUDED.notes, updated_at = NOW()
+		RETURNING id, updated_at
+	`, x.EventID, userID, x.OccurrenceDate.Format("2006-01-02"), x.IsCancelled, x.Title, movedStr, x.Notes).Scan(&x.ID, &x.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Touch the series so sync clients pull its new exceptions
+	_, err = tx.Exec(ctx, `UPDATE calendar_events SET updated_at = NOW() WHERE id = $1`, x.EventID)
+	return err
+}
+
+// DeleteCalendarEventException restores an occurrence to what the series says
+func (db *DB) DeleteCalendarEventException(ctx context.Context, userID, eventID uuid.UUID, occurrenceDate time.Time) error {
+	tag, err := db.Pool.Exec(ctx, `
+		DELETE FROM calendar_event_exceptions
+		WHERE event_id = $1 AND user_id = $2 AND occurrence_date = $3
+	`, eventID, userID, civilDate(occurrenceDate).Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	_, err = db.Pool.Exec(ctx, `UPDATE calendar_events SET updated_at = NOW() WHERE id = $1`, eventID)
+	return err
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRuleOccurrences(t *testing.T) {
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	title := "درس خاص"
+
+	// Weekly on Saturdays from 1 March 2025
+	series := CalendarEvent{
+		Title:       "درس",
+		EventDate:   date(3, 1),
+		IsRecurring: true,
+		RRule:       "FREQ=WEEKLY;BYDAY=SA",
+		Exceptions: []CalendarEventException{
+			{OccurrenceDate: date(3, 8), IsCancelled: true},
+			{OccurrenceDate: date(3, 15), Title: &title},
+			{OccurrenceDate: date(3, 22), EventDate: ptr(date(3, 24))},
+			{OccurrenceDate: date(3, 29), EventDate: ptr(date(4, 2))},  // moved out of the range
+			{OccurrenceDate: date(4, 5), EventDate: ptr(date(3, 31))},  // moved into the range
+			{OccurrenceDate: date(3, 10), EventDate: ptr(date(3, 11))}, // not an occurrence
+		},
+	}
+	ranged := series
+	ranged.Exceptions = nil
+	ranged.EndDate = ptr(date(3, 2))
+
+	type occurrence struct {
+		date, original, end string
+		title               string
+	}
+	tests := []struct {
+		name     string
+		event    CalendarEvent
+		from, to time.Time
+		want     []occurrence
+	}{
+		{
+			"cancelled, overridden and moved occurrences", series, date(3, 1), date(3, 31),
+			[]occurrence{
+				{"2025-03-01", "2025-03-01", "", "درس"},
+				{"2025-03-15", "2025-03-15", "", title},
+				{"2025-03-24", "2025-03-22", "", "درس"},
+				{"2025-03-31", "2025-04-05", "", "درس"},
+			},
+		},
+		{
+			"a moved occurrence is found from its new date alone", series, date(3, 31), date(3, 31),
+			[]occurrence{{"2025-03-31", "2025-04-05", "", "درس"}},
+		},
+		{
+			"multi-day occurrences overlapping the start of the range", ranged, date(3, 2), date(3, 8),
+			[]occurrence{
+				{"2025-03-01", "2025-03-01", "2025-03-02", "درس"},
+				{"2025-03-08", "2025-03-08", "2025-03-09", "درس"},
+			},
+		},
+		{
+			"events without a rule have no rule occurrences", CalendarEvent{EventDate: date(3, 1), IsRecurring: true}, date(3, 1), date(3, 31),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []occurrence
+			for _, o := range RuleOccurrences(tt.event, tt.from, tt.to) {
+				g := occurrence{date: o.EventDate.Format(time.DateOnly), original: o.OccurrenceDate.Format(time.DateOnly), title: o.Title}
+				if o.EndDate != nil {
+					g.end = o.EndDate.Format(time.DateOnly)
+				}
+				if o.Exceptions != nil {
+					t.Errorf("occurrence on %s carries the series exceptions", g.date)
+				}
+				got = append(got, g)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RuleOccurrences =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultEventTimeZone is the zone of event times that don't name one
+const DefaultEventTimeZone = "Asia/Kuwait"
+
+// AllDayReminderHour is when the day of an all-day event starts for its reminders
+const AllDayReminderHour = 9
+
+// MaxReminderMinutes bounds how far ahead a reminder can be (four weeks)
+const MaxReminderMinutes = 28 * 24 * 60
+
+// ReminderPresets are the reminder offsets offered in the event form, in minutes
+var ReminderPresets = []int{0, 15, 60, 24 * 60, 3 * 24 * 60, 7 * 24 * 60}
+
+var (
+	eventLocationsMu sync.Mutex
+	eventLocations   = map[string]*time.Location{}
+)
+
+// LoadEventLocation returns the named IANA zone, Asia/Kuwait (UTC+3 when the
+// zone database is missing) for an empty name, or an error for an unknown one
+func LoadEventLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultEventTimeZone
+	}
+
+	eventLocationsMu.Lock()
+	defer eventLocationsMu.Unlock()
+	if loc, ok := eventLocations[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if name != DefaultEventTimeZone {
+			return nil, err
+		}
+		loc = time.FixedZone(DefaultEventTimeZone, 3*60*60)
+	}
+	eventLocations[name] = loc
+	return loc, nil
+}
+
+// IsAllDay returns true if the event has no start time
+func (e *CalendarEvent) IsAllDay() bool {
+	return e.StartTime == ""
+}
+
+// Location returns the zone the event's times are in
+func (e *CalendarEvent) Location() *time.Location {
+	loc, err := LoadEventLocation(e.TimeZone)
+	if err != nil {
+		loc, _ = LoadEventLocation("")
+	}
+	return loc
+}
+
+// StartAt returns when the occurrence on EventDate starts: at its start time,
+// or at midnight for all-day events, in the event's zone
+func (e *CalendarEvent) StartAt() time.Time {
+	h, m, _ := parseClock(e.StartTime)
+	return time.Date(e.EventDate.Year(), e.EventDate.Month(), e.EventDate.Day(), h, m, 0, 0, e.Location())
+}
+
+// EndAt returns when a timed occurrence ends, on its end date if it spans
+// several days; zero if the event has no end time
+func (e *CalendarEvent) EndAt() time.Time {
+	h, m, ok := parseClock(e.EndTime)
+	if e.IsAllDay() || !ok {
+		return time.Time{}
+	}
+	day := e.EventDate
+	if e.HasDateRange() {
+		day = *e.EndDate
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, e.Location())
+}
+
+// ReminderAt returns when the reminder minutes before the occurrence on
+// EventDate fires; all-day events count from 09:00 on the day
+func (e *CalendarEvent) ReminderAt(minutes int) time.Time {
+	start := e.StartAt()
+	if e.IsAllDay() {
+		start = start.Add(AllDayReminderHour * time.Hour)
+	}
+	return start.Add(-time.Duration(minutes) * time.Minute)
+}
+
+// parseClock reads an "HH:MM" time of day
+func parseClock(s string) (hour, minute int, ok bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, false
+	}
+	return t.Hour(), t.Minute(), true
+}
+
+// ReminderLabel describes a reminder offset in Arabic, e.g. "قبل 3 أيام"
+func ReminderLabel(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return "عند البدء"
+	case minutes%(7*24*60) == 0:
+		return "قبل " + arabicCount(minutes/(7*24*60), "أسبوع", "أسبوعين", "أسابيع")
+	case minutes%(24*60) == 0:
+		return "قبل " + arabicCount(minutes/(24*60), "يوم", "يومين", "أيام")
+	case minutes%60 == 0:
+		return "قبل " + arabicCount(minutes/60, "ساعة", "ساعتين", "ساعات")
+	default:
+		return "قبل " + arabicCount(minutes, "دقيقة", "دقيقتين", "دقائق")
+	}
+}
+
+// arabicCount phrases a count with the singular, dual or plural noun
+func arabicCount(n int, one, two, few string) string {
+	switch {
+	case n == 1:
+		return one
+	case n == 2:
+		return two
+	case n <= 10:
+		return fmt.Sprintf("%d %s", n, few)
+	default:
+		return fmt.Sprintf("%d %s", n, one)
+	}
+}
+
+// normalizeReminders drops out-of-range and repeated offsets and sorts the rest
+func normalizeReminders(reminders []int) []int {
+	seen := make(map[int]bool, len(reminders))
+	out := []int{}
+	for _, m := range reminders {
+		if m < 0 || m > MaxReminderMinutes || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// SetCalendarEventTime makes an event timed, starting at startTime ("HH:MM")
+// and optionally ending at endTime in the IANA zone timeZone, or all-day when
+// startTime is empty
+func (db *DB) SetCalendarEventTime(ctx context.Context, eventID uuid.UUID, startTime, endTime, timeZone string) error {
+	start, end, zone, err := eventTimeArgs(startTime, endTime, timeZone)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		UPDATE calendar_events SET start_time = $2, end_time = $3, time_zone = $4, updated_at = NOW()
+		WHERE id = $1
+	`, eventID, start, end, zone)
+	return err
+}
+
+// eventTimeArgs validates an event's times and returns them as column values:
+// all nil for an all-day event, and a nil zone for the default one
+func eventTimeArgs(startTime, endTime, timeZone string) (start, end, zone *string, err error) {
+	if startTime == "" {
+		return nil, nil, nil, nil
+	}
+	if _, _, ok := parseClock(startTime); !ok {
+		return nil, nil, nil, fmt.Errorf("invalid start time %q", startTime)
+	}
+	start = &startTime
+	if endTime != "" {
+		if _, _, ok := parseClock(endTime); !ok {
+			return nil, nil, nil, fmt.Errorf("invalid end time %q", endTime)
+		}
+		end = &endTime
+	}
+	if timeZone != "" && timeZone != DefaultEventTimeZone {
+		if _, err := LoadEventLocation(timeZone); err != nil {
+			return nil, nil, nil, err
+		}
+		zone = &timeZone
+	}
+	return start, end, zone, nil
+}
+
+// SetCalendarEventReminders replaces an event's reminder offsets (minutes before the start)
+func (db *DB) SetCalendarEventReminders(ctx context.Context, eventID uuid.UUID, reminders []int) error {
+	remindersJSON, err := json.Marshal(normalizeReminders(reminders))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		UPDATE calendar_events SET reminders = $2, updated_at = NOW()
+		WHERE id = $1
+	`, eventID, remindersJSON)
+	return err
+}
+
+// GetEventsWithReminders returns every user's events with reminders that can
+// have an occurrence starting in [from, to], with the exceptions of rule
+// series. One-off events outside the window are left out; yearly events and
+// rule series only once they have started, since they repeat
+func (db *DB) GetEventsWithReminders(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+calendarEventColumns+`
+		FROM calendar_events
+		WHERE is_deleted = false AND reminders <> '[]'
+		  AND event_date <= $2
+		  AND (is_recurring OR rrule IS NOT NULL OR event_date >= $1)
+		ORDER BY user_id
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []CalendarEvent
+	for rows.Next() {
+		e, err := scanCalendarEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rows are ordered by user, so each user's events are one run
+	for i := 0; i < len(events); {
+		j := i
+		for j < len(events) && events[j].UserID == events[i].UserID {
+			j++
+		}
+		if err := db.attachCalendarEventExceptions(ctx, events[i].UserID, events[i:j]); err != nil {
+			return nil, err
+		}
+		i = j
+	}
+	return events, nil
+}
+
+const eventNotificationColumns = `n.id, n.user_id, n.event_id, n.occurrence_date, n.remind_minutes, n.fire_at,
+	n.title, n.body, n.delivered_at, n.created_at`
+
+// EnqueueEventNotifications queues due reminders for delivery in one insert,
+// skipping those already queued; it returns how many were newly queued
+func (db *DB) EnqueueEventNotifications(ctx context.Context, notifications []EventNotification) (int, error) {
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	n := len(notifications)
+	userIDs, eventIDs := make([]uuid.UUID, n), make([]uuid.UUID, n)
+	dates, minutes := make([]string, n), make([]int32, n)
+	fireAts, titles, bodies := make([]time.Time, n), make([]string, n), make([]string, n)
+	for i, x := range notifications {
+		userIDs[i], eventIDs[i] = x.UserID, x.EventID
+		dates[i], minutes[i] = x.OccurrenceDate.Format("2006-01-02"), int32(x.RemindMinutes)
+		fireAts[i], titles[i], bodies[i] = x.FireAt, x.Title, x.Body
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO event_notifications (user_id, event_id, occurrence_date, remind_minutes, fire_at, title, body)
+		SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::date[], $4::int[], $5::timestamptz[], $6::text[], $7::text[])
+		ON CONFLICT (event_id, occurrence_date, remind_minutes) DO NOTHING
+	`, userIDs, eventIDs, dates, minutes, fireAts, titles, bodies)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// GetPendingEventNotifications returns the user's undelivered reminders that
+// are due at now, leaving out those of deleted events and of occurrences that
+// started more than a day ago
+func (db *DB) GetPendingEventNotifications(ctx context.Context, userID uuid.UUID, now time.Time) ([]EventNotification, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+eventNotificationColumns+`
+		FROM event_notifications n
+		JOIN calendar_events e ON e.id = n.event_id AND e.is_deleted = false
+		WHERE n.user_id = $1 AND n.delivered_at IS NULL AND n.fire_at <= $2
+		  AND n.fire_at + make_interval(mins => n.remind_minutes) > $2 - INTERVAL '1 day'
+		ORDER BY n.fire_at
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []EventNotification{}
+	for rows.Next() {
+		var n EventNotification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.EventID, &n.OccurrenceDate, &n.RemindMinutes, &n.FireAt,
+			&n.Title, &n.Body, &n.DeliveredAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkEventNotificationDelivered records that the user has seen a reminder
+func (db *DB) MarkEventNotificationDelivered(ctx context.Context, userID, notificationID uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE event_notifications SET delivered_at = COALESCE(delivered_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
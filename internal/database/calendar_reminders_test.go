@@ -0,0 +1,63 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReminderAt(t *testing.T) {
+	kuwait, err := LoadEventLocation("Asia/Kuwait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	london, err := LoadEventLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		event   CalendarEvent
+		minutes int
+		want    time.Time
+	}{
+		{
+			"timed event in its zone",
+			CalendarEvent{EventDate: date, StartTime: "18:30", TimeZone: "Asia/Kuwait"},
+			15, time.Date(2025, 3, 30, 18, 15, 0, 0, kuwait),
+		},
+		{
+			"at the start",
+			CalendarEvent{EventDate: date, StartTime: "18:30", TimeZone: "Asia/Kuwait"},
+			0, time.Date(2025, 3, 30, 18, 30, 0, 0, kuwait),
+		},
+		{
+			"reaches back into the previous day",
+			CalendarEvent{EventDate: date, StartTime: "08:00", TimeZone: "Asia/Kuwait"},
+			24 * 60, time.Date(2025, 3, 29, 8, 0, 0, 0, kuwait),
+		},
+		{
+			"all-day events count from the reminder hour",
+			CalendarEvent{EventDate: date, TimeZone: "Asia/Kuwait"},
+			60, time.Date(2025, 3, 30, AllDayReminderHour-1, 0, 0, 0, kuwait),
+		},
+		{
+			"across a DST change counts real time",
+			CalendarEvent{EventDate: date, StartTime: "03:00", TimeZone: "Europe/London"},
+			2 * 60, time.Date(2025, 3, 30, 0, 0, 0, 0, london),
+		},
+		{
+			"unknown zone falls back to the default",
+			CalendarEvent{EventDate: date, StartTime: "18:30", TimeZone: "Mars/Base"},
+			30, time.Date(2025, 3, 30, 18, 0, 0, 0, (&CalendarEvent{}).Location()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.ReminderAt(tt.minutes); !got.Equal(tt.want) {
+				t.Errorf("ReminderAt(%d) = %v, want %v", tt.minutes, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const libraryExerciseColumns = `id, user_id, name, name_ar, muscle_group, secondary_muscles, equipment, aliases, created_at, updated_at`
+
+// libraryStamp identifies a version of a user's catalogue: any insert,
+// update or delete changes the count or the latest update time
+type libraryStamp struct {
+	count   int
+	updated time.Time
+}
+
+func (a libraryStamp) equal(b libraryStamp) bool {
+	return a.count == b.count && a.updated.Equal(b.updated)
+}
+
+type cachedLibrary struct {
+	stamp libraryStamp
+	lib   *ExerciseLibrary
+}
+
+// exerciseLibraries keeps each user's catalogue between workout saves
+var (
+	exerciseLibrariesMu sync.Mutex
+	exerciseLibraries   = map[uuid.UUID]cachedLibrary{}
+)
+
+// defaultExercises seeds each user's library with common lifts
+var defaultExercises = []LibraryExercise{
+	{Name: "Bench Press", NameAr: "ضغط الصدر بالبار", MuscleGroup: MuscleChest, SecondaryMuscles: []string{MuscleTriceps, MuscleShoulders}, Equipment: "barbell", Aliases: []string{"barbell bench press", "بنش برس", "بنش"}},
+	{Name: "Incline Bench Press", NameAr: "ضغط الصدر المائل", MuscleGroup: MuscleChest, SecondaryMuscles: []string{MuscleShoulders, MuscleTriceps}, Equipment: "barbell", Aliases: []string{"incline press", "بنش مائل"}},
+	{Name: "Dumbbell Bench Press", NameAr: "ضغط الصدر بالدمبل", MuscleGroup: MuscleChest, SecondaryMuscles: []string{MuscleTriceps, MuscleShoulders}, Equipment: "dumbbell", Aliases: []string{"db bench press", "بنش دمبل"}},
+	{Name: "Chest Fly", NameAr: "تفتيح الصدر", MuscleGroup: MuscleChest, Equipment: "dumbbell", Aliases: []string{"dumbbell fly", "فراشة"}},
+	{Name: "Push-up", NameAr: "ضغط", MuscleGroup: MuscleChest, SecondaryMuscles: []string{MuscleTriceps, MuscleShoulders, MuscleCore}, Equipment: "bodyweight", Aliases: []string{"pushup", "push up", "تمرين الضغط"}},
+	{Name: "Dip", NameAr: "متوازي", MuscleGroup: MuscleTriceps, SecondaryMuscles: []string{MuscleChest, MuscleShoulders}, Equipment: "bodyweight", Aliases: []string{"dips", "ديبس"}},
+	{Name: "Deadlift", NameAr: "الرفعة الميتة", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleHamstrings, MuscleGlutes, MuscleForearms}, Equipment: "barbell", Aliases: []string{"conventional deadlift", "ديدلفت"}},
+	{Name: "Barbell Row", NameAr: "تجديف بالبار", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleBiceps, MuscleForearms}, Equipment: "barbell", Aliases: []string{"bent over row", "row", "سحب بالبار"}},
+	{Name: "Dumbbell Row", NameAr: "تجديف بالدمبل", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleBiceps}, Equipment: "dumbbell", Aliases: []string{"one arm row", "سحب دمبل"}},
+	{Name: "Pull-up", NameAr: "عقلة", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleBiceps, MuscleForearms}, Equipment: "bodyweight", Aliases: []string{"pullup", "pull up", "chin-up", "chin up"}},
+	{Name: "Lat Pulldown", NameAr: "سحب أمامي", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleBiceps}, Equipment: "cable", Aliases: []string{"pulldown", "lat pull down", "سحب علوي"}},
+	{Name: "Seated Cable Row", NameAr: "سحب أرضي", MuscleGroup: MuscleBack, SecondaryMuscles: []string{MuscleBiceps}, Equipment: "cable", Aliases: []string{"cable row", "seated row"}},
+	{Name: "Overhead Press", NameAr: "ضغط الكتف بالبار", MuscleGroup: MuscleShoulders, SecondaryMuscles: []string{MuscleTriceps, MuscleCore}, Equipment: "barbell", Aliases: []string{"ohp", "military press", "shoulder press", "ضغط أكتاف"}},
+	{Name: "Dumbbell Shoulder Press", NameAr: "ضغط الكتف بالدمبل", MuscleGroup: MuscleShoulders, SecondaryMuscles: []string{MuscleTriceps}, Equipment: "dumbbell", Aliases: []string{"db shoulder press", "ضغط كتف دمبل"}},
+	{Name: "Lateral Raise", NameAr: "رفرفة جانبي", MuscleGroup: MuscleShoulders, Equipment: "dumbbell", Aliases: []string{"side raise", "lateral raises", "جانبي"}},
+	{Name: "Face Pull", NameAr: "سحب للوجه", MuscleGroup: MuscleShoulders, SecondaryMuscles: []string{MuscleBack}, Equipment: "cable", Aliases: []string{"face pulls"}},
+	{Name: "Barbell Curl", NameAr: "بايسبس بالبار", MuscleGroup: MuscleBiceps, SecondaryMuscles: []string{MuscleForearms}, Equipment: "barbell", Aliases: []string{"curl", "biceps curl", "بايسبس"}},
+	{Name: "Dumbbell Curl", NameAr: "بايسبس بالدمبل", MuscleGroup: MuscleBiceps, SecondaryMuscles: []string{MuscleForearms}, Equipment: "dumbbell", Aliases: []string{"db curl"}},
+	{Name: "Hammer Curl", NameAr: "بايسبس مطرقة", MuscleGroup: MuscleBiceps, SecondaryMuscles: []string{MuscleForearms}, Equipment: "dumbbell", Aliases: []string{"hammer curls", "هامر"}},
+	{Name: "Triceps Pushdown", NameAr: "ترايسبس بالكيبل", MuscleGroup: MuscleTriceps, Equipment: "cable", Aliases: []string{"pushdown", "tricep pushdown", "ترايسبس"}},
+	{Name: "Skull Crusher", NameAr: "ترايسبس فرنسي", MuscleGroup: MuscleTriceps, Equipment: "barbell", Aliases: []string{"skull crushers", "lying triceps extension"}},
+	{Name: "Back Squat", NameAr: "سكوات", MuscleGroup: MuscleQuads, SecondaryMuscles: []string{MuscleGlutes, MuscleHamstrings, MuscleCore}, Equipment: "barbell", Aliases: []string{"squat", "squats", "قرفصاء", "سكوات خلفي"}},
+	{Name: "Front Squat", NameAr: "سكوات أمامي", MuscleGroup: MuscleQuads, SecondaryMuscles: []string{MuscleGlutes, MuscleCore}, Equipment: "barbell", Aliases: []string{"front squats"}},
+	{Name: "Leg Press", NameAr: "دفع الأرجل", MuscleGroup: MuscleQuads, SecondaryMuscles: []string{MuscleGlutes}, Equipment: "machine", Aliases: []string{"ليق برس"}},
+	{Name: "Lunge", NameAr: "طعنات", MuscleGroup: MuscleQuads, SecondaryMuscles: []string{MuscleGlutes, MuscleHamstrings}, Equipment: "dumbbell", Aliases: []string{"lunges", "walking lunge", "لانجز"}},
+	{Name: "Leg Extension", NameAr: "رفرفة أمامي للأرجل", MuscleGroup: MuscleQuads, Equipment: "machine", Aliases: []string{"leg extensions"}},
+	{Name: "Romanian Deadlift", NameAr: "الرفعة الرومانية", MuscleGroup: MuscleHamstrings, SecondaryMuscles: []string{MuscleGlutes, MuscleBack}, Equipment: "barbell", Aliases: []string{"rdl", "رفعة رومانية"}},
+	{Name: "Leg Curl", NameAr: "رفرفة خلفي للأرجل", MuscleGroup: MuscleHamstrings, Equipment: "machine", Aliases: []string{"hamstring curl", "leg curls"}},
+	{Name: "Hip Thrust", NameAr: "دفع الورك", MuscleGroup: MuscleGlutes, SecondaryMuscles: []string{MuscleHamstrings}, Equipment: "barbell", Aliases: []string{"hip thrusts", "glute bridge"}},
+	{Name: "Calf Raise", NameAr: "سمانة", MuscleGroup: MuscleCalves, Equipment: "machine", Aliases: []string{"calf raises", "standing calf raise"}},
+	{Name: "Plank", NameAr: "بلانك", MuscleGroup: MuscleCore, Equipment: "bodyweight", Aliases: []string{"planks", "لوح"}},
+	{Name: "Hanging Leg Raise", NameAr: "رفع الأرجل معلق", MuscleGroup: MuscleCore, Equipment: "bodyweight", Aliases: []string{"leg raise", "رفع أرجل"}},
+	{Name: "Crunch", NameAr: "بطن", MuscleGroup: MuscleCore, Equipment: "bodyweight", Aliases: []string{"crunches", "sit-up", "sit up", "معدة"}},
+	{Name: "Kettlebell Swing", NameAr: "أرجحة الكيتل بل", MuscleGroup: MuscleFullBody, SecondaryMuscles: []string{MuscleGlutes, MuscleHamstrings, MuscleCore}, Equipment: "kettlebell", Aliases: []string{"kb swing", "swing"}},
+}
+
+func scanLibraryExercise(row pgx.Row) (*LibraryExercise, error) {
+	var e LibraryExercise
+	err := row.Scan(
+		&e.ID, &e.UserID, &e.Name, &e.NameAr, &e.MuscleGroup, &e.SecondaryMuscles,
+		&e.Equipment, &e.Aliases, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetExerciseLibrary retrieves the user's exercise catalogue, seeding it with
+// the default lifts the first time it's loaded
+func (db *DB) GetExerciseLibrary(ctx context.Context, userID uuid.UUID) (*ExerciseLibrary, error) {
+	if err := db.seedExerciseLibrary(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+libraryExerciseColumns+`
+		FROM exercise_library
+		WHERE user_id = $1
+		ORDER BY muscle_group, name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []LibraryExercise{}
+	for rows.Next() {
+		e, err := scanLibraryExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lib := NewExerciseLibrary(exercises)
+	stamp := libraryStamp{count: len(exercises)}
+	for _, e := range exercises {
+		if e.UpdatedAt.After(stamp.updated) {
+			stamp.updated = e.UpdatedAt
+		}
+	}
+	exerciseLibrariesMu.Lock()
+	exerciseLibraries[userID] = cachedLibrary{stamp: stamp, lib: lib}
+	exerciseLibrariesMu.Unlock()
+
+	return lib, nil
+}
+
+// cachedExerciseLibrary returns the user's catalogue from the last load while
+// its stamp is unchanged, so saving a workout costs one small query instead
+// of seeding and loading the whole library
+func (db *DB) cachedExerciseLibrary(ctx context.Context, userID uuid.UUID) (*ExerciseLibrary, error) {
+	exerciseLibrariesMu.Lock()
+	cached, ok := exerciseLibraries[userID]
+	exerciseLibrariesMu.Unlock()
+
+	if ok {
+		var stamp libraryStamp
+		var updated *time.Time
+		if err := db.Pool.QueryRow(ctx, `
+			SELECT COUNT(*), MAX(updated_at) FROM exercise_library WHERE user_id = $1
+		`, userID).Scan(&stamp.count, &updated); err != nil {
+			return nil, err
+		}
+		if updated != nil {
+			stamp.updated = *updated
+		}
+		if stamp.equal(cached.stamp) {
+			return cached.lib, nil
+		}
+	}
+	return db.GetExerciseLibrary(ctx, userID)
+}
+
+// seedExerciseLibrary inserts the default lifts once per user
+func (db *DB) seedExerciseLibrary(ctx context.Context, userID uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO exercise_library_seeds (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	for _, e := range defaultExercises {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO exercise_library (user_id, name, name_ar, muscle_group, secondary_muscles, equipment, aliases)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT DO NOTHING
+		`, userID, e.Name, e.NameAr, e.MuscleGroup, nonNilStrings(e.SecondaryMuscles), e.Equipment, nonNilStrings(e.Aliases)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateLibraryExercise adds an exercise to the user's catalogue
+func (db *DB) CreateLibraryExercise(ctx context.Context, userID uuid.UUID, e LibraryExercise) (*LibraryExercise, error) {
+	return scanLibraryExercise(db.Pool.QueryRow(ctx, `
+		INSERT INTO exercise_library (user_id, name, name_ar, muscle_group, secondary_muscles, equipment, aliases)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+libraryExerciseColumns,
+		userID, e.Name, e.NameAr, e.MuscleGroup, nonNilStrings(e.SecondaryMuscles), e.Equipment, nonNilStrings(e.Aliases),
+	))
+}
+
+// UpdateLibraryExercise updates an exercise in the user's catalogue
+func (db *DB) UpdateLibraryExercise(ctx context.Context, userID uuid.UUID, e LibraryExercise) (*LibraryExercise, error) {
+	return scanLibraryExercise(db.Pool.QueryRow(ctx, `
+		UPDATE exercise_library SET
+			name = $3, name_ar = $4, muscle_group = $5, secondary_muscles = $6,
+			equipment = $7, aliases = $8, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+libraryExerciseColumns,
+		e.ID, userID, e.Name, e.NameAr, e.MuscleGroup, nonNilStrings(e.SecondaryMuscles), e.Equipment, nonNilStrings(e.Aliases),
+	))
+}
+
+// DeleteLibraryExercise removes an exercise from the user's catalogue. Workouts
+// keep their free-text names; the stale link is dropped on their next save.
+func (db *DB) DeleteLibraryExercise(ctx context.Context, userID, id uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM exercise_library WHERE id = $1 AND user_id = $2`, id, userID)
+	return err
+}
+
+// linkExercises sets ExerciseID on exercises that match the user's catalogue
+func (db *DB) linkExercises(ctx context.Context, userID uuid.UUID, exercises []Exercise) ([]Exercise, error) {
+	exercises = normalizeExercises(exercises)
+	if len(exercises) == 0 {
+		return exercises, nil
+	}
+	lib, err := db.cachedExerciseLibrary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lib.Link(exercises)
+	return exercises, nil
+}
+
+// linkWorkoutExercises is linkExercises for a workout identified only by its ID
+func (db *DB) linkWorkoutExercises(ctx context.Context, workoutID uuid.UUID, exercises []Exercise) ([]Exercise, error) {
+	var userID uuid.UUID
+	if err := db.Pool.QueryRow(ctx, `SELECT user_id FROM workouts WHERE id = $1`, workoutID).Scan(&userID); err != nil {
+		return nil, err
+	}
+	return db.linkExercises(ctx, userID, exercises)
+}
+
+// nonNilStrings keeps NOT NULL text[] columns from receiving NULL
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	out := make([]string, 0, len(s))
+	for _, v := range s {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestExerciseLibraryFind(t *testing.T) {
+	bench := LibraryExercise{ID: uuid.New(), Name: "Bench Press", NameAr: "ضغط الصدر بالبار", MuscleGroup: MuscleChest, Aliases: []string{"bench", "Incline Press"}}
+	incline := LibraryExercise{ID: uuid.New(), Name: "Incline Press", MuscleGroup: MuscleChest, Aliases: []string{"bench"}}
+	dip := LibraryExercise{ID: uuid.New(), Name: "Dip", MuscleGroup: MuscleTriceps, Aliases: []string{"dips"}}
+	dupe := LibraryExercise{ID: uuid.New(), Name: "dip", MuscleGroup: MuscleChest}
+	lib := NewExerciseLibrary([]LibraryExercise{bench, incline, dip, dupe})
+
+	tests := []struct {
+		name string
+		want *uuid.UUID
+	}{
+		{"Bench Press", &bench.ID},
+		{"  bench   PRESS ", &bench.ID},
+		{"ضغط الصدر بالبار", &bench.ID},
+		{"dips", &dip.ID},
+		// An earlier entry's alias doesn't shadow another entry's own name
+		{"incline press", &incline.ID},
+		// Between aliases, and between names, the first entry wins
+		{"bench", &bench.ID},
+		{"DIP", &dip.ID},
+		{"Squat", nil},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lib.Find(tt.name)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("Find(%q) = %q, want no match", tt.name, got.Name)
+			case tt.want != nil && (got == nil || got.ID != *tt.want):
+				t.Errorf("Find(%q) = %v, want %s", tt.name, got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestExerciseLibraryLink(t *testing.T) {
+	squat := LibraryExercise{ID: uuid.New(), Name: "Squat", NameAr: "سكوات", MuscleGroup: MuscleQuads}
+	row := LibraryExercise{ID: uuid.New(), Name: "Barbell Row", MuscleGroup: MuscleBack, Aliases: []string{"row"}}
+	lib := NewExerciseLibrary([]LibraryExercise{squat, row})
+	deleted := uuid.New()
+
+	exercises := []Exercise{
+		{Name: "squat"},
+		{Name: "Leg Day Squat", ExerciseID: &row.ID}, // A link wins over the name
+		{Name: "Row", ExerciseID: &deleted},          // A stale link falls back to the name
+		{Name: "Curl", ExerciseID: &deleted},         // and is dropped if nothing matches
+		{Name: " سكوات "},
+	}
+	lib.Link(exercises)
+
+	want := []*uuid.UUID{&squat.ID, &row.ID, &row.ID, nil, &squat.ID}
+	for i, ex := range exercises {
+		switch {
+		case want[i] == nil && ex.ExerciseID != nil:
+			t.Errorf("%q linked to %s, want no link", ex.Name, *ex.ExerciseID)
+		case want[i] != nil && (ex.ExerciseID == nil || *ex.ExerciseID != *want[i]):
+			t.Errorf("%q linked to %v, want %s", ex.Name, ex.ExerciseID, *want[i])
+		}
+	}
+
+	var nilLib *ExerciseLibrary
+	if got := nilLib.Lookup(Exercise{Name: "Squat"}); got != nil {
+		t.Errorf("nil library Lookup = %v, want nil", got)
+	}
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// galleryNotePreviewLen is how many characters of a day's note the gallery shows
+const galleryNotePreviewLen = 120
+
+// GetImageMonths returns the months that have photos with their counts, newest first
+func (db *DB) GetImageMonths(ctx context.Context, userID uuid.UUID) ([]ImageMonth, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT EXTRACT(YEAR FROM date)::int, EXTRACT(MONTH FROM date)::int, COUNT(*)
+		FROM daily_images
+		WHERE user_id = $1 AND deleted_at IS NULL
+		GROUP BY 1, 2
+		ORDER BY 1 DESC, 2 DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var months []ImageMonth
+	for rows.Next() {
+		var m ImageMonth
+		if err := rows.Scan(&m.Year, &m.Month, &m.Count); err != nil {
+			return nil, err
+		}
+		months = append(months, m)
+	}
+
+	return months, rows.Err()
+}
+
+// GetGalleryImages returns one page of photos between from and to (inclusive, zero = open),
+// newest first, starting after the cursor when one is given
+func (db *DB) GetGalleryImages(ctx context.Context, userID uuid.UUID, from, to time.Time, after *GalleryCursor, limit int) ([]DailyImage, error) {
+	var fromStr, toStr, afterDate *string
+	var afterCreated *time.Time
+	var afterID *uuid.UUID
+	if !from.IsZero() {
+		s := from.Format("2006-01-02")
+		fromStr = &s
+	}
+	if !to.IsZero() {
+		s := to.Format("2006-01-02")
+		toStr = &s
+	}
+	if after != nil {
+		s := after.Date.Format("2006-01-02")
+		afterDate, afterCreated, afterID = &s, &after.CreatedAt, &after.ID
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, variants, created_at
+		FROM daily_images
+		WHERE user_id = $1 AND deleted_at IS NULL
+		  AND ($2::date IS NULL OR date >= $2::date)
+		  AND ($3::date IS NULL OR date <= $3::date)
+		  AND ($4::date IS NULL OR (date, created_at, id) < ($4::date, $5::timestamptz, $6::uuid))
+		ORDER BY date DESC, created_at DESC, id DESC
+		LIMIT $7
+	`, userID, fromStr, toStr, afterDate, afterCreated, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDailyImages(rows)
+}
+
+// GetGalleryDays returns the mood and note preview of each given day, keyed by "2006-01-02"
+func (db *DB) GetGalleryDays(ctx context.Context, userID uuid.UUID, dates []time.Time) (map[string]GalleryDay, error) {
+	dateStrs := make([]string, len(dates))
+	for i, d := range dates {
+		dateStrs[i] = d.Format("2006-01-02")
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT d.day, COALESCE(m.rating, 0), COALESCE(LEFT(n.text, $3), '')
+		FROM (SELECT DISTINCT unnest($2::date[]) AS day) d
+		LEFT JOIN mood_ratings m ON m.user_id = $1 AND m.date = d.day
+		LEFT JOIN notes n ON n.user_id = $1 AND n.date = d.day
+	`, userID, dateStrs, galleryNotePreviewLen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string]GalleryDay)
+	for rows.Next() {
+		var day GalleryDay
+		if err := rows.Scan(&day.Date, &day.Mood, &day.NotePreview); err != nil {
+			return nil, err
+		}
+		days[day.Date.Format("2006-01-02")] = day
+	}
+
+	return days, rows.Err()
+}
+
+// GetDailyImagesByIDs returns the user's non-deleted images among ids, oldest first
+func (db *DB) GetDailyImagesByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]DailyImage, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, variants, created_at
+		FROM daily_images
+		WHERE user_id = $1 AND id = ANY($2) AND deleted_at IS NULL
+		ORDER BY date ASC, created_at ASC
+	`, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDailyImages(rows)
+}
+
+// scanDailyImages reads rows selected with the usual daily_images column list
+func scanDailyImages(rows pgx.Rows) ([]DailyImage, error) {
+	var images []DailyImage
+	for rows.Next() {
+		var img DailyImage
+		var variantsJSON []byte
+		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath,
+			&img.Filename, &img.MimeType, &img.SizeBytes, &variantsJSON, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(variantsJSON, &img.Variants)
+		images = append(images, img)
+	}
+
+	return images, rows.Err()
+}
@@ -6,12 +6,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // GetHabitsByUserID retrieves all habits for a user
 func (db *DB) GetHabitsByUserID(ctx context.Context, userID uuid.UUID) ([]Habit, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, name, icon, scheduled_days, created_at, updated_at, COALESCE(is_deleted, false) as is_deleted
+		SELECT id, user_id, name, icon, scheduled_days, prayer_anchor, prayer_offset, created_at, updated_at, COALESCE(is_deleted, false) as is_deleted
 		FROM habits WHERE user_id = $1
 		ORDER BY created_at
 	`, userID)
@@ -24,10 +25,13 @@ func (db *DB) GetHabitsByUserID(ctx context.Context, userID uuid.UUID) ([]Habit,
 	for rows.Next() {
 		var h Habit
 		var daysJSON []byte
-		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &h.CreatedAt, &h.UpdatedAt, &h.IsDeleted); err != nil {
+		var anchor *string
+		var offset int
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &anchor, &offset, &h.CreatedAt, &h.UpdatedAt, &h.IsDeleted); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &h.ScheduledDays)
+		h.PrayerAnchor = prayerAnchorFromColumns(anchor, offset)
 		habits = append(habits, h)
 	}
 
@@ -39,7 +43,7 @@ func (db *DB) GetHabitsForDay(ctx context.Context, userID uuid.UUID, date time.T
 	weekday := date.Weekday()
 
 	rows, err := db.Pool.Query(ctx, `
-		SELECT h.id, h.user_id, h.name, h.icon, h.scheduled_days, h.created_at, h.updated_at,
+		SELECT h.id, h.user_id, h.name, h.icon, h.scheduled_days, h.prayer_anchor, h.prayer_offset, h.created_at, h.updated_at,
 			   COALESCE(hc.completed, false) as completed
 		FROM habits h
 		LEFT JOIN habits_completions hc ON h.id = hc.habit_id AND hc.date = $2
@@ -55,10 +59,13 @@ func (db *DB) GetHabitsForDay(ctx context.Context, userID uuid.UUID, date time.T
 	for rows.Next() {
 		var h HabitWithCompletion
 		var daysJSON []byte
-		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &h.CreatedAt, &h.UpdatedAt, &h.Completed); err != nil {
+		var anchor *string
+		var offset int
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &anchor, &offset, &h.CreatedAt, &h.UpdatedAt, &h.Completed); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &h.ScheduledDays)
+		h.PrayerAnchor = prayerAnchorFromColumns(anchor, offset)
 
 		// Check if habit is scheduled for this day
 		if h.IsScheduledFor(weekday) {
@@ -107,6 +114,39 @@ func (db *DB) UpdateHabit(ctx context.Context, habitID uuid.UUID, name string, i
 	return err
 }
 
+// SetHabitPrayerAnchor anchors one of the user's habits to a prayer time, or
+// clears the anchor when nil. pgx.ErrNoRows if the habit isn't theirs.
+func (db *DB) SetHabitPrayerAnchor(ctx context.Context, habitID, userID uuid.UUID, anchor *PrayerAnchor) error {
+	var prayer *string
+	offset := 0
+	if anchor != nil && anchor.Prayer != "" {
+		if !anchor.Valid() {
+			return ErrInvalidPrayerAnchor
+		}
+		prayer, offset = &anchor.Prayer, anchor.OffsetMinutes
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE habits SET prayer_anchor = $3, prayer_offset = $4, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, habitID, userID, prayer, offset)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// prayerAnchorFromColumns builds a habit's anchor from its prayer_anchor/prayer_offset columns
+func prayerAnchorFromColumns(prayer *string, offset int) *PrayerAnchor {
+	if prayer == nil || *prayer == "" {
+		return nil
+	}
+	return &PrayerAnchor{Prayer: *prayer, OffsetMinutes: offset}
+}
+
 // DeleteHabit deletes a habit
 func (db *DB) DeleteHabit(ctx context.Context, habitID uuid.UUID) error {
 	_, err := db.Pool.Exec(ctx, `DELETE FROM habits WHERE id = $1`, habitID)
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyMetrics holds everything tracked for a single day, used by the insights engine
+type DailyMetrics struct {
+	Date            time.Time
+	Mood            *int               // nil if no mood was logged
+	HabitsScheduled []uuid.UUID        // Habits that were scheduled (and existed) on this day
+	HabitsCompleted map[uuid.UUID]bool // Completed habits
+	DosesScheduled  int                // Total medication doses due
+	DosesTaken      int                // Medication doses marked as taken
+	WorkedOut       bool               // A non-rest workout log exists
+}
+
+// MedicationAdherence returns taken/scheduled doses, or -1 if nothing was due
+func (d *DailyMetrics) MedicationAdherence() float64 {
+	if d.DosesScheduled == 0 {
+		return -1
+	}
+	return float64(d.DosesTaken) / float64(d.DosesScheduled)
+}
+
+// GetDailyMetrics builds one DailyMetrics entry per day between from and to (inclusive)
+func (db *DB) GetDailyMetrics(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]DailyMetrics, error) {
+	fromStr := from.Format("2006-01-02")
+	toStr := to.Format("2006-01-02")
+
+	var days []DailyMetrics
+	index := make(map[string]int)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		index[d.Format("2006-01-02")] = len(days)
+		days = append(days, DailyMetrics{Date: d, HabitsCompleted: make(map[uuid.UUID]bool)})
+	}
+
+	// Mood ratings
+	rows, err := db.Pool.Query(ctx, `
+		SELECT date, rating FROM mood_ratings
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+	`, userID, fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var rating int
+		if err := rows.Scan(&date, &rating); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if i, ok := index[date.Format("2006-01-02")]; ok {
+			r := rating
+			days[i].Mood = &r
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Scheduled habits (only from the day they were created)
+	habits, err := db.GetHabitsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range days {
+		for _, h := range habits {
+			if h.IsDeleted || !h.IsScheduledFor(days[i].Date.Weekday()) {
+				continue
+			}
+			created := time.Date(h.CreatedAt.Year(), h.CreatedAt.Month(), h.CreatedAt.Day(), 0, 0, 0, 0, days[i].Date.Location())
+			if days[i].Date.Before(created) {
+				continue
+			}
+			days[i].HabitsScheduled = append(days[i].HabitsScheduled, h.ID)
+		}
+	}
+
+	// Habit completions
+	rows, err = db.Pool.Query(ctx, `
+		SELECT habit_id, date FROM habits_completions
+		WHERE user_id = $1 AND completed = true AND date >= $2 AND date <= $3
+	`, userID, fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var habitID uuid.UUID
+		var date time.Time
+		if err := rows.Scan(&habitID, &date); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if i, ok := index[date.Format("2006-01-02")]; ok {
+			days[i].HabitsCompleted[habitID] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Medication doses due
+	medications, err := db.GetActiveMedications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	timesPerDay := make(map[uuid.UUID]int)
+	for _, m := range medications {
+		timesPerDay[m.ID] = m.TimesPerDay
+	}
+	for i := range days {
+		for _, m := range medications {
+			if m.IsActive && m.IsScheduledOn(days[i].Date) {
+				days[i].DosesScheduled += m.TimesPerDay
+			}
+		}
+	}
+
+	// Medication doses taken
+	rows, err = db.Pool.Query(ctx, `
+		SELECT medication_id, date, dose_number FROM medication_logs
+		WHERE user_id = $1 AND taken = true AND date >= $2 AND date <= $3
+	`, userID, fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var medID uuid.UUID
+		var date time.Time
+		var doseNumber int
+		if err := rows.Scan(&medID, &date, &doseNumber); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i, ok := index[date.Format("2006-01-02")]
+		if !ok || doseNumber < 1 || doseNumber > timesPerDay[medID] {
+			continue
+		}
+		if days[i].DosesTaken < days[i].DosesScheduled {
+			days[i].DosesTaken++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Workout days (rest days don't count)
+	rows, err = db.Pool.Query(ctx, `
+		SELECT date FROM workout_logs
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		  AND COALESCE(is_rest_day, false) = false
+		  AND (jsonb_array_length(completed_exercises) > 0 OR jsonb_array_length(cardio) > 0)
+	`, userID, fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		if i, ok := index[date.Format("2006-01-02")]; ok {
+			days[i].WorkedOut = true
+		}
+	}
+
+	return days, rows.Err()
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Media kinds (which table references an uploaded file)
+const (
+	MediaKindDailyImage     = "daily_image"
+	MediaKindBlogImage      = "blog_image"
+	MediaKindTaskAttachment = "task_attachment"
+	MediaKindAvatar         = "avatar"
+)
+
+// GetMediaOwner finds the user that owns an uploaded file by its stored path (e.g. "/uploads/...")
+// Returns uuid.Nil and an empty kind if no record references the path
+func (db *DB) GetMediaOwner(ctx context.Context, path string) (uuid.UUID, string, error) {
+	var ownerID uuid.UUID
+	var kind string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT user_id, 'daily_image' FROM daily_images
+		WHERE original_path = $1 OR thumbnail_path = $1
+		   OR EXISTS (SELECT 1 FROM jsonb_each(variants) v WHERE v.value->>'url' = $1 OR v.value->>'webp_url' = $1)
+		UNION ALL
+		SELECT user_id, 'blog_image' FROM blog_images
+		WHERE original_path = $1 OR thumbnail_path = $1
+		   OR EXISTS (SELECT 1 FROM jsonb_each(variants) v WHERE v.value->>'url' = $1 OR v.value->>'webp_url' = $1)
+		UNION ALL
+		SELECT user_id, 'task_attachment' FROM task_attachments WHERE file_path = $1
+		UNION ALL
+		SELECT id, 'avatar' FROM users WHERE avatar_url = $1
+		LIMIT 1
+	`, path).Scan(&ownerID, &kind)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, "", nil
+		}
+		return uuid.Nil, "", err
+	}
+
+	return ownerID, kind, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // weekdayToEnglish maps Go weekday to English day name (matching database format)
@@ -22,20 +23,54 @@ var weekdayToEnglish = map[time.Weekday]string{
 // MedicationWithDoses combines medication with its dose statuses for a day
 type MedicationWithDoses struct {
 	Medication
-	DoseTaken []bool `json:"dose_taken"` // Status for each dose (indexed 0 to TimesPerDay-1)
+	DoseTaken []bool       `json:"dose_taken"`           // Status for each dose (indexed 0 to TimesPerDay-1)
+	DoseTimes []*time.Time `json:"dose_times,omitempty"` // Prayer-anchored time of each dose, if any
+}
+
+// DoseAnchor returns the prayer anchor of a dose (1-based), if it has one
+func (m *Medication) DoseAnchor(doseNumber int) *PrayerAnchor {
+	if doseNumber < 1 || doseNumber > len(m.DoseAnchors) || m.DoseAnchors[doseNumber-1].Prayer == "" {
+		return nil
+	}
+	return &m.DoseAnchors[doseNumber-1]
+}
+
+// IsScheduledOn checks if the medication should be taken on a given date
+// (weekday schedule plus start/end range for limited durations)
+func (m *Medication) IsScheduledOn(date time.Time) bool {
+	dayName := weekdayToEnglish[date.Weekday()]
+
+	isScheduled := len(m.ScheduledDays) == 0 // Empty means every day
+	for _, day := range m.ScheduledDays {
+		if day == dayName {
+			isScheduled = true
+			break
+		}
+	}
+
+	// Check if within date range for limited duration
+	if m.DurationType == "limited" {
+		if m.StartDate != nil && date.Before(*m.StartDate) {
+			isScheduled = false
+		}
+		if m.EndDate != nil && date.After(*m.EndDate) {
+			isScheduled = false
+		}
+	}
+
+	return isScheduled
 }
 
 // GetMedicationsForDay retrieves active medications for a specific day with dose statuses
 // Excludes deleted medications
 func (db *DB) GetMedicationsForDay(ctx context.Context, userID uuid.UUID, date time.Time) ([]MedicationWithDoses, error) {
-	dayName := weekdayToEnglish[date.Weekday()]
 	dateStr := date.Format("2006-01-02")
 
 	// First get all active medications
 	rows, err := db.Pool.Query(ctx, `
 		SELECT m.id, m.user_id, m.name, m.dosage, m.scheduled_days, m.times_per_day,
 			   m.duration_type, m.start_date, m.end_date, COALESCE(m.notes, '') as notes,
-			   COALESCE(m.icon, 'pill.fill') as icon, m.is_active,
+			   COALESCE(m.icon, 'pill.fill') as icon, m.dose_anchors, m.is_active,
 			   m.created_at, m.updated_at
 		FROM medications m
 		WHERE m.user_id = $1 AND m.is_active = true AND COALESCE(m.is_deleted, false) = false
@@ -49,36 +84,18 @@ func (db *DB) GetMedicationsForDay(ctx context.Context, userID uuid.UUID, date t
 	var medications []MedicationWithDoses
 	for rows.Next() {
 		var m MedicationWithDoses
-		var daysJSON []byte
+		var daysJSON, anchorsJSON []byte
 		if err := rows.Scan(
 			&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysJSON, &m.TimesPerDay,
-			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &m.IsActive,
+			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &anchorsJSON, &m.IsActive,
 			&m.CreatedAt, &m.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &m.ScheduledDays)
+		json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 
-		// Check if medication is scheduled for this day
-		isScheduled := len(m.ScheduledDays) == 0 // Empty means every day
-		for _, day := range m.ScheduledDays {
-			if day == dayName {
-				isScheduled = true
-				break
-			}
-		}
-
-		// Check if within date range for limited duration
-		if m.DurationType == "limited" {
-			if m.StartDate != nil && date.Before(*m.StartDate) {
-				isScheduled = false
-			}
-			if m.EndDate != nil && date.After(*m.EndDate) {
-				isScheduled = false
-			}
-		}
-
-		if isScheduled {
+		if m.IsScheduledOn(date) {
 			// Initialize dose statuses
 			m.DoseTaken = make([]bool, m.TimesPerDay)
 			medications = append(medications, m)
@@ -134,7 +151,7 @@ func (db *DB) GetAllMedications(ctx context.Context, userID uuid.UUID) ([]Medica
 	rows, err := db.Pool.Query(ctx, `
 		SELECT id, user_id, name, dosage, scheduled_days, times_per_day,
 			   duration_type, start_date, end_date, COALESCE(notes, '') as notes,
-			   COALESCE(icon, 'pill.fill') as icon, is_active, COALESCE(is_deleted, false) as is_deleted,
+			   COALESCE(icon, 'pill.fill') as icon, dose_anchors, is_active, COALESCE(is_deleted, false) as is_deleted,
 			   created_at, updated_at
 		FROM medications WHERE user_id = $1
 		ORDER BY created_at
@@ -147,15 +164,16 @@ func (db *DB) GetAllMedications(ctx context.Context, userID uuid.UUID) ([]Medica
 	var medications []Medication
 	for rows.Next() {
 		var m Medication
-		var daysJSON []byte
+		var daysJSON, anchorsJSON []byte
 		if err := rows.Scan(
 			&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysJSON, &m.TimesPerDay,
-			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &m.IsActive, &m.IsDeleted,
+			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &anchorsJSON, &m.IsActive, &m.IsDeleted,
 			&m.CreatedAt, &m.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &m.ScheduledDays)
+		json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 		medications = append(medications, m)
 	}
 
@@ -167,7 +185,7 @@ func (db *DB) GetActiveMedications(ctx context.Context, userID uuid.UUID) ([]Med
 	rows, err := db.Pool.Query(ctx, `
 		SELECT id, user_id, name, dosage, scheduled_days, times_per_day,
 			   duration_type, start_date, end_date, COALESCE(notes, '') as notes,
-			   COALESCE(icon, 'pill.fill') as icon, is_active, COALESCE(is_deleted, false) as is_deleted,
+			   COALESCE(icon, 'pill.fill') as icon, dose_anchors, is_active, COALESCE(is_deleted, false) as is_deleted,
 			   created_at, updated_at
 		FROM medications WHERE user_id = $1 AND COALESCE(is_deleted, false) = false
 		ORDER BY created_at
@@ -180,15 +198,16 @@ func (db *DB) GetActiveMedications(ctx context.Context, userID uuid.UUID) ([]Med
 	var medications []Medication
 	for rows.Next() {
 		var m Medication
-		var daysJSON []byte
+		var daysJSON, anchorsJSON []byte
 		if err := rows.Scan(
 			&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysJSON, &m.TimesPerDay,
-			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &m.IsActive, &m.IsDeleted,
+			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &anchorsJSON, &m.IsActive, &m.IsDeleted,
 			&m.CreatedAt, &m.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &m.ScheduledDays)
+		json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 		medications = append(medications, m)
 	}
 
@@ -205,14 +224,14 @@ func (db *DB) CreateMedication(ctx context.Context, userID uuid.UUID, name, dosa
 	}
 
 	var m Medication
-	var daysBytes []byte
+	var daysBytes, anchorsJSON []byte
 	err := db.Pool.QueryRow(ctx, `
 		INSERT INTO medications (user_id, name, dosage, scheduled_days, times_per_day, duration_type, start_date, end_date, notes, icon)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, user_id, name, dosage, scheduled_days, times_per_day, duration_type, start_date, end_date, COALESCE(notes, '') as notes, COALESCE(icon, 'pill.fill') as icon, is_active, created_at, updated_at
+		RETURNING id, user_id, name, dosage, scheduled_days, times_per_day, duration_type, start_date, end_date, COALESCE(notes, '') as notes, COALESCE(icon, 'pill.fill') as icon, dose_anchors, is_active, created_at, updated_at
 	`, userID, name, dosage, daysJSON, timesPerDay, durationType, startDate, endDate, notes, icon).Scan(
 		&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysBytes, &m.TimesPerDay,
-		&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &m.IsActive,
+		&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &anchorsJSON, &m.IsActive,
 		&m.CreatedAt, &m.UpdatedAt,
 	)
 
@@ -221,6 +240,7 @@ func (db *DB) CreateMedication(ctx context.Context, userID uuid.UUID, name, dosa
 	}
 
 	json.Unmarshal(daysBytes, &m.ScheduledDays)
+	json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 	return &m, nil
 }
 
@@ -288,20 +308,50 @@ func (db *DB) UpdateMedication(ctx context.Context, medicationID uuid.UUID, name
 	return err
 }
 
+// SetMedicationDoseAnchors stores the prayer anchors of one of the user's
+// medications' doses (an empty Prayer leaves a dose unanchored).
+// pgx.ErrNoRows if the medication isn't theirs.
+func (db *DB) SetMedicationDoseAnchors(ctx context.Context, medicationID, userID uuid.UUID, anchors []PrayerAnchor) error {
+	if anchors == nil {
+		anchors = []PrayerAnchor{}
+	}
+	for _, a := range anchors {
+		if a.Prayer != "" && !a.Valid() {
+			return ErrInvalidPrayerAnchor
+		}
+	}
+	anchorsJSON, err := json.Marshal(anchors)
+	if err != nil {
+		return err
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE medications SET dose_anchors = $3, updated_at = now()
+		WHERE id = $1 AND user_id = $2
+	`, medicationID, userID, anchorsJSON)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 // GetMedicationByID retrieves a single medication by ID
 func (db *DB) GetMedicationByID(ctx context.Context, medicationID uuid.UUID) (*Medication, error) {
 	var m Medication
-	var daysJSON []byte
+	var daysJSON, anchorsJSON []byte
 
 	err := db.Pool.QueryRow(ctx, `
 		SELECT id, user_id, name, dosage, scheduled_days, times_per_day,
 			   duration_type, start_date, end_date, COALESCE(notes, '') as notes,
-			   COALESCE(icon, 'pill.fill') as icon, is_active, COALESCE(is_deleted, false) as is_deleted,
+			   COALESCE(icon, 'pill.fill') as icon, dose_anchors, is_active, COALESCE(is_deleted, false) as is_deleted,
 			   created_at, updated_at
 		FROM medications WHERE id = $1
 	`, medicationID).Scan(
 		&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysJSON, &m.TimesPerDay,
-		&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &m.IsActive, &m.IsDeleted,
+		&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes, &m.Icon, &anchorsJSON, &m.IsActive, &m.IsDeleted,
 		&m.CreatedAt, &m.UpdatedAt,
 	)
 
@@ -310,6 +360,7 @@ func (db *DB) GetMedicationByID(ctx context.Context, medicationID uuid.UUID) (*M
 	}
 
 	json.Unmarshal(daysJSON, &m.ScheduledDays)
+	json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 	return &m, nil
 }
 
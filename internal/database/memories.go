@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetMemoryDates returns which of the given dates have a note, image, mood or workout log,
+// leaving out days the user has hidden
+func (db *DB) GetMemoryDates(ctx context.Context, userID uuid.UUID, dates []time.Time) ([]time.Time, error) {
+	dateStrs := make([]string, len(dates))
+	for i, d := range dates {
+		dateStrs[i] = d.Format("2006-01-02")
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT date FROM (
+			SELECT date FROM notes WHERE user_id = $1 AND date = ANY($2::date[]) AND text <> ''
+			UNION
+			SELECT date FROM daily_images WHERE user_id = $1 AND date = ANY($2::date[]) AND deleted_at IS NULL
+			UNION
+			SELECT date FROM mood_ratings WHERE user_id = $1 AND date = ANY($2::date[])
+			UNION
+			SELECT date FROM workout_logs WHERE user_id = $1 AND date = ANY($2::date[])
+		) AS memory_dates
+		WHERE date NOT IN (SELECT date FROM hidden_memories WHERE user_id = $1)
+		ORDER BY date DESC
+	`, userID, dateStrs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []time.Time
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+
+	return result, rows.Err()
+}
+
+// GetMemoryDays loads the note, images, mood and workout log of each date
+// with one query per source, keyed by "2006-01-02". Kind and Ago are left
+// for the caller.
+func (db *DB) GetMemoryDays(ctx context.Context, userID uuid.UUID, dates []time.Time) (map[string]*Memory, error) {
+	days := make(map[string]*Memory, len(dates))
+	dateStrs := make([]string, len(dates))
+	for i, d := range dates {
+		dateStrs[i] = d.Format("2006-01-02")
+		days[dateStrs[i]] = &Memory{Date: d, Images: []DailyImage{}}
+	}
+	if len(dates) == 0 {
+		return days, nil
+	}
+
+	// day returns the memory a row belongs to
+	day := func(d time.Time) *Memory {
+		return days[d.Format("2006-01-02")]
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, text, date, created_at, updated_at
+		FROM notes
+		WHERE user_id = $1 AND date = ANY($2::date[])
+	`, userID, dateStrs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Text, &n.Date, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m := day(n.Date); m != nil {
+			m.Note = &n
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, variants, created_at
+		FROM daily_images
+		WHERE user_id = $1 AND date = ANY($2::date[]) AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, userID, dateStrs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var img DailyImage
+		var variantsJSON []byte
+		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath,
+			&img.Filename, &img.MimeType, &img.SizeBytes, &variantsJSON, &img.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		json.Unmarshal(variantsJSON, &img.Variants)
+		if m := day(img.Date); m != nil {
+			m.Images = append(m.Images, img)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+		SELECT id, user_id, rating, date, created_at
+		FROM mood_ratings
+		WHERE user_id = $1 AND date = ANY($2::date[])
+	`, userID, dateStrs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var r MoodRating
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Rating, &r.Date, &r.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m := day(r.Date); m != nil {
+			m.Mood = &r
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+		SELECT id, user_id, name, completed_exercises, cardio, weight, date, created_at, updated_at, is_rest_day
+		FROM workout_logs
+		WHERE user_id = $1 AND date = ANY($2::date[])
+	`, userID, dateStrs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var wl WorkoutLog
+		var exercisesJSON, cardioJSON []byte
+		if err := rows.Scan(
+			&wl.ID, &wl.UserID, &wl.WorkoutName, &exercisesJSON, &cardioJSON,
+			&wl.Weight, &wl.Date, &wl.CreatedAt, &wl.UpdatedAt, &wl.IsRestDay,
+		); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(exercisesJSON, &wl.CompletedExercises)
+		if cardioJSON != nil {
+			json.Unmarshal(cardioJSON, &wl.Cardio)
+		}
+		if m := day(wl.Date); m != nil {
+			m.WorkoutLog = &wl
+		}
+	}
+
+	return days, rows.Err()
+}
+
+// HideMemoryDay stops a day from showing up in memories
+func (db *DB) HideMemoryDay(ctx context.Context, userID uuid.UUID, date time.Time) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO hidden_memories (user_id, date)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, date) DO NOTHING
+	`, userID, date.Format("2006-01-02"))
+	return err
+}
+
+// UnhideMemoryDay lets a hidden day show up in memories again
+func (db *DB) UnhideMemoryDay(ctx context.Context, userID uuid.UUID, date time.Time) error {
+	_, err := db.Pool.Exec(ctx, `
+		DELETE FROM hidden_memories WHERE user_id = $1 AND date = $2
+	`, userID, date.Format("2006-01-02"))
+	return err
+}
+
+// GetHiddenMemoryDays lists the days a user has hidden from memories
+func (db *DB) GetHiddenMemoryDays(ctx context.Context, userID uuid.UUID) ([]time.Time, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT date FROM hidden_memories WHERE user_id = $1 ORDER BY date DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+
+	return dates, rows.Err()
+}
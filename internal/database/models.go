@@ -1,6 +1,10 @@
 package database
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +38,7 @@ type Habit struct {
 	Name          string    `json:"name"`
 	Icon          string    `json:"icon"`
 	ScheduledDays []string  `json:"scheduled_days"` // Day names: "Sunday", "Monday", etc.
+	PrayerAnchor  *PrayerAnchor `json:"prayer_anchor,omitempty"` // Done around a prayer, e.g. after Fajr
 	CreatedAt     time.Time  `json:"created_at"`
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 	IsDeleted     bool       `json:"is_deleted"`
@@ -78,26 +83,42 @@ type HabitCompletion struct {
 // HabitWithCompletion combines habit with its completion status
 type HabitWithCompletion struct {
 	Habit
-	Completed bool `json:"completed"`
+	Completed bool       `json:"completed"`
+	DueAt     *time.Time `json:"due_at,omitempty"` // Prayer-anchored time on that day
+}
+
+// PrayerAnchor ties a habit or medication dose to a prayer time, offset by
+// minutes (negative = before the prayer)
+type PrayerAnchor struct {
+	Prayer        string `json:"prayer"` // fajr, sunrise, dhuhr, asr, maghrib, isha
+	OffsetMinutes int    `json:"offset_minutes"`
+}
+
+// PrayerSettings holds the location and method used to compute prayer times
+type PrayerSettings struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Method    string  `json:"method"`
 }
 
 // Medication represents a medication to track
 type Medication struct {
-	ID            uuid.UUID  `json:"id"`
-	UserID        uuid.UUID  `json:"user_id"`
-	Name          string     `json:"name"`
-	Dosage        string     `json:"dosage"`
-	ScheduledDays []string   `json:"scheduled_days"` // Day names: "Sunday", "Monday", etc.
-	TimesPerDay   int        `json:"times_per_day"`
-	DurationType  string     `json:"duration_type"` // "lifetime" or "limited"
-	StartDate     *time.Time `json:"start_date"`
-	EndDate       *time.Time `json:"end_date"`
-	Notes         string     `json:"notes"`
-	Icon          string     `json:"icon"`
-	IsActive      bool       `json:"is_active"`
-	IsDeleted     bool       `json:"is_deleted"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID            uuid.UUID      `json:"id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Name          string         `json:"name"`
+	Dosage        string         `json:"dosage"`
+	ScheduledDays []string       `json:"scheduled_days"` // Day names: "Sunday", "Monday", etc.
+	TimesPerDay   int            `json:"times_per_day"`
+	DurationType  string         `json:"duration_type"` // "lifetime" or "limited"
+	StartDate     *time.Time     `json:"start_date"`
+	EndDate       *time.Time     `json:"end_date"`
+	Notes         string         `json:"notes"`
+	Icon          string         `json:"icon"`
+	DoseAnchors   []PrayerAnchor `json:"dose_anchors"` // Per dose; an empty Prayer means not anchored
+	IsActive      bool           `json:"is_active"`
+	IsDeleted     bool           `json:"is_deleted"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
 }
 
 // MedicationLog tracks medication intake
@@ -139,6 +160,56 @@ type Note struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// NoteTemplate is a user-defined journaling template (e.g. gratitude list, three wins)
+type NoteTemplate struct {
+	ID           uuid.UUID           `json:"id"`
+	UserID       uuid.UUID           `json:"user_id"`
+	Name         string              `json:"name"`
+	Icon         string              `json:"icon"`
+	Fields       []NoteTemplateField `json:"fields"`
+	DisplayOrder int                 `json:"display_order"`
+	IsDeleted    bool                `json:"is_deleted"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// NoteTemplateField is a single structured field in a note template
+type NoteTemplateField struct {
+	Key   string `json:"key"`   // Stable identifier used for queries
+	Label string `json:"label"` // Display label
+	Type  string `json:"type"`  // "text" or "list"
+	Count int    `json:"count"` // Number of list items (list fields only)
+}
+
+// NoteEntry is the value of one template field on one day
+type NoteEntry struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	TemplateID uuid.UUID `json:"template_id"`
+	FieldKey   string    `json:"field_key"`
+	Date       time.Time `json:"date"`
+	Value      string    `json:"value"` // List fields store one item per line
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Items splits a list entry into its non-empty lines
+func (e *NoteEntry) Items() []string {
+	var items []string
+	for _, line := range strings.Split(e.Value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// NoteTemplateWithEntries combines a template with its values for a day
+type NoteTemplateWithEntries struct {
+	NoteTemplate
+	Entries map[string]NoteEntry `json:"entries"` // field_key -> entry
+}
+
 // MoodRating represents daily mood (1-5)
 type MoodRating struct {
 	ID        uuid.UUID `json:"id"`
@@ -158,11 +229,105 @@ type DailyImage struct {
 	Filename      string    `json:"filename"`
 	MimeType      string    `json:"mime_type"`
 	SizeBytes     int       `json:"size_bytes"`
+	Variants      ImageVariants `json:"variants"`
 	CreatedAt     time.Time  `json:"created_at"`
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 	IsDeleted     bool       `json:"is_deleted"`
 }
 
+// ImageVariant is one resized copy of an uploaded image
+type ImageVariant struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	URL     string `json:"url"`                // JPEG (or PNG for transparent images)
+	WebPURL string `json:"webp_url,omitempty"` // Smaller WebP copy, when one was made
+}
+
+// ImageVariants maps a variant name ("thumb", "medium", "full") to its files
+type ImageVariants map[string]ImageVariant
+
+// Get returns the URL of a variant, or fallback for images uploaded before variants existed
+func (v ImageVariants) Get(name, fallback string) string {
+	if variant, ok := v[name]; ok && variant.URL != "" {
+		return variant.URL
+	}
+	return fallback
+}
+
+// WebP returns the WebP copy of a variant, if one was made
+func (v ImageVariants) WebP(name string) string {
+	return v[name].WebPURL
+}
+
+// Srcset lists the medium and full variants as an <img srcset> value (empty for older images)
+func (v ImageVariants) Srcset() string {
+	var parts []string
+	seen := make(map[int]bool)
+	for _, name := range []string{"medium", "full"} {
+		variant, ok := v[name]
+		if !ok || variant.URL == "" || seen[variant.Width] {
+			continue
+		}
+		seen[variant.Width] = true
+		parts = append(parts, fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// URLs returns every stored file of all variants
+func (v ImageVariants) URLs() []string {
+	var urls []string
+	for _, variant := range v {
+		if variant.URL != "" {
+			urls = append(urls, variant.URL)
+		}
+		if variant.WebPURL != "" {
+			urls = append(urls, variant.WebPURL)
+		}
+	}
+	return urls
+}
+
+// ImageMonth is how many photos a user has in one month (gallery navigation)
+type ImageMonth struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Count int `json:"count"`
+}
+
+// GalleryCursor is the position after the last image of a gallery page
+// (images are ordered newest day first, then newest upload)
+type GalleryCursor struct {
+	Date      time.Time
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// GalleryDay is the mood and note of a day shown alongside its photos
+type GalleryDay struct {
+	Date        time.Time `json:"date"`
+	Mood        int       `json:"mood,omitempty"` // 1-5, 0 = not rated
+	NotePreview string    `json:"note_preview,omitempty"`
+}
+
+// StorageUsage is how many bytes of uploads a user has stored, per kind
+type StorageUsage struct {
+	DailyImagesBytes int64 `json:"daily_images_bytes"`
+	BlogImagesBytes  int64 `json:"blog_images_bytes"`
+	AttachmentsBytes int64 `json:"attachments_bytes"`
+	AvatarBytes      int64 `json:"avatar_bytes"`
+	TotalBytes       int64 `json:"total_bytes"`
+	QuotaBytes       int64 `json:"quota_bytes"` // Set by the handler from the user's role, 0 = unlimited
+}
+
+// Percent returns how much of the quota is used (0 when unlimited)
+func (u *StorageUsage) Percent() int {
+	if u.QuotaBytes <= 0 {
+		return 0
+	}
+	return int(min(u.TotalBytes*100/u.QuotaBytes, 100))
+}
+
 // Workout represents a workout plan
 type Workout struct {
 	ID           uuid.UUID  `json:"id"`
@@ -174,12 +339,238 @@ type Workout struct {
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 	IsRestDay    bool       `json:"is_rest_day"`
+
+	// Set by GetWorkoutsForDay when the active program schedules this workout, not stored
+	Scheduled *ProgramDay `json:"scheduled,omitempty"`
 }
 
-// Exercise represents a single exercise in a workout
+// Exercise represents a single exercise in a workout.
+// On templates the Target* fields describe the plan; on workout logs Sets
+// records what was actually performed.
 type Exercise struct {
-	Order int    `json:"order"`
-	Name  string `json:"name"`
+	Order        int           `json:"order"`
+	Name         string        `json:"name"`
+	ExerciseID   *uuid.UUID    `json:"exercise_id,omitempty"` // Exercise library entry, linked on save
+	TargetSets   int           `json:"target_sets,omitempty"`
+	TargetReps   int           `json:"target_reps,omitempty"`
+	TargetWeight float64       `json:"target_weight,omitempty"` // kg
+	Sets         []ExerciseSet `json:"sets,omitempty"`
+}
+
+// ExerciseSet represents one performed set of an exercise
+type ExerciseSet struct {
+	Reps        int     `json:"reps"`
+	Weight      float64 `json:"weight"`                 // kg, 0 = bodyweight
+	RPE         float64 `json:"rpe,omitempty"`          // 1-10, 0 = not recorded
+	RestSeconds int     `json:"rest_seconds,omitempty"` // rest taken after the set
+	Notes       string  `json:"notes,omitempty"`
+}
+
+// HasTarget reports whether the exercise has any planned target
+func (e Exercise) HasTarget() bool {
+	return e.TargetSets > 0 || e.TargetReps > 0 || e.TargetWeight > 0
+}
+
+// TargetLabel formats the exercise's targets, e.g. "3×10 @ 60kg"
+func (e Exercise) TargetLabel() string {
+	label := ""
+	switch {
+	case e.TargetSets > 0 && e.TargetReps > 0:
+		label = fmt.Sprintf("%d×%d", e.TargetSets, e.TargetReps)
+	case e.TargetSets > 0:
+		label = fmt.Sprintf("%d sets", e.TargetSets)
+	case e.TargetReps > 0:
+		label = fmt.Sprintf("%d reps", e.TargetReps)
+	}
+	if e.TargetWeight > 0 {
+		if label != "" {
+			label += " @ "
+		}
+		label += strconv.FormatFloat(e.TargetWeight, 'f', -1, 64) + "kg"
+	}
+	return label
+}
+
+// Volume returns the total lifted volume (reps × weight) across all sets
+func (e Exercise) Volume() float64 {
+	var v float64
+	for _, s := range e.Sets {
+		v += float64(s.Reps) * s.Weight
+	}
+	return v
+}
+
+// Muscle groups used by the exercise library
+const (
+	MuscleChest      = "chest"
+	MuscleBack       = "back"
+	MuscleShoulders  = "shoulders"
+	MuscleBiceps     = "biceps"
+	MuscleTriceps    = "triceps"
+	MuscleForearms   = "forearms"
+	MuscleQuads      = "quads"
+	MuscleHamstrings = "hamstrings"
+	MuscleGlutes     = "glutes"
+	MuscleCalves     = "calves"
+	MuscleCore       = "core"
+	MuscleFullBody   = "full_body"
+)
+
+// MuscleGroups lists the muscle groups in display order
+var MuscleGroups = []string{
+	MuscleChest, MuscleBack, MuscleShoulders, MuscleBiceps, MuscleTriceps, MuscleForearms,
+	MuscleQuads, MuscleHamstrings, MuscleGlutes, MuscleCalves, MuscleCore, MuscleFullBody,
+}
+
+// Equipment types used by the exercise library
+var Equipment = []string{"barbell", "dumbbell", "machine", "cable", "bodyweight", "kettlebell", "band", "other"}
+
+// LibraryExercise is an entry in the user's exercise catalogue
+type LibraryExercise struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Name             string    `json:"name"`
+	NameAr           string    `json:"name_ar"`
+	MuscleGroup      string    `json:"muscle_group"`
+	SecondaryMuscles []string  `json:"secondary_muscles"`
+	Equipment        string    `json:"equipment"`
+	Aliases          []string  `json:"aliases"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ExerciseLibrary indexes a user's catalogue by ID and by every name it's known by
+type ExerciseLibrary struct {
+	Exercises []LibraryExercise
+	byID      map[uuid.UUID]*LibraryExercise
+	byName    map[string]*LibraryExercise
+}
+
+// NewExerciseLibrary builds the lookup indexes for a catalogue
+func NewExerciseLibrary(exercises []LibraryExercise) *ExerciseLibrary {
+	lib := &ExerciseLibrary{
+		Exercises: exercises,
+		byID:      make(map[uuid.UUID]*LibraryExercise, len(exercises)),
+		byName:    make(map[string]*LibraryExercise, len(exercises)*3),
+	}
+	// Names and Arabic names first, so an alias never shadows another
+	// exercise's own name; within each pass the first entry wins
+	index := func(e *LibraryExercise, names []string) {
+		for _, name := range names {
+			if key := exerciseNameKey(name); key != "" {
+				if _, taken := lib.byName[key]; !taken {
+					lib.byName[key] = e
+				}
+			}
+		}
+	}
+	for i := range exercises {
+		e := &lib.Exercises[i]
+		lib.byID[e.ID] = e
+		index(e, []string{e.Name, e.NameAr})
+	}
+	for i := range lib.Exercises {
+		index(&lib.Exercises[i], lib.Exercises[i].Aliases)
+	}
+	return lib
+}
+
+// Get returns the entry with the given ID
+func (lib *ExerciseLibrary) Get(id uuid.UUID) *LibraryExercise {
+	if lib == nil {
+		return nil
+	}
+	return lib.byID[id]
+}
+
+// Find matches a free-text exercise name against names, Arabic names and aliases
+func (lib *ExerciseLibrary) Find(name string) *LibraryExercise {
+	if lib == nil {
+		return nil
+	}
+	return lib.byName[exerciseNameKey(name)]
+}
+
+// Lookup resolves a workout exercise by its linked ID, falling back to its name
+func (lib *ExerciseLibrary) Lookup(ex Exercise) *LibraryExercise {
+	if ex.ExerciseID != nil {
+		if e := lib.Get(*ex.ExerciseID); e != nil {
+			return e
+		}
+	}
+	return lib.Find(ex.Name)
+}
+
+// Link sets ExerciseID on every exercise that matches a library entry
+func (lib *ExerciseLibrary) Link(exercises []Exercise) {
+	for i := range exercises {
+		if e := lib.Lookup(exercises[i]); e != nil {
+			id := e.ID
+			exercises[i].ExerciseID = &id
+		} else {
+			exercises[i].ExerciseID = nil
+		}
+	}
+}
+
+// CanonicalName returns the library name for a free-text name, or the name itself
+func (lib *ExerciseLibrary) CanonicalName(name string) string {
+	if e := lib.Find(name); e != nil {
+		return e.Name
+	}
+	return name
+}
+
+// Canonicalize renames logged exercises to their library names in place, so
+// "bench press", "Bench Press" and "ضغط بنش" aggregate as one exercise
+func (lib *ExerciseLibrary) Canonicalize(logs []WorkoutLog) {
+	for i := range logs {
+		for j, ex := range logs[i].CompletedExercises {
+			if e := lib.Lookup(ex); e != nil {
+				logs[i].CompletedExercises[j].Name = e.Name
+			}
+		}
+	}
+}
+
+// Substitutions returns other exercises for the same primary muscle group,
+// the ones sharing the most secondary muscles first
+func (lib *ExerciseLibrary) Substitutions(id uuid.UUID) []LibraryExercise {
+	target := lib.Get(id)
+	if target == nil {
+		return nil
+	}
+
+	type candidate struct {
+		exercise LibraryExercise
+		shared   int
+	}
+	var candidates []candidate
+	for _, e := range lib.Exercises {
+		if e.ID == target.ID || e.MuscleGroup != target.MuscleGroup {
+			continue
+		}
+		shared := 0
+		for _, m := range e.SecondaryMuscles {
+			for _, t := range target.SecondaryMuscles {
+				if m == t {
+					shared++
+				}
+			}
+		}
+		candidates = append(candidates, candidate{e, shared})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].shared > candidates[j].shared })
+
+	subs := make([]LibraryExercise, len(candidates))
+	for i, c := range candidates {
+		subs[i] = c.exercise
+	}
+	return subs
+}
+
+func exerciseNameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
 }
 
 // WorkoutLog represents a completed workout session
@@ -194,12 +585,207 @@ type WorkoutLog struct {
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	IsRestDay          bool       `json:"is_rest_day"`
+
+	// Computed from earlier logs, not stored
+	PersonalRecords []PersonalRecord `json:"personal_records,omitempty"`
+}
+
+// PersonalRecord is a best-ever performance for an exercise, set by one workout log
+type PersonalRecord struct {
+	Exercise string    `json:"exercise"`
+	Kind     string    `json:"kind"` // heaviest_weight, most_reps, best_e1rm
+	Weight   float64   `json:"weight"`
+	Reps     int       `json:"reps"`
+	E1RM     float64   `json:"e1rm"`
+	Previous float64   `json:"previous"` // The record that was beaten (weight, reps or e1RM)
+	Date     time.Time `json:"date"`
+	LogID    uuid.UUID `json:"log_id"`
 }
 
 // Cardio represents cardio activity
 type Cardio struct {
-	Name    string `json:"name"`
-	Minutes int    `json:"minutes"`
+	Name            string     `json:"name"`
+	Minutes         int        `json:"minutes"`
+	DurationSeconds int        `json:"duration_seconds,omitempty"` // Exact duration when known (imports); Minutes is kept for older clients
+	DistanceKm      float64    `json:"distance_km,omitempty"`
+	AvgHeartRate    int        `json:"avg_heart_rate,omitempty"`
+	MaxHeartRate    int        `json:"max_heart_rate,omitempty"`
+	Calories        int        `json:"calories,omitempty"`
+	ElevationGainM  float64    `json:"elevation_gain_m,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	Source          string     `json:"source,omitempty"` // "" = manual, gpx, tcx, fit
+}
+
+// Seconds returns the session duration in seconds
+func (c Cardio) Seconds() int {
+	if c.DurationSeconds > 0 {
+		return c.DurationSeconds
+	}
+	return c.Minutes * 60
+}
+
+// PaceSecondsPerKm returns the average pace, or 0 without distance and duration
+func (c Cardio) PaceSecondsPerKm() int {
+	if c.DistanceKm <= 0 || c.Seconds() <= 0 {
+		return 0
+	}
+	return int(float64(c.Seconds())/c.DistanceKm + 0.5)
+}
+
+// PaceLabel formats the pace as "m:ss /km", empty when unknown
+func (c Cardio) PaceLabel() string {
+	pace := c.PaceSecondsPerKm()
+	if pace == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d /km", pace/60, pace%60)
+}
+
+// Body metric units
+const (
+	UnitKg = "kg"
+	UnitLb = "lb"
+)
+
+// KgPerLb converts pounds to kilograms
+const KgPerLb = 0.45359237
+
+// CmPerInch converts inches to centimeters
+const CmPerInch = 2.54
+
+// BodyMetric is one day's body weight and measurements (always stored in kg / cm)
+type BodyMetric struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Date       time.Time `json:"date"`
+	WeightKg   *float64  `json:"weight_kg,omitempty"`
+	BodyFatPct *float64  `json:"body_fat_pct,omitempty"`
+	WaistCm    *float64  `json:"waist_cm,omitempty"`
+	ChestCm    *float64  `json:"chest_cm,omitempty"`
+	HipsCm     *float64  `json:"hips_cm,omitempty"`
+	ArmCm      *float64  `json:"arm_cm,omitempty"`
+	ThighCm    *float64  `json:"thigh_cm,omitempty"`
+	NeckCm     *float64  `json:"neck_cm,omitempty"`
+	Notes      string    `json:"notes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// BodyMetricSettings holds the display unit and goal weight
+type BodyMetricSettings struct {
+	Unit         string   `json:"unit"`                     // kg or lb
+	GoalWeightKg *float64 `json:"goal_weight_kg,omitempty"` // nil = no goal
+}
+
+// ToDisplayWeight converts kilograms to the user's unit
+func (s *BodyMetricSettings) ToDisplayWeight(kg float64) float64 {
+	if s.Unit == UnitLb {
+		return kg / KgPerLb
+	}
+	return kg
+}
+
+// FromDisplayWeight converts a weight in the user's unit to kilograms
+func (s *BodyMetricSettings) FromDisplayWeight(w float64) float64 {
+	if s.Unit == UnitLb {
+		return w * KgPerLb
+	}
+	return w
+}
+
+// ToDisplayLength converts centimeters to the user's unit (inches when using lb)
+func (s *BodyMetricSettings) ToDisplayLength(cm float64) float64 {
+	if s.Unit == UnitLb {
+		return cm / CmPerInch
+	}
+	return cm
+}
+
+// FromDisplayLength converts a length in the user's unit to centimeters
+func (s *BodyMetricSettings) FromDisplayLength(l float64) float64 {
+	if s.Unit == UnitLb {
+		return l * CmPerInch
+	}
+	return l
+}
+
+// LengthUnit returns the length unit that goes with the weight unit
+func (s *BodyMetricSettings) LengthUnit() string {
+	if s.Unit == UnitLb {
+		return "in"
+	}
+	return "cm"
+}
+
+// Training program schedule types
+const (
+	ScheduleWeekly   = "weekly"   // Slots are Sunday..Saturday
+	ScheduleRotation = "rotation" // Slots cycle day by day from the start date
+)
+
+// TrainingProgram is a multi-week plan that schedules workout templates
+type TrainingProgram struct {
+	ID                     uuid.UUID    `json:"id"`
+	UserID                 uuid.UUID    `json:"user_id"`
+	Name                   string       `json:"name"`
+	Description            string       `json:"description"`
+	ScheduleType           string       `json:"schedule_type"` // weekly, rotation
+	Slots                  []*uuid.UUID `json:"slots"`         // Workout IDs, nil = rest day
+	Weeks                  int          `json:"weeks"`         // 0 = open-ended
+	DeloadEvery            int          `json:"deload_every"`  // 0 = never
+	DeloadPercent          int          `json:"deload_percent"`
+	ProgressionIncrementKg float64      `json:"progression_increment_kg"` // 0 = no progression
+	StartDate              time.Time    `json:"start_date"`
+	IsActive               bool         `json:"is_active"`
+	CreatedAt              time.Time    `json:"created_at"`
+	UpdatedAt              time.Time    `json:"updated_at"`
+}
+
+// ProgramDay is what a program schedules on a given date
+type ProgramDay struct {
+	ProgramID   uuid.UUID  `json:"program_id"`
+	ProgramName string     `json:"program_name"`
+	Week        int        `json:"week"`        // 1-based
+	TotalWeeks  int        `json:"total_weeks"` // 0 = open-ended
+	Deload      bool       `json:"deload"`
+	IsRestDay   bool       `json:"is_rest_day"`
+	WorkoutID   *uuid.UUID `json:"workout_id,omitempty"`
+}
+
+// DayFor returns what the program schedules on date, or nil before it starts,
+// after its last week, or when it has no slots
+func (p *TrainingProgram) DayFor(date time.Time) *ProgramDay {
+	start := time.Date(p.StartDate.Year(), p.StartDate.Month(), p.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	offset := int(day.Sub(start).Hours() / 24)
+	if offset < 0 || len(p.Slots) == 0 {
+		return nil
+	}
+
+	week := offset/7 + 1
+	if p.Weeks > 0 && week > p.Weeks {
+		return nil
+	}
+
+	var slot *uuid.UUID
+	if p.ScheduleType == ScheduleRotation {
+		slot = p.Slots[offset%len(p.Slots)]
+	} else {
+		if int(date.Weekday()) >= len(p.Slots) {
+			return nil
+		}
+		slot = p.Slots[date.Weekday()]
+	}
+
+	return &ProgramDay{
+		ProgramID:   p.ID,
+		ProgramName: p.Name,
+		Week:        week,
+		TotalWeeks:  p.Weeks,
+		Deload:      p.DeloadEvery > 0 && week%p.DeloadEvery == 0,
+		IsRestDay:   slot == nil,
+		WorkoutID:   slot,
+	}
 }
 
 // MarkdownNote represents a long-form note
@@ -214,6 +800,18 @@ type MarkdownNote struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Revision is a prior version of a daily note or blog post
+type Revision struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	EntityType string    `json:"entity_type"` // "note" or "markdown_note"
+	EntityID   uuid.UUID `json:"entity_id"`
+	Title      string    `json:"title"` // Blog posts only
+	Content    string    `json:"content"`
+	Source     string    `json:"source"` // Where the overwriting change came from: "web", "api" or "sync"
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 
 // Show represents a TV show or anime
 type Show struct {
@@ -279,33 +877,112 @@ type Task struct {
 	IsDeleted    bool       `json:"is_deleted"`
 	Collapsed    bool       `json:"collapsed"`
 	Completed    bool       `json:"completed"`
+	Blocked      bool       `json:"blocked"` // Has a prerequisite that isn't completed
+}
+
+// TaskDependency records that a task can't be completed before another one
+type TaskDependency struct {
+	ID              uuid.UUID `json:"id"`
+	TaskID          uuid.UUID `json:"task_id"`
+	DependsOnTaskID uuid.UUID `json:"depends_on_task_id"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 // CalendarEvent represents a calendar event (birthday, travel, holiday, anniversary, general)
 type CalendarEvent struct {
-	ID          uuid.UUID  `json:"id"`
-	UserID      uuid.UUID  `json:"user_id"`
-	Title       string     `json:"title"`
-	EventType   string     `json:"event_type"`   // birthday, travel, holiday, anniversary, general
-	EventDate   time.Time  `json:"event_date"`   // Start date of the event
-	EndDate     *time.Time `json:"end_date"`     // End date (optional, for date ranges)
-	IsRecurring bool       `json:"is_recurring"` // Repeats yearly?
-	Notes       string     `json:"notes"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	IsDeleted   bool       `json:"is_deleted"`
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Title        string     `json:"title"`
+	EventType    string     `json:"event_type"`           // birthday, travel, holiday, anniversary, general
+	EventDate    time.Time  `json:"event_date"`           // Start date of the event (first occurrence for rule series)
+	EndDate      *time.Time `json:"end_date"`             // End date (optional, for date ranges)
+	IsRecurring  bool       `json:"is_recurring"`         // Repeats? Yearly unless RRule is set
+	CalendarType string     `json:"calendar_type"`        // gregorian or hijri: which calendar the yearly repeat follows
+	RRule        string     `json:"rrule,omitempty"`      // RFC 5545 rule (e.g. FREQ=WEEKLY;BYDAY=SU); empty = yearly when IsRecurring
+	StartTime    string     `json:"start_time,omitempty"` // "HH:MM" local to TimeZone; empty = all-day
+	EndTime      string     `json:"end_time,omitempty"`   // "HH:MM", optional
+	TimeZone     string     `json:"time_zone,omitempty"`  // IANA zone of the times; empty = Asia/Kuwait
+	Reminders    []int      `json:"reminders"`            // Minutes before the start to remind
+	Notes        string     `json:"notes"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	IsDeleted    bool       `json:"is_deleted"`
+
+	Exceptions []CalendarEventException `json:"exceptions,omitempty"` // Changed occurrences of a rule series
 }
 
+// CalendarEventException cancels or overrides one occurrence of a recurring
+// series. OccurrenceDate is the date the rule puts the occurrence on; nil
+// override fields keep the series' values.
+type CalendarEventException struct {
+	ID             uuid.UUID  `json:"id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	OccurrenceDate time.Time  `json:"occurrence_date"`
+	IsCancelled    bool       `json:"is_cancelled"`
+	Title          *string    `json:"title,omitempty"`
+	EventDate      *time.Time `json:"event_date,omitempty"` // Moved to this date
+	Notes          *string    `json:"notes,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Calendars a recurring event can repeat on
+const (
+	CalendarGregorian = "gregorian"
+	CalendarHijri     = "hijri"
+)
+
 // HasDateRange returns true if the event spans multiple days
 func (e *CalendarEvent) HasDateRange() bool {
 	return e.EndDate != nil && !e.EndDate.IsZero()
 }
 
+// IsHijri returns true if the event repeats yearly on its Hijri date
+func (e *CalendarEvent) IsHijri() bool {
+	return e.IsRecurring && e.CalendarType == CalendarHijri
+}
+
+// HasRule returns true if the event repeats by a recurrence rule rather than yearly
+func (e *CalendarEvent) HasRule() bool {
+	return e.IsRecurring && e.RRule != ""
+}
+
+// CalendarSettings holds the user's calendar display preferences
+type CalendarSettings struct {
+	ShowIslamicHolidays bool `json:"show_islamic_holidays"`
+}
+
+// CalendarFeed is a user's secret-token iCalendar subscription
+type CalendarFeed struct {
+	UserID             uuid.UUID `json:"user_id"`
+	Token              string    `json:"token"`
+	IncludeMedications bool      `json:"include_medications"`
+	IncludeTasks       bool      `json:"include_tasks"`
+	CreatedAt          time.Time `json:"created_at"`
+	RotatedAt          time.Time `json:"rotated_at"`
+}
+
 // CalendarEventForDay includes additional display info
 type CalendarEventForDay struct {
 	CalendarEvent
-	YearsAgo int  `json:"years_ago"` // Years since original event (for birthdays)
-	IsToday  bool `json:"is_today"`  // Is the event today?
+	YearsAgo  int  `json:"years_ago"`  // Years since original event (for birthdays; Hijri years for Hijri events)
+	IsToday   bool `json:"is_today"`   // Is the event today?
+	IsBuiltin bool `json:"is_builtin"` // Islamic holiday from the overlay, not a stored event
+
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty"` // For rule series: the date the rule puts this occurrence on
+}
+
+// EventNotification is a calendar reminder that came due, queued for delivery
+type EventNotification struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	OccurrenceDate time.Time  `json:"occurrence_date"`
+	RemindMinutes  int        `json:"remind_minutes"` // Minutes before the start
+	FireAt         time.Time  `json:"fire_at"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // DashboardData holds all data for the main dashboard
@@ -319,17 +996,101 @@ type DashboardData struct {
 	MoodRating     *MoodRating
 	Workouts       []Workout
 	WorkoutLog     *WorkoutLog
-	CalendarEvents []CalendarEventForDay // Calendar events for the day
-	WeekEvents     map[string]bool       // Dates with events in the current week
+	CalendarEvents []CalendarEventForDay     // Calendar events for the day
+	Reminders      []EventNotification       // Calendar reminders that are due
+	WeekEvents     map[string]bool           // Dates with events in the current week
+	Journal        []NoteTemplateWithEntries // Journaling templates with today's values
+	JournalPrompt  string                    // Guided prompt of the day
+	Memories       []Memory                  // Same day in earlier months and years
+	ProgramDay     *ProgramDay               // What the active training program schedules today
+}
+
+// Memory kinds
+const (
+	MemoryMonthsAgo = "months"
+	MemoryYearsAgo  = "years"
+)
+
+// Memory is a past day resurfaced on a later date ("on this day")
+type Memory struct {
+	Date       time.Time    `json:"date"`
+	Kind       string       `json:"kind"` // months, years
+	Ago        int          `json:"ago"`  // How many months or years back
+	Note       *Note        `json:"note,omitempty"`
+	Images     []DailyImage `json:"images"`
+	Mood       *MoodRating  `json:"mood,omitempty"`
+	WorkoutLog *WorkoutLog  `json:"workout_log,omitempty"`
+}
+
+// ReviewData holds the aggregated stats for a weekly or monthly review
+type ReviewData struct {
+	Period              string            `json:"period"` // "week" or "month"
+	From                time.Time         `json:"from"`
+	To                  time.Time         `json:"to"`
+	Habits              []HabitReviewStat `json:"habits"`
+	HabitRate           float64           `json:"habit_rate"` // 0-1, -1 if nothing was scheduled
+	DosesScheduled      int               `json:"doses_scheduled"`
+	DosesTaken          int               `json:"doses_taken"`
+	MedicationAdherence float64           `json:"medication_adherence"` // 0-1, -1 if nothing was due
+	Mood                []MoodPoint       `json:"mood"`
+	MoodAverage         float64           `json:"mood_average"`
+	MoodDays            int               `json:"mood_days"`
+	PreviousMoodAverage float64           `json:"previous_mood_average"` // Same-length period before this one
+	PreviousMoodDays    int               `json:"previous_mood_days"`
+	WorkoutsDone        int               `json:"workouts_done"`
+	StartWeight         *float64          `json:"start_weight"`
+	EndWeight           *float64          `json:"end_weight"`
+	CompletedTodos      []Todo            `json:"completed_todos"`
+	CompletedTasks      []Task            `json:"completed_tasks"`
+	UpcomingEvents      []ReviewEvent     `json:"upcoming_events"` // Events in the following period
+	Summary             *MonthlySummary   `json:"summary"`         // Monthly reviews only
+}
+
+// WeightChange returns the body weight difference over the period (0 if unknown)
+func (r *ReviewData) WeightChange() float64 {
+	if r.StartWeight == nil || r.EndWeight == nil {
+		return 0
+	}
+	return *r.EndWeight - *r.StartWeight
+}
+
+// HabitReviewStat holds completion counts for a single habit within a review period
+type HabitReviewStat struct {
+	HabitID   uuid.UUID `json:"habit_id"`
+	Name      string    `json:"name"`
+	Icon      string    `json:"icon"`
+	Scheduled int       `json:"scheduled"`
+	Completed int       `json:"completed"`
+}
+
+// Rate returns the completion rate (0-1)
+func (s HabitReviewStat) Rate() float64 {
+	if s.Scheduled == 0 {
+		return 0
+	}
+	return float64(s.Completed) / float64(s.Scheduled)
+}
+
+// MoodPoint is a single day in the mood trend
+type MoodPoint struct {
+	Date   time.Time `json:"date"`
+	Rating *int      `json:"rating"`
+}
+
+// ReviewEvent is a calendar event occurrence on a specific date
+type ReviewEvent struct {
+	Date  time.Time           `json:"date"`
+	Event CalendarEventForDay `json:"event"`
 }
 
 // DailyNoteEntry holds data for a single day in the daily notes page
 type DailyNoteEntry struct {
-	Date   time.Time
-	Note   *Note
-	Todos  []Todo
-	Images []DailyImage
-	Mood   *MoodRating
+	Date    time.Time
+	Note    *Note
+	Todos   []Todo
+	Images  []DailyImage
+	Mood    *MoodRating
+	Journal []NoteTemplateWithEntries
 }
 
 // MonthlySummary represents a monthly summary (AI-generated or manual)
@@ -373,6 +1134,7 @@ type BlogImage struct {
 	Filename       string     `json:"filename"`
 	MimeType       string     `json:"mime_type"`
 	SizeBytes      int        `json:"size_bytes"`
+	Variants       ImageVariants `json:"variants"`
 	PositionMarker string     `json:"position_marker"`
 	IsDeleted      bool       `json:"is_deleted"`
 	CreatedAt      time.Time  `json:"created_at"`
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetNoteTemplates retrieves all active journaling templates for a user
+func (db *DB) GetNoteTemplates(ctx context.Context, userID uuid.UUID) ([]NoteTemplate, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, name, icon, fields, display_order, is_deleted, created_at, updated_at
+		FROM note_templates
+		WHERE user_id = $1 AND is_deleted = false
+		ORDER BY display_order, created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []NoteTemplate
+	for rows.Next() {
+		var t NoteTemplate
+		var fieldsJSON []byte
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Icon, &fieldsJSON, &t.DisplayOrder, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(fieldsJSON, &t.Fields)
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// GetNoteTemplateByID retrieves a single template owned by the user
+func (db *DB) GetNoteTemplateByID(ctx context.Context, templateID, userID uuid.UUID) (*NoteTemplate, error) {
+	var t NoteTemplate
+	var fieldsJSON []byte
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, name, icon, fields, display_order, is_deleted, created_at, updated_at
+		FROM note_templates
+		WHERE id = $1 AND user_id = $2 AND is_deleted = false
+	`, templateID, userID).Scan(&t.ID, &t.UserID, &t.Name, &t.Icon, &fieldsJSON, &t.DisplayOrder, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	json.Unmarshal(fieldsJSON, &t.Fields)
+	return &t, nil
+}
+
+// CreateNoteTemplate creates a new journaling template
+func (db *DB) CreateNoteTemplate(ctx context.Context, userID uuid.UUID, name, icon string, fields []NoteTemplateField) (*NoteTemplate, error) {
+	fieldsJSON, _ := json.Marshal(fields)
+	if icon == "" {
+		icon = "📝"
+	}
+
+	var t NoteTemplate
+	var fieldsBytes []byte
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO note_templates (user_id, name, icon, fields, display_order)
+		VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(display_order), 0) + 1 FROM note_templates WHERE user_id = $1))
+		RETURNING id, user_id, name, icon, fields, display_order, is_deleted, created_at, updated_at
+	`, userID, name, icon, fieldsJSON).Scan(&t.ID, &t.UserID, &t.Name, &t.Icon, &fieldsBytes, &t.DisplayOrder, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	json.Unmarshal(fieldsBytes, &t.Fields)
+	return &t, nil
+}
+
+// UpdateNoteTemplate updates a template's name, icon and fields
+// Existing entries keep their field_key, so renaming a label doesn't lose history
+func (db *DB) UpdateNoteTemplate(ctx context.Context, templateID uuid.UUID, name, icon string, fields []NoteTemplateField) error {
+	fieldsJSON, _ := json.Marshal(fields)
+	if icon == "" {
+		icon = "📝"
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE note_templates SET name = $2, icon = $3, fields = $4, updated_at = NOW()
+		WHERE id = $1
+	`, templateID, name, icon, fieldsJSON)
+	return err
+}
+
+// SoftDeleteNoteTemplate marks a template as deleted (entries are kept)
+func (db *DB) SoftDeleteNoteTemplate(ctx context.Context, templateID uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE note_templates SET is_deleted = true, updated_at = NOW() WHERE id = $1
+	`, templateID)
+	return err
+}
+
+// GetNoteTemplatesForDay retrieves all templates with their values for a specific day
+func (db *DB) GetNoteTemplatesForDay(ctx context.Context, userID uuid.UUID, date time.Time) ([]NoteTemplateWithEntries, error) {
+	templates, err := db.GetNoteTemplates(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	entries, err := db.GetNoteEntries(ctx, userID, nil, "", date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	byTemplate := make(map[uuid.UUID]map[string]NoteEntry)
+	for _, e := range entries {
+		if byTemplate[e.TemplateID] == nil {
+			byTemplate[e.TemplateID] = make(map[string]NoteEntry)
+		}
+		byTemplate[e.TemplateID][e.FieldKey] = e
+	}
+
+	result := make([]NoteTemplateWithEntries, 0, len(templates))
+	for _, t := range templates {
+		values := byTemplate[t.ID]
+		if values == nil {
+			values = make(map[string]NoteEntry)
+		}
+		result = append(result, NoteTemplateWithEntries{NoteTemplate: t, Entries: values})
+	}
+
+	return result, nil
+}
+
+// SaveNoteEntry creates or updates a template field value for a day (empty value removes it)
+func (db *DB) SaveNoteEntry(ctx context.Context, userID, templateID uuid.UUID, fieldKey string, date time.Time, value string) error {
+	dateStr := date.Format("2006-01-02")
+
+	if value == "" {
+		_, err := db.Pool.Exec(ctx, `
+			DELETE FROM note_entries WHERE template_id = $1 AND field_key = $2 AND date = $3 AND user_id = $4
+		`, templateID, fieldKey, dateStr, userID)
+		return err
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO note_entries (user_id, template_id, field_key, date, value)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (template_id, field_key, date) DO UPDATE SET value = $5, updated_at = NOW()
+	`, userID, templateID, fieldKey, dateStr, value)
+	return err
+}
+
+// GetNoteEntries retrieves template field values between from and to (inclusive)
+// templateID and fieldKey are optional filters
+func (db *DB) GetNoteEntries(ctx context.Context, userID uuid.UUID, templateID *uuid.UUID, fieldKey string, from, to time.Time) ([]NoteEntry, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT e.id, e.user_id, e.template_id, e.field_key, e.date, e.value, e.created_at, e.updated_at
+		FROM note_entries e
+		JOIN note_templates t ON t.id = e.template_id
+		WHERE e.user_id = $1 AND t.is_deleted = false
+		  AND e.date >= $2 AND e.date <= $3
+		  AND ($4::uuid IS NULL OR e.template_id = $4)
+		  AND ($5 = '' OR e.field_key = $5)
+		ORDER BY e.date ASC, t.display_order
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"), templateID, fieldKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []NoteEntry
+	for rows.Next() {
+		var e NoteEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.TemplateID, &e.FieldKey, &e.Date, &e.Value, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -30,12 +30,52 @@ func (db *DB) GetNoteForDay(ctx context.Context, userID uuid.UUID, date time.Tim
 	return &n, nil
 }
 
+// GetNoteByID retrieves a note by its ID
+func (db *DB) GetNoteByID(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (*Note, error) {
+	var n Note
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, text, date, created_at, updated_at
+		FROM notes
+		WHERE id = $1 AND user_id = $2
+	`, noteID, userID).Scan(&n.ID, &n.UserID, &n.Text, &n.Date, &n.CreatedAt, &n.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &n, nil
+}
+
 // SaveNote creates or updates a note for a specific day
-func (db *DB) SaveNote(ctx context.Context, userID uuid.UUID, text string, date time.Time) (*Note, error) {
+// The previous text is kept as a revision; source is one of the RevisionSource constants
+func (db *DB) SaveNote(ctx context.Context, userID uuid.UUID, text string, date time.Time, source string) (*Note, error) {
 	dateStr := date.Format("2006-01-02")
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var prevID uuid.UUID
+	var prevText string
+	err = tx.QueryRow(ctx, `
+		SELECT id, text FROM notes WHERE user_id = $1 AND date = $2 FOR UPDATE
+	`, userID, dateStr).Scan(&prevID, &prevText)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && prevText != "" && prevText != text {
+		if err := saveRevision(ctx, tx, userID, RevisionEntityNote, prevID, "", prevText, source); err != nil {
+			return nil, err
+		}
+	}
+
 	var n Note
-	err := db.Pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		INSERT INTO notes (user_id, text, date)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (user_id, date) DO UPDATE SET text = $2, updated_at = NOW()
@@ -46,6 +86,10 @@ func (db *DB) SaveNote(ctx context.Context, userID uuid.UUID, text string, date
 		return nil, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return &n, nil
 }
 
@@ -117,7 +161,7 @@ func (db *DB) GetNotesForMonth(ctx context.Context, userID uuid.UUID, year int,
 	return notes, rows.Err()
 }
 
-// GetDatesWithContentForMonth retrieves all unique dates that have notes, images, or todos
+// GetDatesWithContentForMonth retrieves all unique dates that have notes, images, todos, or journal entries
 func (db *DB) GetDatesWithContentForMonth(ctx context.Context, userID uuid.UUID, year int, month int) ([]time.Time, error) {
 	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, 0).Add(-time.Second)
@@ -129,6 +173,8 @@ func (db *DB) GetDatesWithContentForMonth(ctx context.Context, userID uuid.UUID,
 			SELECT date FROM daily_images WHERE user_id = $1 AND date >= $2 AND date <= $3
 			UNION
 			SELECT date FROM todos WHERE user_id = $1 AND date >= $2 AND date <= $3
+			UNION
+			SELECT date FROM note_entries WHERE user_id = $1 AND date >= $2 AND date <= $3
 		) AS all_dates
 		ORDER BY date ASC
 	`, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"ohabits/internal/services/prayer"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidPrayerAnchor is returned for an anchor with an unknown prayer or
+// an offset beyond prayer.MaxOffsetMinutes
+var ErrInvalidPrayerAnchor = errors.New("invalid prayer anchor")
+
+// Valid reports whether a is a known prayer within the allowed offset
+func (a PrayerAnchor) Valid() bool {
+	return prayer.Prayer(a.Prayer).Valid() &&
+		a.OffsetMinutes >= -prayer.MaxOffsetMinutes && a.OffsetMinutes <= prayer.MaxOffsetMinutes
+}
+
+// GetPrayerSettings returns the user's prayer time settings, defaulting to
+// Kuwait City with the Kuwaiti method
+func (db *DB) GetPrayerSettings(ctx context.Context, userID uuid.UUID) (*PrayerSettings, error) {
+	settings := PrayerSettings{
+		Latitude:  prayer.DefaultLatitude,
+		Longitude: prayer.DefaultLongitude,
+		Method:    prayer.DefaultMethod,
+	}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT latitude, longitude, method FROM prayer_settings WHERE user_id = $1
+	`, userID).Scan(&settings.Latitude, &settings.Longitude, &settings.Method)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SavePrayerSettings stores the user's location and calculation method
+func (db *DB) SavePrayerSettings(ctx context.Context, userID uuid.UUID, settings PrayerSettings) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO prayer_settings (user_id, latitude, longitude, method, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET latitude = $2, longitude = $3, method = $4, updated_at = NOW()
+	`, userID, settings.Latitude, settings.Longitude, settings.Method)
+	return err
+}
@@ -0,0 +1,24 @@
+package database
+
+import "testing"
+
+func TestPrayerAnchorValid(t *testing.T) {
+	tests := []struct {
+		anchor PrayerAnchor
+		want   bool
+	}{
+		{PrayerAnchor{Prayer: "fajr"}, true},
+		{PrayerAnchor{Prayer: "maghrib", OffsetMinutes: -180}, true},
+		{PrayerAnchor{Prayer: "isha", OffsetMinutes: 180}, true},
+		{PrayerAnchor{Prayer: "isha", OffsetMinutes: 181}, false},
+		{PrayerAnchor{Prayer: "dhuhr", OffsetMinutes: -1000}, false},
+		{PrayerAnchor{Prayer: "witr"}, false},
+		{PrayerAnchor{Prayer: "Fajr"}, false},
+		{PrayerAnchor{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.anchor.Valid(); got != tt.want {
+			t.Errorf("%+v.Valid() = %v, want %v", tt.anchor, got, tt.want)
+		}
+	}
+}
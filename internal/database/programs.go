@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const programColumns = `id, user_id, name, description, schedule_type, slots, weeks, deload_every,
+	deload_percent, progression_increment_kg::float8, start_date, is_active, created_at, updated_at`
+
+func scanProgram(row pgx.Row) (*TrainingProgram, error) {
+	var p TrainingProgram
+	var slotsJSON []byte
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.Name, &p.Description, &p.ScheduleType, &slotsJSON, &p.Weeks, &p.DeloadEvery,
+		&p.DeloadPercent, &p.ProgressionIncrementKg, &p.StartDate, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(slotsJSON, &p.Slots)
+	if p.Slots == nil {
+		p.Slots = []*uuid.UUID{}
+	}
+	return &p, nil
+}
+
+// GetPrograms retrieves all training programs for a user, active first
+func (db *DB) GetPrograms(ctx context.Context, userID uuid.UUID) ([]TrainingProgram, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+programColumns+`
+		FROM training_programs
+		WHERE user_id = $1
+		ORDER BY is_active DESC, created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var programs []TrainingProgram
+	for rows.Next() {
+		p, err := scanProgram(rows)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, *p)
+	}
+
+	return programs, rows.Err()
+}
+
+// GetProgramByID retrieves a training program owned by the user
+func (db *DB) GetProgramByID(ctx context.Context, userID, id uuid.UUID) (*TrainingProgram, error) {
+	return scanProgram(db.Pool.QueryRow(ctx, `
+		SELECT `+programColumns+`
+		FROM training_programs
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+}
+
+// GetActiveProgram retrieves the user's active program, or nil if none is active
+func (db *DB) GetActiveProgram(ctx context.Context, userID uuid.UUID) (*TrainingProgram, error) {
+	p, err := scanProgram(db.Pool.QueryRow(ctx, `
+		SELECT `+programColumns+`
+		FROM training_programs
+		WHERE user_id = $1 AND is_active
+	`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// CreateProgram creates a training program (inactive until activated)
+func (db *DB) CreateProgram(ctx context.Context, userID uuid.UUID, p TrainingProgram) (*TrainingProgram, error) {
+	slotsJSON, _ := json.Marshal(p.Slots)
+	return scanProgram(db.Pool.QueryRow(ctx, `
+		INSERT INTO training_programs (user_id, name, description, schedule_type, slots, weeks,
+			deload_every, deload_percent, progression_increment_kg, start_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING `+programColumns,
+		userID, p.Name, p.Description, p.ScheduleType, slotsJSON, p.Weeks,
+		p.DeloadEvery, p.DeloadPercent, p.ProgressionIncrementKg, p.StartDate.Format("2006-01-02"),
+	))
+}
+
+// UpdateProgram updates a training program owned by the user
+func (db *DB) UpdateProgram(ctx context.Context, userID uuid.UUID, p TrainingProgram) (*TrainingProgram, error) {
+	slotsJSON, _ := json.Marshal(p.Slots)
+	return scanProgram(db.Pool.QueryRow(ctx, `
+		UPDATE training_programs SET
+			name = $3, description = $4, schedule_type = $5, slots = $6, weeks = $7,
+			deload_every = $8, deload_percent = $9, progression_increment_kg = $10,
+			start_date = $11, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+programColumns,
+		p.ID, userID, p.Name, p.Description, p.ScheduleType, slotsJSON, p.Weeks,
+		p.DeloadEvery, p.DeloadPercent, p.ProgressionIncrementKg, p.StartDate.Format("2006-01-02"),
+	))
+}
+
+// DeleteProgram deletes a training program owned by the user
+func (db *DB) DeleteProgram(ctx context.Context, userID, id uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM training_programs WHERE id = $1 AND user_id = $2`, id, userID)
+	return err
+}
+
+// SetActiveProgram makes the program the user's only active one.
+// A nil id deactivates all programs.
+func (db *DB) SetActiveProgram(ctx context.Context, userID uuid.UUID, id *uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE training_programs SET is_active = false, updated_at = NOW()
+		WHERE user_id = $1 AND is_active
+	`, userID); err != nil {
+		return err
+	}
+
+	if id != nil {
+		tag, err := tx.Exec(ctx, `
+			UPDATE training_programs SET is_active = true, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`, *id, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetProgramDay returns what the active program schedules on date, or nil when
+// there's no active program or the date falls outside it
+func (db *DB) GetProgramDay(ctx context.Context, userID uuid.UUID, date time.Time) (*ProgramDay, error) {
+	program, err := db.GetActiveProgram(ctx, userID)
+	if err != nil || program == nil {
+		return nil, err
+	}
+	return program.DayFor(date), nil
+}
+
+// progressionSessions is how many of the latest logged sessions are searched
+// for an exercise's last sets; older history doesn't change the next target
+const progressionSessions = 60
+
+// scheduleWorkouts moves the workout the program schedules on date to the front
+// and replaces its targets with the progressed ones
+func (db *DB) scheduleWorkouts(ctx context.Context, userID uuid.UUID, program *TrainingProgram, date time.Time, workouts []Workout) ([]Workout, error) {
+	day := program.DayFor(date)
+	if day == nil || day.WorkoutID == nil {
+		return workouts, nil
+	}
+
+	idx := -1
+	for i, w := range workouts {
+		if w.ID == *day.WorkoutID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		// The scheduled template was deleted
+		return workouts, nil
+	}
+
+	logs, err := db.GetWorkoutLogsWithSets(ctx, userID, date.AddDate(0, 0, -1), progressionSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := workouts[idx]
+	scheduled.Scheduled = day
+	scheduled.Exercises = progressExercises(program, day, scheduled.Exercises, logs)
+
+	ordered := make([]Workout, 0, len(workouts))
+	ordered = append(ordered, scheduled)
+	ordered = append(ordered, workouts[:idx]...)
+	ordered = append(ordered, workouts[idx+1:]...)
+	return ordered, nil
+}
+
+// progressExercises applies the program's progression rule to each exercise:
+// if every set of the last non-deload session hit the target reps, the top
+// weight goes up by the increment; otherwise it's repeated. Exercises never
+// logged keep the template's target. Deload weeks scale the weight down.
+func progressExercises(program *TrainingProgram, day *ProgramDay, exercises []Exercise, logs []WorkoutLog) []Exercise {
+	result := make([]Exercise, len(exercises))
+	for i, e := range exercises {
+		e.Sets = nil
+		if program.ProgressionIncrementKg > 0 {
+			if last := lastSession(program, e, logs); last != nil {
+				top := 0.0
+				for _, s := range last.Sets {
+					top = math.Max(top, s.Weight)
+				}
+				if top > 0 {
+					e.TargetWeight = top
+					if hitTargets(e, last.Sets) {
+						e.TargetWeight += program.ProgressionIncrementKg
+					}
+				}
+			}
+		}
+		if day.Deload && e.TargetWeight > 0 {
+			e.TargetWeight = roundToHalf(e.TargetWeight * float64(program.DeloadPercent) / 100)
+		}
+		result[i] = e
+	}
+	return result
+}
+
+// lastSession finds the most recent logged sets of an exercise (matched by
+// library link or name), skipping sessions in the program's deload weeks
+func lastSession(program *TrainingProgram, target Exercise, logs []WorkoutLog) *Exercise {
+	key := exerciseNameKey(target.Name)
+	for i := len(logs) - 1; i >= 0; i-- {
+		if d := program.DayFor(logs[i].Date); d != nil && d.Deload {
+			continue
+		}
+		for j := range logs[i].CompletedExercises {
+			e := &logs[i].CompletedExercises[j]
+			if len(e.Sets) == 0 {
+				continue
+			}
+			sameLink := target.ExerciseID != nil && e.ExerciseID != nil && *target.ExerciseID == *e.ExerciseID
+			if sameLink || exerciseNameKey(e.Name) == key {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// hitTargets reports whether the logged sets met the planned sets and reps
+func hitTargets(target Exercise, sets []ExerciseSet) bool {
+	if target.TargetReps <= 0 || len(sets) < target.TargetSets {
+		return false
+	}
+	for _, s := range sets {
+		if s.Reps < target.TargetReps {
+			return false
+		}
+	}
+	return true
+}
+
+func roundToHalf(v float64) float64 {
+	return math.Round(v*2) / 2
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestProgramDayFor(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	start := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC) // a Sunday
+	on := func(days int) time.Time { return start.AddDate(0, 0, days).Add(18 * time.Hour) }
+
+	weekly := &TrainingProgram{
+		ScheduleType: ScheduleWeekly,
+		Slots:        []*uuid.UUID{nil, &a, nil, &b, nil, nil, nil},
+		Weeks:        4,
+		DeloadEvery:  4,
+		StartDate:    start,
+	}
+	rotation := &TrainingProgram{
+		ScheduleType: ScheduleRotation,
+		Slots:        []*uuid.UUID{&a, &b, nil},
+		StartDate:    start.AddDate(0, 0, 1), // Monday
+	}
+
+	tests := []struct {
+		name    string
+		program *TrainingProgram
+		date    time.Time
+		want    *ProgramDay // nil = nothing scheduled
+	}{
+		{"before the start", weekly, on(-1), nil},
+		{"weekly rest day", weekly, on(0), &ProgramDay{Week: 1, TotalWeeks: 4, IsRestDay: true}},
+		{"weekly slot by weekday", weekly, on(1), &ProgramDay{Week: 1, TotalWeeks: 4, WorkoutID: &a}},
+		{"second week", weekly, on(10), &ProgramDay{Week: 2, TotalWeeks: 4, WorkoutID: &b}},
+		{"deload week", weekly, on(22), &ProgramDay{Week: 4, TotalWeeks: 4, Deload: true, WorkoutID: &a}},
+		{"after the last week", weekly, on(28), nil},
+		{"rotation first day", rotation, on(1), &ProgramDay{Week: 1, WorkoutID: &a}},
+		{"rotation cycles", rotation, on(5), &ProgramDay{Week: 1, WorkoutID: &b}},
+		{"rotation rest", rotation, on(6), &ProgramDay{Week: 1, IsRestDay: true}},
+		{"open-ended", rotation, on(299), &ProgramDay{Week: 43, WorkoutID: &b}},
+		{"no slots", &TrainingProgram{StartDate: start}, on(3), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.program.DayFor(tt.date)
+			if got == nil || tt.want == nil {
+				if got != tt.want {
+					t.Fatalf("DayFor = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+			sameWorkout := (got.WorkoutID == nil) == (tt.want.WorkoutID == nil) &&
+				(got.WorkoutID == nil || *got.WorkoutID == *tt.want.WorkoutID)
+			if got.Week != tt.want.Week || got.TotalWeeks != tt.want.TotalWeeks || got.Deload != tt.want.Deload ||
+				got.IsRestDay != tt.want.IsRestDay || !sameWorkout {
+				t.Errorf("DayFor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressExercises(t *testing.T) {
+	start := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	program := &TrainingProgram{
+		ScheduleType:           ScheduleRotation,
+		Slots:                  []*uuid.UUID{new(uuid.UUID)},
+		DeloadEvery:            3,
+		DeloadPercent:          60,
+		ProgressionIncrementKg: 2.5,
+		StartDate:              start,
+	}
+	logged := func(day int, name string, sets ...ExerciseSet) WorkoutLog {
+		return WorkoutLog{
+			Date:               start.AddDate(0, 0, day),
+			CompletedExercises: []Exercise{{Name: name, Sets: sets}},
+		}
+	}
+	squat := Exercise{Name: "Squat", TargetSets: 3, TargetReps: 5, TargetWeight: 60}
+
+	tests := []struct {
+		name     string
+		exercise Exercise
+		logs     []WorkoutLog
+		deload   bool
+		want     float64
+	}{
+		{"never logged keeps the template", squat, nil, false, 60},
+		{
+			"all targets hit adds the increment", squat,
+			[]WorkoutLog{logged(1, "squat", ExerciseSet{Reps: 5, Weight: 70}, ExerciseSet{Reps: 5, Weight: 70}, ExerciseSet{Reps: 6, Weight: 70})},
+			false, 72.5,
+		},
+		{
+			"a missed rep repeats the weight", squat,
+			[]WorkoutLog{logged(1, "Squat", ExerciseSet{Reps: 5, Weight: 70}, ExerciseSet{Reps: 4, Weight: 70}, ExerciseSet{Reps: 5, Weight: 70})},
+			false, 70,
+		},
+		{
+			"too few sets repeats the weight", squat,
+			[]WorkoutLog{logged(1, "Squat", ExerciseSet{Reps: 5, Weight: 70}, ExerciseSet{Reps: 5, Weight: 70})},
+			false, 70,
+		},
+		{
+			"deload sessions are skipped", squat,
+			[]WorkoutLog{
+				logged(1, "Squat", ExerciseSet{Reps: 5, Weight: 80}, ExerciseSet{Reps: 5, Weight: 80}, ExerciseSet{Reps: 5, Weight: 80}),
+				logged(15, "Squat", ExerciseSet{Reps: 5, Weight: 50}, ExerciseSet{Reps: 5, Weight: 50}, ExerciseSet{Reps: 5, Weight: 50}),
+			},
+			false, 82.5,
+		},
+		{
+			"deload scales and rounds to half a kilo", squat,
+			[]WorkoutLog{logged(1, "Squat", ExerciseSet{Reps: 5, Weight: 71}, ExerciseSet{Reps: 3, Weight: 71}, ExerciseSet{Reps: 5, Weight: 71})},
+			true, 42.5,
+		},
+		{
+			"bodyweight sessions keep the template", squat,
+			[]WorkoutLog{logged(1, "Squat", ExerciseSet{Reps: 5}, ExerciseSet{Reps: 5}, ExerciseSet{Reps: 5})},
+			false, 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := &ProgramDay{Deload: tt.deload}
+			got := progressExercises(program, day, []Exercise{tt.exercise}, tt.logs)
+			if len(got) != 1 || got[0].TargetWeight != tt.want {
+				t.Errorf("target weight = %v, want %v", got[0].TargetWeight, tt.want)
+			}
+			if got[0].Sets != nil {
+				t.Errorf("logged sets leaked into the targets: %+v", got[0].Sets)
+			}
+		})
+	}
+}
@@ -2,9 +2,18 @@ package database
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDependencyOnSelf = errors.New("task cannot depend on itself")
+	ErrDependencyExists = errors.New("dependency already exists")
+	ErrDependencyCycle  = errors.New("dependency would create a cycle")
+	ErrTaskBlocked      = errors.New("task is blocked by incomplete tasks")
 )
 
 // ========== PROJECTS ==========
@@ -136,13 +145,20 @@ func (db *DB) getProjectsUpdatedSince(ctx context.Context, userID uuid.UUID, sin
 
 // ========== TASKS ==========
 
+// taskBlockedColumn is true when a task has a prerequisite that isn't completed
+const taskBlockedColumn = `EXISTS (
+			SELECT 1 FROM task_dependencies d
+			JOIN tasks p ON p.id = d.depends_on_task_id
+			WHERE d.task_id = tasks.id AND COALESCE(p.is_deleted, false) = false AND COALESCE(p.completed, false) = false
+		) as blocked`
+
 // GetTasksByProjectID retrieves all tasks for a project
 func (db *DB) GetTasksByProjectID(ctx context.Context, projectID, userID uuid.UUID) ([]Task, error) {
 	rows, err := db.Pool.Query(ctx, `
 		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
 		       status, priority, due_date, COALESCE(completed, false) as completed,
 		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
-		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at, `+taskBlockedColumn+`
 		FROM tasks WHERE project_id = $1 AND user_id = $2 AND COALESCE(is_deleted, false) = false
 		ORDER BY display_order, created_at
 	`, projectID, userID)
@@ -156,7 +172,7 @@ func (db *DB) GetTasksByProjectID(ctx context.Context, projectID, userID uuid.UU
 		var t Task
 		if err := rows.Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
 			&t.Status, &t.Priority, &t.DueDate, &t.Completed,
-			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt, &t.Blocked); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, t)
@@ -170,7 +186,7 @@ func (db *DB) GetAllTasksByUserID(ctx context.Context, userID uuid.UUID) ([]Task
 		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
 		       status, priority, due_date, COALESCE(completed, false) as completed,
 		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
-		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at, `+taskBlockedColumn+`
 		FROM tasks WHERE user_id = $1
 		ORDER BY display_order, created_at
 	`, userID)
@@ -184,7 +200,7 @@ func (db *DB) GetAllTasksByUserID(ctx context.Context, userID uuid.UUID) ([]Task
 		var t Task
 		if err := rows.Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
 			&t.Status, &t.Priority, &t.DueDate, &t.Completed,
-			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt, &t.Blocked); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, t)
@@ -214,24 +230,90 @@ func (db *DB) CreateTask(ctx context.Context, userID, projectID uuid.UUID, paren
 	return &t, nil
 }
 
-// UpdateTask updates a task
-func (db *DB) UpdateTask(ctx context.Context, taskID uuid.UUID, title, description, status, priority string, dueDate *time.Time, displayOrder int, collapsed bool) error {
+// UpdateTask updates one of the user's tasks (pgx.ErrNoRows if it isn't
+// theirs). Completing it while it has incomplete prerequisites fails with
+// ErrTaskBlocked unless force is set; either way those prerequisites are
+// returned. Only a task being completed now is checked, so editing a task
+// that was completed before its prerequisites were added still works.
+// Completing or reopening it touches the tasks that depend on it, whose
+// blocked flag changes with it, so sync clients fetch them again.
+func (db *DB) UpdateTask(ctx context.Context, taskID, userID uuid.UUID, title, description, status, priority string, dueDate *time.Time, displayOrder int, collapsed, force bool) ([]Task, error) {
 	completed := status == "Completed"
-	_, err := db.Pool.Exec(ctx, `
-		UPDATE tasks SET title = $2, description = $3, status = $4, priority = $5,
-		       due_date = $6, display_order = $7, collapsed = $8, completed = $9, updated_at = NOW()
-		WHERE id = $1
-	`, taskID, title, description, status, priority, dueDate, displayOrder, collapsed, completed)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var wasCompleted bool
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(completed, false) FROM tasks
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, taskID, userID).Scan(&wasCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockedBy []Task
+	if completed && !wasCompleted {
+		blockedBy, err = getBlockingTasks(ctx, tx, taskID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockedBy) > 0 && !force {
+			return blockedBy, ErrTaskBlocked
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE tasks SET title = $3, description = $4, status = $5, priority = $6,
+		       due_date = $7, display_order = $8, collapsed = $9, completed = $10,
+		       completed_at = CASE WHEN $10 THEN COALESCE(completed_at, NOW()) END, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, taskID, userID, title, description, status, priority, dueDate, displayOrder, collapsed, completed)
+	if err != nil {
+		return nil, err
+	}
+
+	if completed != wasCompleted {
+		if err := touchDependentTasks(ctx, tx, taskID); err != nil {
+			return nil, err
+		}
+	}
+	return blockedBy, tx.Commit(ctx)
+}
+
+// touchDependentTasks bumps updated_at on the tasks that depend on taskID
+func touchDependentTasks(ctx context.Context, tx pgx.Tx, taskID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE tasks SET updated_at = NOW()
+		WHERE id IN (SELECT task_id FROM task_dependencies WHERE depends_on_task_id = $1)
+	`, taskID)
 	return err
 }
 
-// SoftDeleteTask marks a task as deleted
+// SoftDeleteTask marks a task as deleted. Tasks that depended on it may no
+// longer be blocked, so they are touched too.
 func (db *DB) SoftDeleteTask(ctx context.Context, taskID uuid.UUID) error {
-	_, err := db.Pool.Exec(ctx, `
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE tasks SET is_deleted = true, updated_at = NOW()
 		WHERE id = $1
 	`, taskID)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := touchDependentTasks(ctx, tx, taskID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // getTasksUpdatedSince retrieves tasks updated since a timestamp
@@ -240,7 +322,7 @@ func (db *DB) getTasksUpdatedSince(ctx context.Context, userID uuid.UUID, since
 		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
 		       status, priority, due_date, COALESCE(completed, false) as completed,
 		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
-		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at, `+taskBlockedColumn+`
 		FROM tasks WHERE user_id = $1 AND updated_at > $2
 		ORDER BY updated_at
 	`, userID, since)
@@ -254,7 +336,116 @@ func (db *DB) getTasksUpdatedSince(ctx context.Context, userID uuid.UUID, since
 		var t Task
 		if err := rows.Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
 			&t.Status, &t.Priority, &t.DueDate, &t.Completed,
-			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt, &t.Blocked); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// GetTaskByID retrieves a single task
+func (db *DB) GetTaskByID(ctx context.Context, taskID, userID uuid.UUID) (*Task, error) {
+	var t Task
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
+		       status, priority, due_date, COALESCE(completed, false) as completed,
+		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at, `+taskBlockedColumn+`
+		FROM tasks WHERE id = $1 AND user_id = $2 AND COALESCE(is_deleted, false) = false
+	`, taskID, userID).Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
+		&t.Status, &t.Priority, &t.DueDate, &t.Completed,
+		&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt, &t.Blocked)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ========== TASK DEPENDENCIES ==========
+
+// GetTaskDependencies retrieves the prerequisites of a task
+func (db *DB) GetTaskDependencies(ctx context.Context, taskID, userID uuid.UUID) ([]TaskDependency, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT d.id, d.task_id, d.depends_on_task_id, d.created_at
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE d.task_id = $1 AND t.user_id = $2
+		ORDER BY d.created_at
+	`, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependencies := []TaskDependency{}
+	for rows.Next() {
+		var d TaskDependency
+		if err := rows.Scan(&d.ID, &d.TaskID, &d.DependsOnTaskID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+	return dependencies, rows.Err()
+}
+
+// GetAllTaskDependenciesByUserID retrieves all task dependencies for a user (for sync)
+func (db *DB) GetAllTaskDependenciesByUserID(ctx context.Context, userID uuid.UUID) ([]TaskDependency, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT d.id, d.task_id, d.depends_on_task_id, d.created_at
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE t.user_id = $1
+		ORDER BY d.created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []TaskDependency
+	for rows.Next() {
+		var d TaskDependency
+		if err := rows.Scan(&d.ID, &d.TaskID, &d.DependsOnTaskID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+	return dependencies, rows.Err()
+}
+
+// GetBlockingTasks retrieves the prerequisites of a task that aren't completed
+func (db *DB) GetBlockingTasks(ctx context.Context, taskID, userID uuid.UUID) ([]Task, error) {
+	return getBlockingTasks(ctx, db.Pool, taskID, userID)
+}
+
+// querier runs a query on the pool or inside a transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func getBlockingTasks(ctx context.Context, q querier, taskID, userID uuid.UUID) ([]Task, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
+		       status, priority, due_date, COALESCE(completed, false) as completed,
+		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at, `+taskBlockedColumn+`
+		FROM tasks
+		WHERE id IN (SELECT depends_on_task_id FROM task_dependencies WHERE task_id = $1)
+		  AND user_id = $2 AND COALESCE(is_deleted, false) = false AND COALESCE(completed, false) = false
+		ORDER BY display_order, created_at
+	`, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
+			&t.Status, &t.Priority, &t.DueDate, &t.Completed,
+			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt, &t.Blocked); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, t)
@@ -262,6 +453,103 @@ func (db *DB) getTasksUpdatedSince(ctx context.Context, userID uuid.UUID, since
 	return tasks, rows.Err()
 }
 
+// CreateTaskDependency makes taskID depend on dependsOnTaskID. Both tasks must
+// be the user's (pgx.ErrNoRows otherwise), and the new edge must not close a
+// cycle: dependsOnTaskID may not already depend on taskID, directly or not.
+func (db *DB) CreateTaskDependency(ctx context.Context, userID, taskID, dependsOnTaskID uuid.UUID) (*TaskDependency, error) {
+	if taskID == dependsOnTaskID {
+		return nil, ErrDependencyOnSelf
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialise the user's dependency changes so two concurrent edges can't
+	// form a cycle that neither check sees
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('task_dependencies:' || $1::text))`, userID); err != nil {
+		return nil, err
+	}
+
+	var owned int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM tasks
+		WHERE id IN ($1, $2) AND user_id = $3 AND COALESCE(is_deleted, false) = false
+	`, taskID, dependsOnTaskID, userID).Scan(&owned)
+	if err != nil {
+		return nil, err
+	}
+	if owned != 2 {
+		return nil, pgx.ErrNoRows
+	}
+
+	var exists, cycle bool
+	err = tx.QueryRow(ctx, `
+		WITH RECURSIVE prerequisites(id) AS (
+			SELECT $2::uuid
+			UNION
+			SELECT d.depends_on_task_id FROM task_dependencies d
+			JOIN prerequisites p ON d.task_id = p.id
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM task_dependencies WHERE task_id = $1 AND depends_on_task_id = $2),
+			EXISTS (SELECT 1 FROM prerequisites WHERE id = $1)
+	`, taskID, dependsOnTaskID).Scan(&exists, &cycle)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDependencyExists
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	var d TaskDependency
+	err = tx.QueryRow(ctx, `
+		INSERT INTO task_dependencies (task_id, depends_on_task_id)
+		VALUES ($1, $2)
+		RETURNING id, task_id, depends_on_task_id, created_at
+	`, taskID, dependsOnTaskID).Scan(&d.ID, &d.TaskID, &d.DependsOnTaskID, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The task's blocked flag may have changed
+	if _, err := tx.Exec(ctx, `UPDATE tasks SET updated_at = NOW() WHERE id = $1`, taskID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// DeleteTaskDependency removes a dependency from one of the user's tasks and
+// touches the task, whose blocked flag may have changed
+func (db *DB) DeleteTaskDependency(ctx context.Context, dependencyID, userID uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, `
+		WITH deleted AS (
+			DELETE FROM task_dependencies d
+			USING tasks t
+			WHERE d.id = $1 AND t.id = d.task_id AND t.user_id = $2
+			RETURNING d.task_id
+		)
+		UPDATE tasks SET updated_at = NOW()
+		WHERE id IN (SELECT task_id FROM deleted)
+	`, dependencyID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 // ========== TASK COMMENTS ==========
 
 // GetCommentsByTaskID retrieves all comments for a task
@@ -431,7 +719,7 @@ func (db *DB) SaveTaskAttachment(ctx context.Context, taskID, userID uuid.UUID,
 // SoftDeleteTaskAttachment marks an attachment as deleted
 func (db *DB) SoftDeleteTaskAttachment(ctx context.Context, attachmentID uuid.UUID) error {
 	_, err := db.Pool.Exec(ctx, `
-		UPDATE task_attachments SET is_deleted = true
+		UPDATE task_attachments SET is_deleted = true, deleted_at = NOW()
 		WHERE id = $1
 	`, attachmentID)
 	return err
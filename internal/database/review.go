@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetCompletedTodosInRange retrieves completed todos dated between from and to (inclusive)
+func (db *DB) GetCompletedTodosInRange(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]Todo, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, text, completed, date, created_at, false as is_overdue
+		FROM todos
+		WHERE user_id = $1 AND completed = true AND is_deleted = false AND date >= $2 AND date <= $3
+		ORDER BY date ASC, created_at ASC
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []Todo
+	for rows.Next() {
+		var t Todo
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Text, &t.Completed, &t.Date, &t.CreatedAt, &t.IsOverdue); err != nil {
+			return nil, err
+		}
+		todos = append(todos, t)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetCompletedTasksInRange retrieves project tasks completed between from and to (inclusive)
+func (db *DB) GetCompletedTasksInRange(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]Task, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, project_id, parent_task_id, title, COALESCE(description, '') as description,
+		       status, priority, due_date, COALESCE(completed, false) as completed,
+		       COALESCE(display_order, 0) as display_order, COALESCE(collapsed, false) as collapsed,
+		       COALESCE(is_deleted, false) as is_deleted, created_at, updated_at
+		FROM tasks
+		WHERE user_id = $1 AND completed = true AND COALESCE(is_deleted, false) = false
+		  AND completed_at >= $2 AND completed_at < $3
+		ORDER BY completed_at
+	`, userID, from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.ID, &t.UserID, &t.ProjectID, &t.ParentTaskID, &t.Title, &t.Description,
+			&t.Status, &t.Priority, &t.DueDate, &t.Completed,
+			&t.DisplayOrder, &t.Collapsed, &t.IsDeleted, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// GetWeightRange returns the first and last recorded body weight between from and to
+func (db *DB) GetWeightRange(ctx context.Context, userID uuid.UUID, from, to time.Time) (start, end *float64, err error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT weight_kg::float8 FROM body_metrics
+		WHERE user_id = $1 AND weight_kg > 0 AND date >= $2 AND date <= $3
+		ORDER BY date ASC
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w float64
+		if err := rows.Scan(&w); err != nil {
+			return nil, nil, err
+		}
+		if start == nil {
+			first := w
+			start = &first
+		}
+		last := w
+		end = &last
+	}
+
+	return start, end, rows.Err()
+}
+
+// GetAverageMood returns the average mood rating and number of rated days between from and to
+func (db *DB) GetAverageMood(ctx context.Context, userID uuid.UUID, from, to time.Time) (float64, int, error) {
+	var avg float64
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(AVG(rating), 0)::float8, COUNT(*)
+		FROM mood_ratings
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(&avg, &count)
+	return avg, count, err
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Revision entity types
+const (
+	RevisionEntityNote         = "note"
+	RevisionEntityMarkdownNote = "markdown_note"
+)
+
+// Revision sources (where the change that replaced a version came from)
+const (
+	RevisionSourceWeb  = "web"
+	RevisionSourceAPI  = "api"
+	RevisionSourceSync = "sync"
+)
+
+// revisionCoalesceWindow limits web auto-saves to one revision per editing session
+// Sync and API changes always keep the previous version
+const revisionCoalesceWindow = 10 * time.Minute
+
+// saveRevision stores the version that is about to be overwritten
+func saveRevision(ctx context.Context, tx pgx.Tx, userID uuid.UUID, entityType string, entityID uuid.UUID, title, content, source string) error {
+	if source == RevisionSourceWeb {
+		var lastSource string
+		var lastAt time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT source, created_at FROM revisions
+			WHERE entity_type = $1 AND entity_id = $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, entityType, entityID).Scan(&lastSource, &lastAt)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err == nil && lastSource == RevisionSourceWeb && time.Since(lastAt) < revisionCoalesceWindow {
+			return nil
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO revisions (user_id, entity_type, entity_id, title, content, source)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, userID, entityType, entityID, title, content, source)
+	return err
+}
+
+// GetRevisions retrieves the edit history of a note or blog post (newest first)
+func (db *DB) GetRevisions(ctx context.Context, userID uuid.UUID, entityType string, entityID uuid.UUID) ([]Revision, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, entity_type, entity_id, title, content, source, created_at
+		FROM revisions
+		WHERE user_id = $1 AND entity_type = $2 AND entity_id = $3
+		ORDER BY created_at DESC
+	`, userID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		if err := rows.Scan(&r.ID, &r.UserID, &r.EntityType, &r.EntityID, &r.Title, &r.Content, &r.Source, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieves a single revision owned by the user
+func (db *DB) GetRevision(ctx context.Context, userID uuid.UUID, revisionID uuid.UUID) (*Revision, error) {
+	var r Revision
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, entity_type, entity_id, title, content, source, created_at
+		FROM revisions
+		WHERE id = $1 AND user_id = $2
+	`, revisionID, userID).Scan(&r.ID, &r.UserID, &r.EntityType, &r.EntityID, &r.Title, &r.Content, &r.Source, &r.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
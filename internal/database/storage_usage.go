@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrQuotaExceeded is returned when an upload doesn't fit in the user's quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// storedBytesSQL sums the bytes a user ($1) has stored across all upload kinds
+const storedBytesSQL = `
+	(SELECT COALESCE(SUM(stored_bytes), 0) FROM daily_images WHERE user_id = $1 AND deleted_at IS NULL) +
+	(SELECT COALESCE(SUM(stored_bytes), 0) FROM blog_images WHERE user_id = $1 AND is_deleted = false) +
+	(SELECT COALESCE(SUM(file_size), 0) FROM task_attachments WHERE user_id = $1 AND COALESCE(is_deleted, false) = false) +
+	COALESCE(avatar_bytes, 0)`
+
+// activeReservationSQL is the user's reserved bytes, ignoring a reservation
+// that was never released (the upload died) after an hour
+const activeReservationSQL = `
+	(CASE WHEN storage_reserved_at > NOW() - INTERVAL '1 hour' THEN storage_reserved_bytes ELSE 0 END)`
+
+// GetStorageUsage sums the bytes a user has stored per upload kind
+// Deleted images and attachments don't count, even while their files wait for cleanup
+func (db *DB) GetStorageUsage(ctx context.Context, userID uuid.UUID) (*StorageUsage, error) {
+	var usage StorageUsage
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COALESCE(SUM(stored_bytes), 0) FROM daily_images WHERE user_id = $1 AND deleted_at IS NULL),
+			(SELECT COALESCE(SUM(stored_bytes), 0) FROM blog_images WHERE user_id = $1 AND is_deleted = false),
+			(SELECT COALESCE(SUM(file_size), 0) FROM task_attachments WHERE user_id = $1 AND COALESCE(is_deleted, false) = false),
+			(SELECT COALESCE(avatar_bytes, 0) FROM users WHERE id = $1)
+	`, userID).Scan(&usage.DailyImagesBytes, &usage.BlogImagesBytes, &usage.AttachmentsBytes, &usage.AvatarBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.TotalBytes = usage.DailyImagesBytes + usage.BlogImagesBytes + usage.AttachmentsBytes + usage.AvatarBytes
+	return &usage, nil
+}
+
+// ReserveStorage reserves n bytes of the user's quota (0 = unlimited) for an
+// upload in progress, in one conditional UPDATE, and returns the usage with
+// the reservation counted. When the bytes don't fit it reserves nothing and
+// returns ErrQuotaExceeded with the current usage.
+// Every successful reservation must be released with ReleaseStorage once the
+// upload is saved or has failed.
+func (db *DB) ReserveStorage(ctx context.Context, userID uuid.UUID, n, quota int64) (*StorageUsage, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user row first, so the UPDATE below reads the stored bytes with
+	// a snapshot taken after any concurrent upload has finished
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	var used int64
+	err = tx.QueryRow(ctx, `
+		UPDATE users SET
+			storage_reserved_bytes = `+activeReservationSQL+` + $2,
+			storage_reserved_at = NOW()
+		WHERE id = $1
+			AND ($3::bigint <= 0 OR `+storedBytesSQL+` + `+activeReservationSQL+` + $2 <= $3::bigint)
+		RETURNING `+storedBytesSQL+` + storage_reserved_bytes
+	`, userID, n, quota).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		usage, err := db.GetStorageUsage(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		usage.QuotaBytes = quota
+		return usage, ErrQuotaExceeded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &StorageUsage{TotalBytes: used, QuotaBytes: quota}, nil
+}
+
+// ReleaseStorage gives back n bytes reserved by ReserveStorage
+func (db *DB) ReleaseStorage(ctx context.Context, userID uuid.UUID, n int64) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE users SET storage_reserved_bytes = GREATEST(`+activeReservationSQL+` - $2, 0)
+		WHERE id = $1
+	`, userID, n)
+	return err
+}
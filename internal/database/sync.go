@@ -29,6 +29,7 @@ type SyncAllData struct {
 	Tasks             []Task             `json:"tasks"`
 	TaskComments      []TaskComment      `json:"taskComments"`
 	TaskAttachments   []TaskAttachment   `json:"taskAttachments"`
+	TaskDependencies  []TaskDependency   `json:"taskDependencies"`
 	LastSyncTimestamp time.Time          `json:"lastSyncTimestamp"`
 }
 
@@ -205,6 +206,13 @@ func (db *DB) GetAllSyncData(ctx context.Context, userID uuid.UUID) (*SyncAllDat
 		return nil, err
 	}
 	data.TaskAttachments = taskAttachments
+
+	// Get task dependencies
+	taskDependencies, err := db.GetAllTaskDependenciesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	data.TaskDependencies = taskDependencies
 	return data, nil
 }
 
@@ -531,7 +539,7 @@ func (db *DB) getAllWorkoutLogs(ctx context.Context, userID uuid.UUID) ([]Workou
 
 func (db *DB) getHabitsUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]Habit, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, name, icon, scheduled_days, created_at, updated_at, COALESCE(is_deleted, false) as is_deleted
+		SELECT id, user_id, name, icon, scheduled_days, prayer_anchor, prayer_offset, created_at, updated_at, COALESCE(is_deleted, false) as is_deleted
 		FROM habits WHERE user_id = $1 AND updated_at > $2
 		ORDER BY created_at
 	`, userID, since)
@@ -544,10 +552,13 @@ func (db *DB) getHabitsUpdatedSince(ctx context.Context, userID uuid.UUID, since
 	for rows.Next() {
 		var h Habit
 		var daysJSON []byte
-		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &h.CreatedAt, &h.UpdatedAt, &h.IsDeleted); err != nil {
+		var anchor *string
+		var offset int
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Name, &h.Icon, &daysJSON, &anchor, &offset, &h.CreatedAt, &h.UpdatedAt, &h.IsDeleted); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &h.ScheduledDays)
+		h.PrayerAnchor = prayerAnchorFromColumns(anchor, offset)
 		habits = append(habits, h)
 	}
 
@@ -581,7 +592,7 @@ func (db *DB) getMedicationsUpdatedSince(ctx context.Context, userID uuid.UUID,
 	rows, err := db.Pool.Query(ctx, `
 		SELECT id, user_id, name, dosage, scheduled_days, times_per_day,
 			   duration_type, start_date, end_date, COALESCE(notes, '') as notes,
-			   COALESCE(icon, 'pill.fill') as icon, is_active, COALESCE(is_deleted, false) as is_deleted,
+			   COALESCE(icon, 'pill.fill') as icon, dose_anchors, is_active, COALESCE(is_deleted, false) as is_deleted,
 			   created_at, updated_at
 		FROM medications WHERE user_id = $1 AND updated_at > $2
 		ORDER BY created_at
@@ -594,16 +605,17 @@ func (db *DB) getMedicationsUpdatedSince(ctx context.Context, userID uuid.UUID,
 	var medications []Medication
 	for rows.Next() {
 		var m Medication
-		var daysJSON []byte
+		var daysJSON, anchorsJSON []byte
 		if err := rows.Scan(
 			&m.ID, &m.UserID, &m.Name, &m.Dosage, &daysJSON, &m.TimesPerDay,
 			&m.DurationType, &m.StartDate, &m.EndDate, &m.Notes,
-			&m.Icon, &m.IsActive, &m.IsDeleted,
+			&m.Icon, &anchorsJSON, &m.IsActive, &m.IsDeleted,
 			&m.CreatedAt, &m.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 		json.Unmarshal(daysJSON, &m.ScheduledDays)
+		json.Unmarshal(anchorsJSON, &m.DoseAnchors)
 		medications = append(medications, m)
 	}
 
@@ -704,7 +716,7 @@ func (db *DB) getTodosCreatedSince(ctx context.Context, userID uuid.UUID, since
 
 func (db *DB) getEventsUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]CalendarEvent, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, title, event_type, event_date, end_date, is_recurring, notes, created_at, updated_at, is_deleted
+		SELECT `+calendarEventColumns+`
 		FROM calendar_events
 		WHERE user_id = $1 AND updated_at > $2
 		ORDER BY event_date
@@ -716,18 +728,20 @@ func (db *DB) getEventsUpdatedSince(ctx context.Context, userID uuid.UUID, since
 
 	var events []CalendarEvent
 	for rows.Next() {
-		var e CalendarEvent
-		var notes *string
-		if err := rows.Scan(&e.ID, &e.UserID, &e.Title, &e.EventType, &e.EventDate, &e.EndDate, &e.IsRecurring, &notes, &e.CreatedAt, &e.UpdatedAt, &e.IsDeleted); err != nil {
+		e, err := scanCalendarEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		if notes != nil {
-			e.Notes = *notes
-		}
-		events = append(events, e)
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return events, rows.Err()
+	if err := db.attachCalendarEventExceptions(ctx, userID, events); err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
 func (db *DB) getWorkoutsUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]Workout, error) {
@@ -834,26 +848,43 @@ func (db *DB) SyncPushHabit(ctx context.Context, userID uuid.UUID, serverID *str
 		Name          string   `json:"name"`
 		Icon          string   `json:"icon"`
 		ScheduledDays []string `json:"scheduled_days"`
+		// Absent for clients without prayer anchors, so it is left untouched
+		PrayerAnchor json.RawMessage `json:"prayer_anchor"`
 	}
 	if err := json.Unmarshal(data, &habitData); err != nil {
 		return "", err
 	}
 
+	var id uuid.UUID
 	if serverID != nil {
 		// Update existing
-		id, err := uuid.Parse(*serverID)
+		parsed, err := uuid.Parse(*serverID)
+		if err != nil {
+			return "", err
+		}
+		id = parsed
+		if err := db.UpdateHabit(ctx, id, habitData.Name, habitData.Icon, habitData.ScheduledDays); err != nil {
+			return "", err
+		}
+	} else {
+		// Create new
+		habit, err := db.CreateHabit(ctx, userID, habitData.Name, habitData.Icon, habitData.ScheduledDays)
 		if err != nil {
 			return "", err
 		}
-		return *serverID, db.UpdateHabit(ctx, id, habitData.Name, habitData.Icon, habitData.ScheduledDays)
+		id = habit.ID
 	}
 
-	// Create new
-	habit, err := db.CreateHabit(ctx, userID, habitData.Name, habitData.Icon, habitData.ScheduledDays)
-	if err != nil {
-		return "", err
+	if habitData.PrayerAnchor != nil {
+		var anchor *PrayerAnchor
+		if err := json.Unmarshal(habitData.PrayerAnchor, &anchor); err != nil {
+			return "", err
+		}
+		if err := db.SetHabitPrayerAnchor(ctx, id, userID, anchor); err != nil {
+			return "", err
+		}
 	}
-	return habit.ID.String(), nil
+	return id.String(), nil
 }
 
 // SyncPushMedication handles syncing a medication from the client
@@ -881,26 +912,39 @@ func (db *DB) SyncPushMedication(ctx context.Context, userID uuid.UUID, serverID
 		Notes         string     `json:"notes"`
 		IsActive      bool       `json:"is_active"`
 		Icon          string     `json:"icon"`
+		// Absent for clients without prayer anchors, so they are left untouched
+		DoseAnchors *[]PrayerAnchor `json:"dose_anchors"`
 	}
 	if err := json.Unmarshal(data, &medData); err != nil {
 		return "", err
 	}
 
+	var id uuid.UUID
 	if serverID != nil {
 		// Update existing
-		id, err := uuid.Parse(*serverID)
+		parsed, err := uuid.Parse(*serverID)
+		if err != nil {
+			return "", err
+		}
+		id = parsed
+		if err := db.UpdateMedication(ctx, id, medData.Name, medData.Dosage, medData.ScheduledDays, medData.TimesPerDay, medData.DurationType, medData.StartDate, medData.EndDate, medData.Notes, medData.Icon, medData.IsActive); err != nil {
+			return "", err
+		}
+	} else {
+		// Create new
+		med, err := db.CreateMedication(ctx, userID, medData.Name, medData.Dosage, medData.ScheduledDays, medData.TimesPerDay, medData.DurationType, medData.StartDate, medData.EndDate, medData.Notes, medData.Icon)
 		if err != nil {
 			return "", err
 		}
-		return *serverID, db.UpdateMedication(ctx, id, medData.Name, medData.Dosage, medData.ScheduledDays, medData.TimesPerDay, medData.DurationType, medData.StartDate, medData.EndDate, medData.Notes, medData.Icon, medData.IsActive)
+		id = med.ID
 	}
 
-	// Create new
-	med, err := db.CreateMedication(ctx, userID, medData.Name, medData.Dosage, medData.ScheduledDays, medData.TimesPerDay, medData.DurationType, medData.StartDate, medData.EndDate, medData.Notes, medData.Icon)
-	if err != nil {
-		return "", err
+	if medData.DoseAnchors != nil {
+		if err := db.SetMedicationDoseAnchors(ctx, id, userID, *medData.DoseAnchors); err != nil {
+			return "", err
+		}
 	}
-	return med.ID.String(), nil
+	return id.String(), nil
 }
 
 // SyncPushTodo handles syncing a todo from the client
@@ -958,7 +1002,7 @@ func (db *DB) SyncPushNote(ctx context.Context, userID uuid.UUID, serverID *stri
 	}
 
 	// Notes use upsert based on date, so we always use SaveNote
-	note, err := db.SaveNote(ctx, userID, noteData.Text, noteData.Date)
+	note, err := db.SaveNote(ctx, userID, noteData.Text, noteData.Date, RevisionSourceSync)
 	if err != nil {
 		return "", err
 	}
@@ -998,32 +1042,57 @@ func (db *DB) SyncPushEvent(ctx context.Context, userID uuid.UUID, serverID *str
 	}
 
 	var eventData struct {
-		Title       string     `json:"title"`
-		EventType   string     `json:"event_type"`
-		EventDate   time.Time  `json:"event_date"`
-		EndDate     *time.Time `json:"end_date"`
-		IsRecurring bool       `json:"is_recurring"`
-		Notes       string     `json:"notes"`
+		Title        string     `json:"title"`
+		EventType    string     `json:"event_type"`
+		EventDate    time.Time  `json:"event_date"`
+		EndDate      *time.Time `json:"end_date"`
+		IsRecurring  bool       `json:"is_recurring"`
+		CalendarType string     `json:"calendar_type"`
+		RRule        *string    `json:"rrule"`      // absent = keep the current rule
+		StartTime    *string    `json:"start_time"` // absent = keep the current times; "" = all-day
+		EndTime      string     `json:"end_time"`
+		TimeZone     string     `json:"time_zone"`
+		Reminders    *[]int     `json:"reminders"` // absent = keep the current reminders
+		Notes        string     `json:"notes"`
 	}
 	if err := json.Unmarshal(data, &eventData); err != nil {
 		return "", err
 	}
 
+	var id uuid.UUID
 	if serverID != nil {
 		// Update existing
-		id, err := uuid.Parse(*serverID)
+		var err error
+		if id, err = uuid.Parse(*serverID); err != nil {
+			return "", err
+		}
+		if err := db.UpdateCalendarEvent(ctx, id, eventData.Title, eventData.EventType, eventData.EventDate, eventData.EndDate, eventData.IsRecurring, eventData.CalendarType, eventData.RRule, eventData.Notes); err != nil {
+			return "", err
+		}
+	} else {
+		// Create new
+		var rule string
+		if eventData.RRule != nil {
+			rule = *eventData.RRule
+		}
+		event, err := db.CreateCalendarEvent(ctx, userID, eventData.Title, eventData.EventType, eventData.EventDate, eventData.EndDate, eventData.IsRecurring, eventData.CalendarType, rule, eventData.Notes)
 		if err != nil {
 			return "", err
 		}
-		return *serverID, db.UpdateCalendarEvent(ctx, id, eventData.Title, eventData.EventType, eventData.EventDate, eventData.EndDate, eventData.IsRecurring, eventData.Notes)
+		id = event.ID
 	}
 
-	// Create new
-	event, err := db.CreateCalendarEvent(ctx, userID, eventData.Title, eventData.EventType, eventData.EventDate, eventData.EndDate, eventData.IsRecurring, eventData.Notes)
-	if err != nil {
-		return "", err
+	if eventData.StartTime != nil {
+		if err := db.SetCalendarEventTime(ctx, id, *eventData.StartTime, eventData.EndTime, eventData.TimeZone); err != nil {
+			return "", err
+		}
 	}
-	return event.ID.String(), nil
+	if eventData.Reminders != nil {
+		if err := db.SetCalendarEventReminders(ctx, id, *eventData.Reminders); err != nil {
+			return "", err
+		}
+	}
+	return id.String(), nil
 }
 
 // SyncPushWorkout handles syncing a workout template from the client
@@ -1041,10 +1110,10 @@ func (db *DB) SyncPushWorkout(ctx context.Context, userID uuid.UUID, serverID *s
 	}
 
 	var workoutData struct {
-		IsRestDay bool       `json:"is_rest_day"`
-		Name      string     `json:"name"`
-		Day       string     `json:"day"`
-		Exercises []Exercise `json:"exercises"`
+		IsRestDay bool           `json:"is_rest_day"`
+		Name      string         `json:"name"`
+		Day       string         `json:"day"`
+		Exercises []syncExercise `json:"exercises"`
 	}
 	if err := json.Unmarshal(data, &workoutData); err != nil {
 		return "", err
@@ -1056,11 +1125,17 @@ func (db *DB) SyncPushWorkout(ctx context.Context, userID uuid.UUID, serverID *s
 		if err != nil {
 			return "", err
 		}
-		return *serverID, db.UpdateWorkoutWithRestDay(ctx, id, workoutData.Name, workoutData.Day, workoutData.Exercises, workoutData.IsRestDay)
+		var existing []Exercise
+		if workout, err := db.GetWorkoutByID(ctx, id); err == nil {
+			existing = workout.Exercises
+		}
+		exercises := mergeExerciseDetails(workoutData.Exercises, existing)
+		return *serverID, db.UpdateWorkoutWithRestDay(ctx, id, workoutData.Name, workoutData.Day, exercises, workoutData.IsRestDay)
 	}
 
 	// Create new
-	workout, err := db.CreateWorkoutWithRestDay(ctx, userID, workoutData.Name, workoutData.Day, workoutData.Exercises, workoutData.IsRestDay)
+	exercises := mergeExerciseDetails(workoutData.Exercises, nil)
+	workout, err := db.CreateWorkoutWithRestDay(ctx, userID, workoutData.Name, workoutData.Day, exercises, workoutData.IsRestDay)
 	if err != nil {
 		return "", err
 	}
@@ -1070,19 +1145,26 @@ func (db *DB) SyncPushWorkout(ctx context.Context, userID uuid.UUID, serverID *s
 // SyncPushWorkoutLog handles syncing a workout log from the client
 func (db *DB) SyncPushWorkoutLog(ctx context.Context, userID uuid.UUID, serverID *string, isDeleted bool, data json.RawMessage) (string, error) {
 	var logData struct {
-		WorkoutName string     `json:"workout_name"`
-		Exercises   []Exercise `json:"completed_exercises"`
-		Cardio      []Cardio   `json:"cardio"`
-		Weight      float64    `json:"weight"`
-		Date        time.Time  `json:"date"`
-		IsRestDay   bool       `json:"is_rest_day"`
+		WorkoutName string         `json:"workout_name"`
+		Exercises   []syncExercise `json:"completed_exercises"`
+		Cardio      []Cardio       `json:"cardio"`
+		Weight      float64        `json:"weight"`
+		Date        time.Time      `json:"date"`
+		IsRestDay   bool           `json:"is_rest_day"`
 	}
 	if err := json.Unmarshal(data, &logData); err != nil {
 		return "", err
 	}
 
+	var existingExercises []Exercise
+	if existing, err := db.GetWorkoutLogForDay(ctx, userID, logData.Date); err == nil && existing != nil {
+		existingExercises = existing.CompletedExercises
+		logData.Cardio = mergeCardioDetails(logData.Cardio, existing.Cardio)
+	}
+	exercises := mergeExerciseDetails(logData.Exercises, existingExercises)
+
 	// Workout logs use upsert based on date
-	log, err := db.SaveWorkoutLogWithRestDay(ctx, userID, logData.WorkoutName, logData.Exercises, logData.Cardio, logData.Weight, logData.Date, logData.IsRestDay)
+	log, err := db.SaveWorkoutLogWithRestDay(ctx, userID, logData.WorkoutName, exercises, logData.Cardio, logData.Weight, logData.Date, logData.IsRestDay)
 	if err != nil {
 		return "", err
 	}
@@ -1117,7 +1199,7 @@ func (db *DB) SyncPushMarkdownNote(ctx context.Context, userID uuid.UUID, server
 		if err != nil {
 			return "", err
 		}
-		note, err := db.UpdateBlogPost(ctx, userID, id, noteData.Title, noteData.Content)
+		note, err := db.UpdateBlogPost(ctx, userID, id, noteData.Title, noteData.Content, RevisionSourceSync)
 		if err != nil {
 			return "", err
 		}
@@ -1131,7 +1213,7 @@ func (db *DB) SyncPushMarkdownNote(ctx context.Context, userID uuid.UUID, server
 	}
 	// Update content if provided
 	if noteData.Content != "" {
-		note, err = db.UpdateBlogPost(ctx, userID, note.ID, noteData.Title, noteData.Content)
+		note, err = db.UpdateBlogPost(ctx, userID, note.ID, noteData.Title, noteData.Content, RevisionSourceSync)
 		if err != nil {
 			return "", err
 		}
@@ -1201,7 +1283,7 @@ func (db *DB) SyncPushMedicationLog(ctx context.Context, userID uuid.UUID, serve
 // getAllDailyImages fetches all daily images for a user (including soft-deleted for sync)
 func (db *DB) getAllDailyImages(ctx context.Context, userID uuid.UUID) ([]DailyImage, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, created_at, deleted_at
+		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, variants, created_at, deleted_at
 		FROM daily_images WHERE user_id = $1
 		ORDER BY date DESC, created_at DESC
 	`, userID)
@@ -1213,9 +1295,11 @@ func (db *DB) getAllDailyImages(ctx context.Context, userID uuid.UUID) ([]DailyI
 	var images []DailyImage
 	for rows.Next() {
 		var img DailyImage
-		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath, &img.Filename, &img.MimeType, &img.SizeBytes, &img.CreatedAt, &img.DeletedAt); err != nil {
+		var variantsJSON []byte
+		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath, &img.Filename, &img.MimeType, &img.SizeBytes, &variantsJSON, &img.CreatedAt, &img.DeletedAt); err != nil {
 			return nil, err
 		}
+		json.Unmarshal(variantsJSON, &img.Variants)
 		img.IsDeleted = img.DeletedAt != nil
 		images = append(images, img)
 	}
@@ -1226,7 +1310,7 @@ func (db *DB) getAllDailyImages(ctx context.Context, userID uuid.UUID) ([]DailyI
 // getDailyImagesCreatedSince fetches daily images created or deleted since a timestamp
 func (db *DB) getDailyImagesCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]DailyImage, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, created_at, deleted_at
+		SELECT id, user_id, date, original_path, thumbnail_path, filename, mime_type, size_bytes, variants, created_at, deleted_at
 		FROM daily_images 
 		WHERE user_id = $1 AND (created_at > $2 OR deleted_at > $2)
 		ORDER BY date DESC, created_at DESC
@@ -1239,9 +1323,11 @@ func (db *DB) getDailyImagesCreatedSince(ctx context.Context, userID uuid.UUID,
 	var images []DailyImage
 	for rows.Next() {
 		var img DailyImage
-		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath, &img.Filename, &img.MimeType, &img.SizeBytes, &img.CreatedAt, &img.DeletedAt); err != nil {
+		var variantsJSON []byte
+		if err := rows.Scan(&img.ID, &img.UserID, &img.Date, &img.OriginalPath, &img.ThumbnailPath, &img.Filename, &img.MimeType, &img.SizeBytes, &variantsJSON, &img.CreatedAt, &img.DeletedAt); err != nil {
 			return nil, err
 		}
+		json.Unmarshal(variantsJSON, &img.Variants)
 		img.IsDeleted = img.DeletedAt != nil
 		images = append(images, img)
 	}
@@ -1315,6 +1401,7 @@ func (db *DB) SyncPushTask(ctx context.Context, userID uuid.UUID, serverID *stri
 		DueDate      *string `json:"due_date"`
 		DisplayOrder int     `json:"display_order"`
 		Collapsed    bool    `json:"collapsed"`
+		Force        bool    `json:"force"` // Complete even if blocked by incomplete tasks
 	}
 	if err := json.Unmarshal(data, &taskData); err != nil {
 		return "", fmt.Errorf("failed to unmarshal task data: %w", err)
@@ -1346,7 +1433,8 @@ func (db *DB) SyncPushTask(ctx context.Context, userID uuid.UUID, serverID *stri
 		if err != nil {
 			return "", err
 		}
-		return *serverID, db.UpdateTask(ctx, id, taskData.Title, taskData.Description, taskData.Status, taskData.Priority, nil, taskData.DisplayOrder, taskData.Collapsed)
+		_, err = db.UpdateTask(ctx, id, userID, taskData.Title, taskData.Description, taskData.Status, taskData.Priority, nil, taskData.DisplayOrder, taskData.Collapsed, taskData.Force)
+		return *serverID, err
 	}
 
 	task, err := db.CreateTask(ctx, userID, projectID, parentTaskID, taskData.Title, taskData.Description, taskData.Status, taskData.Priority, nil, taskData.DisplayOrder)
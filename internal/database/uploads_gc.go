@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// GetUploadReferences returns every stored file path still referenced by a row:
+// live images, attachments and avatars, plus rows deleted after `since`
+// (their files are kept for the GC grace period)
+func (db *DB) GetUploadReferences(ctx context.Context, since time.Time) (map[string]bool, error) {
+	refs := make(map[string]bool)
+
+	// Images: both tables keep the full/thumb paths plus every variant URL
+	imageQueries := []string{
+		`SELECT original_path, COALESCE(thumbnail_path, ''), variants FROM daily_images
+		 WHERE deleted_at IS NULL OR deleted_at > $1`,
+		`SELECT original_path, COALESCE(thumbnail_path, ''), variants FROM blog_images
+		 WHERE is_deleted = false OR updated_at > $1`,
+	}
+	for _, query := range imageQueries {
+		rows, err := db.Pool.Query(ctx, query, since)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var original, thumbnail string
+			var variantsJSON []byte
+			if err := rows.Scan(&original, &thumbnail, &variantsJSON); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			refs[original] = true
+			refs[thumbnail] = true
+
+			var variants ImageVariants
+			json.Unmarshal(variantsJSON, &variants)
+			for _, url := range variants.URLs() {
+				refs[url] = true
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Attachments and avatars: one path per row
+	err := db.collectPaths(ctx, refs, `
+		SELECT file_path FROM task_attachments
+		WHERE COALESCE(is_deleted, false) = false OR COALESCE(deleted_at, created_at) > $1
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	err = db.collectPaths(ctx, refs, `SELECT avatar_url FROM users WHERE avatar_url IS NOT NULL AND avatar_url <> ''`)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(refs, "")
+	return refs, nil
+}
+
+// collectPaths adds the single text column returned by query to refs
+func (db *DB) collectPaths(ctx context.Context, refs map[string]bool, query string, args ...interface{}) error {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return err
+		}
+		refs[path] = true
+	}
+	return rows.Err()
+}
+
+// GetUnreferencedBlogImages returns live blog images whose position marker no longer
+// appears in their post (or whose post is gone), for posts unchanged since `before`
+func (db *DB) GetUnreferencedBlogImages(ctx context.Context, before time.Time) ([]BlogImage, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT bi.id, bi.user_id, bi.markdown_note_id, bi.original_path, bi.thumbnail_path, bi.filename, bi.mime_type, bi.size_bytes, bi.variants, bi.position_marker, bi.is_deleted, bi.created_at, bi.updated_at
+		FROM blog_images bi
+		LEFT JOIN markdown_notes n ON n.id = bi.markdown_note_id
+		WHERE bi.is_deleted = false
+		  AND bi.updated_at < $1
+		  AND (n.id IS NULL OR (
+		      n.updated_at < $1
+		      AND bi.position_marker <> ''
+		      AND strpos(n.content, bi.position_marker) = 0
+		      AND strpos(n.content, bi.original_path) = 0
+		  ))
+		ORDER BY bi.created_at
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []BlogImage
+	for rows.Next() {
+		var img BlogImage
+		var variantsJSON []byte
+		if err := rows.Scan(
+			&img.ID, &img.UserID, &img.MarkdownNoteID, &img.OriginalPath, &img.ThumbnailPath,
+			&img.Filename, &img.MimeType, &img.SizeBytes, &variantsJSON, &img.PositionMarker, &img.IsDeleted, &img.CreatedAt, &img.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(variantsJSON, &img.Variants)
+		images = append(images, img)
+	}
+
+	return images, rows.Err()
+}
@@ -106,12 +106,12 @@ func (db *DB) UpdateUserProfile(ctx context.Context, userID uuid.UUID, displayNa
 	return err
 }
 
-// UpdateUserAvatar updates only the user's avatar
-func (db *DB) UpdateUserAvatar(ctx context.Context, userID uuid.UUID, avatarURL string) error {
+// UpdateUserAvatar updates only the user's avatar and its file size (for storage usage)
+func (db *DB) UpdateUserAvatar(ctx context.Context, userID uuid.UUID, avatarURL string, avatarBytes int64) error {
 	_, err := db.Pool.Exec(ctx, `
-		UPDATE users SET avatar_url = $2, updated_at = NOW()
+		UPDATE users SET avatar_url = $2, avatar_bytes = $3, updated_at = NOW()
 		WHERE id = $1
-	`, userID, avatarURL)
+	`, userID, avatarURL, avatarBytes)
 	return err
 }
 
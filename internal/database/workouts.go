@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -40,10 +42,20 @@ func (db *DB) GetWorkoutsByUserID(ctx context.Context, userID uuid.UUID) ([]Work
 	return workouts, rows.Err()
 }
 
-// GetWorkoutsForDay retrieves workouts scheduled for a specific day
-// Note: Currently returns all workouts regardless of day since day filtering is not needed
-func (db *DB) GetWorkoutsForDay(ctx context.Context, userID uuid.UUID, day string) ([]Workout, error) {
-	return db.GetWorkoutsByUserID(ctx, userID)
+// GetWorkoutsForDay retrieves the workouts to choose from on date. When the active
+// training program schedules a workout that day, it comes first with Scheduled set
+// and its targets progressed; the rest follow in display order.
+func (db *DB) GetWorkoutsForDay(ctx context.Context, userID uuid.UUID, date time.Time) ([]Workout, error) {
+	workouts, err := db.GetWorkoutsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := db.GetActiveProgram(ctx, userID)
+	if err != nil || program == nil {
+		return workouts, err
+	}
+	return db.scheduleWorkouts(ctx, userID, program, date, workouts)
 }
 
 // CreateWorkout creates a new workout
@@ -53,11 +65,18 @@ func (db *DB) CreateWorkout(ctx context.Context, userID uuid.UUID, name, day str
 
 // CreateWorkoutWithRestDay creates a new workout with rest day flag
 func (db *DB) CreateWorkoutWithRestDay(ctx context.Context, userID uuid.UUID, name, day string, exercises []Exercise, isRestDay bool) (*Workout, error) {
-	exercisesJSON, _ := json.Marshal(exercises)
+	exercises, err := db.linkExercises(ctx, userID, exercises)
+	if err != nil {
+		return nil, err
+	}
+	exercisesJSON, err := json.Marshal(exercises)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get next display_order for this user
 	var nextOrder int
-	err := db.Pool.QueryRow(ctx, `
+	err = db.Pool.QueryRow(ctx, `
 		SELECT COALESCE(MAX(display_order), 0) + 1 FROM workouts WHERE user_id = $1
 	`, userID).Scan(&nextOrder)
 	if err != nil {
@@ -106,9 +125,16 @@ func (db *DB) GetWorkoutByID(ctx context.Context, workoutID uuid.UUID) (*Workout
 
 // UpdateWorkout updates a workout (without changing is_rest_day)
 func (db *DB) UpdateWorkout(ctx context.Context, workoutID uuid.UUID, name, day string, exercises []Exercise) error {
-	exercisesJSON, _ := json.Marshal(exercises)
+	exercises, err := db.linkWorkoutExercises(ctx, workoutID, exercises)
+	if err != nil {
+		return err
+	}
+	exercisesJSON, err := json.Marshal(exercises)
+	if err != nil {
+		return err
+	}
 
-	_, err := db.Pool.Exec(ctx, `
+	_, err = db.Pool.Exec(ctx, `
 		UPDATE workouts
 		SET name = $2, day = $3, exercises = $4, updated_at = now()
 		WHERE id = $1
@@ -119,9 +145,16 @@ func (db *DB) UpdateWorkout(ctx context.Context, workoutID uuid.UUID, name, day
 
 // UpdateWorkoutWithRestDay updates a workout including is_rest_day flag
 func (db *DB) UpdateWorkoutWithRestDay(ctx context.Context, workoutID uuid.UUID, name, day string, exercises []Exercise, isRestDay bool) error {
-	exercisesJSON, _ := json.Marshal(exercises)
+	exercises, err := db.linkWorkoutExercises(ctx, workoutID, exercises)
+	if err != nil {
+		return err
+	}
+	exercisesJSON, err := json.Marshal(exercises)
+	if err != nil {
+		return err
+	}
 
-	_, err := db.Pool.Exec(ctx, `
+	_, err = db.Pool.Exec(ctx, `
 		UPDATE workouts
 		SET name = $2, day = $3, exercises = $4, is_rest_day = $5, updated_at = now()
 		WHERE id = $1
@@ -188,21 +221,42 @@ func (db *DB) SaveWorkoutLog(ctx context.Context, userID uuid.UUID, workoutName
 // SaveWorkoutLogWithRestDay creates or updates a workout log with rest day support
 func (db *DB) SaveWorkoutLogWithRestDay(ctx context.Context, userID uuid.UUID, workoutName string, exercises []Exercise, cardio []Cardio, weight float64, date time.Time, isRestDay bool) (*WorkoutLog, error) {
 	dateStr := date.Format("2006-01-02")
-	exercisesJSON, _ := json.Marshal(exercises)
-	cardioJSON, _ := json.Marshal(cardio)
+	exercises, err := db.linkExercises(ctx, userID, exercises)
+	if err != nil {
+		return nil, err
+	}
+	exercisesJSON, err := json.Marshal(exercises)
+	if err != nil {
+		return nil, err
+	}
+	cardioJSON, err := json.Marshal(normalizeCardio(cardio))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
 	// Check if log exists
 	var existingID uuid.UUID
-	err := db.Pool.QueryRow(ctx, `
-		SELECT id FROM workout_logs WHERE user_id = $1 AND date = $2
-	`, userID, dateStr).Scan(&existingID)
+	var existingWeight float64
+	err = tx.QueryRow(ctx, `
+		SELECT id, weight FROM workout_logs WHERE user_id = $1 AND date = $2 FOR UPDATE
+	`, userID, dateStr).Scan(&existingID, &existingWeight)
+	exists := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
 
 	var wl WorkoutLog
 	var exercisesBytes, cardioBytes []byte
 
-	if err == nil {
+	if exists {
 		// Update existing
-		err = db.Pool.QueryRow(ctx, `
+		err = tx.QueryRow(ctx, `
 			UPDATE workout_logs
 			SET name = $2, completed_exercises = $3, cardio = $4, weight = $5, is_rest_day = $6, updated_at = now()
 			WHERE id = $1
@@ -213,7 +267,7 @@ func (db *DB) SaveWorkoutLogWithRestDay(ctx context.Context, userID uuid.UUID, w
 		)
 	} else {
 		// Create new
-		err = db.Pool.QueryRow(ctx, `
+		err = tx.QueryRow(ctx, `
 			INSERT INTO workout_logs (user_id, name, completed_exercises, cardio, weight, date, is_rest_day)
 			VALUES ($1, $2, $3, $4, $5, $6, $7)
 			RETURNING id, user_id, name, completed_exercises, cardio, weight, date, created_at, updated_at, is_rest_day
@@ -227,6 +281,19 @@ func (db *DB) SaveWorkoutLogWithRestDay(ctx context.Context, userID uuid.UUID, w
 		return nil, err
 	}
 
+	// Weight entered with a workout also goes into the body metrics log, but only
+	// when this save changed it: re-sending the log's old weight (e.g. appending
+	// cardio) mustn't overwrite a weight corrected on the body metrics page since
+	if weight > 0 && weight <= MaxWeightKg && (!exists || weight != existingWeight) {
+		if err := saveBodyWeight(ctx, tx, userID, date, weight); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	json.Unmarshal(exercisesBytes, &wl.CompletedExercises)
 	if cardioBytes != nil {
 		json.Unmarshal(cardioBytes, &wl.Cardio)
@@ -234,3 +301,181 @@ func (db *DB) SaveWorkoutLogWithRestDay(ctx context.Context, userID uuid.UUID, w
 
 	return &wl, nil
 }
+
+// normalizeExercises cleans exercise data before it is stored: names are trimmed,
+// negative numbers are clamped to zero, RPE is capped at 10 and empty set rows
+// (no reps, weight or notes) are dropped
+func normalizeExercises(exercises []Exercise) []Exercise {
+	out := make([]Exercise, 0, len(exercises))
+	for _, ex := range exercises {
+		ex.Name = strings.TrimSpace(ex.Name)
+		if ex.Name == "" {
+			continue
+		}
+		ex.TargetSets = max(ex.TargetSets, 0)
+		ex.TargetReps = max(ex.TargetReps, 0)
+		ex.TargetWeight = max(ex.TargetWeight, 0)
+
+		var sets []ExerciseSet
+		for _, set := range ex.Sets {
+			set.Reps = max(set.Reps, 0)
+			set.Weight = max(set.Weight, 0)
+			set.RPE = min(max(set.RPE, 0), 10)
+			set.RestSeconds = max(set.RestSeconds, 0)
+			set.Notes = strings.TrimSpace(set.Notes)
+			if set.Reps == 0 && set.Weight == 0 && set.Notes == "" {
+				continue
+			}
+			sets = append(sets, set)
+		}
+		ex.Sets = sets
+		out = append(out, ex)
+	}
+	return out
+}
+
+// AppendCardio adds a cardio entry to the day's workout log, creating the log if there isn't one
+func (db *DB) AppendCardio(ctx context.Context, userID uuid.UUID, date time.Time, cardio Cardio) (*WorkoutLog, error) {
+	existing, err := db.GetWorkoutLogForDay(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return db.SaveWorkoutLogWithRestDay(ctx, userID, "", []Exercise{}, []Cardio{cardio}, 0, date, false)
+	}
+	return db.SaveWorkoutLogWithRestDay(ctx, userID, existing.WorkoutName, existing.CompletedExercises,
+		append(existing.Cardio, cardio), existing.Weight, date, existing.IsRestDay)
+}
+
+// normalizeCardio drops empty entries, clamps negative numbers and keeps
+// Minutes in step with DurationSeconds
+func normalizeCardio(cardio []Cardio) []Cardio {
+	out := make([]Cardio, 0, len(cardio))
+	for _, c := range cardio {
+		c.Name = strings.TrimSpace(c.Name)
+		c.Minutes = max(c.Minutes, 0)
+		c.DurationSeconds = max(c.DurationSeconds, 0)
+		c.DistanceKm = max(c.DistanceKm, 0)
+		c.AvgHeartRate = max(c.AvgHeartRate, 0)
+		c.MaxHeartRate = max(c.MaxHeartRate, 0)
+		c.Calories = max(c.Calories, 0)
+		c.ElevationGainM = max(c.ElevationGainM, 0)
+		if c.DurationSeconds > 0 && c.Minutes == 0 {
+			c.Minutes = (c.DurationSeconds + 30) / 60
+		}
+		if c.Name == "" && c.Minutes == 0 && c.DistanceKm == 0 {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// syncExercise is an Exercise as pushed by a sync client. The detail fields
+// are pointers so that a field the client doesn't send (older clients only
+// know order and name) can be told apart from one the user cleared.
+type syncExercise struct {
+	Order        int            `json:"order"`
+	Name         string         `json:"name"`
+	ExerciseID   *uuid.UUID     `json:"exercise_id"`
+	TargetSets   *int           `json:"target_sets"`
+	TargetReps   *int           `json:"target_reps"`
+	TargetWeight *float64       `json:"target_weight"`
+	Sets         *[]ExerciseSet `json:"sets"`
+}
+
+// mergeExerciseDetails builds the exercises to store from a sync push: fields
+// the client sent are taken as is, including empty ones, and fields it left
+// out are carried over from the existing exercise with the same name
+func mergeExerciseDetails(incoming []syncExercise, existing []Exercise) []Exercise {
+	byName := make(map[string]Exercise, len(existing))
+	for _, ex := range existing {
+		byName[ex.Name] = ex
+	}
+
+	out := make([]Exercise, 0, len(incoming))
+	for _, in := range incoming {
+		ex := byName[in.Name] // zero when the exercise is new
+		ex.Order = in.Order
+		ex.Name = in.Name
+		ex.Sets = slices.Clone(ex.Sets)
+		if in.ExerciseID != nil {
+			ex.ExerciseID = in.ExerciseID
+		}
+		if in.TargetSets != nil {
+			ex.TargetSets = *in.TargetSets
+		}
+		if in.TargetReps != nil {
+			ex.TargetReps = *in.TargetReps
+		}
+		if in.TargetWeight != nil {
+			ex.TargetWeight = *in.TargetWeight
+		}
+		if in.Sets != nil {
+			ex.Sets = slices.Clone(*in.Sets)
+		}
+		out = append(out, ex)
+	}
+	return out
+}
+
+// GetWorkoutLogsWithSets retrieves the latest limit workout logs (0 = all) up
+// to and including `until` that have at least one logged set, oldest first
+// (used for strength progress)
+func (db *DB) GetWorkoutLogsWithSets(ctx context.Context, userID uuid.UUID, until time.Time, limit int) ([]WorkoutLog, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, name, completed_exercises, cardio, weight, date, created_at, updated_at, is_rest_day
+		FROM workout_logs
+		WHERE user_id = $1 AND date <= $2
+		  AND jsonb_path_exists(completed_exercises, '$[*].sets[*]')
+		ORDER BY date DESC
+		LIMIT NULLIF($3::int, 0)
+	`, userID, until.Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []WorkoutLog
+	for rows.Next() {
+		var wl WorkoutLog
+		var exercisesJSON, cardioJSON []byte
+		if err := rows.Scan(
+			&wl.ID, &wl.UserID, &wl.WorkoutName, &exercisesJSON, &cardioJSON,
+			&wl.Weight, &wl.Date, &wl.CreatedAt, &wl.UpdatedAt, &wl.IsRestDay,
+		); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(exercisesJSON, &wl.CompletedExercises)
+		if cardioJSON != nil {
+			json.Unmarshal(cardioJSON, &wl.Cardio)
+		}
+		logs = append(logs, wl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(logs)
+	return logs, nil
+}
+
+// mergeCardioDetails keeps distance, heart rate and the other detailed fields of
+// existing entries when a client only sends name and minutes for them
+func mergeCardioDetails(incoming, existing []Cardio) []Cardio {
+	for i, c := range incoming {
+		if i >= len(existing) || existing[i].Name != c.Name {
+			continue
+		}
+		if c.DurationSeconds == 0 && c.DistanceKm == 0 && c.AvgHeartRate == 0 && c.Calories == 0 && c.Source == "" {
+			merged := existing[i]
+			if c.Minutes != merged.Minutes {
+				merged.Minutes = c.Minutes
+				merged.DurationSeconds = 0
+			}
+			incoming[i] = merged
+		}
+	}
+	return incoming
+}
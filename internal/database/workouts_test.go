@@ -0,0 +1,65 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeExerciseDetails(t *testing.T) {
+	existing := []Exercise{
+		{Order: 1, Name: "سكوات", TargetSets: 3, TargetReps: 5, TargetWeight: 100, Sets: []ExerciseSet{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 105}}},
+		{Order: 2, Name: "ضغط", TargetSets: 3, TargetReps: 8, Sets: []ExerciseSet{{Reps: 8, Weight: 60}}},
+	}
+
+	tests := []struct {
+		name string
+		push string // the client's exercises JSON
+		want []Exercise
+	}{
+		{
+			"fields the client leaves out are kept",
+			`[{"order":1,"name":"سكوات"},{"order":2,"name":"ضغط"}]`,
+			existing,
+		},
+		{
+			"empty sets and zero targets the client sends clear them",
+			`[{"order":1,"name":"سكوات","sets":[],"target_sets":0,"target_reps":0,"target_weight":0}]`,
+			[]Exercise{{Order: 1, Name: "سكوات", Sets: []ExerciseSet{}}},
+		},
+		{
+			"sent fields replace stored ones, the rest are kept",
+			`[{"order":2,"name":"سكوات","target_weight":110,"sets":[{"reps":3,"weight":110}]}]`,
+			[]Exercise{{Order: 2, Name: "سكوات", TargetSets: 3, TargetReps: 5, TargetWeight: 110, Sets: []ExerciseSet{{Reps: 3, Weight: 110}}}},
+		},
+		{
+			"new exercises start empty",
+			`[{"order":1,"name":"عقلة","target_sets":4}]`,
+			[]Exercise{{Order: 1, Name: "عقلة", TargetSets: 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var incoming []syncExercise
+			if err := json.Unmarshal([]byte(tt.push), &incoming); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := json.Marshal(existing)
+
+			got := mergeExerciseDetails(incoming, existing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeExerciseDetails =\n%+v\nwant\n%+v", got, tt.want)
+			}
+
+			// The result must not share sets with the stored exercises
+			for i := range got {
+				for j := range got[i].Sets {
+					got[i].Sets[j].Reps = -1
+				}
+			}
+			if after, _ := json.Marshal(existing); string(after) != string(before) {
+				t.Errorf("mergeExerciseDetails changed the existing exercises: %s", after)
+			}
+		})
+	}
+}
@@ -37,6 +37,9 @@ func (h *Handler) AdminDashboard(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "خطأ في جلب بيانات المستخدمين")
 	}
+	for i := range usersStats {
+		usersStats[i].QuotaBytes = h.storageQuota(usersStats[i].Role)
+	}
 
 	return Render(c, http.StatusOK, pages.AdminDashboard(user, stats, usersStats))
 }
@@ -1,15 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"ohabits/internal/database"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/media"
 	"ohabits/templates/pages"
 
 	"github.com/google/uuid"
@@ -22,7 +23,7 @@ func ResolveBlogImageMarkers(content string, images []database.BlogImage) string
 	markerMap := make(map[string]string)
 	for _, img := range images {
 		if img.OriginalPath != "" {
-			markerMap[img.PositionMarker] = img.OriginalPath
+			markerMap[img.PositionMarker] = img.Variants.Get(media.VariantMedium, img.OriginalPath)
 		}
 	}
 
@@ -36,8 +37,6 @@ func ResolveBlogImageMarkers(content string, images []database.BlogImage) string
 	return result
 }
 
-
-
 // BlogPage renders the blog listing page
 func (h *Handler) BlogPage(c echo.Context) error {
 	userID, ok := middleware.GetUserID(c)
@@ -155,7 +154,7 @@ func (h *Handler) BlogSave(c echo.Context) error {
 		title = "مدونة بدون عنوان"
 	}
 
-	post, err := h.DB.UpdateBlogPost(c.Request().Context(), userID, postID, title, content)
+	post, err := h.DB.UpdateBlogPost(c.Request().Context(), userID, postID, title, content, database.RevisionSourceWeb)
 	if err != nil || post == nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
@@ -231,7 +230,7 @@ func (h *Handler) BlogUploadImage(c echo.Context) error {
 	// Validate file type
 	mimeType := file.Header.Get("Content-Type")
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" {
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" && ext != ".heic" && ext != ".heif" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "نوع الملف غير مدعوم"})
 	}
 
@@ -241,68 +240,75 @@ func (h *Handler) BlogUploadImage(c echo.Context) error {
 	// Generate position marker
 	positionMarker := fmt.Sprintf("blog-img-%s", uuid.New().String()[:8])
 
-	// Create upload directories
-	userDir := filepath.Join("uploads", "blog", userID.String())
-	originalsDir := filepath.Join(userDir, "originals")
-	thumbsDir := filepath.Join(userDir, "thumbnails")
+	ctx := c.Request().Context()
 
-	if err := os.MkdirAll(originalsDir, 0755); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	// Reserve the upload in the storage quota until it's saved
+	usage, err := h.reserveStorage(ctx, userID, file.Size)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
 	}
-	if err := os.MkdirAll(thumbsDir, 0755); err != nil {
+	if err != nil {
+		log.Printf("Storage reservation error: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
+	reserved := file.Size
+	defer func() { h.releaseStorage(userID, reserved) }()
 
-	// Generate unique filename
-	newFilename := fmt.Sprintf("%s_%s%s", positionMarker, uuid.New().String()[:8], ext)
-	originalPath := filepath.Join(originalsDir, newFilename)
-	thumbnailPath := filepath.Join(thumbsDir, newFilename)
-
-	// Save file
-	src, err := file.Open()
+	// Save file (resized, oriented and without EXIF)
+	data, err := readUpload(file)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
-	defer src.Close()
 
-	dst, err := os.Create(originalPath)
+	base := fmt.Sprintf("%s_%s", positionMarker, uuid.New().String()[:8])
+	stored, err := h.storeImage(ctx, "blog/"+userID.String(), base, data, mimeType, file.Filename)
 	if err != nil {
+		if errors.Is(err, media.ErrUnsupported) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "نوع الملف غير مدعوم"})
+		}
+		if errors.Is(err, media.ErrTooLarge) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "أبعاد الصورة كبيرة جداً"})
+		}
+		log.Printf("Blog image store error: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
-	defer dst.Close()
 
-	if _, err = io.Copy(dst, src); err != nil {
-		os.Remove(originalPath)
+	// The variants can take more than the upload; they must fit too
+	reserved, usage, err = h.growReservation(ctx, userID, reserved, stored)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
+	}
+	if err != nil {
+		log.Printf("Storage reservation error: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
 
-	// Create thumbnail (optional)
-	createThumbnail(originalPath, thumbnailPath)
-
 	// If note_id provided, save to blog_images table
 	if noteIDStr != "" {
 		noteID, err := uuid.Parse(noteIDStr)
 		if err == nil {
 			_, dbErr := h.DB.SaveBlogImage(
-				c.Request().Context(),
+				ctx,
 				userID,
 				noteID,
-				"/"+originalPath,
-				"/"+thumbnailPath,
+				stored.OriginalPath,
+				stored.ThumbnailPath,
 				file.Filename,
-				mimeType,
-				int(file.Size),
+				stored.MimeType,
+				stored.SizeBytes,
+				stored.StoredBytes,
 				positionMarker,
+				stored.Variants,
 			)
 			if dbErr != nil {
-				fmt.Printf("Warning: Failed to save blog image record: %v\n", dbErr)
+				log.Printf("Blog image record error: %v", dbErr)
 			}
 		}
 	}
 
 	// Return the position marker (and URL for backward compatibility)
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"url":             "/" + originalPath,
+		"url":             stored.Variants.Get(media.VariantMedium, stored.OriginalPath),
 		"position_marker": positionMarker,
 	})
 }
@@ -1,14 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 
+	"ohabits/internal/database"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/media"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -57,68 +56,66 @@ func (h *Handler) UploadBlogImageAPI(c echo.Context) error {
 
 	// Validate file type
 	mimeType := file.Header.Get("Content-Type")
-	if !strings.HasPrefix(mimeType, "image/") {
+	if !isImageUpload(mimeType, file.Filename) {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "نوع الملف غير مدعوم"})
 	}
 
-	// Create upload directories
-	userDir := filepath.Join("uploads", "blog", userID.String())
-	originalsDir := filepath.Join(userDir, "originals")
-	thumbsDir := filepath.Join(userDir, "thumbnails")
+	ctx := c.Request().Context()
 
-	if err := os.MkdirAll(originalsDir, 0755); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في إنشاء المجلدات"})
+	// Reserve the upload in the storage quota until it's saved
+	usage, err := h.reserveStorage(ctx, userID, file.Size)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
 	}
-	if err := os.MkdirAll(thumbsDir, 0755); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في إنشاء المجلدات"})
-	}
-
-	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	if ext == "" {
-		ext = ".jpg"
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في حفظ الملف"})
 	}
-	newFilename := fmt.Sprintf("%s_%s%s", positionMarker, uuid.New().String()[:8], ext)
+	reserved := file.Size
+	defer func() { h.releaseStorage(userID, reserved) }()
 
-	originalPath := filepath.Join(originalsDir, newFilename)
-	thumbnailPath := filepath.Join(thumbsDir, newFilename)
-
-	// Save original file
-	src, err := file.Open()
+	// Save file (resized, oriented and without EXIF)
+	data, err := readUpload(file)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في قراءة الملف"})
 	}
-	defer src.Close()
 
-	dst, err := os.Create(originalPath)
+	base := fmt.Sprintf("%s_%s", positionMarker, uuid.New().String()[:8])
+	stored, err := h.storeImage(ctx, "blog/"+userID.String(), base, data, mimeType, file.Filename)
 	if err != nil {
+		if errors.Is(err, media.ErrUnsupported) {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "نوع الملف غير مدعوم"})
+		}
+		if errors.Is(err, media.ErrTooLarge) {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "أبعاد الصورة كبيرة جداً"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في حفظ الملف"})
 	}
-	defer dst.Close()
 
-	if _, err = io.Copy(dst, src); err != nil {
-		os.Remove(originalPath)
+	// The variants can take more than the upload; they must fit too
+	reserved, usage, err = h.growReservation(ctx, userID, reserved, stored)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
+	}
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في حفظ الملف"})
 	}
 
-	// Create thumbnail (optional)
-	createThumbnail(originalPath, thumbnailPath)
-
 	// Save to database
 	img, err := h.DB.SaveBlogImage(
-		c.Request().Context(),
+		ctx,
 		userID,
 		noteID,
-		"/"+originalPath,
-		"/"+thumbnailPath,
+		stored.OriginalPath,
+		stored.ThumbnailPath,
 		file.Filename,
-		mimeType,
-		int(file.Size),
+		stored.MimeType,
+		stored.SizeBytes,
+		stored.StoredBytes,
 		positionMarker,
+		stored.Variants,
 	)
 	if err != nil {
-		os.Remove(originalPath)
-		os.Remove(thumbnailPath)
+		h.deleteImageFiles(ctx, stored.Variants)
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "خطأ في حفظ السجل"})
 	}
 
@@ -137,6 +134,7 @@ func (h *Handler) UploadBlogImageAPI(c echo.Context) error {
 			"mime_type":       img.MimeType,
 			"size_bytes":      img.SizeBytes,
 			"position_marker": img.PositionMarker,
+			"variants":        img.Variants,
 		},
 	})
 }
@@ -160,10 +158,11 @@ func (h *Handler) DeleteBlogImageAPI(c echo.Context) error {
 	}
 
 	// Delete files (optional)
-	os.Remove(strings.TrimPrefix(img.OriginalPath, "/"))
+	thumbPath := ""
 	if img.ThumbnailPath != nil {
-		os.Remove(strings.TrimPrefix(*img.ThumbnailPath, "/"))
+		thumbPath = *img.ThumbnailPath
 	}
+	h.deleteImageFiles(c.Request().Context(), img.Variants, img.OriginalPath, thumbPath)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/bodymetrics"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// bodyMetricWindows are the allowed chart windows (in days)
+var bodyMetricWindows = []int{30, 90, 180, 365}
+
+// BodyMetricsPage renders the body weight and measurements page
+func (h *Handler) BodyMetricsPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	user, err := h.DB.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	window := parseBodyMetricWindow(c.QueryParam("days"))
+	view, err := h.loadBodyMetrics(c, userID, window)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "حدث خطأ")
+	}
+
+	return Render(c, http.StatusOK, pages.BodyMetricsPage(user, view, bodyMetricWindows))
+}
+
+// SaveBodyMetric saves the entry for a day from the page form (values in the user's unit)
+func (h *Handler) SaveBodyMetric(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	ctx := c.Request().Context()
+	settings, err := h.DB.GetBodyMetricSettings(ctx, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.FormValue("date"), KuwaitTZ)
+	if err != nil {
+		date = GetKuwaitDate(GetKuwaitTime())
+	}
+
+	weight := func(name string) *float64 {
+		return convertFormValue(c.FormValue(name), settings.FromDisplayWeight)
+	}
+	length := func(name string) *float64 {
+		return convertFormValue(c.FormValue(name), settings.FromDisplayLength)
+	}
+	m := database.BodyMetric{
+		Date:       date,
+		WeightKg:   weight("weight"),
+		BodyFatPct: convertFormValue(c.FormValue("body_fat_pct"), func(v float64) float64 { return v }),
+		WaistCm:    length("waist"),
+		ChestCm:    length("chest"),
+		HipsCm:     length("hips"),
+		ArmCm:      length("arm"),
+		ThighCm:    length("thigh"),
+		NeckCm:     length("neck"),
+		Notes:      strings.TrimSpace(c.FormValue("notes")),
+	}
+	if f := checkBodyMetric(&m); f != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("قيمة غير صالحة لـ%s", f.label),
+		})
+	}
+	if !bodyMetricHasValues(m) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "أدخل قياساً واحداً على الأقل"})
+	}
+
+	if _, err := h.DB.SaveBodyMetric(ctx, userID, m); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"body_metric_saved","type":"success"}}`)
+	return h.renderBodyMetricsContent(c, userID)
+}
+
+// DeleteBodyMetric deletes a body metric entry from the page
+func (h *Handler) DeleteBodyMetric(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	if err := h.DB.DeleteBodyMetric(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"body_metric_deleted","type":"success"}}`)
+	return h.renderBodyMetricsContent(c, userID)
+}
+
+// SaveBodyMetricSettings saves the unit preference and goal weight from the page
+func (h *Handler) SaveBodyMetricSettings(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	settings := database.BodyMetricSettings{Unit: c.FormValue("unit")}
+	if settings.Unit != database.UnitLb {
+		settings.Unit = database.UnitKg
+	}
+	// The goal is entered in the newly selected unit
+	settings.GoalWeightKg = convertFormValue(c.FormValue("goal_weight"), settings.FromDisplayWeight)
+	if !validMeasurement(settings.GoalWeightKg, database.MaxWeightKg) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "قيمة غير صالحة للوزن المستهدف"})
+	}
+
+	if err := h.DB.SaveBodyMetricSettings(c.Request().Context(), userID, settings); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"body_settings_saved","type":"success"}}`)
+	return h.renderBodyMetricsContent(c, userID)
+}
+
+// renderBodyMetricsContent re-renders the page body for the window in the hx-vals / query
+func (h *Handler) renderBodyMetricsContent(c echo.Context, userID uuid.UUID) error {
+	window := parseBodyMetricWindow(c.FormValue("days"))
+	view, err := h.loadBodyMetrics(c, userID, window)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return Render(c, http.StatusOK, pages.BodyMetricsContent(view, bodyMetricWindows))
+}
+
+// loadBodyMetrics loads entries, settings and the trend for the last `window` days
+func (h *Handler) loadBodyMetrics(c echo.Context, userID uuid.UUID, window int) (*pages.BodyMetricsView, error) {
+	ctx := c.Request().Context()
+
+	settings, err := h.DB.GetBodyMetricSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := GetKuwaitDate(GetKuwaitTime())
+	from := to.AddDate(0, 0, -(window - 1))
+	metrics, err := h.DB.GetBodyMetrics(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pages.BodyMetricsView{
+		Window:   window,
+		Today:    to,
+		Settings: settings,
+		Metrics:  metrics,
+		Summary:  bodymetrics.Analyze(metrics, settings.GoalWeightKg),
+	}, nil
+}
+
+// ========== API HANDLERS ==========
+
+// GetBodyMetricsAPI returns entries and the weight trend (all values in kg / cm)
+// GET /api/body-metrics?days=90
+func (h *Handler) GetBodyMetricsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	view, err := h.loadBodyMetrics(c, userID, parseBodyMetricWindow(c.QueryParam("days")))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load body metrics"})
+	}
+
+	metrics := view.Metrics
+	if metrics == nil {
+		metrics = []database.BodyMetric{}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"metrics":  metrics,
+		"trend":    view.Summary,
+		"settings": view.Settings,
+	})
+}
+
+// SaveBodyMetricAPI creates or replaces the entry for a day (values in kg / cm)
+// POST /api/body-metrics
+func (h *Handler) SaveBodyMetricAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		database.BodyMetric
+		Date string `json:"date"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+	}
+
+	m := req.BodyMetric
+	m.Date = date
+	m.Notes = strings.TrimSpace(m.Notes)
+	if f := checkBodyMetric(&m); f != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"error":  fmt.Sprintf("Invalid %s: must be a number up to %g", f.json, f.max),
+		})
+	}
+	if !bodyMetricHasValues(m) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "At least one measurement is required"})
+	}
+
+	saved, err := h.DB.SaveBodyMetric(c.Request().Context(), userID, m)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save body metric"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "metric": saved})
+}
+
+// DeleteBodyMetricAPI deletes a body metric entry
+// DELETE /api/body-metrics/:id
+func (h *Handler) DeleteBodyMetricAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	if err := h.DB.DeleteBodyMetric(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to delete body metric"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// GetBodyMetricSettingsAPI returns the unit preference and goal weight
+// GET /api/body-metrics/settings
+func (h *Handler) GetBodyMetricSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	settings, err := h.DB.GetBodyMetricSettings(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load settings"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "settings": settings})
+}
+
+// UpdateBodyMetricSettingsAPI updates the unit preference and goal weight (goal in kg)
+// PUT /api/body-metrics/settings
+func (h *Handler) UpdateBodyMetricSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var settings database.BodyMetricSettings
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	if settings.Unit != database.UnitKg && settings.Unit != database.UnitLb {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Unit must be kg or lb"})
+	}
+	if settings.GoalWeightKg != nil && *settings.GoalWeightKg <= 0 {
+		settings.GoalWeightKg = nil
+	}
+	if !validMeasurement(settings.GoalWeightKg, database.MaxWeightKg) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"error":  fmt.Sprintf("Invalid goal_weight_kg: must be a number up to %g", float64(database.MaxWeightKg)),
+		})
+	}
+
+	if err := h.DB.SaveBodyMetricSettings(c.Request().Context(), userID, settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save settings"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "settings": settings})
+}
+
+// parseBodyMetricWindow returns a supported window size, defaulting to 90 days
+func parseBodyMetricWindow(s string) int {
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 90
+	}
+	for _, w := range bodyMetricWindows {
+		if days == w {
+			return days
+		}
+	}
+	return 90
+}
+
+// convertFormValue parses a number from a form field and converts it to metric
+// units. Empty and non-positive fields are nil; anything that isn't a number
+// becomes NaN so that validMeasurement rejects it.
+func convertFormValue(s string, toMetric func(float64) float64) *float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		v = math.NaN()
+	} else if v <= 0 {
+		return nil
+	}
+	metric := toMetric(v)
+	return &metric
+}
+
+// bodyMetricField is one measurement of a BodyMetric with its upper bound
+type bodyMetricField struct {
+	json  string
+	label string
+	value **float64
+	max   float64
+}
+
+func bodyMetricFields(m *database.BodyMetric) []bodyMetricField {
+	return []bodyMetricField{
+		{"weight_kg", "الوزن", &m.WeightKg, database.MaxWeightKg},
+		{"body_fat_pct", "نسبة الدهون", &m.BodyFatPct, database.MaxBodyFatPct},
+		{"waist_cm", "الخصر", &m.WaistCm, database.MaxLengthCm},
+		{"chest_cm", "الصدر", &m.ChestCm, database.MaxLengthCm},
+		{"hips_cm", "الورك", &m.HipsCm, database.MaxLengthCm},
+		{"arm_cm", "الذراع", &m.ArmCm, database.MaxLengthCm},
+		{"thigh_cm", "الفخذ", &m.ThighCm, database.MaxLengthCm},
+		{"neck_cm", "الرقبة", &m.NeckCm, database.MaxLengthCm},
+	}
+}
+
+// checkBodyMetric clears non-positive measurements (an emptied field) and
+// returns the first one that isn't finite or is above its bound
+func checkBodyMetric(m *database.BodyMetric) *bodyMetricField {
+	for _, f := range bodyMetricFields(m) {
+		if *f.value != nil && **f.value <= 0 {
+			*f.value = nil
+		}
+		if !validMeasurement(*f.value, f.max) {
+			return &f
+		}
+	}
+	return nil
+}
+
+// validMeasurement reports whether v is unset or a finite number no larger than limit
+func validMeasurement(v *float64, limit float64) bool {
+	return v == nil || (!math.IsNaN(*v) && !math.IsInf(*v, 0) && *v <= limit)
+}
+
+func bodyMetricHasValues(m database.BodyMetric) bool {
+	return m.WeightKg != nil || m.BodyFatPct != nil || m.WaistCm != nil || m.ChestCm != nil ||
+		m.HipsCm != nil || m.ArmCm != nil || m.ThighCm != nil || m.NeckCm != nil || m.Notes != ""
+}
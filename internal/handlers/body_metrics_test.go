@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"ohabits/internal/database"
+)
+
+func TestCheckBodyMetric(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		metric database.BodyMetric
+		want   string // JSON name of the rejected field, "" when valid
+	}{
+		{"valid", database.BodyMetric{WeightKg: f(82.5), BodyFatPct: f(18), WaistCm: f(90)}, ""},
+		{"upper bounds are inclusive", database.BodyMetric{WeightKg: f(700), BodyFatPct: f(100), NeckCm: f(400)}, ""},
+		{"body fat over 100%", database.BodyMetric{BodyFatPct: f(100.1)}, "body_fat_pct"},
+		{"weight overflowing NUMERIC(6,2)", database.BodyMetric{WeightKg: f(12345)}, "weight_kg"},
+		{"length overflowing NUMERIC(5,1)", database.BodyMetric{ThighCm: f(1e6)}, "thigh_cm"},
+		{"NaN", database.BodyMetric{ChestCm: f(math.NaN())}, "chest_cm"},
+		{"+Inf", database.BodyMetric{HipsCm: f(math.Inf(1))}, "hips_cm"},
+		{"non-positive values are cleared, not rejected", database.BodyMetric{WeightKg: f(0), ArmCm: f(-3)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.metric
+			got := ""
+			if bad := checkBodyMetric(&m); bad != nil {
+				got = bad.json
+			}
+			if got != tt.want {
+				t.Errorf("checkBodyMetric rejected %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	m := database.BodyMetric{WeightKg: f(0), ArmCm: f(-3), WaistCm: f(80)}
+	checkBodyMetric(&m)
+	if m.WeightKg != nil || m.ArmCm != nil || m.WaistCm == nil {
+		t.Errorf("checkBodyMetric kept %+v, want only the waist", m)
+	}
+}
+
+func TestConvertFormValue(t *testing.T) {
+	double := func(v float64) float64 { return v * 2 }
+
+	tests := []struct {
+		in    string
+		want  float64 // NaN for input that must be rejected
+		isNil bool
+	}{
+		{"", 0, true},
+		{"  ", 0, true},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{" 40.5 ", 81, false},
+		{"abc", math.NaN(), false},
+		{"NaN", math.NaN(), false},
+		{"Inf", math.Inf(1), false},
+		{"1e999", math.NaN(), false},
+	}
+	for _, tt := range tests {
+		got := convertFormValue(tt.in, double)
+		switch {
+		case tt.isNil:
+			if got != nil {
+				t.Errorf("convertFormValue(%q) = %v, want nil", tt.in, *got)
+			}
+		case got == nil:
+			t.Errorf("convertFormValue(%q) = nil, want %v", tt.in, tt.want)
+		case math.IsNaN(tt.want):
+			if !math.IsNaN(*got) {
+				t.Errorf("convertFormValue(%q) = %v, want NaN", tt.in, *got)
+			}
+		case *got != tt.want:
+			t.Errorf("convertFormValue(%q) = %v, want %v", tt.in, *got, tt.want)
+		}
+		if got != nil && (math.IsNaN(tt.want) || math.IsInf(tt.want, 0)) && validMeasurement(got, database.MaxWeightKg) {
+			t.Errorf("validMeasurement(convertFormValue(%q)) = true, want false", tt.in)
+		}
+	}
+}
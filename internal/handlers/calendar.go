@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"ohabits/internal/database"
 	"ohabits/internal/middleware"
 	"ohabits/templates/pages"
 
@@ -26,7 +27,18 @@ func (h *Handler) CalendarPage(c echo.Context) error {
 
 	events, _ := h.DB.GetCalendarEventsByUserID(c.Request().Context(), userID)
 
-	return Render(c, http.StatusOK, pages.CalendarPage(user, events))
+	settings, err := h.DB.GetCalendarSettings(c.Request().Context(), userID)
+	if err != nil {
+		settings = &database.CalendarSettings{}
+	}
+
+	today := GetKuwaitDate(GetKuwaitTime())
+	month, err := h.calendarMonthData(c, userID, today)
+	if err != nil {
+		month = pages.CalendarMonthData{Month: today, Today: today}
+	}
+
+	return Render(c, http.StatusOK, pages.CalendarPage(user, events, settings, today, month))
 }
 
 // CreateCalendarEvent creates a new calendar event
@@ -74,13 +86,28 @@ func (h *Handler) CreateCalendarEvent(c echo.Context) error {
 		}
 	}
 
-	isRecurring := c.FormValue("is_recurring") == "on" || c.FormValue("is_recurring") == "true"
+	isRecurring, rule, _, err := parseRecurrence(c, eventDate)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"invalid_recurrence","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "قاعدة التكرار غير صالحة"})
+	}
+	eventTime, eventTimeSet, err := parseEventTime(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"invalid_event_time","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "الوقت غير صالح"})
+	}
+	calendarType := c.FormValue("calendar_type")
 	notes := strings.TrimSpace(c.FormValue("notes"))
 
-	_, err = h.DB.CreateCalendarEvent(c.Request().Context(), userID, title, eventType, eventDate, endDate, isRecurring, notes)
+	event, err := h.DB.CreateCalendarEvent(c.Request().Context(), userID, title, eventType, eventDate, endDate, isRecurring, calendarType, rule, notes)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
+	if eventTimeSet {
+		if err := h.saveEventTime(c.Request().Context(), event.ID, eventTime); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+		}
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"event_saved","type":"success"}}`)
 
@@ -128,13 +155,32 @@ func (h *Handler) UpdateCalendarEvent(c echo.Context) error {
 		}
 	}
 
-	isRecurring := c.FormValue("is_recurring") == "on" || c.FormValue("is_recurring") == "true"
+	isRecurring, rule, ruleSet, err := parseRecurrence(c, eventDate)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"invalid_recurrence","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "قاعدة التكرار غير صالحة"})
+	}
+	var rulePtr *string
+	if ruleSet {
+		rulePtr = &rule
+	}
+	eventTime, eventTimeSet, err := parseEventTime(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"invalid_event_time","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "الوقت غير صالح"})
+	}
+	calendarType := c.FormValue("calendar_type")
 	notes := strings.TrimSpace(c.FormValue("notes"))
 
-	err = h.DB.UpdateCalendarEvent(c.Request().Context(), eventID, title, eventType, eventDate, endDate, isRecurring, notes)
+	err = h.DB.UpdateCalendarEvent(c.Request().Context(), eventID, title, eventType, eventDate, endDate, isRecurring, calendarType, rulePtr, notes)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
+	if eventTimeSet {
+		if err := h.saveEventTime(c.Request().Context(), eventID, eventTime); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+		}
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"event_saved","type":"success"}}`)
 
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/ical"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// CalendarFeed serves a user's calendar as an iCalendar subscription.
+// Public: the secret token in the URL is the only credential.
+// GET /calendar/feed/:token(.ics)
+func (h *Handler) CalendarFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	if token == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	ctx := c.Request().Context()
+	feed, err := h.DB.GetCalendarFeedByToken(ctx, token)
+	if err != nil {
+		log.Printf("Error loading calendar feed: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if feed == nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	cal := ical.Calendar{
+		Name:     "ohabits",
+		TimeZone: "Asia/Kuwait",
+		Refresh:  time.Hour,
+	}
+
+	events, err := h.DB.GetCalendarEventsByUserID(ctx, feed.UserID)
+	if err != nil {
+		log.Printf("Error loading calendar events for feed: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	now := GetKuwaitTime()
+	for _, e := range events {
+		cal.Events = append(cal.Events, ical.FromCalendarEvent(e, now)...)
+	}
+
+	if feed.IncludeMedications {
+		meds, err := h.DB.GetActiveMedications(ctx, feed.UserID)
+		if err != nil {
+			log.Printf("Error loading medications for feed: %v", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		for _, m := range meds {
+			if ev, ok := ical.FromMedication(m); ok {
+				cal.Events = append(cal.Events, ev)
+			}
+		}
+	}
+
+	if feed.IncludeTasks {
+		projects, err := h.DB.GetProjectsByUserID(ctx, feed.UserID)
+		if err != nil {
+			log.Printf("Error loading projects for feed: %v", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		projectNames := make(map[uuid.UUID]string, len(projects))
+		for _, p := range projects {
+			projectNames[p.ID] = p.Name
+		}
+
+		tasks, err := h.DB.GetAllTasksByUserID(ctx, feed.UserID)
+		if err != nil {
+			log.Printf("Error loading tasks for feed: %v", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		for _, t := range tasks {
+			if ev, ok := ical.FromTask(t, projectNames); ok {
+				cal.Events = append(cal.Events, ev)
+			}
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Response().Header().Set("Content-Disposition", `inline; filename="ohabits.ics"`)
+	c.Response().Header().Set("Cache-Control", "private, max-age=300")
+	c.Response().WriteHeader(http.StatusOK)
+	return cal.Encode(c.Response())
+}
+
+// SaveCalendarFeedOptions updates what the feed includes from the profile page
+func (h *Handler) SaveCalendarFeedOptions(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	feed, err := h.DB.SaveCalendarFeedOptions(c.Request().Context(), userID,
+		c.FormValue("include_medications") == "on", c.FormValue("include_tasks") == "on")
+	if err != nil {
+		log.Printf("Error saving calendar feed: %v", err)
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_feed_saved","type":"success"}}`)
+	return Render(c, http.StatusOK, pages.CalendarFeedSection(feed, calendarFeedURL(c, feed)))
+}
+
+// RotateCalendarFeed issues a new feed token from the profile page; the old URL stops working
+func (h *Handler) RotateCalendarFeed(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	feed, err := h.DB.RotateCalendarFeedToken(c.Request().Context(), userID)
+	if err != nil {
+		log.Printf("Error rotating calendar feed: %v", err)
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_feed_rotated","type":"success"}}`)
+	return Render(c, http.StatusOK, pages.CalendarFeedSection(feed, calendarFeedURL(c, feed)))
+}
+
+// DeleteCalendarFeed disables the feed from the profile page
+func (h *Handler) DeleteCalendarFeed(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	if err := h.DB.DeleteCalendarFeed(c.Request().Context(), userID); err != nil {
+		log.Printf("Error deleting calendar feed: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_feed_disabled","type":"success"}}`)
+	return Render(c, http.StatusOK, pages.CalendarFeedSection(nil, ""))
+}
+
+// calendarFeedURL builds the absolute subscription URL for a feed
+func calendarFeedURL(c echo.Context, feed *database.CalendarFeed) string {
+	if feed == nil {
+		return ""
+	}
+	return c.Scheme() + "://" + c.Request().Host + "/calendar/feed/" + feed.Token + ".ics"
+}
+
+// ========== API HANDLERS ==========
+
+// calendarFeedResponse is the JSON shape of a feed; the token is only exposed through the URL
+func calendarFeedResponse(c echo.Context, feed *database.CalendarFeed) map[string]interface{} {
+	if feed == nil {
+		return map[string]interface{}{"status": "success", "enabled": false}
+	}
+	return map[string]interface{}{
+		"status":              "success",
+		"enabled":             true,
+		"url":                 calendarFeedURL(c, feed),
+		"include_medications": feed.IncludeMedications,
+		"include_tasks":       feed.IncludeTasks,
+		"rotated_at":          feed.RotatedAt,
+	}
+}
+
+// GetCalendarFeedAPI returns the user's subscription URL and options
+// GET /api/calendar/feed
+func (h *Handler) GetCalendarFeedAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	feed, err := h.DB.GetCalendarFeed(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load calendar feed"})
+	}
+	return c.JSON(http.StatusOK, calendarFeedResponse(c, feed))
+}
+
+// UpdateCalendarFeedAPI enables the feed and sets what it includes
+// PUT /api/calendar/feed
+func (h *Handler) UpdateCalendarFeedAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		IncludeMedications bool `json:"include_medications"`
+		IncludeTasks       bool `json:"include_tasks"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+
+	feed, err := h.DB.SaveCalendarFeedOptions(c.Request().Context(), userID, req.IncludeMedications, req.IncludeTasks)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save calendar feed"})
+	}
+	return c.JSON(http.StatusOK, calendarFeedResponse(c, feed))
+}
+
+// RotateCalendarFeedAPI issues a new feed URL, invalidating the old one
+// POST /api/calendar/feed/rotate
+func (h *Handler) RotateCalendarFeedAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	feed, err := h.DB.RotateCalendarFeedToken(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to rotate calendar feed"})
+	}
+	return c.JSON(http.StatusOK, calendarFeedResponse(c, feed))
+}
+
+// DeleteCalendarFeedAPI disables the feed
+// DELETE /api/calendar/feed
+func (h *Handler) DeleteCalendarFeedAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	if err := h.DB.DeleteCalendarFeed(c.Request().Context(), userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to disable calendar feed"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/ical"
+	"ohabits/internal/services/rrule"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const calendarImportMaxSize = 2 << 20 // 2MB
+
+var errCalendarImportFile = errors.New("invalid calendar file")
+
+// calendarEventTypes are the accepted CalendarEvent.EventType values
+var calendarEventTypes = map[string]bool{
+	"birthday":    true,
+	"travel":      true,
+	"holiday":     true,
+	"anniversary": true,
+	"general":     true,
+}
+
+// PreviewCalendarImport parses an uploaded .ics or .vcf file and shows what would be imported
+// POST /calendar/import
+func (h *Handler) PreviewCalendarImport(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	candidates, err := h.calendarImportCandidates(c, userID)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_import_invalid","type":"error"}}`)
+		return Render(c, http.StatusOK, pages.CalendarImportPreview(nil))
+	}
+
+	return Render(c, http.StatusOK, pages.CalendarImportPreview(candidates))
+}
+
+// ConfirmCalendarImport saves the entries selected in the preview. The form
+// re-sends the uploaded file, which is parsed again rather than trusting rows
+// echoed back by the browser.
+// POST /calendar/import/confirm
+func (h *Handler) ConfirmCalendarImport(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "طلب غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	candidates, err := h.calendarImportCandidates(c, userID)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_import_invalid","type":"error"}}`)
+		all, _ := h.DB.GetCalendarEventsByUserID(ctx, userID)
+		return Render(c, http.StatusOK, pages.CalendarEventsList(all))
+	}
+
+	// "selected" holds the checked row indexes; each row posts its event type
+	types := form["event_type"]
+	var events []database.CalendarEvent
+	for _, s := range form["selected"] {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 0 || i >= len(candidates) {
+			continue
+		}
+		e := candidates[i].Event
+		if i < len(types) {
+			e.EventType = types[i]
+		}
+		events = append(events, e)
+	}
+
+	if _, _, err := h.importCalendarEvents(ctx, userID, events); err != nil {
+		log.Printf("Error importing calendar events: %v", err)
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+	} else {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_imported","type":"success"}}`)
+	}
+
+	all, _ := h.DB.GetCalendarEventsByUserID(ctx, userID)
+	return Render(c, http.StatusOK, pages.CalendarEventsList(all))
+}
+
+// calendarImportCandidates parses the uploaded "file" and flags entries that already exist
+func (h *Handler) calendarImportCandidates(c echo.Context, userID uuid.UUID) ([]ical.Candidate, error) {
+	file, err := c.FormFile("file")
+	if err != nil || file.Size > calendarImportMaxSize {
+		return nil, errCalendarImportFile
+	}
+	data, err := readUpload(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []ical.Candidate
+	switch {
+	case bytes.Contains(data, []byte("BEGIN:VCALENDAR")):
+		events, err := ical.Parse(bytes.NewReader(data), KuwaitTZ)
+		if err != nil {
+			return nil, errCalendarImportFile
+		}
+		candidates = ical.CandidatesFromEvents(events, KuwaitTZ)
+	case bytes.Contains(data, []byte("BEGIN:VCARD")):
+		birthdays, err := ical.ParseVCards(bytes.NewReader(data))
+		if err != nil {
+			return nil, errCalendarImportFile
+		}
+		candidates = ical.CandidatesFromBirthdays(birthdays, GetKuwaitTime().Year())
+	default:
+		return nil, errCalendarImportFile
+	}
+
+	existing, err := h.DB.GetCalendarEventsByUserID(c.Request().Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	ical.MarkDuplicates(candidates, existing)
+	return candidates, nil
+}
+
+// importCalendarEvents creates the valid events that do not duplicate existing
+// ones, all in one transaction
+func (h *Handler) importCalendarEvents(ctx context.Context, userID uuid.UUID, events []database.CalendarEvent) (imported, skipped int, err error) {
+	existing, err := h.DB.GetCalendarEventsByUserID(ctx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var valid []database.CalendarEvent
+	for _, e := range events {
+		if !cleanImportEvent(&e) {
+			skipped++
+			continue
+		}
+
+		duplicate := false
+		for _, x := range existing {
+			if ical.SameEvent(e, x) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			skipped++
+			continue
+		}
+
+		existing = append(existing, e)
+		valid = append(valid, e)
+	}
+
+	if len(valid) == 0 {
+		return 0, skipped, nil
+	}
+	if _, err := h.DB.ImportCalendarEvents(ctx, userID, valid); err != nil {
+		return 0, skipped, err
+	}
+	return len(valid), skipped, nil
+}
+
+// Imported dates outside these years are rejected
+const (
+	calendarImportMinYear = 1900
+	calendarImportMaxYear = 2200
+)
+
+// cleanImportEvent re-validates an event to import, normalising what it can
+// and reporting false when the event can't be saved. API clients post events
+// back from the preview, so every field is checked again rather than trusted.
+func cleanImportEvent(e *database.CalendarEvent) bool {
+	e.Title = strings.TrimSpace(e.Title)
+	e.Notes = strings.TrimSpace(e.Notes)
+	if e.Title == "" || !calendarEventTypes[e.EventType] || !validImportDate(e.EventDate) {
+		return false
+	}
+	if e.EndDate != nil && (!validImportDate(*e.EndDate) || !e.EndDate.After(e.EventDate)) {
+		e.EndDate = nil
+	}
+
+	if e.CalendarType != database.CalendarHijri || !e.IsRecurring {
+		e.CalendarType = database.CalendarGregorian
+	}
+	if e.RRule != "" {
+		if _, err := rrule.Parse(e.RRule); err != nil {
+			return false
+		}
+	}
+
+	if _, err := time.Parse("15:04", e.StartTime); err != nil {
+		e.StartTime, e.EndTime, e.TimeZone = "", "", ""
+	}
+	if _, err := time.Parse("15:04", e.EndTime); err != nil {
+		e.EndTime = ""
+	}
+	if _, err := database.LoadEventLocation(e.TimeZone); err != nil {
+		e.TimeZone = ""
+	}
+
+	reminders := e.Reminders[:0:0]
+	for _, m := range e.Reminders {
+		if m >= 0 && m <= database.MaxReminderMinutes {
+			reminders = append(reminders, m)
+		}
+	}
+	e.Reminders = reminders
+
+	// Exceptions only apply to rule series
+	var exceptions []database.CalendarEventException
+	for _, x := range e.Exceptions {
+		if e.RRule == "" || !validImportDate(x.OccurrenceDate) {
+			continue
+		}
+		if x.EventDate != nil && !validImportDate(*x.EventDate) {
+			x.EventDate = nil
+		}
+		if x.Title != nil {
+			if title := strings.TrimSpace(*x.Title); title != "" {
+				x.Title = &title
+			} else {
+				x.Title = nil
+			}
+		}
+		if x.Notes != nil {
+			notes := strings.TrimSpace(*x.Notes)
+			x.Notes = &notes
+		}
+		exceptions = append(exceptions, database.CalendarEventException{
+			OccurrenceDate: x.OccurrenceDate,
+			IsCancelled:    x.IsCancelled,
+			Title:          x.Title,
+			EventDate:      x.EventDate,
+			Notes:          x.Notes,
+		})
+	}
+	e.Exceptions = exceptions
+	return true
+}
+
+func validImportDate(t time.Time) bool {
+	return t.Year() >= calendarImportMinYear && t.Year() <= calendarImportMaxYear
+}
+
+// ========== API HANDLERS ==========
+
+// PreviewCalendarImportAPI parses an uploaded .ics or .vcf file (multipart field "file")
+// POST /api/calendar/import/preview
+func (h *Handler) PreviewCalendarImportAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	candidates, err := h.calendarImportCandidates(c, userID)
+	if errors.Is(err, errCalendarImportFile) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Upload an .ics or .vcf file up to 2MB"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to read calendar file"})
+	}
+	if candidates == nil {
+		candidates = []ical.Candidate{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "events": candidates})
+}
+
+// ImportCalendarAPI saves the selected events from a preview
+// POST /api/calendar/import
+func (h *Handler) ImportCalendarAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		Events []database.CalendarEvent `json:"events"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+
+	imported, skipped, err := h.importCalendarEvents(c.Request().Context(), userID, req.Events)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"status":   "error",
+			"error":    "Failed to import events",
+			"imported": imported,
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+func TestCleanImportEvent(t *testing.T) {
+	date := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := date.AddDate(0, 0, -1)
+	blank := "  "
+	valid := func() database.CalendarEvent {
+		return database.CalendarEvent{Title: "درس", EventType: "general", EventDate: date}
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(e *database.CalendarEvent)
+		ok    bool
+		check func(e database.CalendarEvent) bool
+	}{
+		{"valid", func(e *database.CalendarEvent) {}, true, nil},
+		{"blank title", func(e *database.CalendarEvent) { e.Title = "  " }, false, nil},
+		{"unknown type", func(e *database.CalendarEvent) { e.EventType = "meeting" }, false, nil},
+		{"missing date", func(e *database.CalendarEvent) { e.EventDate = time.Time{} }, false, nil},
+		{"date out of range", func(e *database.CalendarEvent) { e.EventDate = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC) }, false, nil},
+		{"invalid rule", func(e *database.CalendarEvent) { e.RRule = "FREQ=HOURLY" }, false, nil},
+		{
+			"end before start is dropped",
+			func(e *database.CalendarEvent) { e.EndDate = &before },
+			true, func(e database.CalendarEvent) bool { return e.EndDate == nil },
+		},
+		{
+			"Hijri calendar only for yearly events",
+			func(e *database.CalendarEvent) { e.CalendarType = database.CalendarHijri },
+			true, func(e database.CalendarEvent) bool { return e.CalendarType == database.CalendarGregorian },
+		},
+		{
+			"bad times and zone are dropped",
+			func(e *database.CalendarEvent) { e.StartTime, e.EndTime, e.TimeZone = "25:00", "10:00", "Mars/Base" },
+			true, func(e database.CalendarEvent) bool { return e.StartTime == "" && e.EndTime == "" && e.TimeZone == "" },
+		},
+		{
+			"out-of-range reminders are dropped",
+			func(e *database.CalendarEvent) { e.Reminders = []int{-5, 15, database.MaxReminderMinutes + 1} },
+			true, func(e database.CalendarEvent) bool { return len(e.Reminders) == 1 && e.Reminders[0] == 15 },
+		},
+		{
+			"exceptions without a rule are dropped",
+			func(e *database.CalendarEvent) {
+				e.Exceptions = []database.CalendarEventException{{OccurrenceDate: date, IsCancelled: true}}
+			},
+			true, func(e database.CalendarEvent) bool { return e.Exceptions == nil },
+		},
+		{
+			"exceptions are rebuilt from their checked fields",
+			func(e *database.CalendarEvent) {
+				e.RRule = "FREQ=WEEKLY"
+				e.Exceptions = []database.CalendarEventException{
+					{OccurrenceDate: date.AddDate(0, 0, 7), Title: &blank},
+					{OccurrenceDate: time.Time{}, IsCancelled: true},
+				}
+			},
+			true, func(e database.CalendarEvent) bool {
+				return len(e.Exceptions) == 1 && e.Exceptions[0].Title == nil && e.Exceptions[0].OccurrenceDate.Equal(date.AddDate(0, 0, 7))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := valid()
+			tt.edit(&e)
+			if ok := cleanImportEvent(&e); ok != tt.ok {
+				t.Fatalf("cleanImportEvent = %v, want %v", ok, tt.ok)
+			}
+			if tt.check != nil && !tt.check(e) {
+				t.Errorf("cleanImportEvent left %+v", e)
+			}
+		})
+	}
+}
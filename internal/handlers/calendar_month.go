@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// monthGridRange returns the Sunday-to-Saturday weeks that cover month
+func monthGridRange(month time.Time) (from, to time.Time) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	return getWeekStart(first), last.AddDate(0, 0, 6-int(last.Weekday()))
+}
+
+// calendarOccurrences returns the user's occurrences in [from, to], with the
+// built-in Islamic holidays when they are enabled
+func (h *Handler) calendarOccurrences(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]database.CalendarEventForDay, error) {
+	occurrences, err := h.DB.GetCalendarOccurrences(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if settings, err := h.DB.GetCalendarSettings(ctx, userID); err == nil && settings.ShowIslamicHolidays {
+		occurrences = append(islamicHolidayOccurrences(from, to), occurrences...)
+	}
+	return occurrences, nil
+}
+
+// calendarMonthData loads the month grid for month
+func (h *Handler) calendarMonthData(c echo.Context, userID uuid.UUID, month time.Time) (pages.CalendarMonthData, error) {
+	from, to := monthGridRange(month)
+	occurrences, err := h.calendarOccurrences(c.Request().Context(), userID, from, to)
+	if err != nil {
+		return pages.CalendarMonthData{}, err
+	}
+	return pages.CalendarMonthData{
+		Month:       time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC),
+		From:        from,
+		To:          to,
+		Occurrences: occurrences,
+		Today:       GetKuwaitDate(GetKuwaitTime()),
+	}, nil
+}
+
+// CalendarMonth renders the month grid of the calendar page
+// GET /calendar/month?month=2026-03
+func (h *Handler) CalendarMonth(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	month := GetKuwaitDate(GetKuwaitTime())
+	if s := c.QueryParam("month"); s != "" {
+		m, err := time.Parse("2006-01", s)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "الشهر غير صالح")
+		}
+		month = m
+	}
+
+	data, err := h.calendarMonthData(c, userID, month)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "حدث خطأ")
+	}
+	return Render(c, http.StatusOK, pages.CalendarMonth(data))
+}
+
+// GetCalendarRangeAPI expands all of the user's events into their occurrences
+// in a date range (default: the current month), with exceptions applied and
+// the Islamic holidays when enabled
+// GET /api/calendar/occurrences?from=2026-03-01&to=2026-03-31
+func (h *Handler) GetCalendarRangeAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	today := GetKuwaitDate(GetKuwaitTime())
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+	var err error
+	if s := c.QueryParam("from"); s != "" {
+		if from, err = time.Parse("2006-01-02", s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid from date (use YYYY-MM-DD)"})
+		}
+		to = from.AddDate(0, 1, -1)
+	}
+	if s := c.QueryParam("to"); s != "" {
+		if to, err = time.Parse("2006-01-02", s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid to date (use YYYY-MM-DD)"})
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxOccurrencesRange {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Date range must be forward and at most two years"})
+	}
+
+	occurrences, err := h.calendarOccurrences(c.Request().Context(), userID, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load occurrences"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      "success",
+		"from":        from.Format("2006-01-02"),
+		"to":          to.Format("2006-01-02"),
+		"occurrences": occurrences,
+	})
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/rrule"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// maxOccurrencesRange bounds the span of an occurrences query
+const maxOccurrencesRange = 2 * 366 * 24 * time.Hour
+
+var repeatFreqs = map[string]rrule.Freq{
+	"daily":   rrule.Daily,
+	"weekly":  rrule.Weekly,
+	"monthly": rrule.Monthly,
+}
+
+var repeatWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRecurrence reads the repeat fields of the event form: "repeat" (none,
+// yearly, daily, weekly, monthly or custom with a raw "rrule"), and for
+// rules "repeat_interval", "repeat_weekdays", "repeat_monthly" (day, weekday
+// or last_weekday) and "repeat_end" (never, until or count). Forms without
+// "repeat" fall back to the yearly "is_recurring" checkbox. set is false when
+// the form says nothing about the rule, so an update keeps it.
+func parseRecurrence(c echo.Context, eventDate time.Time) (isRecurring bool, rule string, set bool, err error) {
+	repeat := c.FormValue("repeat")
+	switch repeat {
+	case "":
+		return c.FormValue("is_recurring") == "on" || c.FormValue("is_recurring") == "true", "", false, nil
+	case "none":
+		return false, "", true, nil
+	case "yearly":
+		return true, "", true, nil
+	case "custom":
+		r, err := rrule.Parse(c.FormValue("rrule"))
+		if err != nil {
+			return false, "", false, err
+		}
+		return true, r.String(), true, nil
+	}
+
+	freq, ok := repeatFreqs[repeat]
+	if !ok {
+		return false, "", false, rrule.ErrInvalid
+	}
+	r := rrule.Rule{Freq: freq, Interval: 1, WeekStart: time.Monday}
+	if n, err := strconv.Atoi(c.FormValue("repeat_interval")); err == nil {
+		r.Interval = max(1, min(n, 99))
+	}
+
+	switch freq {
+	case rrule.Weekly:
+		form, _ := c.FormParams()
+		for _, code := range form["repeat_weekdays"] {
+			if day, ok := repeatWeekdays[code]; ok {
+				r.ByDay = append(r.ByDay, rrule.WeekdayNum{Day: day})
+			}
+		}
+	case rrule.Monthly:
+		switch c.FormValue("repeat_monthly") {
+		case "weekday":
+			r.ByDay = []rrule.WeekdayNum{{N: (eventDate.Day()-1)/7 + 1, Day: eventDate.Weekday()}}
+		case "last_weekday":
+			r.ByDay = []rrule.WeekdayNum{{N: -1, Day: eventDate.Weekday()}}
+		}
+	}
+
+	switch c.FormValue("repeat_end") {
+	case "until":
+		until, err := time.Parse("2006-01-02", c.FormValue("repeat_until"))
+		if err != nil || until.Before(eventDate) {
+			return false, "", false, rrule.ErrInvalid
+		}
+		r.Until = until
+	case "count":
+		n, err := strconv.Atoi(c.FormValue("repeat_count"))
+		if err != nil || n < 1 {
+			return false, "", false, rrule.ErrInvalid
+		}
+		r.Count = min(n, 999)
+	}
+	return true, r.String(), true, nil
+}
+
+// seriesOccurrence loads the user's rule series and checks that date is one
+// of its occurrences
+func (h *Handler) seriesOccurrence(c echo.Context, userID uuid.UUID) (*database.CalendarEvent, time.Time, error) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, time.Time{}, pgx.ErrNoRows
+	}
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		return nil, time.Time{}, rrule.ErrInvalid
+	}
+
+	event, err := h.DB.GetCalendarEventByID(c.Request().Context(), userID, eventID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !event.HasRule() {
+		return nil, time.Time{}, rrule.ErrInvalid
+	}
+	r, err := rrule.Parse(event.RRule)
+	if err != nil || !r.Occurs(event.EventDate, date) {
+		return nil, time.Time{}, rrule.ErrInvalid
+	}
+	return event, date, nil
+}
+
+// renderEventsList re-renders the calendar page's events with a toast
+func (h *Handler) renderEventsList(c echo.Context, userID uuid.UUID, toast string) error {
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"`+toast+`","type":"success"}}`)
+	events, _ := h.DB.GetCalendarEventsByUserID(c.Request().Context(), userID)
+	return Render(c, http.StatusOK, pages.CalendarEventsList(events))
+}
+
+// CancelCalendarOccurrence cancels one occurrence of a recurring event
+// POST /calendar/:id/occurrences/:date/cancel
+func (h *Handler) CancelCalendarOccurrence(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	event, date, err := h.seriesOccurrence(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "موعد غير صالح"})
+	}
+
+	x := database.CalendarEventException{EventID: event.ID, OccurrenceDate: date, IsCancelled: true}
+	if _, err := h.DB.SaveCalendarEventException(c.Request().Context(), userID, x); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return h.renderEventsList(c, userID, "occurrence_cancelled")
+}
+
+// UpdateCalendarOccurrence changes the date, title or notes of one occurrence
+// PUT /calendar/:id/occurrences/:date
+func (h *Handler) UpdateCalendarOccurrence(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	event, date, err := h.seriesOccurrence(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "موعد غير صالح"})
+	}
+
+	x := database.CalendarEventException{EventID: event.ID, OccurrenceDate: date}
+	if s := c.FormValue("event_date"); s != "" {
+		moved, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "التاريخ غير صالح"})
+		}
+		x.EventDate = &moved
+	}
+	if title := strings.TrimSpace(c.FormValue("title")); title != "" && title != event.Title {
+		x.Title = &title
+	}
+	if notes := strings.TrimSpace(c.FormValue("notes")); notes != event.Notes {
+		x.Notes = &notes
+	}
+
+	if _, err := h.DB.SaveCalendarEventException(c.Request().Context(), userID, x); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return h.renderEventsList(c, userID, "occurrence_saved")
+}
+
+// RestoreCalendarOccurrence drops the changes to one occurrence (or its cancellation)
+// DELETE /calendar/:id/occurrences/:date
+func (h *Handler) RestoreCalendarOccurrence(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "التاريخ غير صالح"})
+	}
+
+	if err := h.DB.DeleteCalendarEventException(c.Request().Context(), userID, eventID, date); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return h.renderEventsList(c, userID, "occurrence_restored")
+}
+
+// ========== API HANDLERS ==========
+
+// GetCalendarOccurrencesAPI expands an event into its occurrences in a date
+// range (default: the next 90 days), with exceptions applied
+// GET /api/calendar/:id/occurrences?from=2026-01-01&to=2026-03-31
+func (h *Handler) GetCalendarOccurrencesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid event ID"})
+	}
+
+	from := GetKuwaitDate(GetKuwaitTime())
+	to := from.AddDate(0, 0, 90)
+	if s := c.QueryParam("from"); s != "" {
+		if from, err = time.Parse("2006-01-02", s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid from date (use YYYY-MM-DD)"})
+		}
+		to = from.AddDate(0, 0, 90)
+	}
+	if s := c.QueryParam("to"); s != "" {
+		if to, err = time.Parse("2006-01-02", s); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid to date (use YYYY-MM-DD)"})
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxOccurrencesRange {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Date range must be forward and at most two years"})
+	}
+
+	event, err := h.DB.GetCalendarEventByID(c.Request().Context(), userID, eventID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Event not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load event"})
+	}
+
+	occurrences := database.EventOccurrences(*event, from, to)
+	if occurrences == nil {
+		occurrences = []database.CalendarEventForDay{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      "success",
+		"event":       event,
+		"occurrences": occurrences,
+	})
+}
+
+// UpdateCalendarOccurrenceAPI cancels or overrides one occurrence of a recurring event
+// PUT /api/calendar/:id/occurrences/:date
+func (h *Handler) UpdateCalendarOccurrenceAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		IsCancelled bool       `json:"is_cancelled"`
+		Title       *string    `json:"title"`
+		EventDate   *time.Time `json:"event_date"`
+		Notes       *string    `json:"notes"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+
+	event, date, err := h.seriesOccurrence(c, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Event not found"})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Date is not an occurrence of a recurring event"})
+	}
+
+	x := database.CalendarEventException{
+		EventID:        event.ID,
+		OccurrenceDate: date,
+		IsCancelled:    req.IsCancelled,
+		Title:          req.Title,
+		EventDate:      req.EventDate,
+		Notes:          req.Notes,
+	}
+	if x.Title != nil && strings.TrimSpace(*x.Title) == "" {
+		x.Title = nil
+	}
+
+	saved, err := h.DB.SaveCalendarEventException(c.Request().Context(), userID, x)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save occurrence"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "exception": saved})
+}
+
+// RestoreCalendarOccurrenceAPI drops the exception of one occurrence
+// DELETE /api/calendar/:id/occurrences/:date
+func (h *Handler) RestoreCalendarOccurrenceAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid event ID"})
+	}
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date format (use YYYY-MM-DD)"})
+	}
+
+	if err := h.DB.DeleteCalendarEventException(c.Request().Context(), userID, eventID, date); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Occurrence has no changes"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to restore occurrence"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/partials"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+var errInvalidEventTime = errors.New("invalid event time")
+
+// eventTimeForm is the time and reminder part of the event form
+type eventTimeForm struct {
+	StartTime, EndTime, TimeZone string
+	Reminders                    []int
+}
+
+// parseEventTime reads the "all_day" checkbox, "start_time"/"end_time"
+// ("HH:MM"), "time_zone" and the "reminders" checkboxes (minutes before the
+// start). ok is false when the form has no time fields, so an update keeps them.
+func parseEventTime(c echo.Context) (f eventTimeForm, ok bool, err error) {
+	form, err := c.FormParams()
+	if err != nil {
+		return f, false, err
+	}
+	if _, has := form["time_zone"]; !has {
+		return f, false, nil
+	}
+
+	for _, s := range form["reminders"] {
+		if m, err := strconv.Atoi(s); err == nil {
+			f.Reminders = append(f.Reminders, m)
+		}
+	}
+
+	f.TimeZone = c.FormValue("time_zone")
+	if _, err := database.LoadEventLocation(f.TimeZone); err != nil {
+		return f, true, errInvalidEventTime
+	}
+	if c.FormValue("all_day") == "on" || c.FormValue("start_time") == "" {
+		return f, true, nil
+	}
+
+	f.StartTime, f.EndTime = c.FormValue("start_time"), c.FormValue("end_time")
+	start, err := time.Parse("15:04", f.StartTime)
+	if err != nil {
+		return f, true, errInvalidEventTime
+	}
+	if f.EndTime != "" {
+		// A single-day event can't end before it starts
+		end, err := time.Parse("15:04", f.EndTime)
+		if err != nil || (end.Before(start) && c.FormValue("end_date") == "") {
+			return f, true, errInvalidEventTime
+		}
+	}
+	return f, true, nil
+}
+
+// saveEventTime stores the times and reminders read by parseEventTime
+func (h *Handler) saveEventTime(ctx context.Context, eventID uuid.UUID, f eventTimeForm) error {
+	if err := h.DB.SetCalendarEventTime(ctx, eventID, f.StartTime, f.EndTime, f.TimeZone); err != nil {
+		return err
+	}
+	return h.DB.SetCalendarEventReminders(ctx, eventID, f.Reminders)
+}
+
+// DismissEventNotification marks a due reminder shown on the dashboard as seen
+// POST /notifications/:id/dismiss
+func (h *Handler) DismissEventNotification(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	if err := h.DB.MarkEventNotificationDelivered(ctx, userID, id); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	pending, _ := h.DB.GetPendingEventNotifications(ctx, userID, GetKuwaitTime())
+	return Render(c, http.StatusOK, partials.EventReminders(pending))
+}
+
+// ========== API HANDLERS ==========
+
+// GetNotificationsAPI returns the user's due calendar reminders that have not
+// been delivered yet; clients show them and then mark them delivered
+// GET /api/notifications
+func (h *Handler) GetNotificationsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	notifications, err := h.DB.GetPendingEventNotifications(c.Request().Context(), userID, GetKuwaitTime())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load notifications"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "notifications": notifications})
+}
+
+// MarkNotificationDeliveredAPI records that a reminder was shown to the user
+// POST /api/notifications/:id/delivered
+func (h *Handler) MarkNotificationDeliveredAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid notification ID"})
+	}
+
+	if err := h.DB.MarkEventNotificationDelivered(c.Request().Context(), userID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Notification not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to update notification"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
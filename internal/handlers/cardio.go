@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/cardio"
+	"ohabits/templates/partials"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportCardio imports a GPX/TCX/FIT file into the day's workout log from the dashboard
+func (h *Handler) ImportCardio(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.FormValue("date"), KuwaitTZ)
+	if err != nil {
+		date = GetKuwaitDate(GetKuwaitTime())
+	}
+
+	entry, err := readCardioUpload(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"cardio_import_error","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "تعذر قراءة الملف"})
+	}
+
+	ctx := c.Request().Context()
+	log, err := h.DB.AppendCardio(ctx, userID, date, *entry)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	h.attachPersonalRecords(ctx, userID, log)
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"cardio_imported","type":"success"}}`)
+	workouts, _ := h.DB.GetWorkoutsForDay(ctx, userID, date)
+	return Render(c, http.StatusOK, partials.WorkoutSection(workouts, log, date))
+}
+
+// ImportCardioAPI imports a GPX/TCX/FIT file into a day's workout log
+// POST /api/workouts/cardio/import (multipart: file, date=YYYY-MM-DD)
+func (h *Handler) ImportCardioAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.FormValue("date"), KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+	}
+
+	entry, err := readCardioUpload(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	log, err := h.DB.AppendCardio(c.Request().Context(), userID, date, *entry)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save cardio"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      "success",
+		"cardio":      entry,
+		"workout_log": log,
+	})
+}
+
+// readCardioUpload parses the uploaded "file" field into a cardio entry
+func readCardioUpload(c echo.Context) (*database.Cardio, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("file is required")
+	}
+	if file.Size > cardio.MaxFileSize {
+		return nil, errors.New("file is too large")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, cardio.MaxFileSize))
+	if err != nil {
+		return nil, err
+	}
+
+	activity, format, err := cardio.Parse(file.Filename, data)
+	if err != nil {
+		return nil, err
+	}
+	entry := activity.ToCardio(format)
+	return &entry, nil
+}
@@ -7,6 +7,8 @@ import (
 
 	"ohabits/internal/database"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/hijri"
+	"ohabits/internal/services/journal"
 	"ohabits/templates/pages"
 
 	"github.com/labstack/echo/v4"
@@ -70,6 +72,9 @@ func (h *Handler) Dashboard(c echo.Context) error {
 	println("👤 UserID:", userID.String())
 	println("💊 عدد الأدوية:", len(data.Medications))
 
+	// Prayer-anchored times, ordering habits and medications by time of day
+	h.applyPrayerSchedule(ctx, userID, date, data.Habits, data.Medications)
+
 	// Todos for this day
 	data.Todos, _ = h.DB.GetTodosForDay(ctx, userID, date)
 
@@ -82,35 +87,43 @@ func (h *Handler) Dashboard(c echo.Context) error {
 	// Mood for this day
 	data.MoodRating, _ = h.DB.GetMoodForDay(ctx, userID, date)
 
-	// Workouts
-	dayName := getDayName(date.Weekday())
-	data.Workouts, _ = h.DB.GetWorkoutsForDay(ctx, userID, dayName)
+	// Workouts, with the active program's session for this day first
+	data.Workouts, _ = h.DB.GetWorkoutsForDay(ctx, userID, date)
+	data.ProgramDay, _ = h.DB.GetProgramDay(ctx, userID, date)
 
 	// Workout log for this day
 	data.WorkoutLog, _ = h.DB.GetWorkoutLogForDay(ctx, userID, date)
+	h.attachPersonalRecords(ctx, userID, data.WorkoutLog)
 
 	// Calendar events for this day
 	data.CalendarEvents, _ = h.DB.GetCalendarEventsForDay(ctx, userID, date)
 
+	// Calendar reminders that came due and haven't been dismissed
+	data.Reminders, _ = h.DB.GetPendingEventNotifications(ctx, userID, GetKuwaitTime())
+
 	// Calendar events for the week (for indicators on week navigation)
 	weekStart := getWeekStart(date)
-	data.WeekEvents, _ = h.DB.GetDatesWithEventsForWeek(ctx, userID, weekStart)
+	data.WeekEvents, _ = h.DB.GetDatesWithEvents(ctx, userID, weekStart, weekStart.AddDate(0, 0, 6))
+
+	// Built-in Islamic holidays, when enabled on the calendar page
+	if settings, err := h.DB.GetCalendarSettings(ctx, userID); err == nil && settings.ShowIslamicHolidays {
+		data.CalendarEvents = append(islamicHolidayEvents(date), data.CalendarEvents...)
+		for i := 0; i < 7 && data.WeekEvents != nil; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(hijri.HolidaysOn(day)) > 0 {
+				data.WeekEvents[day.Format("2006-01-02")] = true
+			}
+		}
+	}
 
-	return Render(c, http.StatusOK, pages.Dashboard(user, data))
-}
+	// Journaling templates and the guided prompt of the day
+	data.Journal, _ = h.DB.GetNoteTemplatesForDay(ctx, userID, date)
+	data.JournalPrompt = journal.PromptForDate(date).Text(promptLanguage(c))
 
-// getDayName returns Arabic day name
-func getDayName(weekday time.Weekday) string {
-	days := map[time.Weekday]string{
-		time.Sunday:    "الأحد",
-		time.Monday:    "الاثنين",
-		time.Tuesday:   "الثلاثاء",
-		time.Wednesday: "الأربعاء",
-		time.Thursday:  "الخميس",
-		time.Friday:    "الجمعة",
-		time.Saturday:  "السبت",
-	}
-	return days[weekday]
+	// Same day in earlier months and years
+	data.Memories, _ = h.loadMemories(ctx, userID, date)
+
+	return Render(c, http.StatusOK, pages.Dashboard(user, data))
 }
 
 // getWeekStart returns the start of the week (Sunday) for a given date
@@ -151,7 +164,7 @@ func (h *Handler) DailyNotesPage(c echo.Context) error {
 
 	ctx := c.Request().Context()
 
-	// Get all dates with content (notes, images, or todos)
+	// Get all dates with content (notes, images, todos, or journal entries)
 	dates, err := h.DB.GetDatesWithContentForMonth(ctx, userID, year, month)
 	if err != nil {
 		dates = []time.Time{}
@@ -176,6 +189,9 @@ func (h *Handler) DailyNotesPage(c echo.Context) error {
 		// Get mood for this day
 		entry.Mood, _ = h.DB.GetMoodForDay(ctx, userID, date)
 
+		// Get journal template values for this day
+		entry.Journal, _ = h.DB.GetNoteTemplatesForDay(ctx, userID, date)
+
 		entries = append(entries, entry)
 	}
 
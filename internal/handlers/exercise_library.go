@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// ExerciseLibraryPage renders the exercise catalogue
+func (h *Handler) ExerciseLibraryPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	user, err := h.DB.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	lib, err := h.DB.GetExerciseLibrary(c.Request().Context(), userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "حدث خطأ")
+	}
+
+	return Render(c, http.StatusOK, pages.ExerciseLibraryPage(user, lib))
+}
+
+// CreateLibraryExercise adds an exercise from the page form
+func (h *Handler) CreateLibraryExercise(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	form, _ := c.FormParams()
+	e := database.LibraryExercise{
+		Name:             c.FormValue("name"),
+		NameAr:           c.FormValue("name_ar"),
+		MuscleGroup:      c.FormValue("muscle_group"),
+		SecondaryMuscles: form["secondary_muscles"],
+		Equipment:        c.FormValue("equipment"),
+		Aliases:          strings.Split(strings.ReplaceAll(c.FormValue("aliases"), "،", ","), ","),
+	}
+	if err := validateLibraryExercise(&e); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "الاسم والعضلة الأساسية مطلوبان"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.DB.CreateLibraryExercise(ctx, userID, e); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"library_exercise_saved","type":"success"}}`)
+	return h.renderExerciseLibrary(c, userID)
+}
+
+// DeleteLibraryExercise removes an exercise from the page
+func (h *Handler) DeleteLibraryExercise(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	if err := h.DB.DeleteLibraryExercise(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"library_exercise_deleted","type":"success"}}`)
+	return h.renderExerciseLibrary(c, userID)
+}
+
+func (h *Handler) renderExerciseLibrary(c echo.Context, userID uuid.UUID) error {
+	lib, err := h.DB.GetExerciseLibrary(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return Render(c, http.StatusOK, pages.ExerciseLibraryList(lib))
+}
+
+// validateLibraryExercise trims the fields and checks the muscle groups and equipment
+func validateLibraryExercise(e *database.LibraryExercise) error {
+	e.Name = strings.TrimSpace(e.Name)
+	e.NameAr = strings.TrimSpace(e.NameAr)
+	if e.Name == "" {
+		return errors.New("name is required")
+	}
+	if !slices.Contains(database.MuscleGroups, e.MuscleGroup) {
+		return errors.New("muscle_group must be one of: " + strings.Join(database.MuscleGroups, ", "))
+	}
+
+	var secondary []string
+	for _, m := range e.SecondaryMuscles {
+		if m == e.MuscleGroup || slices.Contains(secondary, m) {
+			continue
+		}
+		if !slices.Contains(database.MuscleGroups, m) {
+			return errors.New("unknown secondary muscle group: " + m)
+		}
+		secondary = append(secondary, m)
+	}
+	e.SecondaryMuscles = secondary
+
+	if e.Equipment == "" {
+		e.Equipment = "other"
+	}
+	if !slices.Contains(database.Equipment, e.Equipment) {
+		return errors.New("equipment must be one of: " + strings.Join(database.Equipment, ", "))
+	}
+	return nil
+}
+
+// ========== API HANDLERS ==========
+
+// GetExerciseLibraryAPI returns the user's exercise catalogue
+// GET /api/exercises?muscle_group=chest
+func (h *Handler) GetExerciseLibraryAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	lib, err := h.DB.GetExerciseLibrary(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load exercises"})
+	}
+
+	exercises := lib.Exercises
+	if group := c.QueryParam("muscle_group"); group != "" {
+		exercises = []database.LibraryExercise{}
+		for _, e := range lib.Exercises {
+			if e.MuscleGroup == group || slices.Contains(e.SecondaryMuscles, group) {
+				exercises = append(exercises, e)
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":        "success",
+		"exercises":     exercises,
+		"muscle_groups": database.MuscleGroups,
+		"equipment":     database.Equipment,
+	})
+}
+
+// CreateLibraryExerciseAPI adds an exercise to the catalogue
+// POST /api/exercises
+func (h *Handler) CreateLibraryExerciseAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var e database.LibraryExercise
+	if err := c.Bind(&e); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	if err := validateLibraryExercise(&e); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	created, err := h.DB.CreateLibraryExercise(c.Request().Context(), userID, e)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to create exercise (names must be unique)"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"status": "success", "exercise": created})
+}
+
+// UpdateLibraryExerciseAPI updates an exercise in the catalogue
+// PUT /api/exercises/:id
+func (h *Handler) UpdateLibraryExerciseAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	var e database.LibraryExercise
+	if err := c.Bind(&e); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	e.ID = id
+	if err := validateLibraryExercise(&e); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	updated, err := h.DB.UpdateLibraryExercise(c.Request().Context(), userID, e)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Exercise not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to update exercise"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "exercise": updated})
+}
+
+// DeleteLibraryExerciseAPI removes an exercise from the catalogue
+// DELETE /api/exercises/:id
+func (h *Handler) DeleteLibraryExerciseAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	if err := h.DB.DeleteLibraryExercise(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to delete exercise"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// GetExerciseSubstitutionsAPI returns alternatives that train the same muscle group
+// GET /api/exercises/:id/substitutions
+func (h *Handler) GetExerciseSubstitutionsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	lib, err := h.DB.GetExerciseLibrary(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load exercises"})
+	}
+	exercise := lib.Get(id)
+	if exercise == nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Exercise not found"})
+	}
+
+	subs := lib.Substitutions(id)
+	if subs == nil {
+		subs = []database.LibraryExercise{}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":        "success",
+		"exercise":      exercise,
+		"substitutions": subs,
+	})
+}
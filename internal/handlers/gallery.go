@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	galleryPageSize    = 60
+	galleryMaxPageSize = 200
+	galleryMaxDownload = 200 // Photos per zip
+)
+
+// encodeGalleryCursor turns the last image of a page into an opaque cursor
+func encodeGalleryCursor(img database.DailyImage) string {
+	raw := fmt.Sprintf("%s|%d|%s", img.Date.Format("2006-01-02"), img.CreatedAt.UnixNano(), img.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeGalleryCursor parses a cursor from encodeGalleryCursor (empty = first page)
+func decodeGalleryCursor(s string) (*database.GalleryCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	date, err := time.Parse("2006-01-02", parts[0])
+	if err != nil {
+		return nil, err
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	return &database.GalleryCursor{Date: date, CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
+
+// galleryRange returns the first and last day of ?year=&month= (or the whole year
+// without a month). ok is false when no year is given.
+func galleryRange(c echo.Context) (from, to time.Time, ok bool) {
+	year, err := parseIntParam(c.QueryParam("year"))
+	if err != nil || year < 2000 || year > 2100 {
+		return time.Time{}, time.Time{}, false
+	}
+	if month, err := parseIntParam(c.QueryParam("month")); err == nil && month >= 1 && month <= 12 {
+		from = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 1, -1), true
+	}
+	from = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(1, 0, -1), true
+}
+
+// loadGalleryPage loads one page of photos plus the mood and note of their days
+func (h *Handler) loadGalleryPage(ctx context.Context, userID uuid.UUID, from, to time.Time, after *database.GalleryCursor, limit int) ([]database.DailyImage, map[string]database.GalleryDay, string, error) {
+	// Fetch one extra to know whether there is a next page
+	images, err := h.DB.GetGalleryImages(ctx, userID, from, to, after, limit+1)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	nextCursor := ""
+	if len(images) > limit {
+		images = images[:limit]
+		nextCursor = encodeGalleryCursor(images[len(images)-1])
+	}
+
+	dates := make([]time.Time, len(images))
+	for i, img := range images {
+		dates[i] = img.Date
+	}
+	days, err := h.DB.GetGalleryDays(ctx, userID, dates)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return images, days, nextCursor, nil
+}
+
+// ========== WEB HANDLERS ==========
+
+// GalleryPage renders the photo gallery for a month (defaults to the latest month with photos)
+// GET /gallery?year=&month=
+func (h *Handler) GalleryPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	months, _ := h.DB.GetImageMonths(ctx, userID)
+
+	from, to, ok := galleryRange(c)
+	if !ok || c.QueryParam("month") == "" {
+		// Default to the latest month with photos (or the current month)
+		now := GetKuwaitTime()
+		year, month := now.Year(), int(now.Month())
+		if len(months) > 0 {
+			year, month = months[0].Year, months[0].Month
+		}
+		from = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(0, 1, -1)
+	}
+
+	images, days, nextCursor, err := h.loadGalleryPage(ctx, userID, from, to, nil, galleryPageSize)
+	if err != nil {
+		log.Printf("Gallery error: %v", err)
+	}
+
+	return Render(c, http.StatusOK, pages.GalleryPage(user, months, from.Year(), int(from.Month()), images, days, nextCursor))
+}
+
+// GalleryMore renders the next page of a month's photos (infinite scroll)
+// GET /gallery/more?year=&month=&cursor=&prev=
+func (h *Handler) GalleryMore(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	from, to, ok := galleryRange(c)
+	if !ok {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	cursor, err := decodeGalleryCursor(c.QueryParam("cursor"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	images, days, nextCursor, err := h.loadGalleryPage(c.Request().Context(), userID, from, to, cursor, galleryPageSize)
+	if err != nil {
+		log.Printf("Gallery error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return Render(c, http.StatusOK, pages.GalleryItems(images, days, c.QueryParam("prev"), from.Year(), int(from.Month()), nextCursor))
+}
+
+// DownloadImagesZip streams the selected photos (full size) as a zip archive
+// POST /gallery/download (form ids=...) and POST /api/images/download {"ids": [...]}
+func (h *Handler) DownloadImagesZip(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var rawIDs []string
+	if isAPIRequest(c) {
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+		}
+		rawIDs = req.IDs
+	} else {
+		form, err := c.FormParams()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "طلب غير صالح"})
+		}
+		rawIDs = form["ids"]
+	}
+
+	ids := make([]uuid.UUID, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > galleryMaxDownload {
+		if isAPIRequest(c) {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": fmt.Sprintf("Select between 1 and %d images", galleryMaxDownload)})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("اختر من 1 إلى %d صورة", galleryMaxDownload)})
+	}
+
+	ctx := c.Request().Context()
+	images, err := h.DB.GetDailyImagesByIDs(ctx, userID, ids)
+	if err != nil || len(images) == 0 {
+		if isAPIRequest(c) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Images not found"})
+		}
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "الصور غير موجودة"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="ohabits-photos-%s.zip"`, GetKuwaitTime().Format("20060102")))
+	c.Response().WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(c.Response())
+	used := make(map[string]int)
+	for _, img := range images {
+		key, ok := uploadKey(img.OriginalPath)
+		if !ok {
+			continue
+		}
+		body, _, err := h.Storage.Get(ctx, key)
+		if err != nil {
+			log.Printf("Gallery zip: missing %s: %v", key, err)
+			continue
+		}
+
+		// Photos are already compressed, so store them as-is
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     zipEntryName(img, path.Ext(key), used),
+			Method:   zip.Store,
+			Modified: img.CreatedAt,
+		})
+		if err == nil {
+			_, err = io.Copy(w, body)
+		}
+		body.Close()
+		if err != nil {
+			// Headers are already sent; the client sees a truncated archive
+			log.Printf("Gallery zip error: %v", err)
+			return nil
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		log.Printf("Gallery zip error: %v", err)
+	}
+	return nil
+}
+
+// zipEntryName names a photo "<date>_<original name><stored ext>", numbering duplicates
+func zipEntryName(img database.DailyImage, ext string, used map[string]int) string {
+	base := strings.TrimSuffix(path.Base(strings.ReplaceAll(img.Filename, "\\", "/")), path.Ext(img.Filename))
+	if base == "" || base == "." || base == "/" {
+		base = img.ID.String()[:8]
+	}
+	name := img.Date.Format("2006-01-02") + "_" + base
+
+	used[name]++
+	if n := used[name]; n > 1 {
+		name = fmt.Sprintf("%s_%d", name, n)
+	}
+	return name + ext
+}
+
+// ========== API HANDLERS ==========
+
+// GetGalleryAPI returns a page of photos, newest first, with the mood and note of their days
+// GET /api/images?year=&month=&cursor=&limit=
+func (h *Handler) GetGalleryAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	from, to, _ := galleryRange(c)
+
+	cursor, err := decodeGalleryCursor(c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid cursor"})
+	}
+
+	limit := galleryPageSize
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, galleryMaxPageSize)
+	}
+
+	images, days, nextCursor, err := h.loadGalleryPage(c.Request().Context(), userID, from, to, cursor, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load images"})
+	}
+	if images == nil {
+		images = []database.DailyImage{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      "success",
+		"images":      images,
+		"days":        days,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetImageMonthsAPI returns the months that have photos with their counts
+// GET /api/images/months
+func (h *Handler) GetImageMonthsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	months, err := h.DB.GetImageMonths(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load months"})
+	}
+	if months == nil {
+		months = []database.ImageMonth{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "months": months})
+}
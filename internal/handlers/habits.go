@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"ohabits/templates/partials"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -26,8 +28,9 @@ func (h *Handler) HabitsPage(c echo.Context) error {
 	}
 
 	habits, _ := h.DB.GetHabitsByUserID(c.Request().Context(), userID)
+	times, settings := h.prayerTimes(c.Request().Context(), userID, GetKuwaitDate(GetKuwaitTime()))
 
-	return Render(c, http.StatusOK, pages.HabitsPage(user, habits))
+	return Render(c, http.StatusOK, pages.HabitsPage(user, habits, settings, times))
 }
 
 // ToggleHabit toggles habit completion
@@ -56,6 +59,7 @@ func (h *Handler) ToggleHabit(c echo.Context) error {
 
 	// Get updated habits list
 	habits, _ := h.DB.GetHabitsForDay(c.Request().Context(), userID, date)
+	h.applyPrayerSchedule(c.Request().Context(), userID, date, habits, nil)
 
 	// Find the toggled habit
 	for _, habit := range habits {
@@ -95,10 +99,15 @@ func (h *Handler) CreateHabit(c echo.Context) error {
 		icon = "checkmark.circle.fill"
 	}
 
-	_, err := h.DB.CreateHabit(c.Request().Context(), userID, name, icon, scheduledDays)
+	habit, err := h.DB.CreateHabit(c.Request().Context(), userID, name, icon, scheduledDays)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
+	if anchor := parsePrayerAnchor(c, ""); anchor != nil {
+		if err := h.DB.SetHabitPrayerAnchor(c.Request().Context(), habit.ID, userID, anchor); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+		}
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"habit_saved","type":"success"}}`)
 
@@ -112,6 +121,7 @@ func (h *Handler) CreateHabit(c echo.Context) error {
 	// Return updated habits list for dashboard
 	date := time.Now()
 	habits, _ := h.DB.GetHabitsForDay(c.Request().Context(), userID, date)
+	h.applyPrayerSchedule(c.Request().Context(), userID, date, habits, nil)
 
 	return Render(c, http.StatusOK, partials.HabitsList(habits, date))
 }
@@ -159,6 +169,7 @@ func (h *Handler) DeleteHabit(c echo.Context) error {
 	// Return updated habits list for dashboard
 	date := time.Now()
 	habits, _ := h.DB.GetHabitsForDay(c.Request().Context(), userID, date)
+	h.applyPrayerSchedule(c.Request().Context(), userID, date, habits, nil)
 
 	return Render(c, http.StatusOK, partials.HabitsList(habits, date))
 }
@@ -197,6 +208,12 @@ func (h *Handler) UpdateHabit(c echo.Context) error {
 	if err := h.DB.UpdateHabit(c.Request().Context(), habitID, name, icon, scheduledDays); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
 	}
+	if err := h.DB.SetHabitPrayerAnchor(c.Request().Context(), habitID, userID, parsePrayerAnchor(c, "")); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "العادة غير موجودة"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"habit_saved","type":"success"}}`)
 
@@ -5,6 +5,7 @@ import (
 	"ohabits/internal/database"
 	"ohabits/internal/middleware"
 	"ohabits/internal/services"
+	"ohabits/internal/storage"
 )
 
 // Handler holds all dependencies for HTTP handlers
@@ -13,14 +14,16 @@ type Handler struct {
 	Config    *config.Config
 	Auth      *middleware.AuthMiddleware
 	AIService *services.AIService
+	Storage   storage.BlobStore
 }
 
 // New creates a new Handler instance
-func New(db *database.DB, cfg *config.Config, auth *middleware.AuthMiddleware) *Handler {
+func New(db *database.DB, cfg *config.Config, auth *middleware.AuthMiddleware, store storage.BlobStore) *Handler {
 	return &Handler{
 		DB:        db,
 		Config:    cfg,
 		Auth:      auth,
 		AIService: services.NewAIService(cfg),
+		Storage:   store,
 	}
 }
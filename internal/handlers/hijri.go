@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/hijri"
+	"ohabits/templates/pages"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HijriDateHint returns the Hijri date of the event_date being entered
+// GET /calendar/hijri?event_date=2026-03-20
+func (h *Handler) HijriDateHint(c echo.Context) error {
+	date, err := time.Parse("2006-01-02", c.QueryParam("event_date"))
+	if err != nil {
+		return c.String(http.StatusOK, "")
+	}
+	return c.String(http.StatusOK, "الموافق "+hijri.FromTime(date).String())
+}
+
+// SaveCalendarSettings toggles the Islamic holidays overlay from the calendar page
+// POST /calendar/settings
+func (h *Handler) SaveCalendarSettings(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	settings := database.CalendarSettings{ShowIslamicHolidays: c.FormValue("show_islamic_holidays") == "on"}
+	if err := h.DB.SaveCalendarSettings(c.Request().Context(), userID, settings); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"calendar_settings_saved","type":"success"}}`)
+	return Render(c, http.StatusOK, pages.IslamicHolidaysSection(&settings, GetKuwaitDate(GetKuwaitTime())))
+}
+
+// islamicHolidayEvents returns the built-in Islamic holidays on date as calendar events
+func islamicHolidayEvents(date time.Time) []database.CalendarEventForDay {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	events := islamicHolidayOccurrences(day, day)
+	for i := range events {
+		events[i].IsToday = events[i].EventDate.Equal(day)
+	}
+	return events
+}
+
+// islamicHolidayOccurrences returns the built-in Islamic holidays that overlap
+// [from, to] as calendar events
+func islamicHolidayOccurrences(from, to time.Time) []database.CalendarEventForDay {
+	var events []database.CalendarEventForDay
+	for _, o := range hijri.Between(from, to) {
+		e := database.CalendarEventForDay{
+			CalendarEvent: database.CalendarEvent{
+				Title:        o.Name,
+				EventType:    "holiday",
+				EventDate:    o.Start,
+				IsRecurring:  true,
+				CalendarType: database.CalendarHijri,
+			},
+			IsBuiltin: true,
+		}
+		if o.Days > 1 {
+			end := o.End
+			e.EndDate = &end
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// ========== API HANDLERS ==========
+
+// GetHijriDateAPI converts between Gregorian and Hijri dates. With ?date=YYYY-MM-DD
+// (default today) it returns the Hijri date; with ?year=&month=&day= the Gregorian one.
+// GET /api/hijri
+func (h *Handler) GetHijriDateAPI(c echo.Context) error {
+	if c.QueryParam("year") != "" {
+		year, errY := strconv.Atoi(c.QueryParam("year"))
+		month, errM := strconv.Atoi(c.QueryParam("month"))
+		day, errD := strconv.Atoi(c.QueryParam("day"))
+		d := hijri.Date{Year: year, Month: month, Day: day}
+		if errY != nil || errM != nil || errD != nil || year < 1300 || year > 1600 || !d.Valid() {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid Hijri date"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status":    "success",
+			"hijri":     d,
+			"formatted": d.String(),
+			"date":      d.Time().Format("2006-01-02"),
+		})
+	}
+
+	date := GetKuwaitDate(GetKuwaitTime())
+	if s := c.QueryParam("date"); s != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", s, KuwaitTZ)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date format (use YYYY-MM-DD)"})
+		}
+		date = parsed
+	}
+
+	d := hijri.FromTime(date)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":       "success",
+		"date":         date.Format("2006-01-02"),
+		"hijri":        d,
+		"formatted":    d.String(),
+		"month_length": hijri.MonthLength(d.Year, d.Month),
+		"holidays":     hijri.HolidaysOn(date),
+	})
+}
+
+// GetIslamicHolidaysAPI lists the built-in Islamic holidays in the coming days
+// GET /api/calendar/islamic-holidays?days=365
+func (h *Handler) GetIslamicHolidaysAPI(c echo.Context) error {
+	days, err := strconv.Atoi(c.QueryParam("days"))
+	if err != nil || days <= 0 {
+		days = 365
+	}
+	days = min(days, 3*365)
+
+	holidays := hijri.Upcoming(GetKuwaitDate(GetKuwaitTime()), days)
+	if holidays == nil {
+		holidays = []hijri.Occurrence{}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "holidays": holidays})
+}
+
+// GetCalendarSettingsAPI returns the calendar display preferences
+// GET /api/calendar/settings
+func (h *Handler) GetCalendarSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	settings, err := h.DB.GetCalendarSettings(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load settings"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "settings": settings})
+}
+
+// UpdateCalendarSettingsAPI stores the calendar display preferences
+// PUT /api/calendar/settings
+func (h *Handler) UpdateCalendarSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var settings database.CalendarSettings
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	if err := h.DB.SaveCalendarSettings(c.Request().Context(), userID, settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save settings"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "settings": settings})
+}
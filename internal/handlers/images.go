@@ -1,12 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -14,15 +14,11 @@ import (
 	"ohabits/internal/middleware"
 	"ohabits/templates/partials"
 
-	"github.com/disintegration/imaging"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 const (
-	uploadsDir    = "uploads"
-	thumbWidth    = 150
-	thumbHeight   = 150
 	maxUploadSize = 10 << 20 // 10MB
 )
 
@@ -42,7 +38,7 @@ func (h *Handler) UploadImages(c echo.Context) error {
 	dateStr := c.FormValue("date")
 	var date time.Time
 	var err error
-	
+
 	if dateStr != "" {
 		// Try ISO8601 format first (2026-01-29T12:00:00Z)
 		date, err = time.Parse(time.RFC3339, dateStr)
@@ -56,7 +52,7 @@ func (h *Handler) UploadImages(c echo.Context) error {
 	} else {
 		date = time.Now()
 	}
-	
+
 	// Normalize to just the date (no time)
 	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 
@@ -79,21 +75,10 @@ func (h *Handler) UploadImages(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "لم يتم اختيار صور"})
 	}
 
-	// Create upload directories
-	userDir := filepath.Join(uploadsDir, userID.String())
-	originalsDir := filepath.Join(userDir, "originals")
-	thumbsDir := filepath.Join(userDir, "thumbnails")
-
-	if err := os.MkdirAll(originalsDir, 0755); err != nil {
-		log.Printf("MkdirAll error: %v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"status": "error", "error": "خطأ في إنشاء المجلدات"})
-	}
-	if err := os.MkdirAll(thumbsDir, 0755); err != nil {
-		log.Printf("MkdirAll error: %v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"status": "error", "error": "خطأ في إنشاء المجلدات"})
-	}
+	ctx := c.Request().Context()
 
 	var savedImages []database.DailyImage
+	var overQuota *database.StorageUsage // usage when a file didn't fit the quota
 
 	for _, file := range files {
 		// Check file size
@@ -103,68 +88,26 @@ func (h *Handler) UploadImages(c echo.Context) error {
 
 		// Check mime type
 		mimeType := file.Header.Get("Content-Type")
-		if !strings.HasPrefix(mimeType, "image/") {
+		if !isImageUpload(mimeType, file.Filename) {
 			continue // Skip non-image files
 		}
 
-		// Generate unique filename
-		ext := filepath.Ext(file.Filename)
-		newFilename := fmt.Sprintf("%s_%s%s", date.Format("2006-01-02"), uuid.New().String()[:8], ext)
-
-		originalPath := filepath.Join(originalsDir, newFilename)
-		thumbnailPath := filepath.Join(thumbsDir, newFilename)
-
-		// Open source file
-		src, err := file.Open()
-		if err != nil {
-			log.Printf("File open error: %v", err)
+		img, usage, err := h.saveDailyImage(ctx, userID, date, file, mimeType)
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			overQuota = usage
 			continue
 		}
-
-		// Save original
-		dst, err := os.Create(originalPath)
 		if err != nil {
-			src.Close()
-			log.Printf("Create file error: %v", err)
+			log.Printf("Image save error (%s): %v", file.Filename, err)
 			continue
 		}
-
-		_, err = io.Copy(dst, src)
-		src.Close()
-		dst.Close()
-		if err != nil {
-			log.Printf("Copy error: %v", err)
-			os.Remove(originalPath)
-			continue
-		}
-
-		// Create thumbnail
-		if err := createThumbnail(originalPath, thumbnailPath); err != nil {
-			log.Printf("Thumbnail error: %v", err)
-			// Continue without thumbnail if it fails
-			thumbnailPath = originalPath
-		}
-
-		// Save to database
-		img, err := h.DB.SaveDailyImage(
-			c.Request().Context(),
-			userID,
-			date,
-			"/"+originalPath,
-			"/"+thumbnailPath,
-			file.Filename,
-			mimeType,
-			int(file.Size),
-		)
-		if err != nil {
-			log.Printf("DB save error: %v", err)
-			continue
-		}
-
 		savedImages = append(savedImages, *img)
 	}
 
 	if len(savedImages) == 0 {
+		if overQuota != nil {
+			return quotaExceeded(c, overQuota)
+		}
 		if isAPIRequest(c) {
 			return c.JSON(http.StatusBadRequest, map[string]string{"status": "error", "error": "لم يتم حفظ أي صورة"})
 		}
@@ -178,12 +121,13 @@ func (h *Handler) UploadImages(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"status": "success",
 			"image": map[string]interface{}{
-				"id":            img.ID,
+				"id":             img.ID,
 				"original_path":  img.OriginalPath,
 				"thumbnail_path": img.ThumbnailPath,
-				"filename":      img.Filename,
+				"filename":       img.Filename,
 				"mime_type":      img.MimeType,
 				"size_bytes":     img.SizeBytes,
+				"variants":       img.Variants,
 			},
 		})
 	}
@@ -194,6 +138,53 @@ func (h *Handler) UploadImages(c echo.Context) error {
 	return Render(c, http.StatusOK, partials.ImageGallery(images, date))
 }
 
+// saveDailyImage resizes, orients and strips EXIF from an upload, stores all
+// its variants and records it for the day. database.ErrQuotaExceeded comes
+// with the usage when the upload or its variants don't fit in the quota.
+func (h *Handler) saveDailyImage(ctx context.Context, userID uuid.UUID, date time.Time, file *multipart.FileHeader, mimeType string) (*database.DailyImage, *database.StorageUsage, error) {
+	// Reserve the upload in the storage quota while it's processed, then
+	// grow the reservation to what the variants take
+	usage, err := h.reserveStorage(ctx, userID, file.Size)
+	if err != nil {
+		return nil, usage, err
+	}
+	reserved := file.Size
+	defer func() { h.releaseStorage(userID, reserved) }()
+
+	data, err := readUpload(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := fmt.Sprintf("%s_%s", date.Format("2006-01-02"), uuid.New().String()[:8])
+	stored, err := h.storeImage(ctx, userID.String(), base, data, mimeType, file.Filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	reserved, usage, err = h.growReservation(ctx, userID, reserved, stored)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	img, err := h.DB.SaveDailyImage(
+		ctx,
+		userID,
+		date,
+		stored.OriginalPath,
+		stored.ThumbnailPath,
+		file.Filename,
+		stored.MimeType,
+		stored.SizeBytes,
+		stored.StoredBytes,
+		stored.Variants,
+	)
+	if err != nil {
+		h.deleteImageFiles(ctx, stored.Variants)
+		return nil, nil, err
+	}
+	return img, nil, nil
+}
+
 // DeleteImage deletes an image
 func (h *Handler) DeleteImage(c echo.Context) error {
 	userID, ok := middleware.GetUserID(c)
@@ -219,9 +210,8 @@ func (h *Handler) DeleteImage(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "خطأ في الحذف"})
 	}
 
-	// Delete files (remove leading slash)
-	os.Remove(strings.TrimPrefix(img.OriginalPath, "/"))
-	os.Remove(strings.TrimPrefix(img.ThumbnailPath, "/"))
+	// Delete files
+	h.deleteImageFiles(c.Request().Context(), img.Variants, img.OriginalPath, img.ThumbnailPath)
 
 	// For API requests, return JSON
 	if isAPIRequest(c) {
@@ -232,16 +222,3 @@ func (h *Handler) DeleteImage(c echo.Context) error {
 	images, _ := h.DB.GetImagesForDay(c.Request().Context(), userID, date)
 	return Render(c, http.StatusOK, partials.ImageGallery(images, date))
 }
-
-// createThumbnail creates a thumbnail from the original image
-func createThumbnail(srcPath, dstPath string) error {
-	src, err := imaging.Open(srcPath)
-	if err != nil {
-		return err
-	}
-
-	// Resize to fit within thumbnail dimensions while maintaining aspect ratio
-	thumb := imaging.Fill(src, thumbWidth, thumbHeight, imaging.Center, imaging.Lanczos)
-
-	return imaging.Save(thumb, dstPath)
-}
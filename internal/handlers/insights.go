@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/insights"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// insightWindows are the allowed analysis windows (in days)
+var insightWindows = []int{30, 90, 180, 365}
+
+// InsightsPage renders the correlations page
+func (h *Handler) InsightsPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	user, err := h.DB.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	window := parseInsightWindow(c.QueryParam("days"))
+	report, err := h.buildInsights(c, userID, window)
+	if err != nil {
+		report = &insights.Report{Correlations: []insights.Correlation{}}
+	}
+
+	return Render(c, http.StatusOK, pages.InsightsPage(user, report, window, insightWindows))
+}
+
+// GetInsightsAPI returns mood correlations for the requested window
+// GET /api/insights?days=90
+func (h *Handler) GetInsightsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	window := parseInsightWindow(c.QueryParam("days"))
+	report, err := h.buildInsights(c, userID, window)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to compute insights"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "insights": report})
+}
+
+// buildInsights loads daily metrics for the last `window` days and analyzes them
+func (h *Handler) buildInsights(c echo.Context, userID uuid.UUID, window int) (*insights.Report, error) {
+	ctx := c.Request().Context()
+
+	to := GetKuwaitDate(GetKuwaitTime())
+	from := to.AddDate(0, 0, -(window - 1))
+
+	days, err := h.DB.GetDailyMetrics(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	habits, err := h.DB.GetHabitsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return insights.Analyze(days, habits), nil
+}
+
+// parseInsightWindow returns a supported window size, defaulting to 90 days
+func parseInsightWindow(s string) int {
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 90
+	}
+	for _, w := range insightWindows {
+		if days == w {
+			return w
+		}
+	}
+	return 90
+}
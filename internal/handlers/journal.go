@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/journal"
+	"ohabits/templates/pages"
+	"ohabits/templates/partials"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== WEB HANDLERS ==========
+
+// JournalPage renders the journaling templates page with field search
+func (h *Handler) JournalPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	templates, _ := h.DB.GetNoteTemplates(ctx, userID)
+
+	// Field search: ?template_id=&field=&year=&month=
+	now := GetKuwaitTime()
+	year, month := now.Year(), int(now.Month())
+	if y, err := parseIntParam(c.QueryParam("year")); err == nil && y >= 2020 && y <= 2100 {
+		year = y
+	}
+	if m, err := parseIntParam(c.QueryParam("month")); err == nil && m >= 1 && m <= 12 {
+		month = m
+	}
+
+	var selected *database.NoteTemplate
+	var entries []database.NoteEntry
+	fieldKey := c.QueryParam("field")
+	if id, err := uuid.Parse(c.QueryParam("template_id")); err == nil {
+		selected, _ = h.DB.GetNoteTemplateByID(ctx, id, userID)
+	}
+	if selected != nil {
+		from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, KuwaitTZ)
+		to := from.AddDate(0, 1, -1)
+		entries, _ = h.DB.GetNoteEntries(ctx, userID, &selected.ID, fieldKey, from, to)
+	}
+
+	prompt := journal.PromptForDate(GetKuwaitDate(time.Now()))
+
+	return Render(c, http.StatusOK, pages.JournalPage(user, templates, journal.Presets, prompt, selected, fieldKey, year, month, entries))
+}
+
+// CreateNoteTemplate creates a template from the management form or a preset
+func (h *Handler) CreateNoteTemplate(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	ctx := c.Request().Context()
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	icon := strings.TrimSpace(c.FormValue("icon"))
+	var fields []database.NoteTemplateField
+
+	if preset, ok := journal.FindPreset(c.FormValue("preset")); ok {
+		name, icon, fields = preset.Name, preset.Icon, preset.Fields
+	} else {
+		form, _ := c.FormParams()
+		labels := form["field_label"]
+		types := form["field_type"]
+		counts := form["field_count"]
+		for i, label := range labels {
+			f := database.NoteTemplateField{Label: label, Type: journal.FieldText}
+			if i < len(types) {
+				f.Type = types[i]
+			}
+			if i < len(counts) {
+				f.Count, _ = strconv.Atoi(counts[i])
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	fields = journal.NormalizeFields(fields, nil)
+	if name == "" || len(fields) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "الاسم وحقل واحد على الأقل مطلوبان"})
+	}
+
+	if _, err := h.DB.CreateNoteTemplate(ctx, userID, name, icon, fields); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"template_saved","type":"success"}}`)
+
+	templates, _ := h.DB.GetNoteTemplates(ctx, userID)
+	return Render(c, http.StatusOK, pages.JournalTemplatesList(templates))
+}
+
+// DeleteNoteTemplate soft-deletes a template
+func (h *Handler) DeleteNoteTemplate(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.DB.GetNoteTemplateByID(ctx, templateID, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "القالب غير موجود"})
+	}
+
+	if err := h.DB.SoftDeleteNoteTemplate(ctx, templateID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"template_deleted","type":"success"}}`)
+
+	templates, _ := h.DB.GetNoteTemplates(ctx, userID)
+	return Render(c, http.StatusOK, pages.JournalTemplatesList(templates))
+}
+
+// SaveNoteEntries saves all field values of one template for a day (dashboard form)
+func (h *Handler) SaveNoteEntries(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	templateID, err := uuid.Parse(c.FormValue("template_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.FormValue("date"), KuwaitTZ)
+	if err != nil {
+		date = GetKuwaitDate(time.Now())
+	}
+
+	ctx := c.Request().Context()
+	tmpl, err := h.DB.GetNoteTemplateByID(ctx, templateID, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "القالب غير موجود"})
+	}
+
+	form, _ := c.FormParams()
+	values := make(map[string][]string)
+	for _, f := range tmpl.Fields {
+		values[f.Key] = form["f_"+f.Key]
+	}
+
+	if err := h.saveTemplateValues(c, userID, tmpl, date, values); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"note_saved","type":"success"}}`)
+
+	templates, _ := h.DB.GetNoteTemplatesForDay(ctx, userID, date)
+	return Render(c, http.StatusOK, partials.JournalSection(templates, journal.PromptForDate(date).Text(promptLanguage(c)), date))
+}
+
+// promptLanguage is the language the guided prompt is shown in
+func promptLanguage(c echo.Context) string {
+	return journal.Language(c.Request().Header.Get("Accept-Language"))
+}
+
+// saveTemplateValues joins list items with newlines and stores one entry per field
+func (h *Handler) saveTemplateValues(c echo.Context, userID uuid.UUID, tmpl *database.NoteTemplate, date time.Time, values map[string][]string) error {
+	ctx := c.Request().Context()
+	for _, f := range tmpl.Fields {
+		raw, ok := values[f.Key]
+		if !ok {
+			continue
+		}
+
+		var parts []string
+		for _, v := range raw {
+			if v = strings.TrimSpace(v); v != "" {
+				parts = append(parts, v)
+			}
+		}
+
+		value := strings.Join(parts, "\n")
+		if f.Type == journal.FieldText {
+			value = strings.TrimSpace(strings.Join(raw, "\n"))
+		}
+
+		if err := h.DB.SaveNoteEntry(ctx, userID, tmpl.ID, f.Key, date, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ========== API HANDLERS ==========
+
+type noteTemplateRequest struct {
+	Name   string                       `json:"name"`
+	Icon   string                       `json:"icon"`
+	Fields []database.NoteTemplateField `json:"fields"`
+	Preset string                       `json:"preset"` // Optional preset key instead of name/fields
+}
+
+// GetNoteTemplatesAPI returns the user's templates and the available presets
+// GET /api/journal/templates
+func (h *Handler) GetNoteTemplatesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	templates, err := h.DB.GetNoteTemplates(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get templates"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "templates": templates, "presets": journal.Presets})
+}
+
+// CreateNoteTemplateAPI creates a template
+// POST /api/journal/templates
+func (h *Handler) CreateNoteTemplateAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req noteTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request"})
+	}
+
+	if preset, ok := journal.FindPreset(req.Preset); ok {
+		req.Name, req.Icon, req.Fields = preset.Name, preset.Icon, preset.Fields
+	}
+
+	fields := journal.NormalizeFields(req.Fields, nil)
+	if strings.TrimSpace(req.Name) == "" || len(fields) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Name and at least one field are required"})
+	}
+
+	tmpl, err := h.DB.CreateNoteTemplate(c.Request().Context(), userID, strings.TrimSpace(req.Name), req.Icon, fields)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to create template"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"status": "success", "template": tmpl})
+}
+
+// UpdateNoteTemplateAPI updates a template
+// PUT /api/journal/templates/:id
+func (h *Handler) UpdateNoteTemplateAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid template ID"})
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.DB.GetNoteTemplateByID(ctx, templateID, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Template not found"})
+	}
+
+	var req noteTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request"})
+	}
+
+	fields := journal.NormalizeFields(req.Fields, existing.Fields)
+	if strings.TrimSpace(req.Name) == "" || len(fields) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Name and at least one field are required"})
+	}
+
+	if err := h.DB.UpdateNoteTemplate(ctx, templateID, strings.TrimSpace(req.Name), req.Icon, fields); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to update template"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// DeleteNoteTemplateAPI soft-deletes a template
+// DELETE /api/journal/templates/:id
+func (h *Handler) DeleteNoteTemplateAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid template ID"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.DB.GetNoteTemplateByID(ctx, templateID, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Template not found"})
+	}
+
+	if err := h.DB.SoftDeleteNoteTemplate(ctx, templateID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to delete template"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// GetNoteEntriesAPI returns template values for one day (?date=) or queries a range
+// GET /api/journal/entries?date=YYYY-MM-DD
+// GET /api/journal/entries?from=YYYY-MM-DD&to=YYYY-MM-DD&template_id=&field=
+func (h *Handler) GetNoteEntriesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	ctx := c.Request().Context()
+
+	if dateStr := c.QueryParam("date"); dateStr != "" {
+		date, err := time.ParseInLocation("2006-01-02", dateStr, KuwaitTZ)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date"})
+		}
+		templates, err := h.DB.GetNoteTemplatesForDay(ctx, userID, date)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get entries"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "templates": templates})
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", c.QueryParam("from"), KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid from date"})
+	}
+	to, err := time.ParseInLocation("2006-01-02", c.QueryParam("to"), KuwaitTZ)
+	if err != nil || to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid to date"})
+	}
+
+	var templateID *uuid.UUID
+	if idStr := c.QueryParam("template_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid template ID"})
+		}
+		templateID = &id
+	}
+
+	entries, err := h.DB.GetNoteEntries(ctx, userID, templateID, c.QueryParam("field"), from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get entries"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "entries": entries})
+}
+
+// SaveNoteEntriesAPI saves template values for a day
+// POST /api/journal/entries {"template_id": "...", "date": "YYYY-MM-DD", "values": {"key": "text" | ["item", ...]}}
+func (h *Handler) SaveNoteEntriesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		TemplateID uuid.UUID                  `json:"template_id"`
+		Date       string                     `json:"date"`
+		Values     map[string]json.RawMessage `json:"values"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date"})
+	}
+
+	ctx := c.Request().Context()
+	tmpl, err := h.DB.GetNoteTemplateByID(ctx, req.TemplateID, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Template not found"})
+	}
+
+	values := make(map[string][]string)
+	for key, raw := range req.Values {
+		var items []string
+		if err := json.Unmarshal(raw, &items); err != nil {
+			var text string
+			if err := json.Unmarshal(raw, &text); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid value for " + key})
+			}
+			items = []string{text}
+		}
+		values[key] = items
+	}
+
+	if err := h.saveTemplateValues(c, userID, tmpl, date, values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save entries"})
+	}
+
+	templates, _ := h.DB.GetNoteTemplatesForDay(ctx, userID, date)
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "templates": templates})
+}
+
+// GetJournalPromptAPI returns the guided prompt of the day
+// GET /api/journal/prompt?date=YYYY-MM-DD
+func (h *Handler) GetJournalPromptAPI(c echo.Context) error {
+	date := GetKuwaitDate(time.Now())
+	if d, err := time.ParseInLocation("2006-01-02", c.QueryParam("date"), KuwaitTZ); err == nil {
+		date = d
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "prompt": journal.PromptForDate(date)})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	signedURLDefaultTTL = 15 * time.Minute
+	signedURLMaxTTL     = 24 * time.Hour
+	mediaCacheMaxAge    = 7 * 24 * time.Hour // Upload filenames are unique, so files never change in place
+)
+
+// ServeMedia serves an uploaded file to its owner, or to anyone holding a valid signed URL
+// GET /uploads/*?exp=&sig=
+func (h *Handler) ServeMedia(c echo.Context) error {
+	urlPath, key, ok := resolveMediaPath(c.Param("*"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	cacheControl := fmt.Sprintf("private, max-age=%d", int(mediaCacheMaxAge.Seconds()))
+
+	if sig := c.QueryParam("sig"); sig != "" {
+		expiresAt, ok := h.verifyMediaSignature(urlPath, c.QueryParam("exp"), sig)
+		if !ok {
+			return c.NoContent(http.StatusForbidden)
+		}
+		cacheControl = fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds()))
+	} else {
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			return c.NoContent(http.StatusUnauthorized)
+		}
+		// Not found rather than forbidden, so other users' file names can't be probed
+		if !h.canAccessMedia(c.Request().Context(), userID, urlPath) {
+			return c.NoContent(http.StatusNotFound)
+		}
+	}
+
+	body, info, err := h.Storage.Get(c.Request().Context(), key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Storage get error (%s): %v", key, err)
+		}
+		return c.NoContent(http.StatusNotFound)
+	}
+	defer body.Close()
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", cacheControl)
+	if info.ETag != "" {
+		header.Set("ETag", info.ETag)
+	}
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	}
+
+	// Local files are seekable: ServeContent answers If-None-Match / If-Modified-Since with 304 and handles Range requests
+	if rs, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Response(), c.Request(), path.Base(key), info.ModTime, rs)
+		return nil
+	}
+
+	// Remote objects are streamed; only conditional requests are handled
+	if info.ETag != "" && c.Request().Header.Get("If-None-Match") == info.ETag {
+		return c.NoContent(http.StatusNotModified)
+	}
+	if info.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	return c.Stream(http.StatusOK, info.ContentType, body)
+}
+
+// SignMediaURLsAPI returns short-lived signed URLs for the user's own files (for the native app)
+// POST /api/media/sign {"paths": ["/uploads/..."], "ttl_seconds": 900}
+func (h *Handler) SignMediaURLsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		Paths      []string `json:"paths"`
+		TTLSeconds int      `json:"ttl_seconds"`
+	}
+	if err := c.Bind(&req); err != nil || len(req.Paths) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request"})
+	}
+	if len(req.Paths) > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Too many paths (max 100)"})
+	}
+
+	ttl := signedURLDefaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = min(time.Duration(req.TTLSeconds)*time.Second, signedURLMaxTTL)
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	// Paths the user can't access are left out of the result
+	urls := make(map[string]string)
+	for _, p := range req.Paths {
+		urlPath, _, ok := resolveMediaPath(strings.TrimPrefix(p, "/uploads/"))
+		if !ok || !h.canAccessMedia(c.Request().Context(), userID, urlPath) {
+			continue
+		}
+		urls[p] = h.signMediaURL(urlPath, expiresAt)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":     "success",
+		"urls":       urls,
+		"expires_at": expiresAt.UTC(),
+	})
+}
+
+// canAccessMedia checks the file against daily_images, blog_images, task_attachments and avatars
+func (h *Handler) canAccessMedia(ctx context.Context, userID uuid.UUID, urlPath string) bool {
+	ownerID, kind, err := h.DB.GetMediaOwner(ctx, urlPath)
+	if err != nil {
+		return false
+	}
+
+	if kind == "" {
+		// Blog editor uploads for posts that weren't saved yet have no row;
+		// they live under the owner's own folder
+		return strings.HasPrefix(urlPath, "/uploads/blog/"+userID.String()+"/")
+	}
+
+	if ownerID == userID {
+		return true
+	}
+
+	// Admins see other users' avatars in the admin panel
+	if kind == database.MediaKindAvatar {
+		user, err := h.DB.GetUserByID(ctx, userID)
+		return err == nil && user.Role == middleware.RoleAdmin
+	}
+
+	return false
+}
+
+// resolveMediaPath cleans a path relative to /uploads and returns its URL form and storage key
+func resolveMediaPath(rel string) (string, string, bool) {
+	key, ok := storage.CleanKey(rel)
+	if !ok {
+		return "", "", false
+	}
+	return uploadURL(key), key, true
+}
+
+// signMediaURL builds /uploads/...?exp=&sig= valid until expiresAt
+func (h *Handler) signMediaURL(urlPath string, expiresAt time.Time) string {
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return fmt.Sprintf("%s?exp=%s&sig=%s", urlPath, exp, h.mediaSignature(urlPath, exp))
+}
+
+// verifyMediaSignature checks a signed URL and returns its expiry
+func (h *Handler) verifyMediaSignature(urlPath, exp, sig string) (time.Time, bool) {
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	expiresAt := time.Unix(unix, 0)
+	if time.Now().After(expiresAt) {
+		return time.Time{}, false
+	}
+	if !hmac.Equal([]byte(sig), []byte(h.mediaSignature(urlPath, exp))) {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+func (h *Handler) mediaSignature(urlPath, exp string) string {
+	mac := hmac.New(sha256.New, []byte(h.Config.JWTSecret))
+	mac.Write([]byte("media|" + urlPath + "|" + exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
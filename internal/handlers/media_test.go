@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"ohabits/internal/config"
+)
+
+func TestMediaSignature(t *testing.T) {
+	h := &Handler{Config: &config.Config{JWTSecret: "secret"}}
+	other := &Handler{Config: &config.Config{JWTSecret: "another secret"}}
+	const path = "/uploads/daily/a.jpg"
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	signed, err := url.Parse(h.signMediaURL(path, expiresAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Path != path {
+		t.Fatalf("signMediaURL path = %q, want %q", signed.Path, path)
+	}
+	exp, sig := signed.Query().Get("exp"), signed.Query().Get("sig")
+
+	// Flip the last hex digit so only the final byte differs
+	last := sig[len(sig)-1:]
+	flipped := "0"
+	if last == "0" {
+		flipped = "1"
+	}
+	nearMiss := sig[:len(sig)-1] + flipped
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	tests := []struct {
+		name           string
+		h              *Handler
+		path, exp, sig string
+		ok             bool
+	}{
+		{"valid", h, path, exp, sig, true},
+		{"tampered path", h, "/uploads/daily/b.jpg", exp, sig, false},
+		{"tampered expiry", h, path, strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10), sig, false},
+		{"expired", h, path, expired, h.mediaSignature(path, expired), false},
+		{"unparsable expiry", h, path, "soon", h.mediaSignature(path, "soon"), false},
+		{"signed with another secret", other, path, exp, sig, false},
+		{"only the last byte differs", h, path, exp, nearMiss, false},
+		{"truncated signature", h, path, exp, sig[:len(sig)/2], false},
+		{"empty signature", h, path, exp, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.h.verifyMediaSignature(tt.path, tt.exp, tt.sig)
+			if ok != tt.ok {
+				t.Fatalf("verifyMediaSignature ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !got.Equal(expiresAt) {
+				t.Errorf("verifyMediaSignature expiry = %v, want %v", got, expiresAt)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"ohabits/templates/partials"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -81,6 +83,7 @@ func (h *Handler) ToggleMedication(c echo.Context) error {
 
 	// Get updated medications
 	medications, _ := h.DB.GetMedicationsForDay(ctx, userID, date)
+	h.applyPrayerSchedule(ctx, userID, date, nil, medications)
 
 	// Find the toggled medication
 	for _, med := range medications {
@@ -167,10 +170,15 @@ func (h *Handler) CreateMedication(c echo.Context) error {
 		}
 	}
 
-	_, err := h.DB.CreateMedication(c.Request().Context(), userID, name, dosage, scheduledDays, timesPerDay, durationType, startDate, endDate, notes, icon)
+	med, err := h.DB.CreateMedication(c.Request().Context(), userID, name, dosage, scheduledDays, timesPerDay, durationType, startDate, endDate, notes, icon)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
 	}
+	if anchors := parseDoseAnchors(c, timesPerDay); anchors != nil {
+		if err := h.DB.SetMedicationDoseAnchors(c.Request().Context(), med.ID, userID, anchors); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
+		}
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"med_saved","type":"success"}}`)
 
@@ -184,6 +192,7 @@ func (h *Handler) CreateMedication(c echo.Context) error {
 	// Return updated list for dashboard
 	date := time.Now()
 	medications, _ := h.DB.GetMedicationsForDay(c.Request().Context(), userID, date)
+	h.applyPrayerSchedule(c.Request().Context(), userID, date, nil, medications)
 
 	return Render(c, http.StatusOK, partials.MedicationsList(medications, date))
 }
@@ -266,6 +275,12 @@ func (h *Handler) UpdateMedication(c echo.Context) error {
 	if err := h.DB.UpdateMedication(c.Request().Context(), medID, name, dosage, scheduledDays, timesPerDay, durationType, startDate, endDate, notes, icon, isActive); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
 	}
+	if err := h.DB.SetMedicationDoseAnchors(c.Request().Context(), medID, userID, parseDoseAnchors(c, timesPerDay)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "الدواء غير موجود"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
+	}
 
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"med_saved","type":"success"}}`)
 
@@ -308,6 +323,7 @@ func (h *Handler) DeleteMedication(c echo.Context) error {
 	// Return updated list for dashboard
 	date := time.Now()
 	medications, _ := h.DB.GetMedicationsForDay(c.Request().Context(), userID, date)
+	h.applyPrayerSchedule(c.Request().Context(), userID, date, nil, medications)
 
 	return Render(c, http.StatusOK, partials.MedicationsList(medications, date))
 }
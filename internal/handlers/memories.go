@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/partials"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// memoryMaxYears is how far back "on this day" looks
+const memoryMaxYears = 20
+
+// memoryMonths are the month offsets resurfaced besides full years
+var memoryMonths = []int{1, 6}
+
+// memoryCandidate is one past date to look up for a memory
+type memoryCandidate struct {
+	Date time.Time
+	Kind string
+	Ago  int
+}
+
+// memoryCandidates returns the past dates resurfaced on date: 1 and 6 months ago and the
+// same day in every earlier year. Days that don't exist (Feb 29, Mar 31 → Feb) are skipped
+// for years and clamped to the month's last day for months.
+func memoryCandidates(date time.Time) []memoryCandidate {
+	var candidates []memoryCandidate
+
+	for _, months := range memoryMonths {
+		first := time.Date(date.Year(), date.Month()-time.Month(months), 1, 0, 0, 0, 0, date.Location())
+		day := min(date.Day(), first.AddDate(0, 1, -1).Day())
+		candidates = append(candidates, memoryCandidate{
+			Date: time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, date.Location()),
+			Kind: database.MemoryMonthsAgo,
+			Ago:  months,
+		})
+	}
+
+	for years := 1; years <= memoryMaxYears; years++ {
+		d := time.Date(date.Year()-years, date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		if d.Month() != date.Month() {
+			continue
+		}
+		candidates = append(candidates, memoryCandidate{Date: d, Kind: database.MemoryYearsAgo, Ago: years})
+	}
+
+	return candidates
+}
+
+// loadMemories collects the notes, images, mood and workout of every past day resurfaced on date
+func (h *Handler) loadMemories(ctx context.Context, userID uuid.UUID, date time.Time) ([]database.Memory, error) {
+	candidates := memoryCandidates(date)
+	dates := make([]time.Time, len(candidates))
+	for i, cand := range candidates {
+		dates[i] = cand.Date
+	}
+
+	found, err := h.DB.GetMemoryDates(ctx, userID, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := h.DB.GetMemoryDays(ctx, userID, found)
+	if err != nil {
+		return nil, err
+	}
+
+	memories := make([]database.Memory, 0, len(found))
+	for _, d := range found {
+		key := d.Format("2006-01-02")
+		for _, cand := range candidates {
+			if cand.Date.Format("2006-01-02") != key {
+				continue
+			}
+
+			memory := *days[key]
+			memory.Date, memory.Kind, memory.Ago = cand.Date, cand.Kind, cand.Ago
+			memories = append(memories, memory)
+			break
+		}
+	}
+
+	return memories, nil
+}
+
+// ========== WEB HANDLERS ==========
+
+// HideMemory hides a past day from memories and re-renders the memories card
+// POST /memories/hide (memory_date = day to hide, date = day being viewed)
+func (h *Handler) HideMemory(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	memoryDate, err := time.ParseInLocation("2006-01-02", c.FormValue("memory_date"), KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "تاريخ غير صالح"})
+	}
+	date, err := time.ParseInLocation("2006-01-02", c.FormValue("date"), KuwaitTZ)
+	if err != nil {
+		date = GetKuwaitDate(time.Now())
+	}
+
+	ctx := c.Request().Context()
+	if err := h.DB.HideMemoryDay(ctx, userID, memoryDate); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	memories, _ := h.loadMemories(ctx, userID, date)
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"memory_hidden","type":"success"}}`)
+	return Render(c, http.StatusOK, partials.MemoriesSection(memories, date))
+}
+
+// ========== API HANDLERS ==========
+
+// GetMemoriesAPI returns the past days resurfaced on a date
+// GET /api/memories?date=YYYY-MM-DD
+func (h *Handler) GetMemoriesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	date := GetKuwaitDate(time.Now())
+	if dateStr := c.QueryParam("date"); dateStr != "" {
+		d, err := time.ParseInLocation("2006-01-02", dateStr, KuwaitTZ)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+		}
+		date = d
+	}
+
+	memories, err := h.loadMemories(c.Request().Context(), userID, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load memories"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"date":     date.Format("2006-01-02"),
+		"memories": memories,
+	})
+}
+
+// GetHiddenMemoriesAPI lists the days hidden from memories
+// GET /api/memories/hidden
+func (h *Handler) GetHiddenMemoriesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	dates, err := h.DB.GetHiddenMemoryDays(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load hidden days"})
+	}
+
+	hidden := make([]string, len(dates))
+	for i, d := range dates {
+		hidden[i] = d.Format("2006-01-02")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "dates": hidden})
+}
+
+// HideMemoryAPI hides a day from memories
+// POST /api/memories/hidden {"date": "YYYY-MM-DD"}
+func (h *Handler) HideMemoryAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req struct {
+		Date string `json:"date"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+	}
+
+	if err := h.DB.HideMemoryDay(c.Request().Context(), userID, date); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to hide day"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// UnhideMemoryAPI shows a hidden day in memories again
+// DELETE /api/memories/hidden/:date
+func (h *Handler) UnhideMemoryAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.Param("date"), KuwaitTZ)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+	}
+
+	if err := h.DB.UnhideMemoryDay(c.Request().Context(), userID, date); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to unhide day"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
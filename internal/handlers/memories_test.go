@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+func TestMemoryCandidates(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, KuwaitTZ) }
+	months := func(c []memoryCandidate) []string {
+		var out []string
+		for _, cand := range c {
+			if cand.Kind == database.MemoryMonthsAgo {
+				out = append(out, cand.Date.Format("2006-01-02"))
+			}
+		}
+		return out
+	}
+	years := func(c []memoryCandidate) []int {
+		var out []int
+		for _, cand := range c {
+			if cand.Kind == database.MemoryYearsAgo {
+				out = append(out, cand.Ago)
+			}
+		}
+		return out
+	}
+	every := func() []int {
+		out := make([]int, memoryMaxYears)
+		for i := range out {
+			out[i] = i + 1
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		wantMonths []string
+		wantYears  []int
+	}{
+		{"mid-month", day(2025, 3, 15), []string{"2025-02-15", "2024-09-15"}, every()},
+		{"months wrap into the previous year", day(2025, 1, 15), []string{"2024-12-15", "2024-07-15"}, every()},
+		{"month end clamps to a shorter month", day(2025, 3, 31), []string{"2025-02-28", "2024-09-30"}, every()},
+		{"month end clamps to Feb 29 in a leap year", day(2024, 3, 31), []string{"2024-02-29", "2023-09-30"}, every()},
+		{"Feb 29 only resurfaces in leap years", day(2024, 2, 29), []string{"2024-01-29", "2023-08-29"}, []int{4, 8, 12, 16, 20}},
+		{"Feb 28 resurfaces every year", day(2025, 2, 28), []string{"2025-01-28", "2024-08-28"}, every()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := memoryCandidates(tt.date)
+			if m := months(got); !reflect.DeepEqual(m, tt.wantMonths) {
+				t.Errorf("months ago = %v, want %v", m, tt.wantMonths)
+			}
+			if y := years(got); !reflect.DeepEqual(y, tt.wantYears) {
+				t.Errorf("years ago = %v, want %v", y, tt.wantYears)
+			}
+			for _, cand := range got {
+				if cand.Kind == database.MemoryYearsAgo && !cand.Date.Equal(tt.date.AddDate(-cand.Ago, 0, 0)) {
+					t.Errorf("%d years ago is %s, not the same day", cand.Ago, cand.Date.Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
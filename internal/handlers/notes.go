@@ -29,7 +29,7 @@ func (h *Handler) SaveNote(c echo.Context) error {
 		date = time.Now()
 	}
 
-	note, err := h.DB.SaveNote(c.Request().Context(), userID, text, date)
+	note, err := h.DB.SaveNote(c.Request().Context(), userID, text, date, database.RevisionSourceWeb)
 	if err != nil {
 		// Send error toast
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
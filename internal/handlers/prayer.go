@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/prayer"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// prayerTimes computes the user's prayer times for date's calendar day in Kuwait time
+func (h *Handler) prayerTimes(ctx context.Context, userID uuid.UUID, date time.Time) (prayer.Times, *database.PrayerSettings) {
+	settings, err := h.DB.GetPrayerSettings(ctx, userID)
+	if err != nil {
+		settings = &database.PrayerSettings{Latitude: prayer.DefaultLatitude, Longitude: prayer.DefaultLongitude, Method: prayer.DefaultMethod}
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, KuwaitTZ)
+	return prayer.Compute(day, settings.Latitude, settings.Longitude, settings.Method), settings
+}
+
+// applyPrayerSchedule resolves the prayer-anchored times of habits and doses on
+// date and orders both lists by time of day; items without an anchor keep
+// their order after the anchored ones
+func (h *Handler) applyPrayerSchedule(ctx context.Context, userID uuid.UUID, date time.Time, habits []database.HabitWithCompletion, meds []database.MedicationWithDoses) {
+	times, _ := h.prayerTimes(ctx, userID, date)
+
+	for i := range habits {
+		if a := habits[i].PrayerAnchor; a != nil {
+			if at, ok := times.Anchored(prayer.Prayer(a.Prayer), a.OffsetMinutes); ok {
+				habits[i].DueAt = &at
+			}
+		}
+	}
+	sort.SliceStable(habits, func(i, j int) bool {
+		return earlier(habits[i].DueAt, habits[j].DueAt)
+	})
+
+	firstDose := make(map[uuid.UUID]*time.Time, len(meds))
+	for i := range meds {
+		m := &meds[i]
+		m.DoseTimes = make([]*time.Time, m.TimesPerDay)
+		for dose := 1; dose <= m.TimesPerDay; dose++ {
+			a := m.DoseAnchor(dose)
+			if a == nil {
+				continue
+			}
+			if at, ok := times.Anchored(prayer.Prayer(a.Prayer), a.OffsetMinutes); ok {
+				m.DoseTimes[dose-1] = &at
+				if earlier(&at, firstDose[m.ID]) {
+					firstDose[m.ID] = &at
+				}
+			}
+		}
+	}
+	sort.SliceStable(meds, func(i, j int) bool {
+		return earlier(firstDose[meds[i].ID], firstDose[meds[j].ID])
+	})
+}
+
+// earlier orders times with nil (no time) last
+func earlier(a, b *time.Time) bool {
+	if a == nil {
+		return false
+	}
+	return b == nil || a.Before(*b)
+}
+
+// parsePrayerAnchor reads an anchor from the "<prefix>prayer" and
+// "<prefix>prayer_offset" form fields; nil when no prayer is chosen
+func parsePrayerAnchor(c echo.Context, prefix string) *database.PrayerAnchor {
+	p := prayer.Prayer(c.FormValue(prefix + "prayer"))
+	if !p.Valid() {
+		return nil
+	}
+	offset, _ := strconv.Atoi(strings.TrimSpace(c.FormValue(prefix + "prayer_offset")))
+	return &database.PrayerAnchor{Prayer: string(p), OffsetMinutes: max(-prayer.MaxOffsetMinutes, min(offset, prayer.MaxOffsetMinutes))}
+}
+
+// parseDoseAnchors reads the anchor of each dose ("dose_1_prayer", "dose_1_prayer_offset", ...)
+func parseDoseAnchors(c echo.Context, timesPerDay int) []database.PrayerAnchor {
+	anchors := make([]database.PrayerAnchor, timesPerDay)
+	anchored := false
+	for i := range anchors {
+		if a := parsePrayerAnchor(c, "dose_"+strconv.Itoa(i+1)+"_"); a != nil {
+			anchors[i] = *a
+			anchored = true
+		}
+	}
+	if !anchored {
+		return nil
+	}
+	return anchors
+}
+
+// validPrayerSettings checks coordinates and method
+func validPrayerSettings(s database.PrayerSettings) bool {
+	_, ok := prayer.Methods[s.Method]
+	return ok && s.Latitude >= -90 && s.Latitude <= 90 && s.Longitude >= -180 && s.Longitude <= 180
+}
+
+// SavePrayerSettings stores the location and calculation method from the habits page
+// POST /prayer/settings
+func (h *Handler) SavePrayerSettings(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(c.FormValue("latitude")), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(c.FormValue("longitude")), 64)
+	settings := database.PrayerSettings{Latitude: lat, Longitude: lon, Method: c.FormValue("method")}
+	if errLat != nil || errLon != nil || !validPrayerSettings(settings) {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"prayer_location_invalid","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "موقع غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	if err := h.DB.SavePrayerSettings(ctx, userID, settings); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"prayer_settings_saved","type":"success"}}`)
+	times, _ := h.prayerTimes(ctx, userID, GetKuwaitDate(GetKuwaitTime()))
+	return Render(c, http.StatusOK, pages.PrayerTimesSection(&settings, times))
+}
+
+// ========== API HANDLERS ==========
+
+// GetPrayerTimesAPI returns the prayer times for a date (default today)
+// GET /api/prayer-times?date=2026-03-20
+func (h *Handler) GetPrayerTimesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	date := GetKuwaitDate(GetKuwaitTime())
+	if s := c.QueryParam("date"); s != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", s, KuwaitTZ)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date format (use YYYY-MM-DD)"})
+		}
+		date = parsed
+	}
+
+	times, settings := h.prayerTimes(c.Request().Context(), userID, date)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"date":     date.Format("2006-01-02"),
+		"times":    times,
+		"settings": settings,
+	})
+}
+
+// GetPrayerSettingsAPI returns the location and calculation method
+// GET /api/prayer/settings
+func (h *Handler) GetPrayerSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	settings, err := h.DB.GetPrayerSettings(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load settings"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"settings": settings,
+		"methods":  prayer.Methods,
+	})
+}
+
+// UpdatePrayerSettingsAPI stores the location and calculation method
+// PUT /api/prayer/settings
+func (h *Handler) UpdatePrayerSettingsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var settings database.PrayerSettings
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	if settings.Method == "" {
+		settings.Method = prayer.DefaultMethod
+	}
+	if !validPrayerSettings(settings) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid coordinates or method"})
+	}
+
+	if err := h.DB.SavePrayerSettings(c.Request().Context(), userID, settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save settings"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "settings": settings})
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 
 	// Image format decoders
@@ -13,7 +14,9 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 
+	"ohabits/internal/database"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/media"
 	"ohabits/templates/pages"
 
 	"github.com/disintegration/imaging"
@@ -40,7 +43,7 @@ func (h *Handler) ProfilePage(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/login")
 	}
 
-	return Render(c, http.StatusOK, pages.ProfilePage(user, "", ""))
+	return h.renderProfilePage(c, user, "", "")
 }
 
 // UpdateProfileInfo updates user display name and email
@@ -60,10 +63,10 @@ func (h *Handler) UpdateProfileInfo(c echo.Context) error {
 
 	// Validate input
 	if displayName == "" {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "الاسم مطلوب"))
+		return h.renderProfilePage(c, user, "", "الاسم مطلوب")
 	}
 	if email == "" {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "البريد الإلكتروني مطلوب"))
+		return h.renderProfilePage(c, user, "", "البريد الإلكتروني مطلوب")
 	}
 
 	// Check if email is already used by another user
@@ -71,23 +74,23 @@ func (h *Handler) UpdateProfileInfo(c echo.Context) error {
 		exists, err := h.DB.CheckEmailExists(c.Request().Context(), email, userID)
 		if err != nil {
 			log.Printf("Error checking email: %v", err)
-			return Render(c, http.StatusOK, pages.ProfilePage(user, "", "حدث خطأ"))
+			return h.renderProfilePage(c, user, "", "حدث خطأ")
 		}
 		if exists {
-			return Render(c, http.StatusOK, pages.ProfilePage(user, "", "البريد الإلكتروني مستخدم من قبل"))
+			return h.renderProfilePage(c, user, "", "البريد الإلكتروني مستخدم من قبل")
 		}
 	}
 
 	// Update user info
 	if err := h.DB.UpdateUserInfo(c.Request().Context(), userID, displayName, email); err != nil {
 		log.Printf("Error updating user info: %v", err)
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "حدث خطأ في الحفظ"))
+		return h.renderProfilePage(c, user, "", "حدث خطأ في الحفظ")
 	}
 
 	// Refresh user data
 	user, _ = h.DB.GetUserByID(c.Request().Context(), userID)
 
-	return Render(c, http.StatusOK, pages.ProfilePage(user, "تم حفظ التغييرات بنجاح", ""))
+	return h.renderProfilePage(c, user, "تم حفظ التغييرات بنجاح", "")
 }
 
 // UpdateProfilePassword updates user password
@@ -108,36 +111,36 @@ func (h *Handler) UpdateProfilePassword(c echo.Context) error {
 
 	// Validate input
 	if currentPassword == "" || newPassword == "" || confirmPassword == "" {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "جميع الحقول مطلوبة"))
+		return h.renderProfilePage(c, user, "", "جميع الحقول مطلوبة")
 	}
 
 	if len(newPassword) < 6 {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "كلمة المرور يجب أن تكون 6 أحرف على الأقل"))
+		return h.renderProfilePage(c, user, "", "كلمة المرور يجب أن تكون 6 أحرف على الأقل")
 	}
 
 	if newPassword != confirmPassword {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "كلمة المرور الجديدة غير متطابقة"))
+		return h.renderProfilePage(c, user, "", "كلمة المرور الجديدة غير متطابقة")
 	}
 
 	// Verify current password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "كلمة المرور الحالية غير صحيحة"))
+		return h.renderProfilePage(c, user, "", "كلمة المرور الحالية غير صحيحة")
 	}
 
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "حدث خطأ"))
+		return h.renderProfilePage(c, user, "", "حدث خطأ")
 	}
 
 	// Update password
 	if err := h.DB.UpdateUserPassword(c.Request().Context(), userID, string(hashedPassword)); err != nil {
 		log.Printf("Error updating password: %v", err)
-		return Render(c, http.StatusOK, pages.ProfilePage(user, "", "حدث خطأ في الحفظ"))
+		return h.renderProfilePage(c, user, "", "حدث خطأ في الحفظ")
 	}
 
-	return Render(c, http.StatusOK, pages.ProfilePage(user, "تم تغيير كلمة المرور بنجاح", ""))
+	return h.renderProfilePage(c, user, "تم تغيير كلمة المرور بنجاح", "")
 }
 
 // UpdateProfileAvatar handles avatar upload
@@ -167,30 +170,21 @@ func (h *Handler) UpdateProfileAvatar(c echo.Context) error {
 
 	// Check mime type
 	mimeType := file.Header.Get("Content-Type")
-	if !strings.HasPrefix(mimeType, "image/") {
+	if !isImageUpload(mimeType, file.Filename) {
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_format_error","type":"error"}}`)
 		return Render(c, http.StatusOK, pages.AvatarSection(user))
 	}
 
-	// Create avatars directory
-	avatarsDir := filepath.Join(uploadsDir, "avatars")
-	if err := os.MkdirAll(avatarsDir, 0755); err != nil {
-		log.Printf("Error creating avatars directory: %v", err)
-		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"profile_error","type":"error"}}`)
-		return Render(c, http.StatusOK, pages.AvatarSection(user))
-	}
-
-	// Open source file
-	src, err := file.Open()
+	// Read source file
+	data, err := readUpload(file)
 	if err != nil {
 		log.Printf("Error opening uploaded file: %v", err)
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_error","type":"error"}}`)
 		return Render(c, http.StatusOK, pages.AvatarSection(user))
 	}
-	defer src.Close()
 
-	// Decode image directly from the uploaded file
-	img, err := imaging.Decode(src)
+	// Decode image (EXIF orientation applied, HEIC supported)
+	img, err := media.Decode(data, mimeType, file.Filename)
 	if err != nil {
 		log.Printf("Error decoding image: %v", err)
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_format_error","type":"error"}}`)
@@ -202,30 +196,50 @@ func (h *Handler) UpdateProfileAvatar(c echo.Context) error {
 
 	// Generate unique filename (always save as jpg for consistency)
 	newFilename := fmt.Sprintf("%s_%s.jpg", userID.String(), uuid.New().String()[:8])
-	avatarPath := filepath.Join(avatarsDir, newFilename)
 
-	// Save processed avatar as JPEG
-	if err := imaging.Save(avatar, avatarPath, imaging.JPEGQuality(85)); err != nil {
+	// Encode processed avatar as JPEG
+	avatarData, err := encodeAvatar(avatar)
+	if err != nil {
+		log.Printf("Error encoding avatar: %v", err)
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_error","type":"error"}}`)
+		return Render(c, http.StatusOK, pages.AvatarSection(user))
+	}
+
+	// Reserve the storage the new avatar adds (it replaces the old one)
+	usage, err := h.getStorageUsage(c.Request().Context(), userID)
+	if err != nil {
+		log.Printf("Error loading storage usage: %v", err)
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_error","type":"error"}}`)
+		return Render(c, http.StatusOK, pages.AvatarSection(user))
+	}
+	growth := max(0, int64(len(avatarData))-usage.AvatarBytes)
+	if _, err := h.reserveStorage(c.Request().Context(), userID, growth); err != nil {
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"quota_exceeded","type":"error"}}`)
+		} else {
+			log.Printf("Error reserving storage: %v", err)
+			c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_error","type":"error"}}`)
+		}
+		return Render(c, http.StatusOK, pages.AvatarSection(user))
+	}
+	defer h.releaseStorage(userID, growth)
+
+	// Save processed avatar
+	avatarURL, err := h.putUpload(c.Request().Context(), "avatars/"+newFilename, avatarData, "image/jpeg")
+	if err != nil {
 		log.Printf("Error saving avatar: %v", err)
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"avatar_error","type":"error"}}`)
 		return Render(c, http.StatusOK, pages.AvatarSection(user))
 	}
 
-	// Delete old avatar if exists (with path validation)
+	// Delete old avatar if exists (uploadKey rejects paths outside uploads)
 	if user.GetAvatarURL() != "" {
-		oldPath := strings.TrimPrefix(user.GetAvatarURL(), "/")
-		// تنظيف المسار ومنع Path Traversal
-		cleanPath := filepath.Clean(oldPath)
-		// التأكد من أن المسار داخل مجلد uploads فقط
-		if strings.HasPrefix(cleanPath, "uploads/") && !strings.Contains(cleanPath, "..") {
-			os.Remove(cleanPath)
-		}
+		h.deleteUpload(c.Request().Context(), user.GetAvatarURL())
 	}
 
 	// Update database
-	avatarURL := "/" + avatarPath
-	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, avatarURL); err != nil {
-		os.Remove(avatarPath)
+	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, avatarURL, int64(len(avatarData))); err != nil {
+		h.deleteUpload(c.Request().Context(), avatarURL)
 		log.Printf("Error updating avatar in DB: %v", err)
 		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
 		return Render(c, http.StatusOK, pages.AvatarSection(user))
@@ -397,25 +411,15 @@ func (h *Handler) UploadProfileImageAPI(c echo.Context) error {
 
 	// Check mime type
 	mimeType := file.Header.Get("Content-Type")
-	if !strings.HasPrefix(mimeType, "image/") {
+	if !isImageUpload(mimeType, file.Filename) {
 		return c.JSON(http.StatusBadRequest, ProfileImageAPIResponse{
 			Status: "error",
 			Error:  "Invalid image format",
 		})
 	}
 
-	// Create avatars directory
-	avatarsDir := filepath.Join(uploadsDir, "avatars")
-	if err := os.MkdirAll(avatarsDir, 0755); err != nil {
-		log.Printf("Error creating avatars directory: %v", err)
-		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
-			Status: "error",
-			Error:  "Server error",
-		})
-	}
-
-	// Open source file
-	src, err := file.Open()
+	// Read source file
+	data, err := readUpload(file)
 	if err != nil {
 		log.Printf("Error opening uploaded file: %v", err)
 		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
@@ -423,10 +427,9 @@ func (h *Handler) UploadProfileImageAPI(c echo.Context) error {
 			Error:  "Failed to process image",
 		})
 	}
-	defer src.Close()
 
-	// Decode image
-	img, err := imaging.Decode(src)
+	// Decode image (EXIF orientation applied, HEIC supported)
+	img, err := media.Decode(data, mimeType, file.Filename)
 	if err != nil {
 		log.Printf("Error decoding image: %v", err)
 		return c.JSON(http.StatusBadRequest, ProfileImageAPIResponse{
@@ -440,10 +443,43 @@ func (h *Handler) UploadProfileImageAPI(c echo.Context) error {
 
 	// Generate unique filename
 	newFilename := fmt.Sprintf("%s_%s.jpg", userID.String(), uuid.New().String()[:8])
-	avatarPath := filepath.Join(avatarsDir, newFilename)
 
-	// Save processed avatar as JPEG
-	if err := imaging.Save(avatar, avatarPath, imaging.JPEGQuality(85)); err != nil {
+	// Encode processed avatar as JPEG
+	avatarData, err := encodeAvatar(avatar)
+	if err != nil {
+		log.Printf("Error encoding avatar: %v", err)
+		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
+			Status: "error",
+			Error:  "Failed to process image",
+		})
+	}
+
+	// Reserve the storage the new avatar adds (it replaces the old one)
+	usage, err := h.getStorageUsage(c.Request().Context(), userID)
+	if err != nil {
+		log.Printf("Error loading storage usage: %v", err)
+		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
+			Status: "error",
+			Error:  "Failed to save image",
+		})
+	}
+	growth := max(0, int64(len(avatarData))-usage.AvatarBytes)
+	usage, err = h.reserveStorage(c.Request().Context(), userID, growth)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
+	}
+	if err != nil {
+		log.Printf("Error reserving storage: %v", err)
+		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
+			Status: "error",
+			Error:  "Failed to save image",
+		})
+	}
+	defer h.releaseStorage(userID, growth)
+
+	// Save processed avatar
+	avatarURL, err := h.putUpload(c.Request().Context(), "avatars/"+newFilename, avatarData, "image/jpeg")
+	if err != nil {
 		log.Printf("Error saving avatar: %v", err)
 		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
 			Status: "error",
@@ -453,17 +489,12 @@ func (h *Handler) UploadProfileImageAPI(c echo.Context) error {
 
 	// Delete old avatar if exists
 	if user.GetAvatarURL() != "" {
-		oldPath := strings.TrimPrefix(user.GetAvatarURL(), "/")
-		cleanPath := filepath.Clean(oldPath)
-		if strings.HasPrefix(cleanPath, "uploads/") && !strings.Contains(cleanPath, "..") {
-			os.Remove(cleanPath)
-		}
+		h.deleteUpload(c.Request().Context(), user.GetAvatarURL())
 	}
 
 	// Update database
-	avatarURL := "/" + avatarPath
-	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, avatarURL); err != nil {
-		os.Remove(avatarPath)
+	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, avatarURL, int64(len(avatarData))); err != nil {
+		h.deleteUpload(c.Request().Context(), avatarURL)
 		log.Printf("Error updating avatar in DB: %v", err)
 		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
 			Status: "error",
@@ -498,15 +529,11 @@ func (h *Handler) DeleteProfileImageAPI(c echo.Context) error {
 
 	// Delete old avatar if exists
 	if user.GetAvatarURL() != "" {
-		oldPath := strings.TrimPrefix(user.GetAvatarURL(), "/")
-		cleanPath := filepath.Clean(oldPath)
-		if strings.HasPrefix(cleanPath, "uploads/") && !strings.Contains(cleanPath, "..") {
-			os.Remove(cleanPath)
-		}
+		h.deleteUpload(c.Request().Context(), user.GetAvatarURL())
 	}
 
 	// Update database to clear avatar
-	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, ""); err != nil {
+	if err := h.DB.UpdateUserAvatar(c.Request().Context(), userID, "", 0); err != nil {
 		log.Printf("Error clearing avatar in DB: %v", err)
 		return c.JSON(http.StatusInternalServerError, ProfileImageAPIResponse{
 			Status: "error",
@@ -545,23 +572,9 @@ func (h *Handler) DeleteAccountAPI(c echo.Context) error {
 		})
 	}
 
-	// Delete user avatar file if exists
-	if user.GetAvatarURL() != "" {
-		oldPath := strings.TrimPrefix(user.GetAvatarURL(), "/")
-		cleanPath := filepath.Clean(oldPath)
-		if strings.HasPrefix(cleanPath, "uploads/") && !strings.Contains(cleanPath, "..") {
-			os.Remove(cleanPath)
-		}
-	}
-
-	// Delete user's uploaded images directory
-	userUploadsDir := filepath.Join(uploadsDir, userID.String())
-	if _, err := os.Stat(userUploadsDir); err == nil {
-		os.RemoveAll(userUploadsDir)
-	}
-
 	// Delete user from database (cascades to all related data)
-	if err := h.DB.DeleteUser(c.Request().Context(), userID); err != nil {
+	ctx := c.Request().Context()
+	if err := h.DB.DeleteUser(ctx, userID); err != nil {
 		log.Printf("Error deleting user account %s: %v", userID, err)
 		return c.JSON(http.StatusInternalServerError, DeleteAccountAPIResponse{
 			Status: "error",
@@ -569,9 +582,41 @@ func (h *Handler) DeleteAccountAPI(c echo.Context) error {
 		})
 	}
 
+	// Delete the user's files only once the account is gone, so a failed delete keeps them
+	if user.GetAvatarURL() != "" {
+		h.deleteUpload(ctx, user.GetAvatarURL())
+	}
+	for _, prefix := range []string{userID.String() + "/", "blog/" + userID.String() + "/"} {
+		if err := h.Storage.DeletePrefix(ctx, prefix); err != nil {
+			log.Printf("Error deleting files %s for user %s: %v", prefix, userID, err)
+		}
+	}
+
 	log.Printf("User account deleted: %s", userID)
 
 	return c.JSON(http.StatusOK, DeleteAccountAPIResponse{
 		Status: "success",
 	})
 }
+
+// encodeAvatar encodes a processed avatar as a metadata-free JPEG
+func encodeAvatar(avatar image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, avatar, imaging.JPEG, imaging.JPEGQuality(85)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderProfilePage renders the profile page with the user's storage usage and calendar feed
+func (h *Handler) renderProfilePage(c echo.Context, user *database.User, successMsg, errorMsg string) error {
+	usage, err := h.getStorageUsage(c.Request().Context(), user.ID)
+	if err != nil {
+		log.Printf("Error loading storage usage: %v", err)
+	}
+	feed, err := h.DB.GetCalendarFeed(c.Request().Context(), user.ID)
+	if err != nil {
+		log.Printf("Error loading calendar feed: %v", err)
+	}
+	return Render(c, http.StatusOK, pages.ProfilePage(user, usage, feed, calendarFeedURL(c, feed), successMsg, errorMsg))
+}
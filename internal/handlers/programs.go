@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// ProgramsPage renders the training programs page
+func (h *Handler) ProgramsPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	user, err := h.DB.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	view, err := h.loadPrograms(c, userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "حدث خطأ")
+	}
+
+	return Render(c, http.StatusOK, pages.ProgramsPage(user, view))
+}
+
+// CreateProgram creates a training program from the page form
+func (h *Handler) CreateProgram(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	ctx := c.Request().Context()
+	p := programFromForm(c)
+	workouts, _ := h.DB.GetWorkoutsByUserID(ctx, userID)
+	if err := validateProgram(&p, workouts); err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"program_invalid","type":"error"}}`)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "بيانات البرنامج غير صالحة"})
+	}
+
+	created, err := h.DB.CreateProgram(ctx, userID, p)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
+	}
+	if c.FormValue("activate") == "on" {
+		h.DB.SetActiveProgram(ctx, userID, &created.ID)
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"program_saved","type":"success"}}`)
+	return h.renderProgramsContent(c, userID)
+}
+
+// ActivateProgram makes a program the active one, or deactivates it if it already is
+func (h *Handler) ActivateProgram(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	p, err := h.DB.GetProgramByID(ctx, userID, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "البرنامج غير موجود"})
+	}
+
+	target, toast := &p.ID, "program_activated"
+	if p.IsActive {
+		target, toast = nil, "program_deactivated"
+	}
+	if err := h.DB.SetActiveProgram(ctx, userID, target); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"`+toast+`","type":"success"}}`)
+	return h.renderProgramsContent(c, userID)
+}
+
+// DeleteProgram deletes a training program from the page
+func (h *Handler) DeleteProgram(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	if err := h.DB.DeleteProgram(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"program_deleted","type":"success"}}`)
+	return h.renderProgramsContent(c, userID)
+}
+
+func (h *Handler) renderProgramsContent(c echo.Context, userID uuid.UUID) error {
+	view, err := h.loadPrograms(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+	return Render(c, http.StatusOK, pages.ProgramsContent(view))
+}
+
+func (h *Handler) loadPrograms(c echo.Context, userID uuid.UUID) (*pages.ProgramsView, error) {
+	ctx := c.Request().Context()
+
+	programs, err := h.DB.GetPrograms(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := h.DB.GetWorkoutsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string, len(workouts))
+	for _, w := range workouts {
+		names[w.ID] = w.Name
+	}
+
+	return &pages.ProgramsView{
+		Today:        GetKuwaitDate(GetKuwaitTime()),
+		Programs:     programs,
+		Workouts:     workouts,
+		WorkoutNames: names,
+	}, nil
+}
+
+// programFromForm reads the create form. Weekly programs post slot_0..slot_6
+// (Sunday first); rotations post rotation_slot once per day in order.
+// An empty slot value is a rest day.
+func programFromForm(c echo.Context) database.TrainingProgram {
+	p := database.TrainingProgram{
+		Name:         strings.TrimSpace(c.FormValue("name")),
+		Description:  strings.TrimSpace(c.FormValue("description")),
+		ScheduleType: c.FormValue("schedule_type"),
+		Slots:        []*uuid.UUID{},
+	}
+	p.Weeks, _ = strconv.Atoi(c.FormValue("weeks"))
+	p.DeloadEvery, _ = strconv.Atoi(c.FormValue("deload_every"))
+	p.DeloadPercent, _ = strconv.Atoi(c.FormValue("deload_percent"))
+	p.ProgressionIncrementKg, _ = strconv.ParseFloat(c.FormValue("progression_increment_kg"), 64)
+	p.StartDate, _ = time.ParseInLocation("2006-01-02", c.FormValue("start_date"), KuwaitTZ)
+
+	var values []string
+	if p.ScheduleType == database.ScheduleRotation {
+		form, _ := c.FormParams()
+		values = form["rotation_slot"]
+	} else {
+		for i := 0; i < 7; i++ {
+			values = append(values, c.FormValue("slot_"+strconv.Itoa(i)))
+		}
+	}
+	for _, v := range values {
+		if id, err := uuid.Parse(v); err == nil {
+			p.Slots = append(p.Slots, &id)
+		} else {
+			p.Slots = append(p.Slots, nil)
+		}
+	}
+	return p
+}
+
+// validateProgram fills defaults and checks the schedule only references the user's workouts
+func validateProgram(p *database.TrainingProgram, workouts []database.Workout) error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if p.ScheduleType == "" {
+		p.ScheduleType = database.ScheduleWeekly
+	}
+	switch p.ScheduleType {
+	case database.ScheduleWeekly:
+		if len(p.Slots) != 7 {
+			return errors.New("weekly schedules need 7 slots (Sunday first)")
+		}
+	case database.ScheduleRotation:
+		if len(p.Slots) == 0 {
+			return errors.New("rotation schedules need at least one slot")
+		}
+	default:
+		return errors.New("schedule_type must be weekly or rotation")
+	}
+	if p.Weeks < 0 || p.DeloadEvery < 0 || p.ProgressionIncrementKg < 0 {
+		return errors.New("weeks, deload_every and progression_increment_kg can't be negative")
+	}
+	if p.DeloadPercent <= 0 || p.DeloadPercent > 100 {
+		p.DeloadPercent = 60
+	}
+	if p.StartDate.IsZero() {
+		p.StartDate = GetKuwaitDate(GetKuwaitTime())
+	}
+
+	owned := make(map[uuid.UUID]bool, len(workouts))
+	for _, w := range workouts {
+		owned[w.ID] = true
+	}
+	for _, slot := range p.Slots {
+		if slot != nil && !owned[*slot] {
+			return errors.New("slots must reference your workouts")
+		}
+	}
+	return nil
+}
+
+// ========== API HANDLERS ==========
+
+// programRequest is the API body for creating or updating a program
+type programRequest struct {
+	Name                   string       `json:"name"`
+	Description            string       `json:"description"`
+	ScheduleType           string       `json:"schedule_type"`
+	Slots                  []*uuid.UUID `json:"slots"`
+	Weeks                  int          `json:"weeks"`
+	DeloadEvery            int          `json:"deload_every"`
+	DeloadPercent          int          `json:"deload_percent"`
+	ProgressionIncrementKg float64      `json:"progression_increment_kg"`
+	StartDate              string       `json:"start_date"` // YYYY-MM-DD, defaults to today
+}
+
+func (r programRequest) program() (database.TrainingProgram, error) {
+	p := database.TrainingProgram{
+		Name:                   strings.TrimSpace(r.Name),
+		Description:            strings.TrimSpace(r.Description),
+		ScheduleType:           r.ScheduleType,
+		Slots:                  r.Slots,
+		Weeks:                  r.Weeks,
+		DeloadEvery:            r.DeloadEvery,
+		DeloadPercent:          r.DeloadPercent,
+		ProgressionIncrementKg: r.ProgressionIncrementKg,
+	}
+	if p.Slots == nil {
+		p.Slots = []*uuid.UUID{}
+	}
+	if r.StartDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", r.StartDate, KuwaitTZ)
+		if err != nil {
+			return p, errors.New("invalid start_date (YYYY-MM-DD)")
+		}
+		p.StartDate = date
+	}
+	return p, nil
+}
+
+// GetProgramsAPI returns all training programs
+// GET /api/programs
+func (h *Handler) GetProgramsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	programs, err := h.DB.GetPrograms(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load programs"})
+	}
+	if programs == nil {
+		programs = []database.TrainingProgram{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "programs": programs})
+}
+
+// CreateProgramAPI creates a training program
+// POST /api/programs
+func (h *Handler) CreateProgramAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	var req programRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	p, err := req.program()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	workouts, _ := h.DB.GetWorkoutsByUserID(ctx, userID)
+	if err := validateProgram(&p, workouts); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	created, err := h.DB.CreateProgram(ctx, userID, p)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to create program"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"status": "success", "program": created})
+}
+
+// UpdateProgramAPI replaces a training program's settings and schedule
+// PUT /api/programs/:id
+func (h *Handler) UpdateProgramAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	var req programRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request body"})
+	}
+	p, err := req.program()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+	p.ID = id
+
+	ctx := c.Request().Context()
+	workouts, _ := h.DB.GetWorkoutsByUserID(ctx, userID)
+	if err := validateProgram(&p, workouts); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": err.Error()})
+	}
+
+	updated, err := h.DB.UpdateProgram(ctx, userID, p)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Program not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to update program"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "program": updated})
+}
+
+// DeleteProgramAPI deletes a training program
+// DELETE /api/programs/:id
+func (h *Handler) DeleteProgramAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	if err := h.DB.DeleteProgram(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to delete program"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// ActivateProgramAPI makes a program the user's only active program
+// POST /api/programs/:id/activate
+func (h *Handler) ActivateProgramAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid ID"})
+	}
+
+	err = h.DB.SetActiveProgram(c.Request().Context(), userID, &id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Program not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to activate program"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// DeactivateProgramsAPI stops following any program
+// POST /api/programs/deactivate
+func (h *Handler) DeactivateProgramsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	if err := h.DB.SetActiveProgram(c.Request().Context(), userID, nil); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to deactivate programs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// GetScheduledWorkoutAPI returns what the active program schedules on a day,
+// with the session's progressed targets
+// GET /api/programs/today?date=YYYY-MM-DD
+func (h *Handler) GetScheduledWorkoutAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	date := GetKuwaitDate(GetKuwaitTime())
+	if s := c.QueryParam("date"); s != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", s, KuwaitTZ)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid date (YYYY-MM-DD)"})
+		}
+		date = parsed
+	}
+
+	ctx := c.Request().Context()
+	day, err := h.DB.GetProgramDay(ctx, userID, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load program"})
+	}
+
+	var scheduled *database.Workout
+	if day != nil && day.WorkoutID != nil {
+		workouts, err := h.DB.GetWorkoutsForDay(ctx, userID, date)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load workouts"})
+		}
+		if len(workouts) > 0 && workouts[0].Scheduled != nil {
+			scheduled = &workouts[0]
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":  "success",
+		"date":    date.Format("2006-01-02"),
+		"day":     day,
+		"workout": scheduled,
+	})
+}
@@ -1,16 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 
+	"ohabits/internal/database"
 	"ohabits/internal/middleware"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -210,14 +211,15 @@ func (h *Handler) CreateTaskAPI(c echo.Context) error {
 	return c.JSON(http.StatusCreated, map[string]interface{}{"status": "success", "task": task})
 }
 
-// UpdateTaskAPI updates a task
+// UpdateTaskAPI updates a task. Completing a task with incomplete
+// prerequisites is refused unless "force" is set, in which case the
+// prerequisites come back as a warning.
 // PUT /api/tasks/:id
 func (h *Handler) UpdateTaskAPI(c echo.Context) error {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
 	}
-	_ = userID
 
 	taskID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -232,16 +234,27 @@ func (h *Handler) UpdateTaskAPI(c echo.Context) error {
 		DueDate      *string `json:"due_date"`
 		DisplayOrder int     `json:"display_order"`
 		Collapsed    bool    `json:"collapsed"`
+		Force        bool    `json:"force"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid request"})
 	}
 
 	ctx := c.Request().Context()
-	if err := h.DB.UpdateTask(ctx, taskID, req.Title, req.Description, req.Status, req.Priority, nil, req.DisplayOrder, req.Collapsed); err != nil {
+	blockedBy, err := h.DB.UpdateTask(ctx, taskID, userID, req.Title, req.Description, req.Status, req.Priority, nil, req.DisplayOrder, req.Collapsed, req.Force)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskBlocked) {
+			return c.JSON(http.StatusConflict, map[string]interface{}{"status": "error", "error": "Task is blocked by incomplete tasks", "blocked_by": blockedBy})
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Task not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to update task"})
 	}
 
+	if len(blockedBy) > 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "warning": "Completed while blocked by incomplete tasks", "blocked_by": blockedBy})
+	}
 	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
 
@@ -267,6 +280,102 @@ func (h *Handler) DeleteTaskAPI(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
 
+// ========== TASK DEPENDENCY HANDLERS ==========
+
+// GetTaskDependenciesAPI returns the prerequisites of a task and those not yet completed
+// GET /api/tasks/:id/dependencies
+func (h *Handler) GetTaskDependenciesAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid task ID"})
+	}
+
+	ctx := c.Request().Context()
+	dependencies, err := h.DB.GetTaskDependencies(ctx, taskID, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get task dependencies"})
+	}
+	blockedBy, err := h.DB.GetBlockingTasks(ctx, taskID, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get task dependencies"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":       "success",
+		"dependencies": dependencies,
+		"blocked":      len(blockedBy) > 0,
+		"blocked_by":   blockedBy,
+	})
+}
+
+// CreateTaskDependencyAPI makes a task depend on another task
+// POST /api/tasks/:id/dependencies
+func (h *Handler) CreateTaskDependencyAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid task ID"})
+	}
+
+	var req struct {
+		DependsOnTaskID uuid.UUID `json:"depends_on_task_id"`
+	}
+	if err := c.Bind(&req); err != nil || req.DependsOnTaskID == uuid.Nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "depends_on_task_id is required"})
+	}
+
+	ctx := c.Request().Context()
+	dependency, err := h.DB.CreateTaskDependency(ctx, userID, taskID, req.DependsOnTaskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrDependencyOnSelf):
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "A task cannot depend on itself"})
+		case errors.Is(err, pgx.ErrNoRows):
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Task not found"})
+		case errors.Is(err, database.ErrDependencyExists):
+			return c.JSON(http.StatusConflict, map[string]interface{}{"status": "error", "error": "Dependency already exists"})
+		case errors.Is(err, database.ErrDependencyCycle):
+			return c.JSON(http.StatusConflict, map[string]interface{}{"status": "error", "error": "Dependency would create a cycle"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to create dependency"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"status": "success", "dependency": dependency})
+}
+
+// DeleteTaskDependencyAPI removes a dependency
+// DELETE /api/dependencies/:id
+func (h *Handler) DeleteTaskDependencyAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	dependencyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid dependency ID"})
+	}
+
+	ctx := c.Request().Context()
+	if err := h.DB.DeleteTaskDependency(ctx, dependencyID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Dependency not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to delete dependency"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
 // ========== TASK COMMENT HANDLERS ==========
 
 // GetTaskComments returns all comments for a task
@@ -376,11 +485,18 @@ func (h *Handler) UploadTaskAttachment(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "File too large (max 10MB)"})
 	}
 
-	// Create upload directory
-	uploadDir := filepath.Join("uploads", userID.String(), "tasks")
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to create upload directory"})
+	ctx := c.Request().Context()
+
+	// Reserve the upload in the storage quota until it's saved (attachments
+	// are stored as uploaded, so file.Size is exactly what they take)
+	usage, err := h.reserveStorage(ctx, userID, file.Size)
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return quotaExceeded(c, usage)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to reserve storage"})
 	}
+	defer h.releaseStorage(userID, file.Size)
 
 	// Generate filename
 	ext := filepath.Ext(file.Filename)
@@ -388,30 +504,22 @@ func (h *Handler) UploadTaskAttachment(c echo.Context) error {
 		ext = ".jpg"
 	}
 	filename := fmt.Sprintf("task_%s_%s%s", taskID.String()[:8], uuid.New().String()[:8], ext)
-	filePath := filepath.Join(uploadDir, filename)
 
 	// Save file
-	src, err := file.Open()
+	data, err := readUpload(file)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to read file"})
 	}
-	defer src.Close()
 
-	dst, err := os.Create(filePath)
+	filePath, err := h.putUpload(ctx, userID.String()+"/tasks/"+filename, data, file.Header.Get("Content-Type"))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save file"})
 	}
-	defer dst.Close()
-
-	if _, err = io.Copy(dst, src); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save file"})
-	}
 
 	// Save to database
-	ctx := c.Request().Context()
-	attachment, err := h.DB.SaveTaskAttachment(ctx, taskID, userID, filename, "/"+filePath, file.Header.Get("Content-Type"), file.Size)
+	attachment, err := h.DB.SaveTaskAttachment(ctx, taskID, userID, filename, filePath, file.Header.Get("Content-Type"), file.Size)
 	if err != nil {
-		os.Remove(filePath)
+		h.deleteUpload(ctx, filePath)
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to save attachment record"})
 	}
 
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// storageQuota returns a role's upload quota in bytes (0 = unlimited)
+func (h *Handler) storageQuota(role int) int64 {
+	switch role {
+	case middleware.RoleAdmin:
+		return 0
+	case middleware.RoleSubscribed:
+		return h.Config.QuotaSubscribedMB << 20
+	default:
+		return h.Config.QuotaNormalMB << 20
+	}
+}
+
+// getStorageUsage loads a user's storage usage with their quota filled in
+func (h *Handler) getStorageUsage(ctx context.Context, userID uuid.UUID) (*database.StorageUsage, error) {
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := h.DB.GetStorageUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage.QuotaBytes = h.storageQuota(user.Role)
+	return usage, nil
+}
+
+// reserveStorage reserves n bytes of the user's quota for an upload before
+// anything is stored, so parallel uploads can't overshoot it together.
+// database.ErrQuotaExceeded comes with the current usage for quotaExceeded.
+// A successful reservation must be given back with releaseStorage.
+func (h *Handler) reserveStorage(ctx context.Context, userID uuid.UUID, n int64) (*database.StorageUsage, error) {
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return h.DB.ReserveStorage(ctx, userID, n, h.storageQuota(user.Role))
+}
+
+// growReservation extends an image upload's reservation to the bytes its
+// variants actually take, which can be more than the upload itself. When they
+// don't fit in the quota, the stored files are deleted and
+// database.ErrQuotaExceeded comes back with the usage. It returns the bytes now
+// reserved, to be given back with releaseStorage.
+func (h *Handler) growReservation(ctx context.Context, userID uuid.UUID, reserved int64, stored *storedImage) (int64, *database.StorageUsage, error) {
+	extra := stored.StoredBytes - reserved
+	if extra <= 0 {
+		return reserved, nil, nil
+	}
+	usage, err := h.reserveStorage(ctx, userID, extra)
+	if err != nil {
+		h.deleteImageFiles(ctx, stored.Variants)
+		return reserved, usage, err
+	}
+	return reserved + extra, usage, nil
+}
+
+// releaseStorage gives back a reservation once the upload's row is saved (its
+// bytes are counted from then on) or it has failed. It runs even when the
+// client has gone away.
+func (h *Handler) releaseStorage(userID uuid.UUID, n int64) {
+	if err := h.DB.ReleaseStorage(context.Background(), userID, n); err != nil {
+		log.Printf("Storage release error: %v", err)
+	}
+}
+
+// quotaExceeded responds to an upload that doesn't fit in the user's quota:
+// 413 with a machine-readable code for the app, or an error toast for the web
+func quotaExceeded(c echo.Context, usage *database.StorageUsage) error {
+	if isAPIRequest(c) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{
+			"status":      "error",
+			"error":       "Storage quota exceeded",
+			"code":        "quota_exceeded",
+			"used_bytes":  usage.TotalBytes,
+			"quota_bytes": usage.QuotaBytes,
+		})
+	}
+	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"quota_exceeded","type":"error"}}`)
+	return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "تجاوزت مساحة التخزين المتاحة"})
+}
+
+// GetStorageUsageAPI returns the user's storage usage per kind and their quota
+// GET /api/user/storage
+func (h *Handler) GetStorageUsageAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	usage, err := h.getStorageUsage(c.Request().Context(), userID)
+	if err != nil {
+		log.Printf("Storage usage error: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load storage usage"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":  "success",
+		"storage": usage,
+	})
+}
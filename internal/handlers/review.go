@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ReviewPage renders the weekly/monthly review page
+func (h *Handler) ReviewPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	user, err := h.DB.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	period, date := parseReviewParams(c)
+	review, err := h.buildReview(c, userID, period, date)
+	if err != nil {
+		from, to := reviewRange(period, date)
+		review = &database.ReviewData{Period: period, From: from, To: to, HabitRate: -1, MedicationAdherence: -1}
+	}
+
+	return Render(c, http.StatusOK, pages.ReviewPage(user, review))
+}
+
+// GetReviewAPI returns the aggregated review for a week or month
+// GET /api/review?period=week|month&date=YYYY-MM-DD
+func (h *Handler) GetReviewAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	period, date := parseReviewParams(c)
+	review, err := h.buildReview(c, userID, period, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to build review"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "review": review})
+}
+
+// buildReview aggregates habits, medications, mood, workouts, todos, tasks and events for a period
+func (h *Handler) buildReview(c echo.Context, userID uuid.UUID, period string, date time.Time) (*database.ReviewData, error) {
+	ctx := c.Request().Context()
+	from, to := reviewRange(period, date)
+
+	review := &database.ReviewData{
+		Period:              period,
+		From:                from,
+		To:                  to,
+		HabitRate:           -1,
+		MedicationAdherence: -1,
+	}
+
+	days, err := h.DB.GetDailyMetrics(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	habits, err := h.DB.GetHabitsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Habit completion per habit
+	stats := make(map[uuid.UUID]*database.HabitReviewStat)
+	for _, habit := range habits {
+		if habit.IsDeleted {
+			continue
+		}
+		stats[habit.ID] = &database.HabitReviewStat{HabitID: habit.ID, Name: habit.Name, Icon: habit.Icon}
+	}
+
+	var moodSum, scheduled, completed int
+	for _, day := range days {
+		for _, habitID := range day.HabitsScheduled {
+			stat, ok := stats[habitID]
+			if !ok {
+				continue
+			}
+			stat.Scheduled++
+			scheduled++
+			if day.HabitsCompleted[habitID] {
+				stat.Completed++
+				completed++
+			}
+		}
+
+		review.DosesScheduled += day.DosesScheduled
+		review.DosesTaken += day.DosesTaken
+
+		if day.WorkedOut {
+			review.WorkoutsDone++
+		}
+
+		review.Mood = append(review.Mood, database.MoodPoint{Date: day.Date, Rating: day.Mood})
+		if day.Mood != nil {
+			moodSum += *day.Mood
+			review.MoodDays++
+		}
+	}
+
+	for _, habit := range habits {
+		if stat, ok := stats[habit.ID]; ok && stat.Scheduled > 0 {
+			review.Habits = append(review.Habits, *stat)
+		}
+	}
+	if scheduled > 0 {
+		review.HabitRate = float64(completed) / float64(scheduled)
+	}
+	if review.DosesScheduled > 0 {
+		review.MedicationAdherence = float64(review.DosesTaken) / float64(review.DosesScheduled)
+	}
+	if review.MoodDays > 0 {
+		review.MoodAverage = float64(moodSum) / float64(review.MoodDays)
+	}
+
+	// Mood in the previous period of the same length, for the trend arrow
+	prevFrom, prevTo := reviewRange(period, previousReviewDate(period, from))
+	review.PreviousMoodAverage, review.PreviousMoodDays, err = h.DB.GetAverageMood(ctx, userID, prevFrom, prevTo)
+	if err != nil {
+		return nil, err
+	}
+
+	review.StartWeight, review.EndWeight, err = h.DB.GetWeightRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	review.CompletedTodos, err = h.DB.GetCompletedTodosInRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	review.CompletedTasks, err = h.DB.GetCompletedTasksInRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upcoming events: the period right after this one
+	nextFrom, nextTo := reviewRange(period, nextReviewDate(period, from))
+	upcoming, err := h.DB.GetCalendarOccurrences(ctx, userID, nextFrom, nextTo)
+	if err != nil {
+		return nil, err
+	}
+	periodStart := time.Date(nextFrom.Year(), nextFrom.Month(), nextFrom.Day(), 0, 0, 0, 0, time.UTC)
+	for _, e := range upcoming {
+		day := e.EventDate
+		if day.Before(periodStart) {
+			day = periodStart // Started before the period and still going
+		}
+		review.UpcomingEvents = append(review.UpcomingEvents, database.ReviewEvent{Date: day, Event: e})
+	}
+
+	if period == "month" {
+		review.Summary, err = h.DB.GetMonthlySummary(ctx, userID, from.Year(), int(from.Month()))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return review, nil
+}
+
+// parseReviewParams reads ?period= (week or month) and ?date= (any day in the period)
+func parseReviewParams(c echo.Context) (string, time.Time) {
+	period := c.QueryParam("period")
+	if period != "month" {
+		period = "week"
+	}
+
+	date := GetKuwaitDate(time.Now())
+	if dateStr := c.QueryParam("date"); dateStr != "" {
+		if d, err := time.ParseInLocation("2006-01-02", dateStr, KuwaitTZ); err == nil {
+			date = d
+		}
+	}
+
+	return period, date
+}
+
+// reviewRange returns the first and last day of the week (Sunday-Saturday) or month containing date
+func reviewRange(period string, date time.Time) (time.Time, time.Time) {
+	if period == "month" {
+		from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return from, from.AddDate(0, 1, -1)
+	}
+	from := getWeekStart(date)
+	return from, from.AddDate(0, 0, 6)
+}
+
+// previousReviewDate returns a date inside the period before the one starting at from
+func previousReviewDate(period string, from time.Time) time.Time {
+	if period == "month" {
+		return from.AddDate(0, -1, 0)
+	}
+	return from.AddDate(0, 0, -7)
+}
+
+// nextReviewDate returns a date inside the period after the one starting at from
+func nextReviewDate(period string, from time.Time) time.Time {
+	if period == "month" {
+		return from.AddDate(0, 1, 0)
+	}
+	return from.AddDate(0, 0, 7)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/diff"
+	"ohabits/templates/pages"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== WEB HANDLERS ==========
+
+// NoteRevisionsPage shows the edit history of a daily note
+// GET /notes/revisions?date=YYYY-MM-DD&rev=
+func (h *Handler) NoteRevisionsPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", c.QueryParam("date"), KuwaitTZ)
+	if err != nil {
+		date = GetKuwaitDate(time.Now())
+	}
+
+	note, _ := h.DB.GetNoteForDay(ctx, userID, date)
+	if note == nil {
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/?date=%s", date.Format("2006-01-02")))
+	}
+
+	revisions, _ := h.DB.GetRevisions(ctx, userID, database.RevisionEntityNote, note.ID)
+	selected := selectRevision(revisions, c.QueryParam("rev"))
+
+	var lines []diff.Line
+	if selected != nil {
+		lines = diff.Lines(selected.Content, note.Text)
+	}
+
+	return Render(c, http.StatusOK, pages.NoteRevisionsPage(user, date, revisions, selected, lines))
+}
+
+// BlogRevisionsPage shows the edit history of a blog post
+// GET /blog/:id/revisions?rev=
+func (h *Handler) BlogRevisionsPage(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/blog")
+	}
+
+	post, _ := h.DB.GetBlogPost(ctx, userID, postID)
+	if post == nil {
+		return c.Redirect(http.StatusSeeOther, "/blog")
+	}
+
+	revisions, _ := h.DB.GetRevisions(ctx, userID, database.RevisionEntityMarkdownNote, post.ID)
+	selected := selectRevision(revisions, c.QueryParam("rev"))
+
+	var lines []diff.Line
+	if selected != nil {
+		lines = diff.Lines(selected.Content, post.Content)
+	}
+
+	return Render(c, http.StatusOK, pages.BlogRevisionsPage(user, post, revisions, selected, lines))
+}
+
+// RestoreRevision replaces the current note or blog post with a revision
+// POST /revisions/:id/restore
+func (h *Handler) RestoreRevision(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "غير مصرح"})
+	}
+
+	revisionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "معرف غير صالح"})
+	}
+
+	ctx := c.Request().Context()
+	rev, err := h.DB.GetRevision(ctx, userID, revisionID)
+	if err != nil || rev == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "النسخة غير موجودة"})
+	}
+
+	backURL, err := h.restoreRevision(ctx, userID, rev, database.RevisionSourceWeb)
+	if err != nil {
+		c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"save_error","type":"error"}}`)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ"})
+	}
+
+	c.Response().Header().Set("HX-Redirect", backURL)
+	return c.JSON(http.StatusOK, map[string]string{"success": "true"})
+}
+
+// ========== API HANDLERS ==========
+
+// GetRevisionsAPI lists the revisions of a note or blog post
+// GET /api/revisions?entity_type=note|markdown_note&entity_id=
+func (h *Handler) GetRevisionsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	entityType := c.QueryParam("entity_type")
+	if entityType != database.RevisionEntityNote && entityType != database.RevisionEntityMarkdownNote {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid entity type"})
+	}
+
+	entityID, err := uuid.Parse(c.QueryParam("entity_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid entity ID"})
+	}
+
+	revisions, err := h.DB.GetRevisions(c.Request().Context(), userID, entityType, entityID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to get revisions"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success", "revisions": revisions})
+}
+
+// GetRevisionAPI returns a revision and its diff against the current version
+// GET /api/revisions/:id
+func (h *Handler) GetRevisionAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	revisionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid revision ID"})
+	}
+
+	ctx := c.Request().Context()
+	rev, err := h.DB.GetRevision(ctx, userID, revisionID)
+	if err != nil || rev == nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Revision not found"})
+	}
+
+	var currentTitle, current string
+	switch rev.EntityType {
+	case database.RevisionEntityNote:
+		note, _ := h.DB.GetNoteByID(ctx, userID, rev.EntityID)
+		if note != nil {
+			current = note.Text
+		}
+	case database.RevisionEntityMarkdownNote:
+		post, _ := h.DB.GetBlogPost(ctx, userID, rev.EntityID)
+		if post != nil {
+			currentTitle, current = post.Title, post.Content
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":        "success",
+		"revision":      rev,
+		"current_title": currentTitle,
+		"diff":          diff.Lines(rev.Content, current),
+	})
+}
+
+// RestoreRevisionAPI replaces the current note or blog post with a revision
+// POST /api/revisions/:id/restore
+func (h *Handler) RestoreRevisionAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	revisionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid revision ID"})
+	}
+
+	ctx := c.Request().Context()
+	rev, err := h.DB.GetRevision(ctx, userID, revisionID)
+	if err != nil || rev == nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"status": "error", "error": "Revision not found"})
+	}
+
+	if _, err := h.restoreRevision(ctx, userID, rev, database.RevisionSourceAPI); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to restore revision"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// restoreRevision writes a revision back; the version it replaces becomes a revision itself
+// Returns the URL of the restored note or post
+func (h *Handler) restoreRevision(ctx context.Context, userID uuid.UUID, rev *database.Revision, source string) (string, error) {
+	switch rev.EntityType {
+	case database.RevisionEntityNote:
+		note, err := h.DB.GetNoteByID(ctx, userID, rev.EntityID)
+		if err != nil {
+			return "", err
+		}
+		if note == nil {
+			return "", fmt.Errorf("note %s not found", rev.EntityID)
+		}
+		if _, err := h.DB.SaveNote(ctx, userID, rev.Content, note.Date, source); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("/?date=%s", note.Date.Format("2006-01-02")), nil
+
+	case database.RevisionEntityMarkdownNote:
+		post, err := h.DB.UpdateBlogPost(ctx, userID, rev.EntityID, rev.Title, rev.Content, source)
+		if err != nil {
+			return "", err
+		}
+		if post == nil {
+			return "", fmt.Errorf("blog post %s not found", rev.EntityID)
+		}
+		return fmt.Sprintf("/blog/%s", post.ID.String()), nil
+	}
+
+	return "", fmt.Errorf("unknown revision entity type %q", rev.EntityType)
+}
+
+// selectRevision returns the revision with the given ID, or the newest one
+func selectRevision(revisions []database.Revision, id string) *database.Revision {
+	for i := range revisions {
+		if revisions[i].ID.String() == id {
+			return &revisions[i]
+		}
+	}
+	if len(revisions) > 0 {
+		return &revisions[0]
+	}
+	return nil
+}
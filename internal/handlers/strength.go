@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/middleware"
+	"ohabits/internal/services/strength"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// GetExerciseProgressAPI returns e1RM, volume and personal records for one exercise
+// GET /api/workouts/exercises/:name/progress
+func (h *Handler) GetExerciseProgressAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	name, err := url.PathUnescape(c.Param("name"))
+	if err != nil || strength.Key(name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"status": "error", "error": "Invalid exercise name"})
+	}
+
+	logs, lib, err := h.loadStrengthLogs(c.Request().Context(), userID, GetKuwaitDate(GetKuwaitTime()))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load workout logs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"progress": strength.ExerciseProgress(logs, lib.CanonicalName(name)),
+	})
+}
+
+// GetMuscleGroupSetsAPI returns weekly working sets per muscle group
+// GET /api/workouts/muscle-groups?weeks=8
+func (h *Handler) GetMuscleGroupSetsAPI(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized"})
+	}
+
+	weeks, err := strconv.Atoi(c.QueryParam("weeks"))
+	if err != nil || weeks <= 0 {
+		weeks = 8
+	}
+	weeks = min(weeks, 52)
+
+	today := GetKuwaitDate(GetKuwaitTime())
+	logs, lib, err := h.loadStrengthLogs(c.Request().Context(), userID, today)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"status": "error", "error": "Failed to load workout logs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":        "success",
+		"muscle_groups": database.MuscleGroups,
+		"weeks":         strength.MuscleGroupSets(logs, lib, weeks, today),
+	})
+}
+
+// loadStrengthLogs loads logs with sets up to `until`, with exercise names
+// mapped to their library names so analytics group spellings and aliases together
+func (h *Handler) loadStrengthLogs(ctx context.Context, userID uuid.UUID, until time.Time) ([]database.WorkoutLog, *database.ExerciseLibrary, error) {
+	logs, err := h.DB.GetWorkoutLogsWithSets(ctx, userID, until, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	lib, err := h.DB.GetExerciseLibrary(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lib.Canonicalize(logs)
+	return logs, lib, nil
+}
+
+// attachPersonalRecords flags the records a workout log set compared to all earlier logs
+func (h *Handler) attachPersonalRecords(ctx context.Context, userID uuid.UUID, log *database.WorkoutLog) {
+	if log == nil {
+		return
+	}
+	logs, _, err := h.loadStrengthLogs(ctx, userID, log.Date)
+	if err != nil {
+		return
+	}
+	log.PersonalRecords = strength.DetectRecords(logs)[log.ID]
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime/multipart"
+	"strings"
+
+	"ohabits/internal/database"
+	"ohabits/internal/services/media"
+	"ohabits/internal/storage"
+)
+
+// uploadsURLPrefix is how stored files are addressed in the DB and served (see ServeMedia)
+const uploadsURLPrefix = "/uploads/"
+
+// uploadURL returns the public path stored in the DB for a storage key
+func uploadURL(key string) string {
+	return uploadsURLPrefix + key
+}
+
+// uploadKey converts a stored "/uploads/..." path back to its storage key
+func uploadKey(url string) (string, bool) {
+	if !strings.HasPrefix(url, uploadsURLPrefix) {
+		return "", false
+	}
+	return storage.CleanKey(strings.TrimPrefix(url, uploadsURLPrefix))
+}
+
+// readUpload reads a multipart file into memory (uploads are capped at 10MB)
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// putUpload stores data under key and returns its "/uploads/..." path
+func (h *Handler) putUpload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := h.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+	return uploadURL(key), nil
+}
+
+// deleteUpload removes a stored file by its "/uploads/..." path; failures are only logged
+func (h *Handler) deleteUpload(ctx context.Context, url string) {
+	key, ok := uploadKey(url)
+	if !ok {
+		return
+	}
+	if err := h.Storage.Delete(ctx, key); err != nil {
+		log.Printf("Storage delete error (%s): %v", key, err)
+	}
+}
+
+// storedImage is an uploaded photo after the image pipeline
+type storedImage struct {
+	OriginalPath  string // Full-size variant (EXIF stripped)
+	ThumbnailPath string
+	MimeType      string
+	SizeBytes     int
+	StoredBytes   int64 // All variants together, for storage usage
+	Variants      database.ImageVariants
+}
+
+// variantDirs keeps the originals/ and thumbnails/ layout older images use
+var variantDirs = map[string]string{
+	media.VariantThumb:  "thumbnails",
+	media.VariantMedium: "medium",
+	media.VariantFull:   "originals",
+}
+
+// storeImage processes an upload (orientation, EXIF removal, HEIC, sizes) and stores
+// every variant as dir/<variant dir>/<base><ext>
+func (h *Handler) storeImage(ctx context.Context, dir, base string, data []byte, contentType, filename string) (*storedImage, error) {
+	outputs, err := media.Process(data, contentType, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &storedImage{Variants: database.ImageVariants{}}
+	var stored []string
+	for _, out := range outputs {
+		url, err := h.putUpload(ctx, dir+"/"+variantDirs[out.Variant]+"/"+base+out.Ext, out.Data, out.ContentType)
+		if err != nil {
+			for _, u := range stored {
+				h.deleteUpload(ctx, u)
+			}
+			return nil, err
+		}
+		stored = append(stored, url)
+		img.StoredBytes += int64(len(out.Data))
+
+		variant := img.Variants[out.Variant]
+		variant.Width, variant.Height = out.Width, out.Height
+		if out.ContentType == "image/webp" {
+			variant.WebPURL = url
+		} else {
+			variant.URL = url
+			if out.Variant == media.VariantFull {
+				img.MimeType = out.ContentType
+				img.SizeBytes = len(out.Data)
+			}
+		}
+		img.Variants[out.Variant] = variant
+	}
+
+	img.OriginalPath = img.Variants[media.VariantFull].URL
+	img.ThumbnailPath = img.Variants[media.VariantThumb].URL
+	return img, nil
+}
+
+// deleteImageFiles removes every file of an image: its variants plus the legacy paths
+func (h *Handler) deleteImageFiles(ctx context.Context, variants database.ImageVariants, paths ...string) {
+	seen := make(map[string]bool)
+	for _, url := range append(variants.URLs(), paths...) {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		h.deleteUpload(ctx, url)
+	}
+}
+
+// isImageUpload accepts image/* uploads and HEIC photos sent without an image content type
+func isImageUpload(contentType, filename string) bool {
+	return strings.HasPrefix(contentType, "image/") || media.IsHEIC(contentType, filename)
+}
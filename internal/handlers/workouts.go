@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,6 +11,7 @@ import (
 
 	"ohabits/internal/database"
 	"ohabits/internal/middleware"
+	"ohabits/internal/services/strength"
 	"ohabits/templates/pages"
 	"ohabits/templates/partials"
 
@@ -39,21 +43,28 @@ func (h *Handler) SaveWorkoutLog(c echo.Context) error {
 		weight, _ = strconv.ParseFloat(weightStr, 64)
 	}
 
-	// Parse cardio as array
-	var cardio []database.Cardio
-	if cardioName != "" {
+	// Cardio entries come from the cardio editor as JSON; older forms send a single name/minutes pair
+	cardio := []database.Cardio{}
+	if raw := c.FormValue("cardio_entries"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cardio); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "بيانات الكارديو غير صالحة"})
+		}
+	} else if cardioName != "" {
 		minutes, _ := strconv.Atoi(cardioMinutesStr)
 		cardio = []database.Cardio{{
 			Name:    cardioName,
 			Minutes: minutes,
 		}}
-	} else {
-		cardio = []database.Cardio{}
 	}
 
-	// Get exercises from the selected workout
+	// Logged sets come from the set editor as JSON; without it fall back to
+	// the selected workout's exercise list
 	exercises := []database.Exercise{}
-	if workoutName != "" {
+	if raw := c.FormValue("completed_exercises"); raw != "" && workoutName != "" {
+		if err := json.Unmarshal([]byte(raw), &exercises); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "بيانات التمارين غير صالحة"})
+		}
+	} else if workoutName != "" {
 		workouts, _ := h.DB.GetWorkoutsByUserID(c.Request().Context(), userID)
 		for _, w := range workouts {
 			if w.Name == workoutName {
@@ -69,11 +80,13 @@ func (h *Handler) SaveWorkoutLog(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
 	}
 
+	h.attachPersonalRecords(c.Request().Context(), userID, log)
+
 	// Send success toast
 	c.Response().Header().Set("HX-Trigger", `{"showToast":{"code":"workout_saved","type":"success"}}`)
 
 	// Get workouts for select options
-	workouts, _ := h.DB.GetWorkoutsByUserID(c.Request().Context(), userID)
+	workouts, _ := h.DB.GetWorkoutsForDay(c.Request().Context(), userID, date)
 
 	return Render(c, http.StatusOK, partials.WorkoutSection(workouts, log, date))
 }
@@ -92,7 +105,22 @@ func (h *Handler) WorkoutsPage(c echo.Context) error {
 
 	workouts, _ := h.DB.GetWorkoutsByUserID(c.Request().Context(), userID)
 
-	return Render(c, http.StatusOK, pages.WorkoutsPage(user, workouts))
+	// Strength progress for the selected (or most recently logged) exercise
+	today := GetKuwaitDate(GetKuwaitTime())
+	logs, lib, err := h.loadStrengthLogs(c.Request().Context(), userID, today)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "حدث خطأ")
+	}
+	exerciseNames := strength.ExerciseNames(logs)
+	var progress *strength.Progress
+	if exercise := c.QueryParam("exercise"); exercise != "" {
+		progress = strength.ExerciseProgress(logs, lib.CanonicalName(exercise))
+	} else if len(exerciseNames) > 0 {
+		progress = strength.ExerciseProgress(logs, exerciseNames[0])
+	}
+	muscleWeeks := strength.MuscleGroupSets(logs, lib, 4, today)
+
+	return Render(c, http.StatusOK, pages.WorkoutsPage(user, workouts, exerciseNames, progress, lib, muscleWeeks))
 }
 
 // CreateWorkout creates a new workout
@@ -113,19 +141,12 @@ func (h *Handler) CreateWorkout(c echo.Context) error {
 		day = "N/A"
 	}
 
-	// Parse exercises from form
-	var exercises []database.Exercise
-	for i := 1; i <= 50; i++ {
-		exName := c.FormValue("exercise_" + strconv.Itoa(i))
-		if exName != "" {
-			exercises = append(exercises, database.Exercise{
-				Order: i,
-				Name:  exName,
-			})
-		}
+	exercises, err := parseExerciseForm(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "قيمة التمرين المستهدفة غير صالحة"})
 	}
 
-	_, err := h.DB.CreateWorkout(c.Request().Context(), userID, name, day, exercises)
+	_, err = h.DB.CreateWorkout(c.Request().Context(), userID, name, day, exercises)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "حدث خطأ: " + err.Error()})
 	}
@@ -161,16 +182,9 @@ func (h *Handler) UpdateWorkout(c echo.Context) error {
 		day = "N/A"
 	}
 
-	// Parse exercises from form
-	var exercises []database.Exercise
-	for i := 1; i <= 50; i++ {
-		exName := c.FormValue("exercise_" + strconv.Itoa(i))
-		if exName != "" {
-			exercises = append(exercises, database.Exercise{
-				Order: i,
-				Name:  strings.TrimSpace(exName),
-			})
-		}
+	exercises, err := parseExerciseForm(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "قيمة التمرين المستهدفة غير صالحة"})
 	}
 
 	if err := h.DB.UpdateWorkout(c.Request().Context(), workoutID, name, day, exercises); err != nil {
@@ -244,3 +258,38 @@ func (h *Handler) ReorderWorkouts(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// parseExerciseForm reads exercise_N fields plus their optional
+// target_sets_N, target_reps_N and target_weight_N from a workout form.
+// Targets that are present must be finite and not negative.
+func parseExerciseForm(c echo.Context) ([]database.Exercise, error) {
+	var exercises []database.Exercise
+	for i := 1; i <= 50; i++ {
+		n := strconv.Itoa(i)
+		exName := strings.TrimSpace(c.FormValue("exercise_" + n))
+		if exName == "" {
+			continue
+		}
+		targetSets, _ := strconv.Atoi(c.FormValue("target_sets_" + n))
+		targetReps, _ := strconv.Atoi(c.FormValue("target_reps_" + n))
+		var targetWeight float64
+		if v := strings.TrimSpace(c.FormValue("target_weight_" + n)); v != "" {
+			w, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+				return nil, fmt.Errorf("invalid target weight %q for exercise %d", v, i)
+			}
+			targetWeight = w
+		}
+		if targetSets < 0 || targetReps < 0 {
+			return nil, fmt.Errorf("negative target for exercise %d", i)
+		}
+		exercises = append(exercises, database.Exercise{
+			Order:        i,
+			Name:         exName,
+			TargetSets:   targetSets,
+			TargetReps:   targetReps,
+			TargetWeight: targetWeight,
+		})
+	}
+	return exercises, nil
+}
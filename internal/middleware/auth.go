@@ -135,12 +135,18 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-// OptionalAuth middleware extracts user info if available but doesn't require it
+// OptionalAuth middleware extracts user info from the cookie or Bearer token if available but doesn't require it
 func (m *AuthMiddleware) OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		cookie, err := c.Cookie("token")
-		if err == nil {
-			claims, err := m.ValidateToken(cookie.Value)
+		var tokenString string
+		if cookie, err := c.Cookie("token"); err == nil {
+			tokenString = cookie.Value
+		} else if auth := c.Request().Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			tokenString = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if tokenString != "" {
+			claims, err := m.ValidateToken(tokenString)
 			if err == nil {
 				c.Set("userID", claims.UserID)
 				c.Set("email", claims.Email)
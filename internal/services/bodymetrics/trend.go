@@ -0,0 +1,130 @@
+package bodymetrics
+
+import (
+	"math"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+// Smoothing is the per-day weight of a new reading in the exponential moving
+// average (the 10% used by The Hacker's Diet)
+const Smoothing = 0.1
+
+// RateWindowDays is how far back the weekly rate of change looks
+const RateWindowDays = 28
+
+// MaxProjectionDays caps goal projections; anything further out isn't meaningful
+const MaxProjectionDays = 730
+
+// Point is one weigh-in with its smoothed trend value (kg)
+type Point struct {
+	Date   time.Time `json:"date"`
+	Weight float64   `json:"weight"`
+	Trend  float64   `json:"trend"`
+}
+
+// Summary is the weight trend analysis for a period
+type Summary struct {
+	Points        []Point    `json:"points"`
+	Latest        *float64   `json:"latest,omitempty"`      // Last raw weight
+	Trend         *float64   `json:"trend,omitempty"`       // Last trend value
+	WeeklyRate    *float64   `json:"weekly_rate,omitempty"` // kg per week, nil when there's too little data
+	GoalWeight    *float64   `json:"goal_weight,omitempty"`
+	GoalReached   bool       `json:"goal_reached"`
+	ProjectedDate *time.Time `json:"projected_date,omitempty"` // When the trend reaches the goal at the current rate
+}
+
+// Analyze smooths the weigh-ins (oldest first) and projects when the goal will be reached
+func Analyze(metrics []database.BodyMetric, goal *float64) *Summary {
+	summary := &Summary{Points: []Point{}, GoalWeight: goal}
+
+	var trend float64
+	for _, m := range metrics {
+		if m.WeightKg == nil || *m.WeightKg <= 0 {
+			continue
+		}
+		w := *m.WeightKg
+		if len(summary.Points) == 0 {
+			trend = w
+		} else {
+			// Gaps count as several days of smoothing so a reading after a
+			// week away moves the trend as much as seven daily readings would
+			prev := summary.Points[len(summary.Points)-1]
+			days := math.Max(m.Date.Sub(prev.Date).Hours()/24, 1)
+			alpha := 1 - math.Pow(1-Smoothing, days)
+			trend += alpha * (w - trend)
+		}
+		summary.Points = append(summary.Points, Point{Date: m.Date, Weight: w, Trend: round(trend)})
+	}
+
+	if len(summary.Points) == 0 {
+		return summary
+	}
+	last := summary.Points[len(summary.Points)-1]
+	summary.Latest = &last.Weight
+	summary.Trend = &last.Trend
+
+	if rate, ok := weeklyRate(summary.Points); ok {
+		summary.WeeklyRate = &rate
+	}
+
+	if goal != nil {
+		remaining := *goal - last.Trend
+		switch {
+		case math.Abs(remaining) < 0.1:
+			summary.GoalReached = true
+		case summary.WeeklyRate != nil && *summary.WeeklyRate != 0 && math.Signbit(remaining) == math.Signbit(*summary.WeeklyRate):
+			days := remaining / (*summary.WeeklyRate / 7)
+			if days <= MaxProjectionDays {
+				projected := last.Date.AddDate(0, 0, int(math.Ceil(days)))
+				summary.ProjectedDate = &projected
+			}
+		}
+	}
+
+	return summary
+}
+
+// weeklyRate fits a line through the trend over the last RateWindowDays and
+// returns its slope in kg per week. It needs at least a week of data.
+func weeklyRate(points []Point) (float64, bool) {
+	last := points[len(points)-1].Date
+	cutoff := last.AddDate(0, 0, -RateWindowDays)
+
+	var xs, ys []float64
+	for _, p := range points {
+		if p.Date.Before(cutoff) {
+			continue
+		}
+		xs = append(xs, p.Date.Sub(cutoff).Hours()/24)
+		ys = append(ys, p.Trend)
+	}
+	if len(xs) < 2 || xs[len(xs)-1]-xs[0] < 7 {
+		return 0, false
+	}
+
+	n := float64(len(xs))
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if varX == 0 {
+		return 0, false
+	}
+
+	return round(cov / varX * 7), true
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,151 @@
+package bodymetrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+var day0 = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ptr(v float64) *float64 { return &v }
+
+// weighIn builds a reading n days after day0; a weight of 0 means none was logged
+func weighIn(n int, kg float64) database.BodyMetric {
+	m := database.BodyMetric{Date: day0.AddDate(0, 0, n)}
+	if kg != 0 {
+		m.WeightKg = &kg
+	}
+	return m
+}
+
+func TestAnalyzeTrend(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []database.BodyMetric
+		want    []float64
+	}{
+		{"empty", nil, []float64{}},
+		{"first reading seeds the trend", []database.BodyMetric{weighIn(0, 80)}, []float64{80}},
+		{"one day moves it by 10%", []database.BodyMetric{weighIn(0, 80), weighIn(1, 90)}, []float64{80, 81}},
+		{"two steps", []database.BodyMetric{weighIn(0, 80), weighIn(1, 90), weighIn(2, 90)}, []float64{80, 81, 81.9}},
+		// 1 - 0.9^7 = 0.5217031
+		{"a week's gap counts as seven days", []database.BodyMetric{weighIn(0, 80), weighIn(7, 90)}, []float64{80, 85.22}},
+		{"same-day readings count as one day", []database.BodyMetric{weighIn(0, 80), weighIn(0, 90)}, []float64{80, 81}},
+		{"missing weights are skipped", []database.BodyMetric{weighIn(0, 0), weighIn(1, 70), weighIn(2, 0), weighIn(3, 80)}, []float64{70, 71.9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Analyze(tt.metrics, nil)
+			if len(s.Points) != len(tt.want) {
+				t.Fatalf("got %d points, want %d", len(s.Points), len(tt.want))
+			}
+			for i, p := range s.Points {
+				if p.Trend != tt.want[i] {
+					t.Errorf("point %d trend = %v, want %v", i, p.Trend, tt.want[i])
+				}
+			}
+			if len(tt.want) == 0 {
+				if s.Latest != nil || s.Trend != nil {
+					t.Errorf("latest/trend set with no readings")
+				}
+				return
+			}
+			if *s.Trend != tt.want[len(tt.want)-1] {
+				t.Errorf("summary trend = %v, want %v", *s.Trend, tt.want[len(tt.want)-1])
+			}
+		})
+	}
+}
+
+func TestWeeklyRate(t *testing.T) {
+	line := func(days int, perDay float64) []Point {
+		var pts []Point
+		for d := range days {
+			pts = append(pts, Point{Date: day0.AddDate(0, 0, d), Trend: 100 + perDay*float64(d)})
+		}
+		return pts
+	}
+	// Rising fast until day 11, which is exactly 28 days before the last point
+	rampThenFlat := line(40, 0)
+	for d := range 11 {
+		rampThenFlat[d].Trend = 100 - float64(11-d)
+	}
+
+	tests := []struct {
+		name   string
+		points []Point
+		want   float64
+		ok     bool
+	}{
+		{"losing 0.1 kg a day", line(29, -0.1), -0.7, true},
+		{"gaining 0.05 kg a day", line(15, 0.05), 0.35, true},
+		{"only the last four weeks count", rampThenFlat, 0, true},
+		{"flat", line(20, 0), 0, true},
+		{"under a week", line(7, -0.1), 0, false},
+		{"single point", line(1, 0), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := weeklyRate(tt.points)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("weeklyRate = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAnalyzeGoal(t *testing.T) {
+	// 100 kg falling by 0.1 kg a day for 60 days: after the first few weeks
+	// the trend runs 0.9 kg above the readings and falls at 0.7 kg a week
+	var falling []database.BodyMetric
+	for d := range 60 {
+		falling = append(falling, weighIn(d, 100-0.1*float64(d)))
+	}
+	steady := []database.BodyMetric{weighIn(0, 75), weighIn(5, 75), weighIn(10, 75)}
+
+	t.Run("projection", func(t *testing.T) {
+		s := Analyze(falling, ptr(90))
+		if s.WeeklyRate == nil {
+			t.Fatal("no weekly rate")
+		}
+		if math.Abs(*s.WeeklyRate+0.7) > 0.02 {
+			t.Errorf("weekly rate = %v, want about -0.7", *s.WeeklyRate)
+		}
+		if math.Abs(*s.Trend-95) > 0.05 {
+			t.Errorf("trend = %v, want about 95", *s.Trend)
+		}
+		if s.ProjectedDate == nil {
+			t.Fatal("no projected date")
+		}
+		// About 5 kg to go at 0.1 kg a day
+		last := day0.AddDate(0, 0, 59)
+		if days := s.ProjectedDate.Sub(last).Hours() / 24; days < 49 || days > 51 {
+			t.Errorf("projected %v, %v days after the last reading; want about 50", s.ProjectedDate, days)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		metrics   []database.BodyMetric
+		goal      float64
+		reached   bool
+		projected bool
+	}{
+		{"goal in the wrong direction", falling, 100, false, false},
+		{"goal too far away", falling, 10, false, false},
+		{"goal reached", steady, 75.05, true, false},
+		{"flat trend never arrives", steady, 70, false, false},
+		{"too little data for a rate", []database.BodyMetric{weighIn(0, 80), weighIn(2, 79)}, 70, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Analyze(tt.metrics, ptr(tt.goal))
+			if s.GoalReached != tt.reached || (s.ProjectedDate != nil) != tt.projected {
+				t.Errorf("reached = %v, projected = %v; want %v, %v", s.GoalReached, s.ProjectedDate, tt.reached, tt.projected)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package cardio
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+// Supported file formats
+const (
+	FormatGPX = "gpx"
+	FormatTCX = "tcx"
+	FormatFIT = "fit"
+)
+
+// MaxFileSize is the largest track file accepted for import
+const MaxFileSize = 20 << 20 // 20MB
+
+// ErrUnsupportedFormat is returned for files that aren't GPX, TCX or FIT
+var ErrUnsupportedFormat = errors.New("unsupported track file format")
+
+// ErrNoTrackpoints is returned when a file parses but has nothing to summarize
+var ErrNoTrackpoints = errors.New("track file has no trackpoints")
+
+// Trackpoint is one recorded sample. Lat/Lon are nil for indoor activities.
+type Trackpoint struct {
+	Time      time.Time
+	Lat, Lon  *float64
+	Elevation *float64 // meters
+	HeartRate int      // bpm, 0 = not recorded
+	Distance  *float64 // meters from the start, when the device recorded it
+}
+
+// Activity is a parsed track file. Totals reported by the device (TCX laps,
+// FIT sessions) take precedence over values computed from trackpoints.
+type Activity struct {
+	Name     string
+	Sport    string
+	Points   []Trackpoint
+	Calories int
+
+	// Device-reported totals, zero when absent
+	TotalSeconds float64
+	TotalMeters  float64
+	AvgHeartRate int
+	MaxHeartRate int
+	AscentMeters float64
+}
+
+// Parse reads a GPX, TCX or FIT file, detecting the format from the
+// filename and falling back to sniffing the content
+func Parse(filename string, data []byte) (*Activity, string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	switch format {
+	case FormatGPX, FormatTCX, FormatFIT:
+	default:
+		format = sniff(data)
+	}
+
+	var activity *Activity
+	var err error
+	switch format {
+	case FormatGPX:
+		activity, err = parseGPX(data)
+	case FormatTCX:
+		activity, err = parseTCX(data)
+	case FormatFIT:
+		activity, err = parseFIT(data)
+	default:
+		return nil, "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, format, err
+	}
+	if len(activity.Points) == 0 && activity.TotalSeconds == 0 {
+		return nil, format, ErrNoTrackpoints
+	}
+	return activity, format, nil
+}
+
+func sniff(data []byte) string {
+	if len(data) >= 12 && string(data[8:12]) == ".FIT" {
+		return FormatFIT
+	}
+	head := data[:min(len(data), 1024)]
+	switch {
+	case bytes.Contains(head, []byte("<gpx")):
+		return FormatGPX
+	case bytes.Contains(head, []byte("<TrainingCenterDatabase")):
+		return FormatTCX
+	}
+	return ""
+}
+
+// ToCardio summarizes an activity into a cardio log entry
+func (a *Activity) ToCardio(format string) database.Cardio {
+	c := database.Cardio{
+		Name:           a.Name,
+		Source:         format,
+		Calories:       a.Calories,
+		AvgHeartRate:   a.AvgHeartRate,
+		MaxHeartRate:   a.MaxHeartRate,
+		ElevationGainM: round(a.AscentMeters, 1),
+	}
+	if c.Name == "" {
+		c.Name = sportName(a.Sport)
+	}
+
+	if len(a.Points) > 0 {
+		start := a.Points[0].Time
+		if !start.IsZero() {
+			c.StartedAt = &start
+		}
+	}
+
+	seconds := a.TotalSeconds
+	if seconds == 0 && len(a.Points) > 1 {
+		seconds = a.Points[len(a.Points)-1].Time.Sub(a.Points[0].Time).Seconds()
+	}
+	meters := a.TotalMeters
+	if meters == 0 {
+		meters = trackDistance(a.Points)
+	}
+	if c.ElevationGainM == 0 {
+		c.ElevationGainM = round(elevationGain(a.Points), 1)
+	}
+	if c.AvgHeartRate == 0 || c.MaxHeartRate == 0 {
+		avg, peak := heartRate(a.Points)
+		if c.AvgHeartRate == 0 {
+			c.AvgHeartRate = avg
+		}
+		if c.MaxHeartRate == 0 {
+			c.MaxHeartRate = peak
+		}
+	}
+
+	c.DurationSeconds = int(math.Round(seconds))
+	c.Minutes = int(math.Round(seconds / 60))
+	c.DistanceKm = round(meters/1000, 2)
+	return c
+}
+
+// trackDistance prefers the device's cumulative distance and otherwise sums
+// great-circle distances between consecutive GPS points
+func trackDistance(points []Trackpoint) float64 {
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].Distance != nil {
+			return *points[i].Distance
+		}
+	}
+
+	var total float64
+	var prev *Trackpoint
+	for i := range points {
+		p := &points[i]
+		if p.Lat == nil || p.Lon == nil {
+			continue
+		}
+		if prev != nil {
+			total += haversine(*prev.Lat, *prev.Lon, *p.Lat, *p.Lon)
+		}
+		prev = p
+	}
+	return total
+}
+
+// elevationGain sums climbs, ignoring changes under 1m to filter GPS altitude noise
+func elevationGain(points []Trackpoint) float64 {
+	const threshold = 1.0
+
+	var gain float64
+	var ref *float64
+	for _, p := range points {
+		if p.Elevation == nil {
+			continue
+		}
+		if ref == nil {
+			e := *p.Elevation
+			ref = &e
+			continue
+		}
+		diff := *p.Elevation - *ref
+		if math.Abs(diff) >= threshold {
+			if diff > 0 {
+				gain += diff
+			}
+			*ref = *p.Elevation
+		}
+	}
+	return gain
+}
+
+func heartRate(points []Trackpoint) (avg, peak int) {
+	var sum, n int
+	for _, p := range points {
+		if p.HeartRate <= 0 {
+			continue
+		}
+		sum += p.HeartRate
+		n++
+		peak = max(peak, p.HeartRate)
+	}
+	if n > 0 {
+		avg = int(math.Round(float64(sum) / float64(n)))
+	}
+	return avg, peak
+}
+
+// haversine returns the distance in meters between two coordinates
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// sportName maps sport identifiers from GPX/TCX/FIT to an Arabic display name
+func sportName(sport string) string {
+	switch strings.ToLower(sport) {
+	case "running", "run":
+		return "جري"
+	case "walking", "walk", "hiking":
+		return "مشي"
+	case "biking", "cycling", "bike":
+		return "دراجة"
+	case "swimming", "swim":
+		return "سباحة"
+	case "rowing":
+		return "تجديف"
+	default:
+		return "كارديو"
+	}
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,168 @@
+package cardio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// The fixtures are small hand-made tracks:
+//   - run.gpx: four points 0.001° of latitude apart, 30s apart, with an
+//     elevation wobble under the 1m noise threshold
+//   - ride.tcx: two laps with device totals; the second lap's point has no position
+//   - walk.fit: three record messages (the last with a compressed timestamp
+//     header and an invalid heart rate), a big-endian session message and a
+//     file_id message the parser skips
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		points int
+		want   database.Cardio
+	}{
+		{
+			"run.gpx", FormatGPX, 4,
+			database.Cardio{
+				Name: "Morning Run", Source: FormatGPX, Minutes: 2, DurationSeconds: 90,
+				DistanceKm: 0.33, ElevationGainM: 5, AvgHeartRate: 130, MaxHeartRate: 140,
+				StartedAt: ptrTime(time.Date(2025, 3, 1, 5, 30, 0, 0, time.UTC)),
+			},
+		},
+		{
+			"ride.tcx", FormatTCX, 3,
+			database.Cardio{
+				Name: "دراجة", Source: FormatTCX, Minutes: 15, DurationSeconds: 900,
+				DistanceKm: 2.2, Calories: 120, ElevationGainM: 20, AvgHeartRate: 143, MaxHeartRate: 170,
+				StartedAt: ptrTime(time.Date(2025, 3, 2, 16, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			"walk.fit", FormatFIT, 3,
+			database.Cardio{
+				Name: "مشي", Source: FormatFIT, Minutes: 2, DurationSeconds: 125,
+				DistanceKm: 1, Calories: 95, ElevationGainM: 7, AvgHeartRate: 125, MaxHeartRate: 151,
+				StartedAt: ptrTime(time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data := readFixture(t, tt.file)
+			for _, name := range []string{tt.file, "upload.bin"} { // by extension, then by sniffing
+				activity, format, err := Parse(name, data)
+				if err != nil {
+					t.Fatalf("Parse(%q): %v", name, err)
+				}
+				if format != tt.format || len(activity.Points) != tt.points {
+					t.Fatalf("Parse(%q) = %s with %d points, want %s with %d", name, format, len(activity.Points), tt.format, tt.points)
+				}
+				got := activity.ToCardio(format)
+				if got.StartedAt == nil || !got.StartedAt.Equal(*tt.want.StartedAt) {
+					t.Errorf("started at %v, want %v", got.StartedAt, tt.want.StartedAt)
+				}
+				got.StartedAt = tt.want.StartedAt
+				if got != tt.want {
+					t.Errorf("ToCardio =\n%+v\nwant\n%+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFITRecords(t *testing.T) {
+	activity, err := parseFIT(readFixture(t, "walk.fit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	want := []struct {
+		offset    time.Duration
+		lat       float64
+		elevation float64
+		heartRate int
+		distance  float64
+	}{
+		{0, 24.7, 600, 100, 0},
+		{60 * time.Second, 24.705, 604, 150, 500},
+		{80 * time.Second, 24.71, 602, 0, 1000},
+	}
+	for i, w := range want {
+		p := activity.Points[i]
+		if !p.Time.Equal(start.Add(w.offset)) {
+			t.Errorf("point %d time = %v, want %v", i, p.Time, start.Add(w.offset))
+		}
+		if p.Lat == nil || p.Lon == nil || round(*p.Lat, 5) != w.lat || round(*p.Lon, 5) != 46.7 {
+			t.Errorf("point %d position = %v, %v", i, p.Lat, p.Lon)
+		}
+		if p.Elevation == nil || *p.Elevation != w.elevation {
+			t.Errorf("point %d elevation = %v, want %v", i, p.Elevation, w.elevation)
+		}
+		if p.HeartRate != w.heartRate {
+			t.Errorf("point %d heart rate = %d, want %d", i, p.HeartRate, w.heartRate)
+		}
+		if p.Distance == nil || *p.Distance != w.distance {
+			t.Errorf("point %d distance = %v, want %v", i, p.Distance, w.distance)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	fit := readFixture(t, "walk.fit")
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want error
+	}{
+		{"unknown format", "notes.txt", []byte("hello"), ErrUnsupportedFormat},
+		{"empty gpx", "empty.gpx", []byte(`<gpx><trk><name>x</name></trk></gpx>`), ErrNoTrackpoints},
+		{"truncated fit", "walk.fit", fit[:len(fit)/2], errInvalidFIT},
+		{"fit without signature", "walk.fit", fit[12:], errInvalidFIT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Parse(tt.file, tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Parse error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestElevationGain(t *testing.T) {
+	tests := []struct {
+		name       string
+		elevations []float64
+		want       float64
+	}{
+		{"steady climb", []float64{10, 12, 14}, 4},
+		{"noise is ignored", []float64{10, 10.4, 9.8, 10.5, 10.2}, 0},
+		{"small steps add up once past the threshold", []float64{10, 10.6, 11.2}, 1.2},
+		{"descents don't count", []float64{50, 40, 45}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var points []Trackpoint
+			for _, e := range tt.elevations {
+				points = append(points, Trackpoint{Elevation: &e})
+			}
+			if got := round(elevationGain(points), 2); got != tt.want {
+				t.Errorf("elevationGain = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
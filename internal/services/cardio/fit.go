@@ -0,0 +1,332 @@
+package cardio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// FIT global message numbers and field numbers used for the summary
+// (from the Garmin FIT SDK profile)
+const (
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+
+	fitFieldTimestamp = 253
+
+	fitRecordLat              = 0
+	fitRecordLon              = 1
+	fitRecordAltitude         = 2
+	fitRecordHeartRate        = 3
+	fitRecordDistance         = 5
+	fitRecordEnhancedAltitude = 78
+
+	fitSessionSport        = 5
+	fitSessionElapsedTime  = 7
+	fitSessionTimerTime    = 8
+	fitSessionDistance     = 9
+	fitSessionCalories     = 11
+	fitSessionAvgHeartRate = 16
+	fitSessionMaxHeartRate = 17
+	fitSessionTotalAscent  = 22
+)
+
+// fitEpoch is the FIT timestamp origin (UTC 00:00 Dec 31 1989)
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+var errInvalidFIT = errors.New("invalid FIT file")
+
+type fitField struct {
+	num      byte
+	size     int
+	baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	order     binary.ByteOrder
+	fields    []fitField
+	devFields int // Total bytes of developer fields, skipped
+}
+
+// parseFIT decodes record and session messages from a FIT activity file.
+// Only what's needed for a cardio summary is read; other messages are skipped.
+func parseFIT(data []byte) (*Activity, error) {
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return nil, errInvalidFIT
+	}
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if headerSize < 12 || end > len(data) {
+		return nil, errInvalidFIT
+	}
+
+	activity := &Activity{}
+	defs := map[byte]*fitDefinition{}
+	var lastTimestamp uint32
+	pos := headerSize
+
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		// Compressed timestamp header: a data message with a 5-bit time offset
+		if header&0x80 != 0 {
+			local := (header >> 5) & 0x03
+			offset := uint32(header & 0x1F)
+			ts := lastTimestamp&^0x1F + offset
+			if offset < lastTimestamp&0x1F {
+				ts += 0x20
+			}
+			lastTimestamp = ts
+			def, ok := defs[local]
+			if !ok {
+				return nil, errInvalidFIT
+			}
+			values, n, err := readFITData(data[pos:end], def)
+			if err != nil {
+				return nil, err
+			}
+			pos += n
+			values[fitFieldTimestamp] = float64(ts)
+			applyFITMessage(activity, def.global, values)
+			continue
+		}
+
+		local := header & 0x0F
+		if header&0x40 != 0 {
+			def, n, err := readFITDefinition(data[pos:end], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			pos += n
+			defs[local] = def
+			continue
+		}
+
+		def, ok := defs[local]
+		if !ok {
+			return nil, errInvalidFIT
+		}
+		values, n, err := readFITData(data[pos:end], def)
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		if ts, ok := values[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		}
+		applyFITMessage(activity, def.global, values)
+	}
+
+	return activity, nil
+}
+
+func readFITDefinition(buf []byte, hasDevFields bool) (*fitDefinition, int, error) {
+	if len(buf) < 5 {
+		return nil, 0, errInvalidFIT
+	}
+	def := &fitDefinition{order: binary.LittleEndian}
+	if buf[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(buf[2:4])
+	numFields := int(buf[4])
+	pos := 5
+
+	if len(buf) < pos+numFields*3 {
+		return nil, 0, errInvalidFIT
+	}
+	for i := 0; i < numFields; i++ {
+		def.fields = append(def.fields, fitField{num: buf[pos], size: int(buf[pos+1]), baseType: buf[pos+2]})
+		pos += 3
+	}
+
+	if hasDevFields {
+		if len(buf) < pos+1 {
+			return nil, 0, errInvalidFIT
+		}
+		numDev := int(buf[pos])
+		pos++
+		if len(buf) < pos+numDev*3 {
+			return nil, 0, errInvalidFIT
+		}
+		for i := 0; i < numDev; i++ {
+			def.devFields += int(buf[pos+1])
+			pos += 3
+		}
+	}
+
+	return def, pos, nil
+}
+
+// readFITData reads one data message, returning valid numeric fields by field number
+func readFITData(buf []byte, def *fitDefinition) (map[byte]float64, int, error) {
+	values := map[byte]float64{}
+	pos := 0
+	for _, f := range def.fields {
+		if len(buf) < pos+f.size {
+			return nil, 0, errInvalidFIT
+		}
+		if v, ok := fitValue(buf[pos:pos+f.size], f.baseType, def.order); ok {
+			values[f.num] = v
+		}
+		pos += f.size
+	}
+	if len(buf) < pos+def.devFields {
+		return nil, 0, errInvalidFIT
+	}
+	return values, pos + def.devFields, nil
+}
+
+// fitValue decodes a single numeric value, reporting false for the base type's
+// "invalid" sentinel, strings, byte arrays and arrays of values
+func fitValue(b []byte, baseType byte, order binary.ByteOrder) (float64, bool) {
+	switch baseType {
+	case 0x00, 0x02: // enum, uint8
+		if len(b) != 1 || b[0] == 0xFF {
+			return 0, false
+		}
+		return float64(b[0]), true
+	case 0x0A: // uint8z
+		if len(b) != 1 || b[0] == 0 {
+			return 0, false
+		}
+		return float64(b[0]), true
+	case 0x01: // sint8
+		if len(b) != 1 || b[0] == 0x7F {
+			return 0, false
+		}
+		return float64(int8(b[0])), true
+	case 0x84, 0x8B: // uint16, uint16z
+		if len(b) != 2 {
+			return 0, false
+		}
+		v := order.Uint16(b)
+		if (baseType == 0x84 && v == 0xFFFF) || (baseType == 0x8B && v == 0) {
+			return 0, false
+		}
+		return float64(v), true
+	case 0x83: // sint16
+		if len(b) != 2 {
+			return 0, false
+		}
+		v := int16(order.Uint16(b))
+		if v == math.MaxInt16 {
+			return 0, false
+		}
+		return float64(v), true
+	case 0x86, 0x8C: // uint32, uint32z
+		if len(b) != 4 {
+			return 0, false
+		}
+		v := order.Uint32(b)
+		if (baseType == 0x86 && v == 0xFFFFFFFF) || (baseType == 0x8C && v == 0) {
+			return 0, false
+		}
+		return float64(v), true
+	case 0x85: // sint32
+		if len(b) != 4 {
+			return 0, false
+		}
+		v := int32(order.Uint32(b))
+		if v == math.MaxInt32 {
+			return 0, false
+		}
+		return float64(v), true
+	case 0x88: // float32
+		if len(b) != 4 {
+			return 0, false
+		}
+		v := math.Float32frombits(order.Uint32(b))
+		if order.Uint32(b) == 0xFFFFFFFF {
+			return 0, false
+		}
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func applyFITMessage(activity *Activity, global uint16, v map[byte]float64) {
+	switch global {
+	case fitMesgRecord:
+		ts, ok := v[fitFieldTimestamp]
+		if !ok {
+			return
+		}
+		p := Trackpoint{Time: fitEpoch.Add(time.Duration(ts) * time.Second)}
+		lat, okLat := v[fitRecordLat]
+		lon, okLon := v[fitRecordLon]
+		if okLat && okLon {
+			lat, lon = semicirclesToDegrees(lat), semicirclesToDegrees(lon)
+			p.Lat, p.Lon = &lat, &lon
+		}
+		if alt, ok := v[fitRecordEnhancedAltitude]; ok {
+			e := alt/5 - 500
+			p.Elevation = &e
+		} else if alt, ok := v[fitRecordAltitude]; ok {
+			e := alt/5 - 500
+			p.Elevation = &e
+		}
+		if hr, ok := v[fitRecordHeartRate]; ok {
+			p.HeartRate = int(hr)
+		}
+		if d, ok := v[fitRecordDistance]; ok {
+			m := d / 100
+			p.Distance = &m
+		}
+		activity.Points = append(activity.Points, p)
+
+	case fitMesgSession:
+		// Multi-sport files have several sessions; add them up
+		if t, ok := v[fitSessionTimerTime]; ok {
+			activity.TotalSeconds += t / 1000
+		} else if t, ok := v[fitSessionElapsedTime]; ok {
+			activity.TotalSeconds += t / 1000
+		}
+		if d, ok := v[fitSessionDistance]; ok {
+			activity.TotalMeters += d / 100
+		}
+		if cal, ok := v[fitSessionCalories]; ok {
+			activity.Calories += int(cal)
+		}
+		if hr, ok := v[fitSessionAvgHeartRate]; ok && activity.AvgHeartRate == 0 {
+			activity.AvgHeartRate = int(hr)
+		}
+		if hr, ok := v[fitSessionMaxHeartRate]; ok {
+			activity.MaxHeartRate = max(activity.MaxHeartRate, int(hr))
+		}
+		if asc, ok := v[fitSessionTotalAscent]; ok {
+			activity.AscentMeters += asc
+		}
+		if sport, ok := v[fitSessionSport]; ok && activity.Sport == "" {
+			activity.Sport = fitSportName(int(sport))
+		}
+	}
+}
+
+func semicirclesToDegrees(s float64) float64 {
+	return s * 180 / math.Pow(2, 31)
+}
+
+// fitSportName maps the FIT sport enum to the names used by GPX/TCX
+func fitSportName(sport int) string {
+	switch sport {
+	case 1:
+		return "running"
+	case 2:
+		return "cycling"
+	case 5:
+		return "swimming"
+	case 11:
+		return "walking"
+	case 15:
+		return "rowing"
+	case 17:
+		return "hiking"
+	default:
+		return ""
+	}
+}
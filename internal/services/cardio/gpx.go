@@ -0,0 +1,57 @@
+package cardio
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type gpxFile struct {
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []struct {
+				Lat        float64  `xml:"lat,attr"`
+				Lon        float64  `xml:"lon,attr"`
+				Elevation  *float64 `xml:"ele"`
+				Time       string   `xml:"time"`
+				Extensions struct {
+					// Garmin TrackPointExtension (gpxtpx:hr); namespace-agnostic match
+					HeartRate int `xml:"TrackPointExtension>hr"`
+				} `xml:"extensions"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+func parseGPX(data []byte) (*Activity, error) {
+	var gpx gpxFile
+	if err := xml.Unmarshal(data, &gpx); err != nil {
+		return nil, err
+	}
+
+	activity := &Activity{}
+	for _, trk := range gpx.Tracks {
+		if activity.Name == "" {
+			activity.Name = trk.Name
+		}
+		if activity.Sport == "" {
+			activity.Sport = trk.Type
+		}
+		for _, seg := range trk.Segments {
+			for _, pt := range seg.Points {
+				lat, lon := pt.Lat, pt.Lon
+				t, _ := time.Parse(time.RFC3339, pt.Time)
+				activity.Points = append(activity.Points, Trackpoint{
+					Time:      t,
+					Lat:       &lat,
+					Lon:       &lon,
+					Elevation: pt.Elevation,
+					HeartRate: pt.Extensions.HeartRate,
+				})
+			}
+		}
+	}
+
+	return activity, nil
+}
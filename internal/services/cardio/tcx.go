@@ -0,0 +1,68 @@
+package cardio
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type tcxFile struct {
+	Activities []struct {
+		Sport string `xml:"Sport,attr"`
+		Laps  []struct {
+			TotalTimeSeconds float64 `xml:"TotalTimeSeconds"`
+			DistanceMeters   float64 `xml:"DistanceMeters"`
+			Calories         int     `xml:"Calories"`
+			AverageHeartRate int     `xml:"AverageHeartRateBpm>Value"`
+			MaximumHeartRate int     `xml:"MaximumHeartRateBpm>Value"`
+			Trackpoints      []struct {
+				Time      string   `xml:"Time"`
+				Lat       *float64 `xml:"Position>LatitudeDegrees"`
+				Lon       *float64 `xml:"Position>LongitudeDegrees"`
+				Altitude  *float64 `xml:"AltitudeMeters"`
+				Distance  *float64 `xml:"DistanceMeters"`
+				HeartRate int      `xml:"HeartRateBpm>Value"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+func parseTCX(data []byte) (*Activity, error) {
+	var tcx tcxFile
+	if err := xml.Unmarshal(data, &tcx); err != nil {
+		return nil, err
+	}
+
+	activity := &Activity{}
+	var hrWeighted float64
+	for _, act := range tcx.Activities {
+		if activity.Sport == "" {
+			activity.Sport = act.Sport
+		}
+		for _, lap := range act.Laps {
+			activity.TotalSeconds += lap.TotalTimeSeconds
+			activity.TotalMeters += lap.DistanceMeters
+			activity.Calories += lap.Calories
+			activity.MaxHeartRate = max(activity.MaxHeartRate, lap.MaximumHeartRate)
+			hrWeighted += float64(lap.AverageHeartRate) * lap.TotalTimeSeconds
+
+			for _, tp := range lap.Trackpoints {
+				t, _ := time.Parse(time.RFC3339, tp.Time)
+				activity.Points = append(activity.Points, Trackpoint{
+					Time:      t,
+					Lat:       tp.Lat,
+					Lon:       tp.Lon,
+					Elevation: tp.Altitude,
+					Distance:  tp.Distance,
+					HeartRate: tp.HeartRate,
+				})
+			}
+		}
+	}
+
+	// Lap averages weighted by lap duration
+	if activity.TotalSeconds > 0 && hrWeighted > 0 {
+		activity.AvgHeartRate = int(hrWeighted/activity.TotalSeconds + 0.5)
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,116 @@
+package diff
+
+import "strings"
+
+// Line operations
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxCells caps the LCS table size; larger inputs are shown as a full replacement
+const maxCells = 4_000_000
+
+// Line is a single line of a line-based diff
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns a line-based diff turning a into b (longest common subsequence)
+func Lines(a, b string) []Line {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	// Trim the common prefix and suffix so the table only covers the changed middle
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	var result []Line
+	for _, l := range x[:prefix] {
+		result = append(result, Line{Op: OpEqual, Text: l})
+	}
+	result = append(result, middle(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, l := range x[len(x)-suffix:] {
+		result = append(result, Line{Op: OpEqual, Text: l})
+	}
+	return result
+}
+
+// Stats returns the number of inserted and deleted lines
+func Stats(lines []Line) (inserted, deleted int) {
+	for _, l := range lines {
+		switch l.Op {
+		case OpInsert:
+			inserted++
+		case OpDelete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+func middle(x, y []string) []Line {
+	var result []Line
+	if len(x)*len(y) > maxCells {
+		for _, l := range x {
+			result = append(result, Line{Op: OpDelete, Text: l})
+		}
+		for _, l := range y {
+			result = append(result, Line{Op: OpInsert, Text: l})
+		}
+		return result
+	}
+
+	// lcs[i][j] = length of the LCS of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			result = append(result, Line{Op: OpEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Op: OpDelete, Text: x[i]})
+			i++
+		default:
+			result = append(result, Line{Op: OpInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		result = append(result, Line{Op: OpDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		result = append(result, Line{Op: OpInsert, Text: y[j]})
+	}
+	return result
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
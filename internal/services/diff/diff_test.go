@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	eq := func(s string) Line { return Line{Op: OpEqual, Text: s} }
+	ins := func(s string) Line { return Line{Op: OpInsert, Text: s} }
+	del := func(s string) Line { return Line{Op: OpDelete, Text: s} }
+
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"identical", "a\nb\nc", "a\nb\nc", []Line{eq("a"), eq("b"), eq("c")}},
+		{"both empty", "", "", nil},
+		{"pure insert", "a\nc", "a\nb\nc", []Line{eq("a"), ins("b"), eq("c")}},
+		{"insert into empty", "", "a\nb", []Line{ins("a"), ins("b")}},
+		{"pure delete", "a\nb\nc", "a\nc", []Line{eq("a"), del("b"), eq("c")}},
+		{"delete everything", "a\nb", "", []Line{del("a"), del("b")}},
+		{"replace", "a\nb\nc", "a\nx\nc", []Line{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"trailing newline added", "a\nb", "a\nb\n", []Line{eq("a"), eq("b"), ins("")}},
+		{"trailing newline removed", "a\nb\n", "a\nb", []Line{eq("a"), eq("b"), del("")}},
+		{"CRLF matches LF", "a\r\nb", "a\nb", []Line{eq("a"), eq("b")}},
+		{"moved line", "a\nb\nc", "b\nc\na", []Line{del("a"), eq("b"), eq("c"), ins("a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesOverMaxCells(t *testing.T) {
+	// Past maxCells the changed middle is shown as a full replacement, while
+	// the common prefix and suffix still match
+	n := 2001
+	x, y := make([]string, n), make([]string, n)
+	for i := range n {
+		x[i] = "old " + strconv.Itoa(i)
+		y[i] = "new " + strconv.Itoa(i)
+	}
+	a := "title\n" + strings.Join(x, "\n") + "\nend"
+	b := "title\n" + strings.Join(y, "\n") + "\nend"
+
+	got := Lines(a, b)
+	if len(got) != 2*n+2 {
+		t.Fatalf("Lines returned %d lines, want %d", len(got), 2*n+2)
+	}
+	if got[0] != (Line{Op: OpEqual, Text: "title"}) || got[len(got)-1] != (Line{Op: OpEqual, Text: "end"}) {
+		t.Errorf("Lines lost the common prefix or suffix: %v ... %v", got[0], got[len(got)-1])
+	}
+	for i, l := range got[1 : n+1] {
+		if l != (Line{Op: OpDelete, Text: x[i]}) {
+			t.Fatalf("line %d = %v, want delete of %q", i+1, l, x[i])
+		}
+	}
+	for i, l := range got[n+1 : 2*n+1] {
+		if l != (Line{Op: OpInsert, Text: y[i]}) {
+			t.Fatalf("line %d = %v, want insert of %q", n+i+1, l, y[i])
+		}
+	}
+	if ins, del := Stats(got); ins != n || del != n {
+		t.Errorf("Stats = %d inserted, %d deleted, want %d each", ins, del, n)
+	}
+}
@@ -0,0 +1,216 @@
+// Package hijri converts between Gregorian and Hijri (Umm al-Qura) dates.
+//
+// Dates from 1356 to 1500 AH (14 March 1937 to 16 November 2077) come from
+// the published Umm al-Qura calendar of Saudi Arabia, as tabulated by R. H.
+// van Gent (https://webspace.science.uu.nl/~gent0113/islam/ummalqura.htm).
+// Months the Supreme Court started on a different day after a sighting are
+// listed in announcedStarts. Dates outside the table use the arithmetic
+// (tabular) Islamic calendar, which can be a day or two off.
+package hijri
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Date is a Hijri calendar date
+type Date struct {
+	Year  int `json:"year"`
+	Month int `json:"month"` // 1 (Muharram) - 12 (Dhu al-Hijjah)
+	Day   int `json:"day"`
+}
+
+// MonthNames are the Arabic Hijri month names, Muharram first
+var MonthNames = [12]string{
+	"محرم", "صفر", "ربيع الأول", "ربيع الآخر", "جمادى الأولى", "جمادى الآخرة",
+	"رجب", "شعبان", "رمضان", "شوال", "ذو القعدة", "ذو الحجة",
+}
+
+// FromTime returns the Hijri date of t's calendar day (in t's location)
+func FromTime(t time.Time) Date {
+	day := civilDate(t)
+
+	var idx int
+	if first, end := tableStart(0), tableStart(len(tableDays)-1); !day.Before(first) && day.Before(end) {
+		days := daysSince(first, day)
+		idx = tableFirst + sort.Search(len(tableDays), func(i int) bool { return tableDays[i] > days }) - 1
+	} else {
+		// Estimate from the mean month, then step until day falls inside it
+		idx = int(float64(daysSince(tabularEpoch, day))/29.530588) - 1
+		for !monthStart(idx + 1).After(day) {
+			idx++
+		}
+		for monthStart(idx).After(day) {
+			idx--
+		}
+	}
+
+	return Date{
+		Year:  idx/12 + 1,
+		Month: idx%12 + 1,
+		Day:   daysSince(monthStart(idx), day) + 1,
+	}
+}
+
+// Time returns the Gregorian date (UTC midnight) of d. A day past the end of
+// its month (e.g. the 30th of a 29-day month) falls on the month's last day.
+func (d Date) Time() time.Time {
+	day := min(max(d.Day, 1), MonthLength(d.Year, d.Month))
+	return monthStart(monthIndex(d.Year, d.Month)).AddDate(0, 0, day-1)
+}
+
+// MonthLength returns the number of days (29 or 30) in a Hijri month
+func MonthLength(year, month int) int {
+	idx := monthIndex(year, month)
+	return daysSince(monthStart(idx), monthStart(idx+1))
+}
+
+// Valid reports whether d names an existing day
+func (d Date) Valid() bool {
+	return d.Year > 0 && d.Month >= 1 && d.Month <= 12 && d.Day >= 1 && d.Day <= MonthLength(d.Year, d.Month)
+}
+
+// MonthName returns the Arabic name of d's month
+func (d Date) MonthName() string {
+	if d.Month < 1 || d.Month > 12 {
+		return ""
+	}
+	return MonthNames[d.Month-1]
+}
+
+// String formats d in Arabic, e.g. "15 رمضان 1447هـ"
+func (d Date) String() string {
+	return fmt.Sprintf("%d %s %dهـ", d.Day, d.MonthName(), d.Year)
+}
+
+// DayMonth formats d without the year, e.g. "15 رمضان"
+func (d Date) DayMonth() string {
+	return fmt.Sprintf("%d %s", d.Day, d.MonthName())
+}
+
+// AddYears returns the same day and month `years` Hijri years later
+func (d Date) AddYears(years int) Date {
+	d.Year += years
+	return d
+}
+
+// monthIndex counts months since 1 Muharram 1 AH
+func monthIndex(year, month int) int {
+	return (year-1)*12 + month - 1
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysSince counts the days from a to b (both UTC midnights)
+func daysSince(a, b time.Time) int {
+	// Not b.Sub(a): a Duration can't span the centuries since the epoch
+	return int((b.Unix() - a.Unix()) / 86400)
+}
+
+// monthStart returns the first day (UTC midnight) of the month with index idx
+func monthStart(idx int) time.Time {
+	if i := idx - tableFirst; i >= 0 && i < len(tableDays) {
+		return tableStart(i)
+	}
+	return tabularStart(idx)
+}
+
+// ========== UMM AL-QURA TABLE ==========
+
+const (
+	tableFirstYear = 1356
+	tableLastYear  = 1500
+)
+
+// tableEpoch is 1 Muharram 1356
+var tableEpoch = time.Date(1937, 3, 14, 0, 0, 0, 0, time.UTC)
+
+// ummAlQuraMonths holds the month lengths of each year from tableFirstYear:
+// bit m-1 is set when month m has 30 days, clear when it has 29
+var ummAlQuraMonths = [tableLastYear - tableFirstYear + 1]uint16{
+	0x4d4, 0xd55, 0x64b, 0x497, 0xd55, 0x555, 0x555, 0xd55, // 1356
+	0x755, 0xd55, 0x555, 0x555, 0xd55, 0x6d5, 0x555, 0xea5, // 1364
+	0xd2a, 0xaaa, 0xcd5, 0x655, 0x572, 0xda9, 0x555, 0xaaa, // 1372
+	0x555, 0x52d, 0xa6d, 0x55a, 0x555, 0x74d, 0xd53, 0xd54, // 1380
+	0x556, 0xd55, 0x2d5, 0xd55, 0xd54, 0xd45, 0x655, 0x52d, // 1388
+	0xa5d, 0x55a, 0xad5, 0x6aa, 0xd4b, 0x52a, 0xa57, 0x4ae, // 1396
+	0x976, 0x56c, 0xb55, 0xaaa, 0xa55, 0x4ad, 0x95d, 0x2da, // 1404
+	0x5d9, 0xdb2, 0xba4, 0xb4a, 0xa55, 0x2b5, 0x575, 0xb6a, // 1412
+	0xbd2, 0xbc4, 0xb89, 0xa95, 0x52d, 0x5ad, 0xb6a, 0x6d4, // 1420
+	0xdc9, 0xd92, 0xaa6, 0x956, 0x2ae, 0x56d, 0x36a, 0xb55, // 1428
+	0xaaa, 0x94d, 0x49d, 0x95d, 0x2ba, 0x5b5, 0x5aa, 0xd55, // 1436
+	0xa9a, 0x92e, 0x26e, 0x55d, 0xada, 0x6d4, 0x6a5, 0x54b, // 1444
+	0xa97, 0x54e, 0xaae, 0x5ac, 0xba9, 0xd92, 0xb25, 0x64b, // 1452
+	0xcab, 0x55a, 0xb55, 0x6d2, 0xea5, 0xe4a, 0xa95, 0x52d, // 1460
+	0xaad, 0x36c, 0x759, 0x6d2, 0x695, 0x52d, 0xa5b, 0x4ba, // 1468
+	0x9ba, 0x3b4, 0xb69, 0xb52, 0xaa6, 0x4b6, 0x96d, 0x2ec, // 1476
+	0x6d9, 0xeb2, 0xd54, 0xd2a, 0xa56, 0x4ae, 0x96d, 0xd6a, // 1484
+	0xb54, 0xb29, 0xa93, 0x52b, 0xa57, 0x536, 0xab5, 0x6aa, // 1492
+	0xe93, // 1500
+}
+
+// irregularMonths are the table's months of other lengths: Sha'ban 1364 was
+// printed with 28 days
+var irregularMonths = map[int]int{
+	monthIndex(1364, 8): 28,
+}
+
+// announcedStarts are months whose first day the Supreme Court announced
+// after a sighting, differing from the printed calendar
+var announcedStarts = map[int]time.Time{
+	// The crescent wasn't sighted on 29 Dhu al-Qa'dah 1436, so the month was
+	// completed and Dhu al-Hijjah began on Tuesday 15 September 2015 (Arafat
+	// on the 23rd, Eid al-Adha on the 24th) instead of the 14th
+	monthIndex(1436, 12): time.Date(2015, 9, 15, 0, 0, 0, 0, time.UTC),
+}
+
+var tableFirst = monthIndex(tableFirstYear, 1)
+
+// tableDays are the first days of the table's months (and the month after
+// its last), in days since tableEpoch
+var tableDays = func() []int {
+	days := make([]int, 0, len(ummAlQuraMonths)*12+1)
+	day := 0
+	for y, mask := range ummAlQuraMonths {
+		for m := 0; m < 12; m++ {
+			idx := monthIndex(tableFirstYear+y, m+1)
+			if start, ok := announcedStarts[idx]; ok {
+				// Only this month moves; the next one starts as printed
+				days = append(days, daysSince(tableEpoch, start))
+			} else {
+				days = append(days, day)
+			}
+
+			length := 29
+			if mask&(1<<m) != 0 {
+				length = 30
+			}
+			if l, ok := irregularMonths[idx]; ok {
+				length = l
+			}
+			day += length
+		}
+	}
+	return append(days, day)
+}()
+
+func tableStart(i int) time.Time {
+	return tableEpoch.AddDate(0, 0, tableDays[i])
+}
+
+// ========== TABULAR CALENDAR ==========
+
+// tabularEpoch is 1 Muharram 1 AH (16 July 622 Julian) in the arithmetic calendar
+var tabularEpoch = time.Date(622, 7, 19, 0, 0, 0, 0, time.UTC)
+
+// tabularStart returns the first day of a month in the arithmetic Islamic
+// calendar: 30-year cycles with leap years 2, 5, 7, 10, 13, 16, 18, 21, 24,
+// 26 and 29, and months alternating between 30 and 29 days
+func tabularStart(idx int) time.Time {
+	year, month := idx/12+1, idx%12+1
+	days := (year-1)*354 + (3+11*year)/30 + 29*(month-1) + month/2
+	return tabularEpoch.AddDate(0, 0, days)
+}
@@ -0,0 +1,171 @@
+package hijri
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// First days of months from the printed Umm al-Qura calendar, and 1436-12
+// as announced after the crescent wasn't sighted
+var officialMonths = []struct {
+	hijri Date
+	start time.Time
+}{
+	{Date{1424, 9, 1}, day(2003, 10, 26)},
+	{Date{1425, 10, 1}, day(2004, 11, 14)},
+	{Date{1426, 12, 1}, day(2006, 1, 1)},
+	{Date{1427, 6, 1}, day(2006, 6, 27)},
+	{Date{1428, 9, 1}, day(2007, 9, 13)},
+	{Date{1429, 10, 1}, day(2008, 10, 1)},
+	{Date{1430, 12, 1}, day(2009, 11, 18)},
+	{Date{1432, 9, 1}, day(2011, 8, 1)},
+	{Date{1433, 10, 1}, day(2012, 8, 19)},
+	{Date{1434, 12, 1}, day(2013, 10, 6)},
+	{Date{1436, 9, 1}, day(2015, 6, 18)},
+	{Date{1436, 11, 1}, day(2015, 8, 16)},
+	{Date{1436, 12, 1}, day(2015, 9, 15)},
+	{Date{1437, 1, 1}, day(2015, 10, 14)},
+	{Date{1437, 9, 1}, day(2016, 6, 6)},
+	{Date{1437, 10, 1}, day(2016, 7, 6)},
+	{Date{1438, 12, 1}, day(2017, 8, 23)},
+	{Date{1440, 9, 1}, day(2019, 5, 6)},
+	{Date{1441, 9, 1}, day(2020, 4, 24)},
+	{Date{1441, 10, 1}, day(2020, 5, 24)},
+	{Date{1442, 12, 1}, day(2021, 7, 11)},
+	{Date{1444, 9, 1}, day(2023, 3, 23)},
+	{Date{1444, 10, 1}, day(2023, 4, 21)},
+	{Date{1445, 1, 1}, day(2023, 7, 19)},
+	{Date{1445, 9, 1}, day(2024, 3, 11)},
+	{Date{1445, 10, 1}, day(2024, 4, 10)},
+	{Date{1445, 12, 1}, day(2024, 6, 7)},
+	{Date{1446, 1, 1}, day(2024, 7, 7)},
+	{Date{1446, 6, 1}, day(2024, 12, 2)},
+	{Date{1446, 8, 1}, day(2025, 1, 31)},
+	{Date{1446, 9, 1}, day(2025, 3, 1)},
+	{Date{1446, 12, 1}, day(2025, 5, 28)},
+}
+
+func TestMonthStarts(t *testing.T) {
+	for _, tt := range officialMonths {
+		t.Run(tt.hijri.String(), func(t *testing.T) {
+			if got := FromTime(tt.start); got != tt.hijri {
+				t.Errorf("FromTime(%s) = %v, want %v", tt.start.Format(time.DateOnly), got, tt.hijri)
+			}
+			if got := FromTime(tt.start.AddDate(0, 0, -1)); got.Day < 29 || got.Month != (tt.hijri.Month+10)%12+1 {
+				t.Errorf("FromTime of the day before = %v, want the last day of the previous month", got)
+			}
+			if got := tt.hijri.Time(); !got.Equal(tt.start) {
+				t.Errorf("%v.Time() = %s, want %s", tt.hijri, got.Format(time.DateOnly), tt.start.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestFromTime(t *testing.T) {
+	riyadh := time.FixedZone("AST", 3*3600)
+	tests := []struct {
+		name string
+		t    time.Time
+		want Date
+	}{
+		{"Arafat 1436", day(2015, 9, 23), Date{1436, 12, 9}},
+		{"Eid al-Adha 1436", day(2015, 9, 24), Date{1436, 12, 10}},
+		{"last day of Ramadan 1445", day(2024, 4, 9), Date{1445, 9, 30}},
+		{"time of day is ignored", time.Date(2025, 3, 1, 23, 59, 0, 0, time.UTC), Date{1446, 9, 1}},
+		{"the date is read in t's location", time.Date(2025, 3, 1, 1, 0, 0, 0, riyadh), Date{1446, 9, 1}},
+		{"first day of the table", day(1937, 3, 14), Date{1356, 1, 1}},
+		{"last day of the table", day(2077, 11, 16), Date{1500, 12, 30}},
+		{"tabular calendar before the table", day(1937, 3, 13), Date{1355, 12, 30}},
+		{"tabular calendar after the table", day(2077, 11, 17), Date{1501, 1, 1}},
+		{"tabular new year 1318", day(1900, 5, 1), Date{1318, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromTime(tt.t); got != tt.want {
+				t.Errorf("FromTime(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthLength(t *testing.T) {
+	tests := []struct {
+		year, month, want int
+	}{
+		{1436, 11, 30}, // completed after the crescent wasn't sighted
+		{1436, 12, 29}, // the table's 30th is the announced 1 Muharram 1437
+		{1364, 8, 28},  // printed with 28 days
+		{1445, 9, 30},
+		{1446, 9, 29},
+	}
+	for _, tt := range tests {
+		if got := MonthLength(tt.year, tt.month); got != tt.want {
+			t.Errorf("MonthLength(%d, %d) = %d, want %d", tt.year, tt.month, got, tt.want)
+		}
+	}
+
+	// Every other month from 1300 to 1600, inside the table and out, has 29 or
+	// 30 days, and a year 354 or 355
+	for y := 1300; y <= 1600; y++ {
+		if y == 1364 {
+			continue
+		}
+		total := 0
+		for m := 1; m <= 12; m++ {
+			l := MonthLength(y, m)
+			if l != 29 && l != 30 {
+				t.Errorf("MonthLength(%d, %d) = %d", y, m, l)
+			}
+			total += l
+		}
+		if total < 353 || total > 356 {
+			t.Errorf("year %d has %d days", y, total)
+		}
+	}
+}
+
+func TestDateTimeRoundTrip(t *testing.T) {
+	for d := day(1900, 1, 1); d.Before(day(2100, 1, 1)); d = d.AddDate(0, 0, 1) {
+		h := FromTime(d)
+		if !h.Valid() {
+			t.Fatalf("FromTime(%s) = %v is not valid", d.Format(time.DateOnly), h)
+		}
+		if back := h.Time(); !back.Equal(d) {
+			t.Fatalf("FromTime(%s) = %v, which maps back to %s", d.Format(time.DateOnly), h, back.Format(time.DateOnly))
+		}
+	}
+
+	// A day past the end of the month clamps to its last day
+	if got, want := (Date{1446, 9, 30}).Time(), day(2025, 3, 29); !got.Equal(want) {
+		t.Errorf("30 Ramadan 1446 = %s, want %s", got.Format(time.DateOnly), want.Format(time.DateOnly))
+	}
+	if (Date{1446, 9, 30}).Valid() {
+		t.Error("30 Ramadan 1446 should not be valid")
+	}
+}
+
+func TestHolidaysOn(t *testing.T) {
+	tests := []struct {
+		day  time.Time
+		want []string
+	}{
+		{day(2015, 9, 23), []string{"يوم عرفة"}},
+		{day(2015, 9, 26), []string{"عيد الأضحى"}},
+		{day(2015, 9, 27), nil},
+		{day(2025, 3, 1), []string{"بداية شهر رمضان"}},
+		{day(2025, 3, 31), []string{"عيد الفطر"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, o := range HolidaysOn(tt.day) {
+			got = append(got, o.Name)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("HolidaysOn(%s) = %v, want %v", tt.day.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
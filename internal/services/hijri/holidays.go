@@ -0,0 +1,96 @@
+package hijri
+
+import "time"
+
+// Holiday is a built-in Islamic occasion on a fixed Hijri day
+type Holiday struct {
+	Name  string `json:"name"`
+	Month int    `json:"month"`
+	Day   int    `json:"day"`
+	Days  int    `json:"days"` // length in days (Eid holidays span several)
+}
+
+// Holidays are the occasions shown by the Islamic holidays overlay
+var Holidays = []Holiday{
+	{Name: "رأس السنة الهجرية", Month: 1, Day: 1, Days: 1},
+	{Name: "عاشوراء", Month: 1, Day: 10, Days: 1},
+	{Name: "المولد النبوي", Month: 3, Day: 12, Days: 1},
+	{Name: "الإسراء والمعراج", Month: 7, Day: 27, Days: 1},
+	{Name: "بداية شهر رمضان", Month: 9, Day: 1, Days: 1},
+	{Name: "عيد الفطر", Month: 10, Day: 1, Days: 3},
+	{Name: "يوم عرفة", Month: 12, Day: 9, Days: 1},
+	{Name: "عيد الأضحى", Month: 12, Day: 10, Days: 3},
+}
+
+// Occurrence is a holiday placed on the Gregorian calendar
+type Occurrence struct {
+	Holiday
+	Hijri Date      `json:"hijri"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // last day, inclusive
+}
+
+// HolidaysOn returns the holidays that include t's calendar day
+func HolidaysOn(t time.Time) []Occurrence {
+	day := civilDate(t)
+	h := FromTime(day)
+
+	var out []Occurrence
+	for _, hol := range Holidays {
+		// Multi-day holidays may have started in the previous Hijri year's
+		// month; check the occurrence of this year and the last
+		for _, year := range []int{h.Year, h.Year - 1} {
+			o := occurrence(hol, year)
+			if !day.Before(o.Start) && !day.After(o.End) {
+				out = append(out, o)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Upcoming returns the holidays that start within `days` days from t, in date order
+func Upcoming(t time.Time, days int) []Occurrence {
+	from := civilDate(t)
+	until := from.AddDate(0, 0, days)
+	year := FromTime(from).Year
+
+	var out []Occurrence
+	for y := year; y <= year+1+days/354; y++ {
+		for _, hol := range Holidays {
+			o := occurrence(hol, y)
+			if !o.End.Before(from) && o.Start.Before(until) {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
+}
+
+// Between returns the holidays that overlap [from, to], in date order
+func Between(from, to time.Time) []Occurrence {
+	from, to = civilDate(from), civilDate(to)
+
+	var out []Occurrence
+	for y := FromTime(from).Year - 1; y <= FromTime(to).Year; y++ {
+		for _, hol := range Holidays {
+			o := occurrence(hol, y)
+			if !o.End.Before(from) && !o.Start.After(to) {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
+}
+
+func occurrence(hol Holiday, year int) Occurrence {
+	d := Date{Year: year, Month: hol.Month, Day: hol.Day}
+	start := d.Time()
+	return Occurrence{
+		Holiday: hol,
+		Hijri:   d,
+		Start:   start,
+		End:     start.AddDate(0, 0, max(hol.Days, 1)-1),
+	}
+}
@@ -0,0 +1,264 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/services/hijri"
+	"ohabits/internal/services/rrule"
+
+	"github.com/google/uuid"
+)
+
+// uidDomain is appended to record IDs to make globally unique UIDs
+const uidDomain = "@ohabits"
+
+// eventTypeNames are the Arabic category names for calendar event types
+var eventTypeNames = map[string]string{
+	"birthday":    "عيد ميلاد",
+	"travel":      "سفر",
+	"holiday":     "إجازة",
+	"anniversary": "ذكرى",
+	"general":     "عام",
+}
+
+// weekdayCodes maps the day names stored on habits and medications to RRULE BYDAY codes
+var weekdayCodes = map[string]string{
+	"Sunday":    "SU",
+	"Monday":    "MO",
+	"Tuesday":   "TU",
+	"Wednesday": "WE",
+	"Thursday":  "TH",
+	"Friday":    "FR",
+	"Saturday":  "SA",
+}
+
+// hijriFeedYears is how many Hijri years ahead Hijri-recurring events are listed
+const hijriFeedYears = 5
+
+// FromCalendarEvent converts a calendar event to VEVENTs, all-day unless the
+// event has a start time, with its reminders as alarms. Yearly events repeat
+// with FREQ=YEARLY and rule series with their RRULE, cancelled occurrences as
+// EXDATEs and overridden ones as RECURRENCE-ID instances; all-day date ranges
+// end the day after EndDate. Calendar clients can't repeat on the Hijri
+// calendar, so events that do are listed once per Hijri year, from last year
+// to hijriFeedYears ahead of now.
+func FromCalendarEvent(e database.CalendarEvent, now time.Time) []Event {
+	ev := fromCalendarEvent(e)
+	if e.HasRule() {
+		return withExceptions(ev, e)
+	}
+	if !e.IsHijri() {
+		return []Event{ev}
+	}
+
+	ev.RRule = ""
+	length := ev.End.Sub(ev.Start)
+	origin := hijri.FromTime(e.EventDate)
+	current := hijri.FromTime(now).Year
+	events := make([]Event, 0, hijriFeedYears+2)
+	for y := current - 1; y <= current+hijriFeedYears; y++ {
+		if y < origin.Year {
+			continue
+		}
+		occ := ev
+		occ.UID = fmt.Sprintf("%s-%d%s", e.ID, y, uidDomain)
+		occ.Start = occurrenceStart(e, origin.AddYears(y-origin.Year).Time())
+		occ.End = occ.Start.Add(length)
+		events = append(events, occ)
+	}
+	return events
+}
+
+func fromCalendarEvent(e database.CalendarEvent) Event {
+	ev := Event{
+		UID:          e.ID.String() + uidDomain,
+		Summary:      e.Title,
+		Description:  e.Notes,
+		Start:        dateOf(e.EventDate),
+		AllDay:       true,
+		LastModified: e.UpdatedAt,
+	}
+	ev.End = ev.Start.AddDate(0, 0, 1)
+	if e.HasDateRange() && !e.EndDate.Before(e.EventDate) {
+		ev.End = dateOf(*e.EndDate).AddDate(0, 0, 1)
+	}
+	if !e.IsAllDay() {
+		ev.AllDay = false
+		ev.Start = e.StartAt()
+		ev.End = ev.Start
+		if end := e.EndAt(); end.After(ev.Start) {
+			ev.End = end
+		}
+	}
+	if e.HasRule() {
+		ev.RRule = e.RRule
+		if !ev.AllDay {
+			ev.RRule = timedUntil(e)
+		}
+	} else if e.IsRecurring {
+		ev.RRule = "FREQ=YEARLY"
+	}
+	if name, ok := eventTypeNames[e.EventType]; ok {
+		ev.Categories = []string{name}
+	}
+
+	// All-day reminders count from the reminder hour, alarms from midnight
+	for _, m := range e.Reminders {
+		before := time.Duration(m) * time.Minute
+		if ev.AllDay {
+			before -= database.AllDayReminderHour * time.Hour
+		}
+		ev.Alarms = append(ev.Alarms, before)
+	}
+	return ev
+}
+
+// occurrenceStart returns when the occurrence of e on date starts: the date
+// itself for all-day events
+func occurrenceStart(e database.CalendarEvent, date time.Time) time.Time {
+	if e.IsAllDay() {
+		return dateOf(date)
+	}
+	e.EventDate = date
+	return e.StartAt()
+}
+
+// timedUntil rewrites a rule's UNTIL date as the UTC start of the last
+// occurrence, since with a timed DTSTART it must be a UTC date-time
+func timedUntil(e database.CalendarEvent) string {
+	r, err := rrule.Parse(e.RRule)
+	if err != nil || r.Until.IsZero() {
+		return e.RRule
+	}
+	until := "UNTIL=" + r.Until.Format(dateOnly)
+	return strings.Replace(e.RRule, until, "UNTIL="+occurrenceStart(e, r.Until).UTC().Format(dateTimeUTC), 1)
+}
+
+// withExceptions adds the exceptions of a rule series to its VEVENT
+func withExceptions(ev Event, e database.CalendarEvent) []Event {
+	events := []Event{ev}
+	length := ev.End.Sub(ev.Start)
+	for _, x := range e.Exceptions {
+		original := occurrenceStart(e, x.OccurrenceDate)
+		if x.IsCancelled {
+			events[0].ExDates = append(events[0].ExDates, original)
+			continue
+		}
+		occ := ev
+		occ.RRule = ""
+		occ.RecurrenceID = original
+		occ.Start = original
+		if x.EventDate != nil {
+			occ.Start = occurrenceStart(e, *x.EventDate)
+		}
+		occ.End = occ.Start.Add(length)
+		if x.Title != nil {
+			occ.Summary = *x.Title
+		}
+		if x.Notes != nil {
+			occ.Description = *x.Notes
+		}
+		occ.LastModified = x.UpdatedAt
+		events = append(events, occ)
+	}
+	return events
+}
+
+// FromMedication converts an active medication to a repeating all-day VEVENT on
+// its scheduled weekdays (daily when none are set), ending on its end date for
+// limited courses. ok is false for inactive or deleted medications.
+func FromMedication(m database.Medication) (Event, bool) {
+	if !m.IsActive || m.IsDeleted {
+		return Event{}, false
+	}
+
+	start := dateOf(m.CreatedAt)
+	if m.StartDate != nil {
+		start = dateOf(*m.StartDate)
+	}
+
+	var days []string
+	for _, d := range m.ScheduledDays {
+		if code, ok := weekdayCodes[d]; ok {
+			days = append(days, code)
+		}
+	}
+	rule := "FREQ=DAILY"
+	if len(days) > 0 && len(days) < 7 {
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")
+	}
+	if m.DurationType == "limited" && m.EndDate != nil {
+		if m.EndDate.Before(start) {
+			return Event{}, false
+		}
+		rule += ";UNTIL=" + dateOf(*m.EndDate).Format(dateOnly)
+	}
+
+	summary := "💊 " + m.Name
+	if m.Dosage != "" {
+		summary += " (" + m.Dosage + ")"
+	}
+	description := fmt.Sprintf("%d مرات يومياً", max(m.TimesPerDay, 1))
+	if m.Notes != "" {
+		description += "\n" + m.Notes
+	}
+
+	return Event{
+		UID:          "medication-" + m.ID.String() + uidDomain,
+		Summary:      summary,
+		Description:  description,
+		Categories:   []string{"أدوية"},
+		Start:        start,
+		End:          start.AddDate(0, 0, 1),
+		AllDay:       true,
+		RRule:        rule,
+		LastModified: m.UpdatedAt,
+	}, true
+}
+
+// FromTask converts an open task with a due date to an all-day VEVENT on that
+// date. ok is false for completed, deleted or undated tasks.
+func FromTask(t database.Task, projects map[uuid.UUID]string) (Event, bool) {
+	if t.DueDate == nil || t.IsDeleted || t.Completed || strings.EqualFold(t.Status, "completed") {
+		return Event{}, false
+	}
+
+	start := dateOf(*t.DueDate)
+	ev := Event{
+		UID:          "task-" + t.ID.String() + uidDomain,
+		Summary:      "📌 " + t.Title,
+		Description:  t.Description,
+		Categories:   []string{"مهام"},
+		Start:        start,
+		End:          start.AddDate(0, 0, 1),
+		AllDay:       true,
+		LastModified: t.UpdatedAt,
+	}
+	if t.ProjectID != nil {
+		if name, ok := projects[*t.ProjectID]; ok {
+			ev.Categories = append(ev.Categories, name)
+			if ev.Description != "" {
+				ev.Description = "المشروع: " + name + "\n" + ev.Description
+			} else {
+				ev.Description = "المشروع: " + name
+			}
+		}
+	}
+	switch strings.ToLower(t.Priority) {
+	case "high":
+		ev.Priority = 1
+	case "medium":
+		ev.Priority = 5
+	case "low":
+		ev.Priority = 9
+	}
+	return ev, true
+}
+
+// dateOf drops the time of day, keeping the calendar date as stored
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
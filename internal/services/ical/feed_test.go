@@ -0,0 +1,279 @@
+package ical
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/services/hijri"
+
+	"github.com/google/uuid"
+)
+
+var (
+	feedID      = uuid.MustParse("6f1c2a9e-4b7d-4c1e-9a3f-2d5e8b7c6a10")
+	feedUpdated = time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC)
+)
+
+func TestFromCalendarEvent(t *testing.T) {
+	kuwait, _ := database.LoadEventLocation("")
+	ptr := func(t time.Time) *time.Time { return &t }
+	title := "درس خاص"
+	uid := feedID.String() + "@ohabits"
+
+	base := database.CalendarEvent{ID: feedID, Title: "درس", EventType: "general", EventDate: day(2025, 3, 1), UpdatedAt: feedUpdated}
+	with := func(edit func(e *database.CalendarEvent)) database.CalendarEvent {
+		e := base
+		edit(&e)
+		return e
+	}
+	allDay := func(start, end time.Time) Event {
+		return Event{UID: uid, Summary: "درس", Categories: []string{"عام"}, Start: start, End: end, AllDay: true, LastModified: feedUpdated}
+	}
+
+	tests := []struct {
+		name  string
+		event database.CalendarEvent
+		want  []Event
+	}{
+		{"one-off all-day event", base, []Event{allDay(day(2025, 3, 1), day(2025, 3, 2))}},
+		{
+			"date ranges end the day after the end date",
+			with(func(e *database.CalendarEvent) { e.EndDate = ptr(day(2025, 3, 3)) }),
+			[]Event{allDay(day(2025, 3, 1), day(2025, 3, 4))},
+		},
+		{
+			"yearly events and all-day reminders from the reminder hour",
+			with(func(e *database.CalendarEvent) { e.IsRecurring, e.Reminders = true, []int{0, 24 * 60} }),
+			[]Event{func() Event {
+				ev := allDay(day(2025, 3, 1), day(2025, 3, 2))
+				ev.RRule = "FREQ=YEARLY"
+				ev.Alarms = []time.Duration{-9 * time.Hour, 15 * time.Hour}
+				return ev
+			}()},
+		},
+		{
+			"timed events start and end in their zone",
+			with(func(e *database.CalendarEvent) { e.StartTime, e.EndTime, e.Reminders = "09:00", "10:30", []int{15} }),
+			[]Event{{
+				UID: uid, Summary: "درس", Categories: []string{"عام"}, LastModified: feedUpdated,
+				Start: time.Date(2025, 3, 1, 9, 0, 0, 0, kuwait), End: time.Date(2025, 3, 1, 10, 30, 0, 0, kuwait),
+				Alarms: []time.Duration{15 * time.Minute},
+			}},
+		},
+		{
+			"rule series emit EXDATEs and RECURRENCE-ID instances with the series UID",
+			with(func(e *database.CalendarEvent) {
+				e.IsRecurring, e.RRule = true, "FREQ=WEEKLY;BYDAY=SA"
+				e.Exceptions = []database.CalendarEventException{
+					{OccurrenceDate: day(2025, 3, 8), IsCancelled: true},
+					{OccurrenceDate: day(2025, 3, 15), EventDate: ptr(day(2025, 3, 17)), Title: &title, UpdatedAt: feedUpdated.Add(time.Hour)},
+				}
+			}),
+			[]Event{
+				func() Event {
+					ev := allDay(day(2025, 3, 1), day(2025, 3, 2))
+					ev.RRule = "FREQ=WEEKLY;BYDAY=SA"
+					ev.ExDates = []time.Time{day(2025, 3, 8)}
+					return ev
+				}(),
+				func() Event {
+					ev := allDay(day(2025, 3, 17), day(2025, 3, 18))
+					ev.Summary = title
+					ev.RecurrenceID = day(2025, 3, 15)
+					ev.LastModified = feedUpdated.Add(time.Hour)
+					return ev
+				}(),
+			},
+		},
+		{
+			"timed rules get a UTC UNTIL and timed EXDATEs",
+			with(func(e *database.CalendarEvent) {
+				e.StartTime = "09:00"
+				e.IsRecurring, e.RRule = true, "FREQ=DAILY;UNTIL=20250310"
+				e.Exceptions = []database.CalendarEventException{{OccurrenceDate: day(2025, 3, 2), IsCancelled: true}}
+			}),
+			[]Event{{
+				UID: uid, Summary: "درس", Categories: []string{"عام"}, LastModified: feedUpdated,
+				Start: time.Date(2025, 3, 1, 9, 0, 0, 0, kuwait), End: time.Date(2025, 3, 1, 9, 0, 0, 0, kuwait),
+				RRule:   "FREQ=DAILY;UNTIL=20250310T060000Z",
+				ExDates: []time.Time{time.Date(2025, 3, 2, 9, 0, 0, 0, kuwait)},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromCalendarEvent(tt.event, feedUpdated)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromCalendarEvent =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromCalendarEventHijri(t *testing.T) {
+	// Eid al-Fitr, 1 Shawwal 1441
+	event := database.CalendarEvent{
+		ID: feedID, Title: "العيد", EventType: "holiday", EventDate: day(2020, 5, 24),
+		IsRecurring: true, CalendarType: database.CalendarHijri,
+	}
+
+	uids := func(now time.Time) map[string]time.Time {
+		out := make(map[string]time.Time)
+		for _, ev := range FromCalendarEvent(event, now) {
+			if ev.RRule != "" || !ev.AllDay || !ev.End.Equal(ev.Start.AddDate(0, 0, 1)) {
+				t.Errorf("occurrence %s = %+v, want a single all-day event", ev.UID, ev)
+			}
+			out[ev.UID] = ev.Start
+		}
+		return out
+	}
+
+	// 1 June 2025 is in 1446: last year through five years ahead
+	got := uids(day(2025, 6, 1))
+	if len(got) != hijriFeedYears+2 {
+		t.Errorf("got %d occurrences, want %d", len(got), hijriFeedYears+2)
+	}
+	for y := 1445; y <= 1451; y++ {
+		uid := feedID.String() + "-" + strconv.Itoa(y) + "@ohabits"
+		want := hijri.Date{Year: y, Month: 10, Day: 1}.Time()
+		if start, ok := got[uid]; !ok || !start.Equal(want) {
+			t.Errorf("%s starts %v, want %v", uid, start, want)
+		}
+	}
+
+	// A year later the shared years keep their UIDs and dates
+	for uid, start := range uids(day(2026, 6, 1)) {
+		if prev, ok := got[uid]; ok && !prev.Equal(start) {
+			t.Errorf("%s moved from %v to %v", uid, prev, start)
+		}
+	}
+
+	// Years before the event are skipped
+	if n := len(FromCalendarEvent(event, day(2020, 6, 1))); n != hijriFeedYears+1 {
+		t.Errorf("got %d occurrences in the event's first year, want %d", n, hijriFeedYears+1)
+	}
+}
+
+func TestFromMedication(t *testing.T) {
+	ptr := func(t time.Time) *time.Time { return &t }
+	base := database.Medication{
+		ID: feedID, Name: "فيتامين د", Dosage: "1000IU", TimesPerDay: 2, Notes: "بعد الأكل",
+		IsActive: true, CreatedAt: day(2025, 1, 5), UpdatedAt: feedUpdated,
+	}
+	with := func(edit func(m *database.Medication)) database.Medication {
+		m := base
+		edit(&m)
+		return m
+	}
+
+	tests := []struct {
+		name       string
+		medication database.Medication
+		ok         bool
+		start      time.Time
+		rule       string
+	}{
+		{"daily from creation", base, true, day(2025, 1, 5), "FREQ=DAILY"},
+		{"start date", with(func(m *database.Medication) { m.StartDate = ptr(day(2025, 2, 1)) }), true, day(2025, 2, 1), "FREQ=DAILY"},
+		{"weekdays", with(func(m *database.Medication) { m.ScheduledDays = []string{"Sunday", "Tuesday", "Someday"} }), true, day(2025, 1, 5), "FREQ=WEEKLY;BYDAY=SU,TU"},
+		{
+			"every weekday is daily",
+			with(func(m *database.Medication) {
+				m.ScheduledDays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+			}),
+			true, day(2025, 1, 5), "FREQ=DAILY",
+		},
+		{
+			"limited courses end on their end date",
+			with(func(m *database.Medication) { m.DurationType, m.EndDate = "limited", ptr(day(2025, 1, 20)) }),
+			true, day(2025, 1, 5), "FREQ=DAILY;UNTIL=20250120",
+		},
+		{"end before start", with(func(m *database.Medication) { m.DurationType, m.EndDate = "limited", ptr(day(2025, 1, 1)) }), false, time.Time{}, ""},
+		{"inactive", with(func(m *database.Medication) { m.IsActive = false }), false, time.Time{}, ""},
+		{"deleted", with(func(m *database.Medication) { m.IsDeleted = true }), false, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, ok := FromMedication(tt.medication)
+			if ok != tt.ok {
+				t.Fatalf("FromMedication ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			want := Event{
+				UID:          "medication-" + feedID.String() + "@ohabits",
+				Summary:      "💊 فيتامين د (1000IU)",
+				Description:  "2 مرات يومياً\nبعد الأكل",
+				Categories:   []string{"أدوية"},
+				Start:        tt.start,
+				End:          tt.start.AddDate(0, 0, 1),
+				AllDay:       true,
+				RRule:        tt.rule,
+				LastModified: feedUpdated,
+			}
+			if !reflect.DeepEqual(ev, want) {
+				t.Errorf("FromMedication =\n%+v\nwant\n%+v", ev, want)
+			}
+		})
+	}
+}
+
+func TestFromTask(t *testing.T) {
+	projectID := uuid.MustParse("0b8e7f3c-2a41-4d5e-8c6b-9f1a2e3d4c5b")
+	projects := map[uuid.UUID]string{projectID: "البيت"}
+	due := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	base := database.Task{ID: feedID, Title: "دفع الفاتورة", Status: "pending", Priority: "high", DueDate: &due, UpdatedAt: feedUpdated}
+	with := func(edit func(task *database.Task)) database.Task {
+		task := base
+		edit(&task)
+		return task
+	}
+	event := func(edit func(ev *Event)) Event {
+		ev := Event{
+			UID: "task-" + feedID.String() + "@ohabits", Summary: "📌 دفع الفاتورة", Categories: []string{"مهام"},
+			Start: day(2025, 3, 1), End: day(2025, 3, 2), AllDay: true, Priority: 1, LastModified: feedUpdated,
+		}
+		edit(&ev)
+		return ev
+	}
+
+	tests := []struct {
+		name string
+		task database.Task
+		ok   bool
+		want Event
+	}{
+		{"all-day on the due date", base, true, event(func(ev *Event) {})},
+		{
+			"project name as category and description prefix",
+			with(func(task *database.Task) {
+				task.ProjectID, task.Description, task.Priority = &projectID, "قبل الظهر", "low"
+			}),
+			true, event(func(ev *Event) {
+				ev.Categories = []string{"مهام", "البيت"}
+				ev.Description = "المشروع: البيت\nقبل الظهر"
+				ev.Priority = 9
+			}),
+		},
+		{"unknown priority", with(func(task *database.Task) { task.Priority = "" }), true, event(func(ev *Event) { ev.Priority = 0 })},
+		{"no due date", with(func(task *database.Task) { task.DueDate = nil }), false, Event{}},
+		{"completed", with(func(task *database.Task) { task.Completed = true }), false, Event{}},
+		{"completed status", with(func(task *database.Task) { task.Status = "Completed" }), false, Event{}},
+		{"deleted", with(func(task *database.Task) { task.IsDeleted = true }), false, Event{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, ok := FromTask(tt.task, projects)
+			if ok != tt.ok {
+				t.Fatalf("FromTask ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(ev, tt.want) {
+				t.Errorf("FromTask =\n%+v\nwant\n%+v", ev, tt.want)
+			}
+		})
+	}
+}
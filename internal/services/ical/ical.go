@@ -0,0 +1,190 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProductID identifies ohabits as the generator of exported calendars
+const ProductID = "-//ohabits//Calendar//AR"
+
+// Event is one VEVENT. All-day events use only the date part of Start and End;
+// End is exclusive (the day after the last day), as RFC 5545 requires.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Categories   []string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string          // e.g. "FREQ=YEARLY", without the "RRULE:" prefix
+	ExDates      []time.Time     // Cancelled occurrences of a series (dates when all-day)
+	RecurrenceID time.Time       // Set on an overridden occurrence: the start it replaces
+	Alarms       []time.Duration // Display reminders, as how long before the start (negative = after)
+	Priority     int             // 1 (highest) - 9 (lowest), 0 = undefined
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR with its display name and events
+type Calendar struct {
+	Name     string
+	TimeZone string // IANA name advertised to clients, e.g. "Asia/Kuwait"
+	Refresh  time.Duration
+	Events   []Event
+}
+
+// Encode writes the calendar as an iCalendar (RFC 5545) document
+func (cal *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + ProductID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME:" + escapeText(cal.Name))
+	}
+	if cal.TimeZone != "" {
+		line("X-WR-TIMEZONE:" + cal.TimeZone)
+	}
+	if cal.Refresh > 0 {
+		d := formatDuration(cal.Refresh)
+		line("REFRESH-INTERVAL;VALUE=DURATION:" + d)
+		line("X-PUBLISHED-TTL:" + d)
+	}
+
+	stamp := time.Now().UTC().Format(dateTimeUTC)
+	for _, e := range cal.Events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format(dateOnly))
+			end := e.End
+			if !end.After(e.Start) {
+				end = e.Start.AddDate(0, 0, 1)
+			}
+			line("DTEND;VALUE=DATE:" + end.Format(dateOnly))
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format(dateTimeUTC))
+			if e.End.After(e.Start) {
+				line("DTEND:" + e.End.UTC().Format(dateTimeUTC))
+			}
+		}
+		if e.RRule != "" {
+			line("RRULE:" + e.RRule)
+		}
+		// EXDATE and RECURRENCE-ID take the value type of DTSTART
+		valueDate, format := "", dateTimeUTC
+		if e.AllDay {
+			valueDate, format = ";VALUE=DATE", dateOnly
+		}
+		if len(e.ExDates) > 0 {
+			dates := make([]string, len(e.ExDates))
+			for i, d := range e.ExDates {
+				if !e.AllDay {
+					d = d.UTC()
+				}
+				dates[i] = d.Format(format)
+			}
+			line("EXDATE" + valueDate + ":" + strings.Join(dates, ","))
+		}
+		if !e.RecurrenceID.IsZero() {
+			id := e.RecurrenceID
+			if !e.AllDay {
+				id = id.UTC()
+			}
+			line("RECURRENCE-ID" + valueDate + ":" + id.Format(format))
+		}
+		line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			escaped := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				escaped[i] = escapeText(c)
+			}
+			line("CATEGORIES:" + strings.Join(escaped, ","))
+		}
+		if e.Priority > 0 {
+			line("PRIORITY:" + strconv.Itoa(min(e.Priority, 9)))
+		}
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED:" + e.LastModified.UTC().Format(dateTimeUTC))
+		}
+		line("TRANSP:TRANSPARENT")
+		for _, d := range e.Alarms {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("DESCRIPTION:" + escapeText(e.Summary))
+			line("TRIGGER:" + formatTrigger(d))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+const (
+	dateOnly    = "20060102"
+	dateTimeUTC = "20060102T150405Z"
+)
+
+// escapeText escapes a TEXT value (RFC 5545 §3.3.11)
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting multi-byte characters, and terminates it with CRLF
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	width := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		if width+size > limit {
+			w.WriteString("\r\n ")
+			width = 1
+		}
+		w.WriteString(s[:size])
+		width += size
+		s = s[size:]
+	}
+	w.WriteString("\r\n")
+}
+
+// formatTrigger formats an alarm offset before the start as a TRIGGER
+// duration, e.g. -PT1H for an hour before
+func formatTrigger(before time.Duration) string {
+	if before > 0 {
+		return "-" + formatDuration(before)
+	}
+	return formatDuration(-before)
+}
+
+// formatDuration formats whole hours/minutes as an RFC 5545 duration, e.g. PT1H
+func formatDuration(d time.Duration) string {
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+	s := "PT"
+	if hours > 0 {
+		s += strconv.Itoa(hours) + "H"
+	}
+	if minutes > 0 || hours == 0 {
+		s += strconv.Itoa(minutes) + "M"
+	}
+	return s
+}
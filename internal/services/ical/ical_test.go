@@ -0,0 +1,93 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	cal := &Calendar{
+		Name: "تقويمي", TimeZone: "Asia/Kuwait", Refresh: time.Hour,
+		Events: []Event{
+			{
+				UID: "a@ohabits", Summary: "عيد ميلاد; أحمد, \\ " + strings.Repeat("نص طويل ", 10), Description: "سطر\nآخر",
+				Start: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC), AllDay: true, RRule: "FREQ=YEARLY",
+				ExDates: []time.Time{time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)},
+				Alarms:  []time.Duration{time.Hour},
+			},
+			{
+				UID: "b@ohabits", Summary: "Meeting",
+				Start: time.Date(2025, 3, 4, 9, 30, 0, 0, time.FixedZone("AST", 3*60*60)), End: time.Date(2025, 3, 4, 7, 15, 0, 0, time.UTC),
+				RecurrenceID: time.Date(2025, 3, 3, 6, 30, 0, 0, time.UTC), Priority: 12,
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	var unfolded []string
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line %d is %d octets: %q", i, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded[len(unfolded)-1] += line[1:]
+		} else {
+			unfolded = append(unfolded, line)
+		}
+	}
+
+	for _, want := range []string{
+		"X-WR-CALNAME:تقويمي",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"DTSTART;VALUE=DATE:20250510",
+		"DTEND;VALUE=DATE:20250511", // a missing all-day end is the next day
+		"RRULE:FREQ=YEARLY",
+		"EXDATE;VALUE=DATE:20260510",
+		"SUMMARY:عيد ميلاد\\; أحمد\\, \\\\ " + strings.TrimSpace(strings.Repeat("نص طويل ", 10)),
+		"DESCRIPTION:سطر\\nآخر",
+		"TRIGGER:-PT1H",
+		"DTSTART:20250304T063000Z",
+		"DTEND:20250304T071500Z",
+		"RECURRENCE-ID:20250303T063000Z",
+		"PRIORITY:9",
+	} {
+		found := false
+		for _, line := range unfolded {
+			if strings.TrimSpace(line) == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing line %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		before time.Duration
+		want   string
+	}{
+		{15 * time.Minute, "-PT15M"},
+		{2 * time.Hour, "-PT2H"},
+		{26*time.Hour + 5*time.Minute, "-PT26H5M"},
+		{0, "PT0M"},
+		{-10 * time.Minute, "PT10M"},
+	}
+	for _, tt := range tests {
+		if got := formatTrigger(tt.before); got != tt.want {
+			t.Errorf("formatTrigger(%v) = %q, want %q", tt.before, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package ical
+
+import (
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/services/hijri"
+	"ohabits/internal/services/rrule"
+)
+
+// Candidate is an imported entry mapped to a calendar event, shown for review
+// before it is saved
+type Candidate struct {
+	Event     database.CalendarEvent `json:"event"`
+	Duplicate bool                   `json:"duplicate"`      // matches an existing event
+	Note      string                 `json:"note,omitempty"` // what could not be carried over
+}
+
+// eventTypeKeywords guess the event type from a title or category, in order:
+// "عيد ميلاد" must be tried before the holiday "عيد"
+var eventTypeKeywords = []struct {
+	eventType string
+	words     []string
+}{
+	{"birthday", []string{"birthday", "bday", "b-day", "عيد ميلاد", "ميلاد"}},
+	{"anniversary", []string{"anniversary", "ذكرى", "زواج"}},
+	{"travel", []string{"flight", "trip", "travel", "hotel", "سفر", "رحلة", "طيران", "فندق"}},
+	{"holiday", []string{"holiday", "vacation", "eid", "ramadan", "national day", "عطلة", "إجازة", "اجازة", "عيد", "رمضان", "العيد الوطني"}},
+}
+
+// CandidatesFromEvents maps VEVENTs to calendar events. Plain yearly rules
+// become IsRecurring; other supported rules are kept as RRule with EXDATEs as
+// cancelled occurrences and RECURRENCE-ID instances as overridden ones.
+// Unsupported rules import only the first occurrence. Timed events are placed
+// on their date and time in loc, and alarms become reminders.
+func CandidatesFromEvents(events []Event, loc *time.Location) []Candidate {
+	out := make([]Candidate, 0, len(events))
+	series := make(map[string]int) // UID -> index in out of a rule series
+	var overrides []Event
+	for _, e := range events {
+		title := strings.TrimSpace(e.Summary)
+		if title == "" {
+			continue
+		}
+		if !e.RecurrenceID.IsZero() {
+			overrides = append(overrides, e)
+			continue
+		}
+
+		start := e.Start
+		if !e.AllDay {
+			start = start.In(loc)
+		}
+		ce := database.CalendarEvent{
+			Title:     title,
+			EventType: guessEventType(title, e.Categories),
+			EventDate: dateOf(start),
+			Notes:     strings.TrimSpace(e.Description),
+		}
+
+		// DTEND is exclusive for all-day events
+		if !e.End.IsZero() {
+			last := e.End
+			if e.AllDay {
+				last = last.AddDate(0, 0, -1)
+			} else {
+				last = last.In(loc).Add(-time.Nanosecond)
+			}
+			if last = dateOf(last); last.After(ce.EventDate) {
+				ce.EndDate = &last
+			}
+		}
+
+		if !e.AllDay {
+			ce.StartTime = start.Format("15:04")
+			if !e.End.IsZero() {
+				ce.EndTime = e.End.In(loc).Format("15:04")
+			}
+			if zone := loc.String(); zone != database.DefaultEventTimeZone {
+				ce.TimeZone = zone
+			}
+		}
+		ce.Reminders = reminderMinutes(e)
+
+		c := Candidate{Event: ce}
+		upper := strings.ToUpper(e.RRule)
+		switch {
+		case e.RRule == "":
+		case ruleFreq(e.RRule) == "YEARLY" && strings.Contains(upper, "RSCALE=ISLAMIC"):
+			// RFC 7529 rules such as RSCALE=ISLAMIC-UMALQURA repeat on the Hijri date
+			c.Event.IsRecurring = true
+			c.Event.CalendarType = database.CalendarHijri
+		default:
+			r, err := rrule.Parse(e.RRule)
+			if err != nil {
+				c.Note = "قاعدة التكرار غير مدعومة، سيُستورد أول موعد فقط"
+				break
+			}
+			c.Event.IsRecurring = true
+			if rule := r.String(); rule != "FREQ=YEARLY" {
+				c.Event.RRule = rule
+				for _, d := range e.ExDates {
+					if !e.AllDay {
+						d = d.In(loc)
+					}
+					c.Event.Exceptions = append(c.Event.Exceptions, database.CalendarEventException{
+						OccurrenceDate: dateOf(d),
+						IsCancelled:    true,
+					})
+				}
+				if e.UID != "" {
+					series[e.UID] = len(out)
+				}
+			}
+		}
+		if c.Event.EventType == "birthday" {
+			c.Event.EndDate = nil
+		}
+		out = append(out, c)
+	}
+
+	// Overridden occurrences become exceptions of their series, or stand-alone
+	// events when the series isn't in the file
+	var standalone []Event
+	for _, e := range overrides {
+		i, ok := series[e.UID]
+		if !ok {
+			e.RecurrenceID = time.Time{}
+			standalone = append(standalone, e)
+			continue
+		}
+		original, start := e.RecurrenceID, e.Start
+		if !e.AllDay {
+			original, start = original.In(loc), start.In(loc)
+		}
+		x := database.CalendarEventException{OccurrenceDate: dateOf(original)}
+		if moved := dateOf(start); !moved.Equal(x.OccurrenceDate) {
+			x.EventDate = &moved
+		}
+		if title := strings.TrimSpace(e.Summary); title != out[i].Event.Title {
+			x.Title = &title
+		}
+		if notes := strings.TrimSpace(e.Description); notes != out[i].Event.Notes {
+			x.Notes = &notes
+		}
+		out[i].Event.Exceptions = append(out[i].Event.Exceptions, x)
+	}
+	if len(standalone) > 0 {
+		out = append(out, CandidatesFromEvents(standalone, loc)...)
+	}
+	return out
+}
+
+// reminderMinutes converts an event's alarms to reminder offsets; all-day
+// reminders count from the reminder hour, so alarms before it are dropped
+func reminderMinutes(e Event) []int {
+	var out []int
+	for _, before := range e.Alarms {
+		m := int(before / time.Minute)
+		if e.AllDay {
+			m += database.AllDayReminderHour * 60
+		}
+		if m >= 0 && m <= database.MaxReminderMinutes {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// CandidatesFromBirthdays maps vCard birthdays to yearly birthday events.
+// Birthdays without a year are placed in `year` so no age is shown.
+func CandidatesFromBirthdays(birthdays []Birthday, year int) []Candidate {
+	out := make([]Candidate, 0, len(birthdays))
+	for _, b := range birthdays {
+		date := b.Date
+		note := ""
+		if !b.HasYear {
+			date = time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+			note = "سنة الميلاد غير معروفة"
+		}
+		out = append(out, Candidate{
+			Event: database.CalendarEvent{
+				Title:       "عيد ميلاد " + b.Name,
+				EventType:   "birthday",
+				EventDate:   date,
+				IsRecurring: true,
+			},
+			Note: note,
+		})
+	}
+	return out
+}
+
+// MarkDuplicates flags candidates that match an existing event or an earlier
+// candidate: same title and date, or same month and day when either repeats yearly
+func MarkDuplicates(candidates []Candidate, existing []database.CalendarEvent) {
+	seen := append([]database.CalendarEvent(nil), existing...)
+	for i := range candidates {
+		c := &candidates[i]
+		for _, e := range seen {
+			if SameEvent(c.Event, e) {
+				c.Duplicate = true
+				break
+			}
+		}
+		if !c.Duplicate {
+			seen = append(seen, c.Event)
+		}
+	}
+}
+
+// SameEvent reports whether two events describe the same occasion. Events
+// repeating on the Hijri calendar compare by Hijri month and day.
+func SameEvent(a, b database.CalendarEvent) bool {
+	if !strings.EqualFold(strings.TrimSpace(a.Title), strings.TrimSpace(b.Title)) {
+		return false
+	}
+	if a.IsHijri() || b.IsHijri() {
+		ha, hb := hijri.FromTime(a.EventDate), hijri.FromTime(b.EventDate)
+		return ha.Month == hb.Month && ha.Day == hb.Day
+	}
+	if a.IsRecurring || b.IsRecurring {
+		return a.EventDate.Month() == b.EventDate.Month() && a.EventDate.Day() == b.EventDate.Day()
+	}
+	return dateOf(a.EventDate).Equal(dateOf(b.EventDate))
+}
+
+func guessEventType(title string, categories []string) string {
+	text := strings.ToLower(title + " " + strings.Join(categories, " "))
+	for _, k := range eventTypeKeywords {
+		for _, w := range k.words {
+			if strings.Contains(text, w) {
+				return k.eventType
+			}
+		}
+	}
+	return "general"
+}
+
+// ruleFreq returns the FREQ part of an RRULE, e.g. "YEARLY"
+func ruleFreq(rule string) string {
+	for _, part := range strings.Split(rule, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok && strings.EqualFold(k, "FREQ") {
+			return strings.ToUpper(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package ical
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCandidatesFromEvents(t *testing.T) {
+	kuwait := mustLoad(t, "Asia/Kuwait")
+	ptr := func(t time.Time) *time.Time { return &t }
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   []Candidate
+	}{
+		{
+			"all-day end is exclusive and untitled events are skipped",
+			[]Event{
+				{Summary: " رحلة إلى لندن ", Description: "الفندق", Start: day(2025, 3, 1), End: day(2025, 3, 4), AllDay: true},
+				{Summary: "  ", Start: day(2025, 3, 1), AllDay: true},
+			},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "رحلة إلى لندن", EventType: "travel", EventDate: day(2025, 3, 1), EndDate: ptr(day(2025, 3, 3)), Notes: "الفندق",
+			}}},
+		},
+		{
+			"timed events move to the local date and alarms become reminders",
+			[]Event{{
+				Summary: "Meeting",
+				Start:   time.Date(2025, 3, 1, 21, 30, 0, 0, time.UTC), End: time.Date(2025, 3, 1, 22, 30, 0, 0, time.UTC),
+				Alarms: []time.Duration{15 * time.Minute, -time.Hour},
+			}},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "Meeting", EventType: "general", EventDate: day(2025, 3, 2),
+				StartTime: "00:30", EndTime: "01:30", Reminders: []int{15},
+			}}},
+		},
+		{
+			"all-day alarms count from the reminder hour",
+			[]Event{{Summary: "Eid", Start: day(2025, 3, 30), End: day(2025, 3, 31), AllDay: true, Alarms: []time.Duration{24 * time.Hour}}},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "Eid", EventType: "holiday", EventDate: day(2025, 3, 30), Reminders: []int{24*60 + database.AllDayReminderHour*60},
+			}}},
+		},
+		{
+			"yearly rules repeat yearly, birthdays last one day",
+			[]Event{{Summary: "Sara's birthday", Start: day(1990, 1, 15), End: day(1990, 1, 17), AllDay: true, RRule: "FREQ=YEARLY"}},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "Sara's birthday", EventType: "birthday", EventDate: day(1990, 1, 15), IsRecurring: true,
+			}}},
+		},
+		{
+			"RSCALE=ISLAMIC repeats on the Hijri date",
+			[]Event{{Summary: "ذكرى", Start: day(2025, 3, 1), AllDay: true, RRule: "RSCALE=ISLAMIC-UMALQURA;FREQ=YEARLY"}},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "ذكرى", EventType: "anniversary", EventDate: day(2025, 3, 1), IsRecurring: true, CalendarType: database.CalendarHijri,
+			}}},
+		},
+		{
+			"rule series keep EXDATEs and overridden occurrences as exceptions",
+			[]Event{
+				{UID: "s", Summary: "درس", Start: day(2025, 3, 1), AllDay: true, RRule: "FREQ=WEEKLY;BYDAY=SA", ExDates: []time.Time{day(2025, 3, 8)}},
+				{UID: "s", Summary: "درس خاص", Start: day(2025, 3, 17), AllDay: true, RecurrenceID: day(2025, 3, 15)},
+				{UID: "s", Summary: "درس", Description: "في المسجد", Start: day(2025, 3, 22), AllDay: true, RecurrenceID: day(2025, 3, 22)},
+			},
+			[]Candidate{{Event: database.CalendarEvent{
+				Title: "درس", EventType: "general", EventDate: day(2025, 3, 1), IsRecurring: true, RRule: "FREQ=WEEKLY;BYDAY=SA",
+				Exceptions: []database.CalendarEventException{
+					{OccurrenceDate: day(2025, 3, 8), IsCancelled: true},
+					{OccurrenceDate: day(2025, 3, 15), EventDate: ptr(day(2025, 3, 17)), Title: str("درس خاص")},
+					{OccurrenceDate: day(2025, 3, 22), Notes: str("في المسجد")},
+				},
+			}}},
+		},
+		{
+			"unsupported rules import the first occurrence with a note",
+			[]Event{{Summary: "Standup", Start: day(2025, 3, 1), AllDay: true, RRule: "FREQ=HOURLY"}},
+			[]Candidate{{
+				Event: database.CalendarEvent{Title: "Standup", EventType: "general", EventDate: day(2025, 3, 1)},
+				Note:  "قاعدة التكرار غير مدعومة، سيُستورد أول موعد فقط",
+			}},
+		},
+		{
+			"an override without its series stands alone",
+			[]Event{{UID: "missing", Summary: "Lesson", Start: day(2025, 3, 17), AllDay: true, RecurrenceID: day(2025, 3, 15)}},
+			[]Candidate{{Event: database.CalendarEvent{Title: "Lesson", EventType: "general", EventDate: day(2025, 3, 17)}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CandidatesFromEvents(tt.events, kuwait)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CandidatesFromEvents =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameEvent(t *testing.T) {
+	event := func(title string, date time.Time, recurring bool, calendarType string) database.CalendarEvent {
+		return database.CalendarEvent{Title: title, EventDate: date, IsRecurring: recurring, CalendarType: calendarType}
+	}
+
+	tests := []struct {
+		name string
+		a, b database.CalendarEvent
+		want bool
+	}{
+		{"same title and date", event("Trip", day(2025, 3, 1), false, ""), event("Trip", day(2025, 3, 1), false, ""), true},
+		{"titles compare case- and space-insensitively", event(" trip ", day(2025, 3, 1), false, ""), event("TRIP", day(2025, 3, 1), false, ""), true},
+		{"time of day is ignored", event("Trip", time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), false, ""), event("Trip", day(2025, 3, 1), false, ""), true},
+		{"different title", event("Trip", day(2025, 3, 1), false, ""), event("Flight", day(2025, 3, 1), false, ""), false},
+		{"different year of a one-off event", event("Trip", day(2025, 3, 1), false, ""), event("Trip", day(2024, 3, 1), false, ""), false},
+		{"yearly events match on month and day", event("Sara", day(1990, 1, 15), true, ""), event("Sara", day(2025, 1, 15), false, ""), true},
+		{"yearly events on another day", event("Sara", day(1990, 1, 15), true, ""), event("Sara", day(2025, 1, 16), true, ""), false},
+		// 1 Ramadan 1446 and 1447
+		{"Hijri events match on the Hijri date", event("رمضان", day(2025, 3, 1), true, database.CalendarHijri), event("رمضان", day(2026, 2, 18), false, ""), true},
+		{"Hijri events on the same Gregorian day of another year", event("رمضان", day(2025, 3, 1), true, database.CalendarHijri), event("رمضان", day(2026, 3, 1), false, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SameEvent(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameEvent = %v, want %v", got, tt.want)
+			}
+			if got := SameEvent(tt.b, tt.a); got != tt.want {
+				t.Errorf("SameEvent (swapped) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	existing := []database.CalendarEvent{{Title: "Sara", EventDate: day(1990, 1, 15), IsRecurring: true}}
+	candidates := []Candidate{
+		{Event: database.CalendarEvent{Title: "Sara", EventDate: day(2025, 1, 15)}},
+		{Event: database.CalendarEvent{Title: "Trip", EventDate: day(2025, 3, 1)}},
+		{Event: database.CalendarEvent{Title: "trip", EventDate: day(2025, 3, 1)}},
+		{Event: database.CalendarEvent{Title: "Trip", EventDate: day(2025, 4, 1)}},
+	}
+
+	MarkDuplicates(candidates, existing)
+
+	var got []bool
+	for _, c := range candidates {
+		got = append(got, c.Duplicate)
+	}
+	// Only the first of two identical candidates is new
+	if want := []bool{true, false, true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicate flags = %v, want %v", got, want)
+	}
+	if len(existing) != 1 {
+		t.Errorf("MarkDuplicates grew existing to %d events", len(existing))
+	}
+}
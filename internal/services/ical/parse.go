@@ -0,0 +1,351 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoEntries is returned when a file has no calendar or contact data
+var ErrNoEntries = errors.New("no VCALENDAR or VCARD data found")
+
+// Birthday is a contact's birthday read from a vCard BDAY property
+type Birthday struct {
+	Name    string
+	Date    time.Time // only month and day are meaningful when HasYear is false
+	HasYear bool
+}
+
+// contentLine is one unfolded "NAME;PARAM=VALUE:value" line
+type contentLine struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse reads the VEVENTs of an iCalendar document. All-day dates are kept as
+// UTC midnight; date-times are read in their TZID zone, or loc when floating.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := readContentLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var cur *Event
+	found := false
+	depth := 0 // nesting inside the VEVENT (VALARM etc.)
+	inAlarm := false
+	for _, l := range lines {
+		switch {
+		case l.Name == "BEGIN" && strings.EqualFold(l.Value, "VCALENDAR"):
+			found = true
+		case l.Name == "BEGIN" && strings.EqualFold(l.Value, "VEVENT") && cur == nil:
+			cur = &Event{}
+		case cur == nil:
+			continue
+		case l.Name == "BEGIN":
+			inAlarm = depth == 0 && strings.EqualFold(l.Value, "VALARM")
+			depth++
+		case l.Name == "END" && depth > 0:
+			depth--
+			inAlarm = false
+		case inAlarm && depth == 1 && l.Name == "TRIGGER":
+			if before, ok := parseTrigger(l); ok {
+				cur.Alarms = append(cur.Alarms, before)
+			}
+		case l.Name == "END" && strings.EqualFold(l.Value, "VEVENT"):
+			if !cur.Start.IsZero() {
+				if !cur.End.After(cur.Start) {
+					cur.End = time.Time{}
+					if cur.AllDay {
+						cur.End = cur.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *cur)
+			}
+			cur = nil
+		case depth > 0:
+			continue
+		default:
+			applyEventProperty(cur, l, loc)
+		}
+	}
+	if !found {
+		return nil, ErrNoEntries
+	}
+	return events, nil
+}
+
+func applyEventProperty(e *Event, l contentLine, loc *time.Location) {
+	switch l.Name {
+	case "UID":
+		e.UID = l.Value
+	case "SUMMARY":
+		e.Summary = unescapeText(l.Value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(l.Value)
+	case "CATEGORIES":
+		for _, c := range splitText(l.Value) {
+			if c = strings.TrimSpace(c); c != "" {
+				e.Categories = append(e.Categories, c)
+			}
+		}
+	case "RRULE":
+		e.RRule = l.Value
+	case "EXDATE":
+		for _, v := range strings.Split(l.Value, ",") {
+			if t, _, ok := parseDateValue(contentLine{Name: l.Name, Params: l.Params, Value: v}, loc); ok {
+				e.ExDates = append(e.ExDates, t)
+			}
+		}
+	case "RECURRENCE-ID":
+		if t, _, ok := parseDateValue(l, loc); ok {
+			e.RecurrenceID = t
+		}
+	case "DTSTART":
+		if t, allDay, ok := parseDateValue(l, loc); ok {
+			e.Start, e.AllDay = t, allDay
+		}
+	case "DTEND":
+		if t, _, ok := parseDateValue(l, loc); ok {
+			e.End = t
+		}
+	}
+}
+
+// parseTrigger reads an alarm TRIGGER relative to the start (e.g. -PT15M,
+// -P1D) as how long before the start it fires; absolute triggers and ones
+// relative to the end are not supported
+func parseTrigger(l contentLine) (time.Duration, bool) {
+	if strings.EqualFold(l.Params["VALUE"], "DATE-TIME") || strings.EqualFold(l.Params["RELATED"], "END") {
+		return 0, false
+	}
+	d, ok := parseDuration(l.Value)
+	return -d, ok
+}
+
+// parseDuration reads an RFC 5545 duration such as -P1DT2H or PT15M
+func parseDuration(s string) (time.Duration, bool) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	s, ok := strings.CutPrefix(strings.ToUpper(s), "P")
+	if !ok || s == "" {
+		return 0, false
+	}
+
+	dateUnits := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	timeUnits := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var d time.Duration
+	n, digits, inTime := 0, false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		units := dateUnits
+		if inTime {
+			units = timeUnits
+		}
+		switch {
+		case c == 'T' && !inTime && !digits:
+			inTime = true
+		case c >= '0' && c <= '9':
+			n, digits = n*10+int(c-'0'), true
+		case digits && units[c] != 0:
+			d += time.Duration(n) * units[c]
+			n, digits = 0, false
+		default:
+			return 0, false
+		}
+	}
+	if digits {
+		return 0, false
+	}
+	return sign * d, true
+}
+
+// ParseVCards reads the birthdays of the contacts in a vCard file.
+// Contacts without a BDAY are skipped.
+func ParseVCards(r io.Reader) ([]Birthday, error) {
+	lines, err := readContentLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Birthday
+	var name, structured, bday string
+	inCard, found := false, false
+	for _, l := range lines {
+		switch {
+		case l.Name == "BEGIN" && strings.EqualFold(l.Value, "VCARD"):
+			inCard, found = true, true
+			name, structured, bday = "", "", ""
+		case !inCard:
+			continue
+		case l.Name == "END" && strings.EqualFold(l.Value, "VCARD"):
+			inCard = false
+			if name == "" {
+				name = structured
+			}
+			if b, ok := parseBirthday(bday); ok && name != "" {
+				b.Name = name
+				out = append(out, b)
+			}
+		case l.Name == "FN":
+			name = strings.TrimSpace(unescapeText(l.Value))
+		case l.Name == "N":
+			// N:Family;Given;Additional;Prefix;Suffix
+			parts := strings.Split(l.Value, ";")
+			var given, family string
+			family = unescapeText(parts[0])
+			if len(parts) > 1 {
+				given = unescapeText(parts[1])
+			}
+			structured = strings.TrimSpace(given + " " + family)
+		case l.Name == "BDAY":
+			bday = l.Value
+		}
+	}
+	if !found {
+		return nil, ErrNoEntries
+	}
+	return out, nil
+}
+
+// parseBirthday accepts the BDAY forms in use: 19900115, 1990-01-15,
+// --0115, --01-15 and any of those followed by a time
+func parseBirthday(s string) (Birthday, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, 'T'); i > 0 {
+		s = s[:i]
+	}
+	if strings.HasPrefix(s, "--") {
+		md := strings.ReplaceAll(s[2:], "-", "")
+		t, err := time.Parse("0102", md)
+		if err != nil {
+			return Birthday{}, false
+		}
+		return Birthday{Date: time.Date(2000, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}, true
+	}
+	for _, layout := range []string{dateOnly, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Birthday{Date: t, HasYear: true}, true
+		}
+	}
+	return Birthday{}, false
+}
+
+// parseDateValue reads a DATE or DATE-TIME property value
+func parseDateValue(l contentLine, loc *time.Location) (t time.Time, allDay bool, ok bool) {
+	v := strings.TrimSpace(l.Value)
+	if strings.EqualFold(l.Params["VALUE"], "DATE") || len(v) == len(dateOnly) {
+		t, err := time.Parse(dateOnly, v)
+		return t, true, err == nil
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(dateTimeUTC, v)
+		return t, false, err == nil
+	}
+	zone := loc
+	if tzid := l.Params["TZID"]; tzid != "" {
+		if z, err := time.LoadLocation(strings.Trim(tzid, "/")); err == nil {
+			zone = z
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, zone)
+	return t, false, err == nil
+}
+
+// readContentLines unfolds and splits the content lines of an iCalendar or vCard file
+func readContentLines(r io.Reader) ([]contentLine, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var raw []string
+	for sc.Scan() {
+		s := strings.TrimRight(sc.Text(), "\r")
+		if len(raw) == 0 {
+			s = strings.TrimPrefix(s, "\ufeff")
+		}
+		if (strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += s[1:]
+			continue
+		}
+		if s != "" {
+			raw = append(raw, s)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	lines := make([]contentLine, 0, len(raw))
+	for _, s := range raw {
+		if l, ok := splitContentLine(s); ok {
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
+
+// splitContentLine splits at the first colon outside a quoted parameter value
+func splitContentLine(s string) (contentLine, bool) {
+	quoted := false
+	colon := -1
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return contentLine{}, false
+	}
+
+	head := strings.Split(s[:colon], ";")
+	name := strings.ToUpper(head[0])
+	// vCard group prefixes, e.g. "item1.BDAY"
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	l := contentLine{Name: name, Value: s[colon+1:], Params: map[string]string{}}
+	for _, p := range head[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			l.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return l, true
+}
+
+// unescapeText reverses escapeText
+func unescapeText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// splitText splits a comma-separated TEXT list, honouring escaped commas
+func splitText(s string) []string {
+	var out []string
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			b.WriteByte(s[i])
+			b.WriteByte(s[i+1])
+			i++
+		case s[i] == ',':
+			out = append(out, unescapeText(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return append(out, unescapeText(b.String()))
+}
@@ -0,0 +1,225 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+// calendar wraps VEVENT lines in a VCALENDAR with CRLF line endings
+func calendar(lines ...string) string {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...)
+	all = append(all, "END:VCALENDAR")
+	return strings.Join(all, "\r\n") + "\r\n"
+}
+
+func TestParse(t *testing.T) {
+	riyadh := mustLoad(t, "Asia/Riyadh")
+	kuwait := mustLoad(t, "Asia/Kuwait")
+
+	tests := []struct {
+		name string
+		ics  string
+		want []Event
+	}{
+		{
+			"all-day event without an end lasts one day",
+			calendar("BEGIN:VEVENT", "UID:a", "SUMMARY:عيد", "DTSTART;VALUE=DATE:20250330", "END:VEVENT"),
+			[]Event{{UID: "a", Summary: "عيد", AllDay: true,
+				Start: time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"UTC date-time",
+			calendar("BEGIN:VEVENT", "DTSTART:20250301T090000Z", "DTEND:20250301T100000Z", "END:VEVENT"),
+			[]Event{{Start: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"TZID is honoured and floating times use the fallback zone",
+			calendar("BEGIN:VEVENT", "DTSTART;TZID=Asia/Riyadh:20250301T090000", "DTEND:20250301T100000", "END:VEVENT"),
+			[]Event{{Start: time.Date(2025, 3, 1, 9, 0, 0, 0, riyadh), End: time.Date(2025, 3, 1, 10, 0, 0, 0, kuwait)}},
+		},
+		{
+			"an end before the start is dropped",
+			calendar("BEGIN:VEVENT", "DTSTART:20250301T090000Z", "DTEND:20250301T080000Z", "END:VEVENT"),
+			[]Event{{Start: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"folded lines, escapes and categories",
+			calendar("BEGIN:VEVENT", "SUMMARY:Lunch\\, then", "  a walk", "DESCRIPTION:line one\\nline two\\; done",
+				"CATEGORIES:Work,Family\\, friends", "DTSTART:20250301T090000Z", "END:VEVENT"),
+			[]Event{{Summary: "Lunch, then a walk", Description: "line one\nline two; done",
+				Categories: []string{"Work", "Family, friends"}, Start: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"recurrence, exceptions and override",
+			calendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250301", "RRULE:FREQ=WEEKLY;BYDAY=SA",
+				"EXDATE;VALUE=DATE:20250308,20250315", "RECURRENCE-ID;VALUE=DATE:20250322", "END:VEVENT"),
+			[]Event{{Start: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), AllDay: true,
+				RRule:        "FREQ=WEEKLY;BYDAY=SA",
+				ExDates:      []time.Time{time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+				RecurrenceID: time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"alarms read only their own TRIGGER",
+			calendar("BEGIN:VEVENT", "DTSTART:20250301T090000Z",
+				"BEGIN:VALARM", "TRIGGER:-PT15M", "DESCRIPTION:not the event", "END:VALARM",
+				"BEGIN:VALARM", "TRIGGER;RELATED=END:-PT5M", "END:VALARM",
+				"BEGIN:VALARM", "TRIGGER;VALUE=DATE-TIME:20250301T080000Z", "END:VALARM",
+				"BEGIN:VALARM", "TRIGGER:-P1DT2H", "END:VALARM",
+				"END:VEVENT"),
+			[]Event{{Start: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), Alarms: []time.Duration{15 * time.Minute, 26 * time.Hour}}},
+		},
+		{
+			"events without a start are skipped",
+			calendar("BEGIN:VEVENT", "SUMMARY:x", "END:VEVENT", "BEGIN:VTODO", "DTSTART:20250301T090000Z", "END:VTODO"),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.ics), kuwait)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse(strings.NewReader("BEGIN:VCARD\r\nEND:VCARD\r\n"), time.UTC); !errors.Is(err, ErrNoEntries) {
+		t.Errorf("Parse of a vCard = %v, want ErrNoEntries", err)
+	}
+}
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	modified := time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC)
+	events := []Event{
+		{
+			UID: "birthday@ohabits", Summary: "عيد ميلاد أحمد; \\ مع العائلة, " + strings.Repeat("وهذا نص طويل ", 8),
+			Description: "سطر أول\nسطر ثانٍ", Categories: []string{"عائلة", "a,b"},
+			Start: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 5, 11, 0, 0, 0, 0, time.UTC), AllDay: true,
+			RRule: "FREQ=YEARLY", ExDates: []time.Time{time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)},
+			Alarms: []time.Duration{24 * time.Hour},
+		},
+		{
+			UID: "meeting@ohabits", Summary: "Meeting",
+			Start: time.Date(2025, 3, 4, 6, 30, 0, 0, time.UTC), End: time.Date(2025, 3, 4, 7, 15, 0, 0, time.UTC),
+			RecurrenceID: time.Date(2025, 3, 3, 6, 30, 0, 0, time.UTC),
+			Alarms:       []time.Duration{10 * time.Minute, 90 * time.Minute},
+			Priority:     1, LastModified: modified,
+		},
+	}
+
+	var buf bytes.Buffer
+	cal := &Calendar{Name: "تقويمي", TimeZone: "Asia/Kuwait", Refresh: time.Hour, Events: events}
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line %d is %d octets: %q", i, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+	}
+
+	got, err := Parse(&buf, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Priority and LAST-MODIFIED are written for clients but not read back
+	events[1].Priority, events[1].LastModified = 0, time.Time{}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, events)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"PT15M", 15 * time.Minute, true},
+		{"-PT1H30M", -90 * time.Minute, true},
+		{"+P1D", 24 * time.Hour, true},
+		{"P1W", 7 * 24 * time.Hour, true},
+		{"-P1DT2H3M4S", -(26*time.Hour + 3*time.Minute + 4*time.Second), true},
+		{"pt5m", 5 * time.Minute, true},
+		{"P", 0, false},
+		{"P1H", 0, false},
+		{"PT1D", 0, false},
+		{"P15", 0, false},
+		{"15M", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseDuration(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseDuration(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	// TRIGGER values as formatTrigger writes them
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"-PT15M", 15 * time.Minute},
+		{"-PT2H", 2 * time.Hour},
+		{"-PT26H5M", 26*time.Hour + 5*time.Minute},
+		{"PT0M", 0},
+		{"PT10M", -10 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, ok := parseTrigger(contentLine{Value: tt.in})
+		if !ok || got != tt.want {
+			t.Errorf("parseTrigger(%q) = %v, %v; want %v", tt.in, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseVCards(t *testing.T) {
+	vcf := strings.Join([]string{
+		"BEGIN:VCARD", "VERSION:3.0", "FN:سارة", "BDAY:1990-01-15", "END:VCARD",
+		"BEGIN:VCARD", "VERSION:4.0", "N:Doe;John;;;", "item1.BDAY:--0229", "END:VCARD",
+		"BEGIN:VCARD", "FN:No Birthday", "END:VCARD",
+		"BEGIN:VCARD", "FN:Timestamped", "BDAY:19851103T000000Z", "END:VCARD",
+		"BEGIN:VCARD", "FN:Broken", "BDAY:sometime", "END:VCARD",
+	}, "\n")
+	got, err := ParseVCards(strings.NewReader(vcf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Birthday{
+		{Name: "سارة", Date: time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC), HasYear: true},
+		{Name: "John Doe", Date: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{Name: "Timestamped", Date: time.Date(1985, 11, 3, 0, 0, 0, 0, time.UTC), HasYear: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseVCards =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, err := ParseVCards(strings.NewReader(calendar())); !errors.Is(err, ErrNoEntries) {
+		t.Errorf("ParseVCards of a calendar = %v, want ErrNoEntries", err)
+	}
+}
@@ -0,0 +1,372 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+// Thresholds used to decide whether a correlation is worth showing
+const (
+	MinSamples            = 10 // Minimum paired days before we compute anything
+	MinGroupSize          = 3  // Minimum days on each side (with / without)
+	HighConfidenceSamples = 30
+)
+
+// Factor kinds
+const (
+	KindHabit      = "habit"
+	KindWorkout    = "workout"
+	KindMedication = "medication"
+)
+
+// Confidence levels
+const (
+	ConfidenceHigh         = "high"
+	ConfidenceMedium       = "medium"
+	ConfidenceLow          = "low"
+	ConfidenceInsufficient = "insufficient"
+)
+
+// Correlation describes how one tracked factor relates to mood
+type Correlation struct {
+	Kind        string  `json:"kind"`                // habit, workout, medication
+	FactorID    string  `json:"factor_id,omitempty"` // Habit ID for habit factors
+	Factor      string  `json:"factor"`              // Display name
+	Lag         int     `json:"lag"`                 // 0 = same day, 1 = mood on the following day
+	N           int     `json:"n"`                   // Paired days used
+	NWith       int     `json:"n_with"`              // Days the factor was done / fully adhered
+	NWithout    int     `json:"n_without"`
+	MoodWith    float64 `json:"mood_with"`    // Average mood when the factor was done
+	MoodWithout float64 `json:"mood_without"` // Average mood when it wasn't
+	R           float64 `json:"r"`            // Pearson (point-biserial) correlation
+	CILow       float64 `json:"ci_low"`       // 95% confidence interval for r
+	CIHigh      float64 `json:"ci_high"`
+	PValue      float64 `json:"p_value"`
+	AdjustedP   float64 `json:"adjusted_p"` // Benjamini-Hochberg adjusted across all factors
+	Confidence  string  `json:"confidence"`
+}
+
+// Difference returns the mood difference between days with and without the factor
+func (c *Correlation) Difference() float64 {
+	return c.MoodWith - c.MoodWithout
+}
+
+// Report is the full insights result for a window
+type Report struct {
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Days         int           `json:"days"`
+	MoodDays     int           `json:"mood_days"`
+	AverageMood  float64       `json:"average_mood"`
+	Correlations []Correlation `json:"correlations"`
+}
+
+// Analyze computes mood correlations for habits, workouts and medication adherence
+func Analyze(days []database.DailyMetrics, habits []database.Habit) *Report {
+	report := &Report{Days: len(days), Correlations: []Correlation{}}
+	if len(days) == 0 {
+		return report
+	}
+	report.From = days[0].Date
+	report.To = days[len(days)-1].Date
+
+	var moodSum float64
+	for _, d := range days {
+		if d.Mood != nil {
+			report.MoodDays++
+			moodSum += float64(*d.Mood)
+		}
+	}
+	if report.MoodDays > 0 {
+		report.AverageMood = moodSum / float64(report.MoodDays)
+	}
+
+	// Habits: mood on days the habit was completed vs. skipped (only when scheduled)
+	for _, h := range habits {
+		if h.IsDeleted {
+			continue
+		}
+		habitID := h.ID
+		c := correlate(days, 0, func(d database.DailyMetrics) (float64, bool) {
+			for _, id := range d.HabitsScheduled {
+				if id == habitID {
+					if d.HabitsCompleted[habitID] {
+						return 1, true
+					}
+					return 0, true
+				}
+			}
+			return 0, false
+		})
+		c.Kind = KindHabit
+		c.FactorID = habitID.String()
+		c.Factor = h.Name
+		report.Correlations = append(report.Correlations, c)
+	}
+
+	// Workouts: same day and the day after
+	for _, lag := range []int{0, 1} {
+		c := correlate(days, lag, func(d database.DailyMetrics) (float64, bool) {
+			if d.WorkedOut {
+				return 1, true
+			}
+			return 0, true
+		})
+		c.Kind = KindWorkout
+		report.Correlations = append(report.Correlations, c)
+	}
+
+	// Medication adherence: same day and the day after
+	for _, lag := range []int{0, 1} {
+		c := correlate(days, lag, func(d database.DailyMetrics) (float64, bool) {
+			adherence := d.MedicationAdherence()
+			return adherence, adherence >= 0
+		})
+		c.Kind = KindMedication
+		report.Correlations = append(report.Correlations, c)
+	}
+
+	adjustPValues(report.Correlations)
+	for i := range report.Correlations {
+		report.Correlations[i].Confidence = confidence(&report.Correlations[i])
+	}
+
+	sort.SliceStable(report.Correlations, func(i, j int) bool {
+		a, b := report.Correlations[i], report.Correlations[j]
+		if confidenceRank(a.Confidence) != confidenceRank(b.Confidence) {
+			return confidenceRank(a.Confidence) < confidenceRank(b.Confidence)
+		}
+		return math.Abs(a.R) > math.Abs(b.R)
+	})
+
+	return report
+}
+
+// correlate pairs the factor on day d with mood on day d+lag
+func correlate(days []database.DailyMetrics, lag int, factor func(database.DailyMetrics) (float64, bool)) Correlation {
+	c := Correlation{Lag: lag, PValue: 1, AdjustedP: 1}
+
+	var xs, ys []float64
+	var sumWith, sumWithout float64
+	for i := 0; i+lag < len(days); i++ {
+		mood := days[i+lag].Mood
+		if mood == nil {
+			continue
+		}
+		x, ok := factor(days[i])
+		if !ok {
+			continue
+		}
+		y := float64(*mood)
+		xs = append(xs, x)
+		ys = append(ys, y)
+
+		if x >= 1 {
+			c.NWith++
+			sumWith += y
+		} else {
+			c.NWithout++
+			sumWithout += y
+		}
+	}
+
+	c.N = len(xs)
+	if c.NWith > 0 {
+		c.MoodWith = sumWith / float64(c.NWith)
+	}
+	if c.NWithout > 0 {
+		c.MoodWithout = sumWithout / float64(c.NWithout)
+	}
+
+	r, ok := pearson(xs, ys)
+	if !ok || c.N < 4 {
+		return c
+	}
+	c.R = r
+
+	// 95% CI via Fisher z-transform
+	r = math.Max(math.Min(r, 0.999999), -0.999999)
+	z := math.Atanh(r)
+	se := 1 / math.Sqrt(float64(c.N-3))
+	c.CILow = math.Tanh(z - 1.96*se)
+	c.CIHigh = math.Tanh(z + 1.96*se)
+
+	// Two-sided p-value from the t distribution with n-2 degrees of freedom
+	df := float64(c.N - 2)
+	t := r * math.Sqrt(df/(1-r*r))
+	c.PValue = studentTwoSidedP(t, df)
+	c.AdjustedP = c.PValue
+
+	return c
+}
+
+// pearson returns the Pearson correlation, or false if either side has no variance
+func pearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0, false
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// adjustPValues applies the Benjamini-Hochberg procedure so that testing
+// many habits at once doesn't produce "significant" results by chance
+func adjustPValues(cs []Correlation) {
+	var idx []int
+	for i := range cs {
+		if cs[i].N >= MinSamples {
+			idx = append(idx, i)
+		}
+	}
+	m := len(idx)
+	if m == 0 {
+		return
+	}
+
+	sort.Slice(idx, func(a, b int) bool { return cs[idx[a]].PValue < cs[idx[b]].PValue })
+
+	prev := 1.0
+	for rank := m; rank >= 1; rank-- {
+		i := idx[rank-1]
+		adjusted := math.Min(prev, cs[i].PValue*float64(m)/float64(rank))
+		cs[i].AdjustedP = adjusted
+		prev = adjusted
+	}
+}
+
+func confidence(c *Correlation) string {
+	if c.N < MinSamples || c.NWith < MinGroupSize || c.NWithout < MinGroupSize {
+		return ConfidenceInsufficient
+	}
+	switch {
+	case c.AdjustedP < 0.01 && c.N >= HighConfidenceSamples:
+		return ConfidenceHigh
+	case c.AdjustedP < 0.05:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+func confidenceRank(level string) int {
+	switch level {
+	case ConfidenceHigh:
+		return 0
+	case ConfidenceMedium:
+		return 1
+	case ConfidenceLow:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// studentTwoSidedP returns P(|T| > |t|) for Student's t with df degrees of freedom
+func studentTwoSidedP(t, df float64) float64 {
+	if df <= 0 || math.IsNaN(t) {
+		return 1
+	}
+	if math.IsInf(t, 0) {
+		return 0
+	}
+	x := df / (df + t*t)
+	return regularizedIncompleteBeta(df/2, 0.5, x)
+}
+
+// regularizedIncompleteBeta computes I_x(a, b) using the continued fraction expansion
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgA, _ := math.Lgamma(a)
+	lgB, _ := math.Lgamma(b)
+	lgAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lgAB - lgA - lgB + a*math.Log(x) + b*math.Log(1-x))
+
+	// Use the symmetry relation for faster convergence
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+	}
+	return front * betaContinuedFraction(a, b, x) / a
+}
+
+// betaContinuedFraction evaluates the incomplete beta continued fraction (modified Lentz)
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-12
+		tiny          = 1e-300
+	)
+
+	qab, qap, qam := a+b, a+1, a-1
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+
+		if math.Abs(del-1) < epsilon {
+			break
+		}
+	}
+
+	return h
+}
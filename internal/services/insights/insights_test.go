@@ -0,0 +1,164 @@
+package insights
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+)
+
+func approx(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs, ys []float64
+		want   float64
+		ok     bool
+	}{
+		{"textbook", []float64{1, 2, 3, 4, 5}, []float64{2, 4, 5, 4, 5}, 6 / math.Sqrt(60), true},
+		{"perfect positive", []float64{1, 2, 3}, []float64{10, 20, 30}, 1, true},
+		{"perfect negative", []float64{1, 2, 3}, []float64{3, 2, 1}, -1, true},
+		{"binary factor", []float64{0, 0, 1, 1}, []float64{2, 3, 4, 5}, 0.894427191, true},
+		{"no variance in x", []float64{1, 1, 1}, []float64{1, 2, 3}, 0, false},
+		{"no variance in y", []float64{1, 2, 3}, []float64{4, 4, 4}, 0, false},
+		{"too few points", []float64{1}, []float64{1}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pearson(tt.xs, tt.ys)
+			if ok != tt.ok || !approx(got, tt.want, 1e-9) {
+				t.Errorf("pearson = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStudentTwoSidedP(t *testing.T) {
+	tests := []struct {
+		name  string
+		t, df float64
+		want  float64
+	}{
+		// Closed forms: df=1 is the Cauchy distribution, df=2 has P = 1 - t/sqrt(2+t²)
+		{"cauchy t=1", 1, 1, 0.5},
+		{"df=2 t=2", 2, 2, 1 - 2/math.Sqrt(6)},
+		// Critical values from the t table
+		{"df=1 critical", 12.7062047, 1, 0.05},
+		{"df=10 critical", 2.228138852, 10, 0.05},
+		{"df=30 critical 1%", 2.749995654, 30, 0.01},
+		{"negative t is symmetric", -2.228138852, 10, 0.05},
+		{"t=0", 0, 10, 1},
+		{"infinite t", math.Inf(1), 10, 0},
+		{"no degrees of freedom", 3, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := studentTwoSidedP(tt.t, tt.df); !approx(got, tt.want, 1e-6) {
+				t.Errorf("studentTwoSidedP(%v, %v) = %v, want %v", tt.t, tt.df, got, tt.want)
+			}
+		})
+	}
+}
+
+// days builds consecutive days with the given moods and workout flags
+func days(moods []int, workedOut []bool) []database.DailyMetrics {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]database.DailyMetrics, len(moods))
+	for i := range moods {
+		mood := moods[i]
+		out[i] = database.DailyMetrics{Date: start.AddDate(0, 0, i), Mood: &mood, WorkedOut: workedOut[i]}
+	}
+	return out
+}
+
+func TestCorrelate(t *testing.T) {
+	// x = 1..5 against mood 2,4,5,4,5: r = 6/sqrt(60), t = 2.1213 on 3 df
+	ds := days([]int{2, 4, 5, 4, 5}, make([]bool, 5))
+	c := correlate(ds, 0, func(d database.DailyMetrics) (float64, bool) {
+		return float64(d.Date.Day()), true
+	})
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"r", c.R, 0.7745966692},
+		// Fisher z: tanh(atanh(r) ± 1.96/sqrt(n-3))
+		{"ci low", c.CILow, -0.3401045564},
+		{"ci high", c.CIHigh, 0.9842365518},
+		// Exact for 3 df: 1 - (2/π)(θ + sinθ cosθ), θ = atan(t/√3)
+		{"p", c.PValue, 0.1240270627},
+	}
+	for _, ch := range checks {
+		if !approx(ch.got, ch.want, 1e-6) {
+			t.Errorf("%s = %v, want %v", ch.name, ch.got, ch.want)
+		}
+	}
+	if c.N != 5 || c.NWith != 5 || c.NWithout != 0 {
+		t.Errorf("counts = %d/%d/%d, want 5/5/0", c.N, c.NWith, c.NWithout)
+	}
+}
+
+func TestCorrelateLag(t *testing.T) {
+	// Workouts on days 0 and 2 lift the mood of the following day
+	ds := days([]int{3, 5, 3, 5, 3}, []bool{true, false, true, false, false})
+	factor := func(d database.DailyMetrics) (float64, bool) {
+		if d.WorkedOut {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	next := correlate(ds, 1, factor)
+	if next.N != 4 || next.NWith != 2 || next.MoodWith != 5 || next.MoodWithout != 3 {
+		t.Errorf("lag 1: n=%d with=%d moodWith=%v moodWithout=%v", next.N, next.NWith, next.MoodWith, next.MoodWithout)
+	}
+	if same := correlate(ds, 0, factor); same.MoodWith != 3 {
+		t.Errorf("lag 0: moodWith=%v, want 3", same.MoodWith)
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	cs := []Correlation{
+		{N: MinSamples, PValue: 0.01},
+		{N: MinSamples, PValue: 0.04},
+		{N: MinSamples, PValue: 0.03},
+		{N: MinSamples, PValue: 0.005},
+		{N: MinSamples - 1, PValue: 0.001, AdjustedP: 0.001}, // too few samples: not counted or adjusted
+	}
+	adjustPValues(cs)
+
+	// Benjamini-Hochberg over m=4: p·m/rank, made monotone from the largest rank down
+	want := []float64{0.02, 0.04, 0.04, 0.02, 0.001}
+	for i, w := range want {
+		if !approx(cs[i].AdjustedP, w, 1e-12) {
+			t.Errorf("AdjustedP[%d] = %v, want %v", i, cs[i].AdjustedP, w)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Correlation
+		want string
+	}{
+		{"too few days", Correlation{N: 9, NWith: 5, NWithout: 4, AdjustedP: 0.001}, ConfidenceInsufficient},
+		{"one-sided", Correlation{N: 40, NWith: 38, NWithout: 2, AdjustedP: 0.001}, ConfidenceInsufficient},
+		{"high", Correlation{N: 30, NWith: 15, NWithout: 15, AdjustedP: 0.009}, ConfidenceHigh},
+		{"strong but short", Correlation{N: 29, NWith: 15, NWithout: 14, AdjustedP: 0.009}, ConfidenceMedium},
+		{"medium", Correlation{N: 20, NWith: 10, NWithout: 10, AdjustedP: 0.04}, ConfidenceMedium},
+		{"low", Correlation{N: 20, NWith: 10, NWithout: 10, AdjustedP: 0.2}, ConfidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := confidence(&tt.c); got != tt.want {
+				t.Errorf("confidence = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
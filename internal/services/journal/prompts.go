@@ -0,0 +1,78 @@
+package journal
+
+import (
+	"strings"
+	"time"
+)
+
+// Prompt is a guided journaling question in Arabic and English
+type Prompt struct {
+	AR string `json:"ar"`
+	EN string `json:"en"`
+}
+
+// Text returns the prompt in the requested language ("en" or Arabic by default)
+func (p Prompt) Text(lang string) string {
+	if lang == "en" {
+		return p.EN
+	}
+	return p.AR
+}
+
+// Language picks the prompt language from an Accept-Language header: "en"
+// when English is preferred over Arabic, Arabic otherwise. Accounts have no
+// language setting, so the browser's or app's preference stands in for it.
+func Language(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		base, _, _ := strings.Cut(tag, "-")
+		switch base {
+		case "en":
+			return "en"
+		case "ar":
+			return "ar"
+		}
+	}
+	return "ar"
+}
+
+// Prompts is the rotating prompt library (one prompt per day, in order)
+var Prompts = []Prompt{
+	{AR: "ما الشيء الذي جعلك تبتسم اليوم؟", EN: "What made you smile today?"},
+	{AR: "ما أكثر شيء تعلمته اليوم؟", EN: "What is the most important thing you learned today?"},
+	{AR: "من الشخص الذي تشعر بالامتنان له اليوم، ولماذا؟", EN: "Who are you grateful for today, and why?"},
+	{AR: "ما التحدي الذي واجهته اليوم وكيف تعاملت معه؟", EN: "What challenge did you face today and how did you handle it?"},
+	{AR: "لو عاد بك اليوم، ما الذي ستفعله بشكل مختلف؟", EN: "If you could redo today, what would you do differently?"},
+	{AR: "ما الشيء الصغير الذي أنجزته وتفخر به؟", EN: "What small win are you proud of today?"},
+	{AR: "كيف كانت طاقتك اليوم؟ ما الذي رفعها أو خفضها؟", EN: "How was your energy today? What raised or drained it?"},
+	{AR: "ما الذي تتطلع إليه غداً؟", EN: "What are you looking forward to tomorrow?"},
+	{AR: "صف لحظة هادئة عشتها اليوم.", EN: "Describe a calm moment you had today."},
+	{AR: "ما العادة التي تريد تقويتها هذا الأسبوع؟", EN: "Which habit do you want to strengthen this week?"},
+	{AR: "ما الذي أقلقك اليوم؟ وهل هو تحت سيطرتك؟", EN: "What worried you today? Is it within your control?"},
+	{AR: "اكتب ثلاث كلمات تصف يومك.", EN: "Write three words that describe your day."},
+	{AR: "ما اللطف الذي قدمته أو تلقيته اليوم؟", EN: "What kindness did you give or receive today?"},
+	{AR: "ما الذي تحتاج أن تسامح نفسك عليه؟", EN: "What do you need to forgive yourself for?"},
+	{AR: "ما الهدف الذي اقتربت منه خطوة اليوم؟", EN: "Which goal did you move one step closer to today?"},
+	{AR: "ما الشيء الذي تأجله منذ فترة؟ وما الخطوة الأولى؟", EN: "What have you been putting off? What's the first step?"},
+	{AR: "متى شعرت بأنك في أفضل حالاتك اليوم؟", EN: "When did you feel at your best today?"},
+	{AR: "ما النعمة التي تعتبرها أمراً مسلّماً به؟", EN: "What blessing do you usually take for granted?"},
+	{AR: "ما الحديث الذي بقي في ذهنك اليوم؟", EN: "Which conversation stayed on your mind today?"},
+	{AR: "كيف اعتنيت بصحتك اليوم؟", EN: "How did you take care of your health today?"},
+	{AR: "ما الذي تريد أن تتذكره من هذا اليوم بعد سنة؟", EN: "What do you want to remember about today a year from now?"},
+	{AR: "ما القرار الذي اتخذته اليوم وأنت راضٍ عنه؟", EN: "What decision did you make today that you're happy with?"},
+	{AR: "ما الشيء الجديد الذي جربته مؤخراً؟", EN: "What new thing have you tried recently?"},
+	{AR: "ما الذي يمكنك التخلي عنه لتخفيف الضغط؟", EN: "What could you let go of to reduce stress?"},
+	{AR: "اكتب رسالة قصيرة لنفسك بعد شهر.", EN: "Write a short note to yourself one month from now."},
+	{AR: "ما الأولوية الأهم لغد؟", EN: "What is the single most important priority for tomorrow?"},
+	{AR: "ما الذي ألهمك اليوم؟", EN: "What inspired you today?"},
+	{AR: "كيف كان نومك وكيف أثر على يومك؟", EN: "How did you sleep, and how did it affect your day?"},
+	{AR: "من الشخص الذي تود أن تتواصل معه قريباً؟", EN: "Who would you like to reach out to soon?"},
+	{AR: "ما الدرس الذي علمك إياه هذا الأسبوع؟", EN: "What lesson did this week teach you?"},
+}
+
+// PromptForDate returns the prompt of the day; every user sees the same prompt on the same date
+func PromptForDate(date time.Time) Prompt {
+	days := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	return Prompts[int(days%int64(len(Prompts)))]
+}
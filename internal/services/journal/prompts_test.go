@@ -0,0 +1,22 @@
+package journal
+
+import "testing"
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "ar"},
+		{"ar-KW,ar;q=0.9,en;q=0.8", "ar"},
+		{"en-US,en;q=0.9,ar;q=0.8", "en"},
+		{"EN", "en"},
+		{"fr-FR,fr;q=0.9,en;q=0.5", "en"},
+		{"fr-FR,de;q=0.5", "ar"},
+	}
+	for _, tt := range tests {
+		if got := Language(tt.header); got != tt.want {
+			t.Errorf("Language(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
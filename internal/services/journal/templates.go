@@ -0,0 +1,180 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"ohabits/internal/database"
+)
+
+// Field types
+const (
+	FieldText = "text"
+	FieldList = "list"
+)
+
+// MaxListItems caps the number of inputs shown for a list field
+const MaxListItems = 10
+
+// Preset is a built-in template users can add with one click
+type Preset struct {
+	Key    string                       `json:"key"`
+	Name   string                       `json:"name"`
+	Icon   string                       `json:"icon"`
+	Fields []database.NoteTemplateField `json:"fields"`
+}
+
+// Presets are the suggested starter templates
+var Presets = []Preset{
+	{
+		Key:  "gratitude",
+		Name: "الامتنان",
+		Icon: "🙏",
+		Fields: []database.NoteTemplateField{
+			{Key: "gratitude", Label: "أنا ممتن لـ", Type: FieldList, Count: 3},
+		},
+	},
+	{
+		Key:  "three_wins",
+		Name: "ثلاثة إنجازات",
+		Icon: "🏆",
+		Fields: []database.NoteTemplateField{
+			{Key: "wins", Label: "إنجازات اليوم", Type: FieldList, Count: 3},
+		},
+	},
+	{
+		Key:  "tomorrow",
+		Name: "أولوية الغد",
+		Icon: "🎯",
+		Fields: []database.NoteTemplateField{
+			{Key: "priority", Label: "أهم شيء غداً", Type: FieldText},
+		},
+	},
+	{
+		Key:  "evening_review",
+		Name: "مراجعة المساء",
+		Icon: "🌙",
+		Fields: []database.NoteTemplateField{
+			{Key: "went_well", Label: "ما الذي سار بشكل جيد؟", Type: FieldText},
+			{Key: "improve", Label: "ما الذي يمكن تحسينه؟", Type: FieldText},
+		},
+	},
+}
+
+// FindPreset returns the preset with the given key
+func FindPreset(key string) (Preset, bool) {
+	for _, p := range Presets {
+		if p.Key == key {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// NormalizeFields validates field types and counts and assigns missing keys.
+// A field sent without a key keeps the key of the previous field with the same
+// label, or else of the previous field at its position when no other field
+// claims that key, so existing entries stay attached after a reorder or a
+// relabel.
+func NormalizeFields(fields []database.NoteTemplateField, previous []database.NoteTemplateField) []database.NoteTemplateField {
+	keyByLabel := make(map[string]string)
+	for _, f := range previous {
+		keyByLabel[f.Label] = f.Key
+	}
+	claimed := make(map[string]bool)
+	for _, f := range fields {
+		if f.Key != "" {
+			claimed[f.Key] = true
+		} else if key, ok := keyByLabel[strings.TrimSpace(f.Label)]; ok {
+			claimed[key] = true
+		}
+	}
+
+	used := make(map[string]bool)
+	var result []database.NoteTemplateField
+	for _, f := range fields {
+		f.Label = strings.TrimSpace(f.Label)
+		if f.Label == "" {
+			continue
+		}
+
+		if f.Type != FieldList {
+			f.Type = FieldText
+			f.Count = 0
+		} else if f.Count < 1 {
+			f.Count = 3
+		} else if f.Count > MaxListItems {
+			f.Count = MaxListItems
+		}
+
+		if f.Key == "" {
+			f.Key = keyByLabel[f.Label]
+		}
+		if pos := len(result); f.Key == "" && pos < len(previous) && !claimed[previous[pos].Key] {
+			f.Key = previous[pos].Key
+			claimed[f.Key] = true
+		}
+		if f.Key == "" {
+			f.Key = slugify(f.Label)
+		}
+		base := f.Key
+		for i := 2; used[f.Key] || f.Key == ""; i++ {
+			f.Key = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[f.Key] = true
+
+		result = append(result, f)
+	}
+	return result
+}
+
+// slugify turns an ASCII label into a key; non-Latin labels fall back to "field"
+func slugify(label string) string {
+	var b strings.Builder
+	lastUnderscore := false
+	for _, r := range strings.ToLower(label) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			lastUnderscore = false
+		case !lastUnderscore && b.Len() > 0:
+			b.WriteRune('_')
+			lastUnderscore = true
+		}
+	}
+	key := strings.Trim(b.String(), "_")
+	if key == "" {
+		return "field"
+	}
+	return key
+}
+
+// RenderText renders the filled fields of a template as plain text for the daily note
+func RenderText(t database.NoteTemplateWithEntries) string {
+	var b strings.Builder
+	for _, f := range t.Fields {
+		entry, ok := t.Entries[f.Key]
+		if !ok {
+			continue
+		}
+		b.WriteString(f.Label)
+		b.WriteString(":\n")
+		if f.Type == FieldList {
+			for _, item := range entry.Items() {
+				b.WriteString("• ")
+				b.WriteString(item)
+				b.WriteString("\n")
+			}
+		} else {
+			b.WriteString(strings.TrimSpace(entry.Value))
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// HasEntries reports whether any field of the template has a value
+func HasEntries(t database.NoteTemplateWithEntries) bool {
+	return len(t.Entries) > 0
+}
@@ -0,0 +1,81 @@
+package journal
+
+import (
+	"reflect"
+	"testing"
+
+	"ohabits/internal/database"
+)
+
+func TestNormalizeFields(t *testing.T) {
+	previous := []database.NoteTemplateField{
+		{Key: "wins", Label: "إنجازات اليوم", Type: FieldList, Count: 3},
+		{Key: "mood", Label: "المزاج", Type: FieldText},
+	}
+
+	type field struct{ key, label string }
+	tests := []struct {
+		name     string
+		fields   []database.NoteTemplateField
+		previous []database.NoteTemplateField
+		want     []field
+	}{
+		{
+			"new fields get slugs, non-Latin labels fall back to field",
+			[]database.NoteTemplateField{{Label: " Daily Wins! "}, {Label: "ملاحظات"}, {Label: "ملاحظات أخرى"}, {Label: "  "}},
+			nil,
+			[]field{{"daily_wins", "Daily Wins!"}, {"field", "ملاحظات"}, {"field_2", "ملاحظات أخرى"}},
+		},
+		{
+			"keys follow their labels when fields are reordered",
+			[]database.NoteTemplateField{{Label: "المزاج"}, {Label: "إنجازات اليوم"}},
+			previous,
+			[]field{{"mood", "المزاج"}, {"wins", "إنجازات اليوم"}},
+		},
+		{
+			"a relabelled field keeps the key at its position",
+			[]database.NoteTemplateField{{Label: "أفضل ما حدث اليوم"}, {Label: "المزاج"}},
+			previous,
+			[]field{{"wins", "أفضل ما حدث اليوم"}, {"mood", "المزاج"}},
+		},
+		{
+			"a position's key isn't reused when another field claims it",
+			[]database.NoteTemplateField{{Label: "المزاج"}, {Label: "سؤال جديد"}},
+			previous,
+			[]field{{"mood", "المزاج"}, {"field", "سؤال جديد"}},
+		},
+		{
+			"keys sent by the client win",
+			[]database.NoteTemplateField{{Key: "mood", Label: "كيف أشعر"}, {Label: "إنجازات"}},
+			previous,
+			[]field{{"mood", "كيف أشعر"}, {"field", "إنجازات"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []field
+			for _, f := range NormalizeFields(tt.fields, tt.previous) {
+				got = append(got, field{f.Key, f.Label})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeFields keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFieldsTypes(t *testing.T) {
+	got := NormalizeFields([]database.NoteTemplateField{
+		{Label: "a", Type: "date", Count: 4},
+		{Label: "b", Type: FieldList},
+		{Label: "c", Type: FieldList, Count: 50},
+	}, nil)
+	want := []database.NoteTemplateField{
+		{Key: "a", Label: "a", Type: FieldText},
+		{Key: "b", Label: "b", Type: FieldList, Count: 3},
+		{Key: "c", Label: "c", Type: FieldList, Count: MaxListItems},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeFields = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+// Package media turns uploaded photos into EXIF-free, correctly oriented, resized variants.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+
+	// Image format decoders
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+	"github.com/gen2brain/heic"
+	_ "golang.org/x/image/webp"
+)
+
+// Variant names
+const (
+	VariantThumb  = "thumb"
+	VariantMedium = "medium"
+	VariantFull   = "full"
+)
+
+const jpegQuality = 82
+
+// Spec describes one output size
+type Spec struct {
+	Name string
+	Size int  // Longest side (or square side when Crop)
+	Crop bool // Fill a Size×Size square instead of fitting inside it
+}
+
+// Specs are the variants produced for every photo
+var Specs = []Spec{
+	{Name: VariantThumb, Size: 320, Crop: true},
+	{Name: VariantMedium, Size: 1024},
+	{Name: VariantFull, Size: 2560},
+}
+
+// Output is one encoded file
+type Output struct {
+	Variant     string
+	Ext         string // ".jpg", ".png" or ".webp"
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// MaxPixels caps the width×height of an upload. A small file can declare a
+// huge canvas, and decoding allocates all of it up front.
+const MaxPixels = 50_000_000
+
+var (
+	// ErrUnsupported is returned when the upload isn't a decodable image
+	ErrUnsupported = errors.New("media: unsupported image format")
+	// ErrTooLarge is returned when the image has more than MaxPixels pixels
+	ErrTooLarge = errors.New("media: image dimensions too large")
+)
+
+// IsHEIC reports whether the upload is an HEIC/HEIF photo (iPhone default)
+func IsHEIC(contentType, filename string) bool {
+	name := strings.ToLower(filename)
+	return contentType == "image/heic" || contentType == "image/heif" ||
+		strings.HasSuffix(name, ".heic") || strings.HasSuffix(name, ".heif")
+}
+
+// Decode reads an image of any supported format (JPEG, PNG, GIF, WebP, HEIC) and
+// applies its EXIF orientation. Decoding drops all metadata, including GPS location.
+// The header is checked first: images over MaxPixels return ErrTooLarge.
+func Decode(data []byte, contentType, filename string) (image.Image, error) {
+	if err := checkDimensions(data, IsHEIC(contentType, filename)); err != nil {
+		return nil, err
+	}
+
+	if IsHEIC(contentType, filename) {
+		// libheif applies the container's rotation/mirroring itself
+		img, err := heic.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrUnsupported
+		}
+		return img, nil
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		// HEIC sent with a generic content type is still recognised by its ftyp box
+		if img, herr := heic.Decode(bytes.NewReader(data)); herr == nil {
+			return img, nil
+		}
+		return nil, ErrUnsupported
+	}
+	return img, nil
+}
+
+// checkDimensions reads only the image header and rejects images over MaxPixels
+func checkDimensions(data []byte, heif bool) error {
+	var cfg image.Config
+	var err error
+	if heif {
+		cfg, err = heic.DecodeConfig(bytes.NewReader(data))
+	} else if cfg, _, err = image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		// HEIC sent with a generic content type
+		cfg, err = heic.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Process decodes an upload and encodes every variant in Specs.
+// Opaque images become JPEG, transparent ones PNG plus a WebP copy when it's smaller:
+// the pure-Go WebP encoder is lossless, so it beats PNG for graphics and screenshots
+// but never JPEG for photos (and takes seconds on a full-size photo).
+func Process(data []byte, contentType, filename string) ([]Output, error) {
+	img, err := Decode(data, contentType, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	opaque := isOpaque(img)
+
+	var outputs []Output
+	for _, spec := range Specs {
+		resized := Resize(img, spec)
+
+		base, err := encodeBase(resized, opaque)
+		if err != nil {
+			return nil, err
+		}
+		base.Variant = spec.Name
+		outputs = append(outputs, base)
+
+		if opaque {
+			continue
+		}
+		if webp, err := encodeWebP(resized); err == nil && len(webp.Data) < len(base.Data) {
+			webp.Variant = spec.Name
+			outputs = append(outputs, webp)
+		}
+	}
+
+	return outputs, nil
+}
+
+// Resize scales img down to the spec (never up)
+func Resize(img image.Image, spec Spec) image.Image {
+	if spec.Crop {
+		return imaging.Fill(img, spec.Size, spec.Size, imaging.Center, imaging.Lanczos)
+	}
+
+	b := img.Bounds()
+	if b.Dx() <= spec.Size && b.Dy() <= spec.Size {
+		return img
+	}
+	return imaging.Fit(img, spec.Size, spec.Size, imaging.Lanczos)
+}
+
+// EncodeJPEG encodes img as a metadata-free JPEG
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	return imaging.Encode(w, img, imaging.JPEG, imaging.JPEGQuality(jpegQuality))
+}
+
+func encodeBase(img image.Image, opaque bool) (Output, error) {
+	var buf bytes.Buffer
+	out := Output{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	if opaque {
+		if err := EncodeJPEG(&buf, img); err != nil {
+			return Output{}, err
+		}
+		out.Ext, out.ContentType = ".jpg", "image/jpeg"
+	} else {
+		if err := png.Encode(&buf, img); err != nil {
+			return Output{}, err
+		}
+		out.Ext, out.ContentType = ".png", "image/png"
+	}
+
+	out.Data = buf.Bytes()
+	return out, nil
+}
+
+func encodeWebP(img image.Image) (Output, error) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return Output{}, err
+	}
+	return Output{
+		Ext:         ".webp",
+		ContentType: "image/webp",
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// isOpaque reports whether every pixel is fully opaque
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
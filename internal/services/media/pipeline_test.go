@@ -0,0 +1,90 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+func TestDecodeDimensions(t *testing.T) {
+	var small bytes.Buffer
+	if err := gif.Encode(&small, image.NewPaletted(image.Rect(0, 0, 4, 3), color.Palette{color.Black}), nil); err != nil {
+		t.Fatal(err)
+	}
+	// A few bytes can declare a 65535×65535 canvas
+	huge := bytes.Clone(small.Bytes())
+	copy(huge[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"small", small.Bytes(), nil},
+		{"huge canvas", huge, ErrTooLarge},
+		{"not an image", []byte("hello"), ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data, "image/gif", "x.gif")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decode error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessWebPVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		img      image.Image
+		base     string
+		wantWebP bool
+	}{
+		{"photo", photo(400, 300), "image/jpeg", false},
+		{"transparent", image.NewNRGBA(image.Rect(0, 0, 400, 300)), "image/png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			outputs, err := Process(buf.Bytes(), "image/png", "x.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kinds := map[string]map[string]bool{}
+			for _, out := range outputs {
+				if kinds[out.Variant] == nil {
+					kinds[out.Variant] = map[string]bool{}
+				}
+				kinds[out.Variant][out.ContentType] = true
+			}
+			for _, spec := range Specs {
+				if !kinds[spec.Name][tt.base] {
+					t.Errorf("%s: no %s output", spec.Name, tt.base)
+				}
+				if kinds[spec.Name]["image/webp"] != tt.wantWebP {
+					t.Errorf("%s: webp output = %v, want %v", spec.Name, kinds[spec.Name]["image/webp"], tt.wantWebP)
+				}
+			}
+		})
+	}
+}
+
+// photo returns an opaque gradient
+func photo(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x + y), 0xff})
+		}
+	}
+	return img
+}
@@ -0,0 +1,242 @@
+// Package prayer computes the daily Islamic prayer times locally from a
+// latitude/longitude and a calculation method, without any network lookup.
+//
+// The sun's position follows the low-precision formulas of the US Naval
+// Observatory (good to about a minute this century); each time is refined by
+// recomputing the sun's position at the first estimate.
+package prayer
+
+import (
+	"math"
+	"time"
+
+	"ohabits/internal/services/hijri"
+)
+
+// Prayer identifies one of the daily prayer times
+type Prayer string
+
+const (
+	Fajr    Prayer = "fajr"
+	Sunrise Prayer = "sunrise"
+	Dhuhr   Prayer = "dhuhr"
+	Asr     Prayer = "asr"
+	Maghrib Prayer = "maghrib"
+	Isha    Prayer = "isha"
+)
+
+// Prayers lists the times in the order of the day
+var Prayers = []Prayer{Fajr, Sunrise, Dhuhr, Asr, Maghrib, Isha}
+
+// MaxOffsetMinutes bounds how far an item anchored to a prayer can be from it
+const MaxOffsetMinutes = 180
+
+var prayerNames = map[Prayer]string{
+	Fajr:    "الفجر",
+	Sunrise: "الشروق",
+	Dhuhr:   "الظهر",
+	Asr:     "العصر",
+	Maghrib: "المغرب",
+	Isha:    "العشاء",
+}
+
+// Name returns the Arabic name of p
+func (p Prayer) Name() string {
+	return prayerNames[p]
+}
+
+// Valid reports whether p is a known prayer time
+func (p Prayer) Valid() bool {
+	_, ok := prayerNames[p]
+	return ok
+}
+
+// Method is a calculation convention: the sun depression angles for Fajr and
+// Isha, or a fixed Isha interval after Maghrib
+type Method struct {
+	Name        string  `json:"name"`
+	FajrAngle   float64 `json:"fajr_angle"`
+	IshaAngle   float64 `json:"isha_angle,omitempty"`
+	IshaMinutes int     `json:"isha_minutes,omitempty"` // after Maghrib; 30 more in Ramadan
+}
+
+// DefaultMethod is used when a user has not chosen one
+const DefaultMethod = "kuwait"
+
+// Methods are the supported calculation methods by key
+var Methods = map[string]Method{
+	"kuwait":      {Name: "وزارة الأوقاف الكويتية", FajrAngle: 18, IshaAngle: 17.5},
+	"umm_al_qura": {Name: "أم القرى (مكة المكرمة)", FajrAngle: 18.5, IshaMinutes: 90},
+	"mwl":         {Name: "رابطة العالم الإسلامي", FajrAngle: 18, IshaAngle: 17},
+	"egypt":       {Name: "الهيئة المصرية العامة للمساحة", FajrAngle: 19.5, IshaAngle: 17.5},
+	"karachi":     {Name: "جامعة العلوم الإسلامية بكراتشي", FajrAngle: 18, IshaAngle: 18},
+	"isna":        {Name: "أمريكا الشمالية (ISNA)", FajrAngle: 15, IshaAngle: 15},
+}
+
+// MethodKeys lists the method keys in display order
+var MethodKeys = []string{"kuwait", "umm_al_qura", "mwl", "egypt", "karachi", "isna"}
+
+// Kuwait City, the default location
+const (
+	DefaultLatitude  = 29.3759
+	DefaultLongitude = 47.9774
+)
+
+// Times are the prayer times of one day. A time that does not occur at the
+// location (e.g. Isha at high latitudes in summer) is zero.
+type Times struct {
+	Fajr    time.Time `json:"fajr"`
+	Sunrise time.Time `json:"sunrise"`
+	Dhuhr   time.Time `json:"dhuhr"`
+	Asr     time.Time `json:"asr"`
+	Maghrib time.Time `json:"maghrib"`
+	Isha    time.Time `json:"isha"`
+}
+
+// Of returns the time of prayer p
+func (t Times) Of(p Prayer) time.Time {
+	switch p {
+	case Fajr:
+		return t.Fajr
+	case Sunrise:
+		return t.Sunrise
+	case Dhuhr:
+		return t.Dhuhr
+	case Asr:
+		return t.Asr
+	case Maghrib:
+		return t.Maghrib
+	case Isha:
+		return t.Isha
+	}
+	return time.Time{}
+}
+
+// Anchored returns the time `offset` minutes after prayer p (before it when
+// negative), and false when p is unknown or does not occur that day
+func (t Times) Anchored(p Prayer, offset int) (time.Time, bool) {
+	at := t.Of(p)
+	if at.IsZero() {
+		return time.Time{}, false
+	}
+	return at.Add(time.Duration(offset) * time.Minute), true
+}
+
+// Compute returns the prayer times of date's calendar day at the given
+// coordinates, in date's location. Unknown methods fall back to DefaultMethod.
+func Compute(date time.Time, lat, lon float64, methodKey string) Times {
+	m, ok := Methods[methodKey]
+	if !ok {
+		m = Methods[DefaultMethod]
+	}
+
+	loc := date.Location()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	jd0 := float64(day.Unix())/86400 + unixEpochJD // 0h UT of the day
+
+	// at converts hours of the day in UT to a time in loc
+	at := func(hours float64) time.Time {
+		if math.IsNaN(hours) {
+			return time.Time{}
+		}
+		return day.Add(time.Duration(hours * float64(time.Hour))).Round(time.Minute).In(loc)
+	}
+
+	// Each event is computed at a first guess (hours UT), then refined
+	var t Times
+	t.Dhuhr = at(refine(12-lon/15, func(h float64) float64 { return noon(jd0, h, lon) }))
+	t.Sunrise = at(refine(6-lon/15, func(h float64) float64 { return sunAngleTime(jd0, h, lat, lon, 0.833, true) }))
+	t.Maghrib = at(refine(18-lon/15, func(h float64) float64 { return sunAngleTime(jd0, h, lat, lon, 0.833, false) }))
+	t.Fajr = at(refine(5-lon/15, func(h float64) float64 { return sunAngleTime(jd0, h, lat, lon, m.FajrAngle, true) }))
+	t.Asr = at(refine(15-lon/15, func(h float64) float64 { return asrTime(jd0, h, lat, lon, 1) }))
+
+	if m.IshaMinutes > 0 {
+		minutes := m.IshaMinutes
+		if hijri.FromTime(date).Month == 9 { // Ramadan
+			minutes += 30
+		}
+		if !t.Maghrib.IsZero() {
+			t.Isha = t.Maghrib.Add(time.Duration(minutes) * time.Minute)
+		}
+	} else {
+		t.Isha = at(refine(19.5-lon/15, func(h float64) float64 { return sunAngleTime(jd0, h, lat, lon, m.IshaAngle, false) }))
+	}
+	return t
+}
+
+const (
+	j2000       = 2451545.0
+	unixEpochJD = 2440587.5
+)
+
+func sinDeg(x float64) float64  { return math.Sin(x * math.Pi / 180) }
+func cosDeg(x float64) float64  { return math.Cos(x * math.Pi / 180) }
+func tanDeg(x float64) float64  { return math.Tan(x * math.Pi / 180) }
+func asinDeg(x float64) float64 { return math.Asin(x) * 180 / math.Pi }
+func acosDeg(x float64) float64 { return math.Acos(x) * 180 / math.Pi }
+
+// refine evaluates f at the guess and again at its own result
+func refine(guess float64, f func(hours float64) float64) float64 {
+	h := f(guess)
+	if math.IsNaN(h) {
+		return h
+	}
+	return f(h)
+}
+
+// sunPosition returns the sun's declination (degrees) and the equation of
+// time (hours) at Julian day jd
+func sunPosition(jd float64) (decl, eqt float64) {
+	d := jd - j2000
+	g := math.Mod(357.529+0.98560028*d, 360)
+	q := math.Mod(280.459+0.98564736*d, 360)
+	l := math.Mod(q+1.915*sinDeg(g)+0.020*sinDeg(2*g), 360)
+	e := 23.439 - 0.00000036*d
+
+	ra := math.Atan2(cosDeg(e)*sinDeg(l), cosDeg(l)) * 180 / math.Pi / 15
+	decl = asinDeg(sinDeg(e) * sinDeg(l))
+	eqt = q/15 - fixHour(ra)
+	if eqt > 12 {
+		eqt -= 24
+	} else if eqt < -12 {
+		eqt += 24
+	}
+	return decl, eqt
+}
+
+func fixHour(h float64) float64 {
+	h = math.Mod(h, 24)
+	if h < 0 {
+		h += 24
+	}
+	return h
+}
+
+// noon returns solar noon (hours UT) with the sun's position taken at hours h
+func noon(jd0, h, lon float64) float64 {
+	_, eqt := sunPosition(jd0 + h/24)
+	return 12 - eqt - lon/15
+}
+
+// sunAngleTime returns when the sun is `angle` degrees below the horizon
+// (hours UT), before noon when morning is set; NaN when it never gets there
+func sunAngleTime(jd0, h, lat, lon, angle float64, morning bool) float64 {
+	decl, _ := sunPosition(jd0 + h/24)
+	cosT := (-sinDeg(angle) - sinDeg(decl)*sinDeg(lat)) / (cosDeg(decl) * cosDeg(lat))
+	if cosT < -1 || cosT > 1 {
+		return math.NaN()
+	}
+	t := acosDeg(cosT) / 15
+	if morning {
+		return noon(jd0, h, lon) - t
+	}
+	return noon(jd0, h, lon) + t
+}
+
+// asrTime returns when an object's shadow is `factor` times its length plus
+// its noon shadow (1 for the majority, 2 for Hanafi)
+func asrTime(jd0, h, lat, lon, factor float64) float64 {
+	decl, _ := sunPosition(jd0 + h/24)
+	angle := -math.Atan(1/(factor+tanDeg(math.Abs(lat-decl)))) * 180 / math.Pi
+	return sunAngleTime(jd0, h, lat, lon, angle, false)
+}
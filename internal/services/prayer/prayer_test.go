@@ -0,0 +1,113 @@
+package prayer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Reference times were worked out with NOAA's solar position equations (Meeus
+// chapter 25), independently of the formulas used here
+func TestCompute(t *testing.T) {
+	arabia := time.FixedZone("+03", 3*3600)
+	oslo := time.FixedZone("+02", 2*3600)
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		lat, lon float64
+		method   string
+		want     [6]string // Fajr, Sunrise, Dhuhr, Asr, Maghrib, Isha; "" = does not occur
+	}{
+		{
+			"Kuwait City, summer solstice", time.Date(2025, 6, 21, 0, 0, 0, 0, arabia),
+			DefaultLatitude, DefaultLongitude, "kuwait",
+			[6]string{"03:13", "04:49", "11:50", "15:24", "18:51", "20:23"},
+		},
+		{
+			"Kuwait City, winter solstice", time.Date(2025, 12, 21, 0, 0, 0, 0, arabia),
+			DefaultLatitude, DefaultLongitude, "kuwait",
+			[6]string{"05:13", "06:38", "11:46", "14:36", "16:54", "18:17"},
+		},
+		{
+			"Mecca in Ramadan: Isha two hours after Maghrib", time.Date(2025, 3, 5, 0, 0, 0, 0, arabia),
+			21.4225, 39.8262, "umm_al_qura",
+			[6]string{"05:22", "06:38", "12:32", "15:54", "18:27", "20:27"},
+		},
+		{
+			"Mecca after Ramadan: Isha 90 minutes after Maghrib", time.Date(2025, 4, 5, 0, 0, 0, 0, arabia),
+			21.4225, 39.8262, "umm_al_qura",
+			[6]string{"04:53", "06:10", "12:23", "15:48", "18:37", "20:07"},
+		},
+		{
+			"Oslo at midsummer: no Fajr or Isha", time.Date(2025, 6, 21, 12, 0, 0, 0, oslo),
+			59.9139, 10.7522, "mwl",
+			[6]string{"", "03:54", "13:19", "18:01", "22:44", ""},
+		},
+		{
+			"unknown method falls back to the default", time.Date(2025, 6, 21, 0, 0, 0, 0, arabia),
+			DefaultLatitude, DefaultLongitude, "nope",
+			[6]string{"03:13", "04:49", "11:50", "15:24", "18:51", "20:23"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			times := Compute(tt.date, tt.lat, tt.lon, tt.method)
+			for i, p := range Prayers {
+				got := times.Of(p)
+				if tt.want[i] == "" {
+					if !got.IsZero() {
+						t.Errorf("%s = %v, want none", p, got)
+					}
+					continue
+				}
+				want, err := time.ParseInLocation("2006-01-02 15:04", tt.date.Format("2006-01-02 ")+tt.want[i], tt.date.Location())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Location() != tt.date.Location() {
+					t.Errorf("%s is in %v, want %v", p, got.Location(), tt.date.Location())
+				}
+				if d := got.Sub(want); d < -2*time.Minute || d > 2*time.Minute {
+					t.Errorf("%s = %s, want %s ± 2 min", p, got.Format("15:04"), tt.want[i])
+				}
+			}
+			if tt.method == "umm_al_qura" {
+				// The fixed interval is exact, not within tolerance
+				minutes := Methods["umm_al_qura"].IshaMinutes
+				if tt.date.Month() == 3 {
+					minutes += 30
+				}
+				if gap := times.Isha.Sub(times.Maghrib); gap != time.Duration(minutes)*time.Minute {
+					t.Errorf("Isha is %v after Maghrib, want %d minutes", gap, minutes)
+				}
+			}
+		})
+	}
+}
+
+func TestAnchored(t *testing.T) {
+	maghrib := time.Date(2025, 6, 21, 18, 51, 0, 0, time.UTC)
+	times := Times{Maghrib: maghrib}
+
+	tests := []struct {
+		prayer Prayer
+		offset int
+		want   time.Time
+		ok     bool
+	}{
+		{Maghrib, 0, maghrib, true},
+		{Maghrib, 15, maghrib.Add(15 * time.Minute), true},
+		{Maghrib, -30, maghrib.Add(-30 * time.Minute), true},
+		{Isha, 10, time.Time{}, false},
+		{Prayer("witr"), 0, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s%+d", tt.prayer, tt.offset), func(t *testing.T) {
+			got, ok := times.Anchored(tt.prayer, tt.offset)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("Anchored = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
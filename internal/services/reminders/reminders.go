@@ -0,0 +1,151 @@
+// Package reminders queues the calendar event reminders that come due for
+// delivery. Each run looks at the events with reminders that can come due,
+// works out when the reminders of their nearby occurrences fire, and queues
+// the ones that fired since the last run; clients then pick them up from the queue.
+package reminders
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ohabits/internal/database"
+)
+
+// DefaultLookback is how long after firing a reminder is still queued, so
+// reminders due while the server was down are not lost
+const DefaultLookback = 6 * time.Hour
+
+// Scheduler queues due reminders
+type Scheduler struct {
+	DB       *database.DB
+	Lookback time.Duration
+}
+
+// Report summarises one run
+type Report struct {
+	Events int // Events with reminders looked at
+	Queued int // Reminders newly queued
+	Failed int
+}
+
+// Run queues the reminders that fired in (now-Lookback, now]
+func (s *Scheduler) Run(ctx context.Context, now time.Time) (*Report, error) {
+	lookback := s.Lookback
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+	since := now.Add(-lookback)
+
+	// Occurrences whose reminders can fire in the window start at most
+	// MaxReminderMinutes after it, with a day's margin either side for the
+	// event's zone
+	from := since.AddDate(0, 0, -1)
+	to := now.Add(database.MaxReminderMinutes*time.Minute).AddDate(0, 0, 1)
+	events, err := s.DB.GetEventsWithReminders(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Events: len(events)}
+
+	due := dueNotifications(events, since, now)
+	queued, err := s.DB.EnqueueEventNotifications(ctx, due)
+	if err != nil {
+		log.Printf("[reminders] failed to queue %d reminders: %v", len(due), err)
+		report.Failed = len(due)
+		return report, nil
+	}
+	report.Queued = queued
+
+	return report, nil
+}
+
+// dueNotifications returns the reminders of events that fire in (since, now],
+// once per event, occurrence and reminder
+func dueNotifications(events []database.CalendarEvent, since, now time.Time) []database.EventNotification {
+	type key struct {
+		eventID        uuid.UUID
+		occurrenceDate string
+		minutes        int
+	}
+	seen := make(map[key]bool)
+
+	var due []database.EventNotification
+	for _, e := range events {
+		furthest := 0
+		for _, m := range e.Reminders {
+			furthest = max(furthest, m)
+		}
+		from := since.AddDate(0, 0, -1)
+		to := now.Add(time.Duration(furthest)*time.Minute).AddDate(0, 0, 1)
+
+		for _, o := range database.EventOccurrences(e, from, to) {
+			occurrenceDate := o.EventDate
+			if o.OccurrenceDate != nil {
+				occurrenceDate = *o.OccurrenceDate
+			}
+			for _, m := range e.Reminders {
+				fireAt := o.ReminderAt(m)
+				if !fireAt.After(since) || fireAt.After(now) {
+					continue
+				}
+				k := key{e.ID, occurrenceDate.Format("2006-01-02"), m}
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+				due = append(due, database.EventNotification{
+					UserID:         e.UserID,
+					EventID:        e.ID,
+					OccurrenceDate: occurrenceDate,
+					RemindMinutes:  m,
+					FireAt:         fireAt,
+					Title:          o.Title,
+					Body:           notificationBody(o.CalendarEvent, m),
+				})
+			}
+		}
+	}
+	return due
+}
+
+// RunEvery runs the scheduler once now and then on an interval until ctx is
+// cancelled
+func (s *Scheduler) RunEvery(ctx context.Context, interval time.Duration) {
+	s.runOnce(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context) {
+	report, err := s.Run(ctx, time.Now())
+	if err != nil {
+		log.Printf("[reminders] run failed: %v", err)
+		return
+	}
+	if report.Queued > 0 || report.Failed > 0 {
+		log.Printf("[reminders] events %d, queued %d, failed %d", report.Events, report.Queued, report.Failed)
+	}
+}
+
+// notificationBody says how far off the occurrence is and when it starts,
+// e.g. "قبل ساعة · 2026-03-14 18:30"
+func notificationBody(e database.CalendarEvent, minutes int) string {
+	when := e.EventDate.Format("2006-01-02")
+	if !e.IsAllDay() {
+		when += " " + e.StartTime
+	}
+	return database.ReminderLabel(minutes) + " · " + when
+}
@@ -0,0 +1,108 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ohabits/internal/database"
+)
+
+func TestNotificationBody(t *testing.T) {
+	date := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		event   database.CalendarEvent
+		minutes int
+		want    string
+	}{
+		{"timed", database.CalendarEvent{EventDate: date, StartTime: "18:30"}, 60, "قبل ساعة · 2026-03-14 18:30"},
+		{"all-day has no time", database.CalendarEvent{EventDate: date}, 2 * 24 * 60, "قبل يومين · 2026-03-14"},
+		{"at the start", database.CalendarEvent{EventDate: date, StartTime: "07:05"}, 0, "عند البدء · 2026-03-14 07:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notificationBody(tt.event, tt.minutes); got != tt.want {
+				t.Errorf("notificationBody = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueNotifications(t *testing.T) {
+	kuwait, err := database.LoadEventLocation("Asia/Kuwait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	now := time.Date(2025, 3, 15, 18, 0, 0, 0, kuwait)
+	since := now.Add(-time.Hour)
+
+	meeting := database.CalendarEvent{
+		ID: uuid.New(), UserID: uuid.New(), Title: "اجتماع", EventDate: date(3, 15),
+		StartTime: "18:30", TimeZone: "Asia/Kuwait", Reminders: []int{15, 30, 60, 90},
+	}
+	// Weekly on Saturdays; the reminder a day ahead of 15 March fired a day ago
+	lesson := database.CalendarEvent{
+		ID: uuid.New(), UserID: meeting.UserID, Title: "درس", EventDate: date(3, 1),
+		StartTime: "18:00", TimeZone: "Asia/Kuwait", IsRecurring: true, RRule: "FREQ=WEEKLY;BYDAY=SA",
+		Reminders: []int{0, 24 * 60},
+	}
+	// Moved to 16 March, so its reminder a day ahead fires now
+	moved := lesson
+	moved.ID = uuid.New()
+	moved.Exceptions = []database.CalendarEventException{{OccurrenceDate: date(3, 15), EventDate: func() *time.Time { d := date(3, 16); return &d }()}}
+
+	type due struct {
+		eventID    uuid.UUID
+		occurrence string
+		minutes    int
+	}
+	tests := []struct {
+		name   string
+		events []database.CalendarEvent
+		want   []due
+	}{
+		{
+			// 15 fires later and 90 on since, which the previous run covered
+			"only reminders in (since, now]",
+			[]database.CalendarEvent{meeting},
+			[]due{{meeting.ID, "2025-03-15", 30}, {meeting.ID, "2025-03-15", 60}},
+		},
+		{
+			"rule series fire for the occurrence in the window",
+			[]database.CalendarEvent{lesson},
+			[]due{{lesson.ID, "2025-03-15", 0}},
+		},
+		{
+			"moved occurrences keep their original date",
+			[]database.CalendarEvent{moved},
+			[]due{{moved.ID, "2025-03-15", 24 * 60}},
+		},
+		{
+			"each reminder is queued once",
+			[]database.CalendarEvent{meeting, meeting},
+			[]due{{meeting.ID, "2025-03-15", 30}, {meeting.ID, "2025-03-15", 60}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dueNotifications(tt.events, since, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("dueNotifications = %+v, want %+v", got, tt.want)
+			}
+			for i, n := range got {
+				w := tt.want[i]
+				if n.EventID != w.eventID || n.OccurrenceDate.Format("2006-01-02") != w.occurrence || n.RemindMinutes != w.minutes {
+					t.Errorf("dueNotifications[%d] = %s %s %d, want %s %s %d", i,
+						n.EventID, n.OccurrenceDate.Format("2006-01-02"), n.RemindMinutes, w.eventID, w.occurrence, w.minutes)
+				}
+				if n.FireAt.After(now) || !n.FireAt.After(since) {
+					t.Errorf("dueNotifications[%d] fires at %v, outside (%v, %v]", i, n.FireAt, since, now)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,572 @@
+// Package rrule parses and expands RFC 5545 recurrence rules for all-day
+// calendar events.
+//
+// The supported subset covers what people schedule by hand: FREQ=DAILY,
+// WEEKLY, MONTHLY or YEARLY with INTERVAL, COUNT or UNTIL, BYDAY (with an
+// ordinal such as 2MO or -1FR for monthly and yearly rules), BYMONTHDAY,
+// BYMONTH and BYSETPOS. Dates are calendar days at UTC midnight.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Freq is the base frequency of a rule
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+	Yearly  Freq = "YEARLY"
+)
+
+// ErrInvalid is returned for malformed or unsupported rules
+var ErrInvalid = errors.New("invalid or unsupported recurrence rule")
+
+// WeekdayNum is a BYDAY entry: a weekday, optionally the Nth (or Nth from the
+// end when negative) of the month, or of the year for yearly rules without BYMONTH
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       Freq
+	Interval   int          // >= 1
+	Count      int          // 0 = unlimited
+	Until      time.Time    // zero = no end date; inclusive
+	ByDay      []WeekdayNum // empty = the start date's weekday (weekly)
+	ByMonthDay []int        // negative counts from the end of the month
+	ByMonth    []int
+	BySetPos   []int        // picks from each period's dates; negative counts from the end
+	WeekStart  time.Weekday // WKST, Monday by default
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayNames = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// maxPeriods bounds expansion for rules that rarely or never match
+const maxPeriods = 50000
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU". An
+// "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return nil, ErrInvalid
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, ErrInvalid
+		}
+		value = strings.ToUpper(strings.TrimSpace(value))
+		var err error
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "FREQ":
+			r.Freq = Freq(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if r.Interval < 1 {
+				err = ErrInvalid
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if r.Count < 1 {
+				err = ErrInvalid
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(value, -31, 31)
+		case "BYMONTH":
+			r.ByMonth, err = parseInts(value, 1, 12)
+		case "BYSETPOS":
+			r.BySetPos, err = parseInts(value, -366, 366)
+		case "WKST":
+			day, ok := weekdayCodes[value]
+			if !ok {
+				err = ErrInvalid
+			}
+			r.WeekStart = day
+		default:
+			return nil, ErrInvalid
+		}
+		if err != nil {
+			return nil, ErrInvalid
+		}
+	}
+
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return nil, ErrInvalid
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, ErrInvalid
+	}
+
+	// Ordinal weekdays only make sense within a month or a year
+	maxOrdinal := 0
+	switch {
+	case r.Freq == Monthly || (r.Freq == Yearly && len(r.ByMonth) > 0):
+		maxOrdinal = 5
+	case r.Freq == Yearly:
+		maxOrdinal = 53
+	}
+	for _, wd := range r.ByDay {
+		if wd.N > maxOrdinal || -wd.N > maxOrdinal {
+			return nil, ErrInvalid
+		}
+	}
+	if len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0 {
+		return nil, ErrInvalid
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	if len(v) >= 8 {
+		if t, err := time.Parse("20060102", v[:8]); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+func parseByDay(v string) ([]WeekdayNum, error) {
+	var out []WeekdayNum
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, ErrInvalid
+		}
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, ErrInvalid
+		}
+		wd := WeekdayNum{Day: day}
+		if n := item[:len(item)-2]; n != "" {
+			num, err := strconv.Atoi(n)
+			if err != nil || num == 0 || num < -53 || num > 53 {
+				return nil, ErrInvalid
+			}
+			wd.N = num
+		}
+		out = append(out, wd)
+	}
+	return out, nil
+}
+
+func parseInts(v string, lo, hi int) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < lo || n > hi {
+			return nil, ErrInvalid
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// String formats the rule in RFC 5545 form, without the "RRULE:" prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayNames[d.Day]
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// Between returns the occurrence dates of a series starting on start that
+// fall within [from, to], in order
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	start, from, to = dateOf(start), dateOf(from), dateOf(to)
+	if to.Before(start) || to.Before(from) {
+		return nil
+	}
+
+	// Without COUNT, periods entirely before from can be skipped
+	p := 0
+	if r.Count == 0 && from.After(start) {
+		p = max(0, r.periodsBetween(start, from)/r.Interval-1)
+	}
+
+	var out []time.Time
+	seen := 0
+	for n := 0; n < maxPeriods; n, p = n+1, p+1 {
+		periodStart, candidates := r.period(start, p*r.Interval)
+		if periodStart.After(to) {
+			break
+		}
+		for _, d := range candidates {
+			if d.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && d.After(r.Until) {
+				return out
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return out
+			}
+			if d.After(to) {
+				return out
+			}
+			if !d.Before(from) {
+				out = append(out, d)
+			}
+		}
+	}
+	return out
+}
+
+// Occurs reports whether day is an occurrence of a series starting on start
+func (r *Rule) Occurs(start, day time.Time) bool {
+	return len(r.Between(start, day, day)) > 0
+}
+
+// Next returns the first occurrence on or after from, searching up to ten
+// years ahead
+func (r *Rule) Next(start, from time.Time) (time.Time, bool) {
+	if from.Before(start) {
+		from = start
+	}
+	for y := 0; y < 10; y++ {
+		lo := dateOf(from).AddDate(y, 0, 0)
+		if occ := r.Between(start, lo, lo.AddDate(1, 0, -1)); len(occ) > 0 {
+			return occ[0], true
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodsBetween counts whole frequency periods from start to t
+func (r *Rule) periodsBetween(start, t time.Time) int {
+	switch r.Freq {
+	case Daily:
+		return int(t.Sub(start).Hours() / 24)
+	case Weekly:
+		return int(r.weekOf(t).Sub(r.weekOf(start)).Hours() / (24 * 7))
+	case Monthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	default:
+		return t.Year() - start.Year()
+	}
+}
+
+// period returns the first day of the period `offset` periods after the one
+// containing start, and the candidate dates in it (sorted)
+func (r *Rule) period(start time.Time, offset int) (time.Time, []time.Time) {
+	var first time.Time
+	var out []time.Time
+	switch r.Freq {
+	case Daily:
+		first = start.AddDate(0, 0, offset)
+		if r.matchesFilters(first) {
+			out = []time.Time{first}
+		}
+	case Weekly:
+		first = r.weekOf(start).AddDate(0, 0, 7*offset)
+		for i := 0; i < 7; i++ {
+			d := first.AddDate(0, 0, i)
+			if r.matchesWeekly(d, start) && r.inMonths(d) {
+				out = append(out, d)
+			}
+		}
+	case Monthly:
+		first = time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		if r.inMonths(first) {
+			out = r.daysInMonth(first, start)
+		}
+	default:
+		first = time.Date(start.Year()+offset, 1, 1, 0, 0, 0, 0, time.UTC)
+		var months []int
+		switch {
+		case len(r.ByMonth) > 0:
+			months = r.ByMonth
+		case len(r.ByMonthDay) > 0:
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		case len(r.ByDay) > 0:
+			out = r.daysInYear(first)
+		default:
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			out = append(out, r.daysInMonth(time.Date(first.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC), start)...)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return first, r.setPositions(dedupe(out))
+}
+
+// setPositions keeps the BYSETPOS-th of a period's sorted dates
+func (r *Rule) setPositions(dates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return dates
+	}
+	var out []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(dates) + pos
+		}
+		if i >= 0 && i < len(dates) {
+			out = append(out, dates[i])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return dedupe(out)
+}
+
+// dedupe drops repeats from sorted dates
+func dedupe(dates []time.Time) []time.Time {
+	out := dates[:0]
+	for i, d := range dates {
+		if i == 0 || !d.Equal(dates[i-1]) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// daysInYear returns the BYDAY matches across the year starting at first:
+// every such weekday, or the Nth of them in the year
+func (r *Rule) daysInYear(first time.Time) []time.Time {
+	next := first.AddDate(1, 0, 0)
+	var out []time.Time
+	for _, wd := range r.ByDay {
+		var matches []time.Time
+		d := first.AddDate(0, 0, (int(wd.Day)-int(first.Weekday())+7)%7)
+		for ; d.Before(next); d = d.AddDate(0, 0, 7) {
+			matches = append(matches, d)
+		}
+		out = append(out, pickOrdinal(matches, wd.N)...)
+	}
+	return out
+}
+
+// pickOrdinal returns all matches for n = 0, else the nth (from the end when negative)
+func pickOrdinal(matches []time.Time, n int) []time.Time {
+	switch {
+	case n == 0:
+		return matches
+	case n > 0 && n <= len(matches):
+		return matches[n-1 : n]
+	case n < 0 && -n <= len(matches):
+		return matches[len(matches)+n : len(matches)+n+1]
+	}
+	return nil
+}
+
+// daysInMonth returns the matching days of the month starting at first:
+// BYMONTHDAY, else BYDAY, else the start date's day of month
+func (r *Rule) daysInMonth(first, start time.Time) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+	var out []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = length + md + 1
+			}
+			if day >= 1 && day <= length {
+				d := first.AddDate(0, 0, day-1)
+				if r.matchesByDayFilter(d) {
+					out = append(out, d)
+				}
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var matches []time.Time
+			for day := 1; day <= length; day++ {
+				if d := first.AddDate(0, 0, day-1); d.Weekday() == wd.Day {
+					matches = append(matches, d)
+				}
+			}
+			out = append(out, pickOrdinal(matches, wd.N)...)
+		}
+	default:
+		if start.Day() <= length {
+			out = append(out, first.AddDate(0, 0, start.Day()-1))
+		}
+	}
+	return out
+}
+
+// matchesWeekly reports whether d is one of the rule's weekdays (or the
+// start's weekday when BYDAY is absent)
+func (r *Rule) matchesWeekly(d, start time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return d.Weekday() == start.Weekday()
+	}
+	return r.matchesByDayFilter(d)
+}
+
+// matchesFilters applies BYDAY, BYMONTHDAY and BYMONTH as filters (daily rules)
+func (r *Rule) matchesFilters(d time.Time) bool {
+	if !r.matchesByDayFilter(d) || !r.inMonths(d) {
+		return false
+	}
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := d.AddDate(0, 1, -d.Day()).Day()
+	for _, md := range r.ByMonthDay {
+		if md == d.Day() || (md < 0 && length+md+1 == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesByDayFilter(d time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) inMonths(d time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == d.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+// weekOf returns the first day (per WKST) of the week containing d
+func (r *Rule) weekOf(d time.Time) time.Time {
+	diff := (int(d.Weekday()) - int(r.WeekStart) + 7) % 7
+	return d.AddDate(0, 0, -diff)
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+var (
+	arabicWeekdays = [7]string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"}
+	arabicOrdinals = map[int]string{1: "الأول", 2: "الثاني", 3: "الثالث", 4: "الرابع", 5: "الخامس"}
+)
+
+// Describe summarizes the rule in Arabic, e.g. "كل أسبوعين: الأحد، الثلاثاء"
+func (r *Rule) Describe() string {
+	every := map[Freq][2]string{
+		Daily:   {"يومياً", "كل %d أيام"},
+		Weekly:  {"أسبوعياً", "كل %d أسابيع"},
+		Monthly: {"شهرياً", "كل %d أشهر"},
+		Yearly:  {"سنوياً", "كل %d سنوات"},
+	}[r.Freq]
+	s := every[0]
+	if r.Interval == 2 {
+		s = map[Freq]string{Daily: "كل يومين", Weekly: "كل أسبوعين", Monthly: "كل شهرين", Yearly: "كل سنتين"}[r.Freq]
+	} else if r.Interval > 2 {
+		s = fmt.Sprintf(every[1], r.Interval)
+	}
+
+	var details []string
+	for _, wd := range r.ByDay {
+		name := arabicWeekdays[wd.Day]
+		if wd.N == -1 {
+			name = "آخر " + name
+		} else if ord, ok := arabicOrdinals[wd.N]; ok {
+			name = name + " " + ord
+		}
+		details = append(details, name)
+	}
+	for _, md := range r.ByMonthDay {
+		if md == -1 {
+			details = append(details, "آخر يوم")
+		} else {
+			details = append(details, "يوم "+strconv.Itoa(md))
+		}
+	}
+	if len(details) > 0 {
+		s += ": " + strings.Join(details, "، ")
+	}
+	if len(r.BySetPos) > 0 {
+		var positions []string
+		for _, pos := range r.BySetPos {
+			if pos == -1 {
+				positions = append(positions, "الأخير")
+			} else if ord, ok := arabicOrdinals[pos]; ok {
+				positions = append(positions, ord)
+			} else {
+				positions = append(positions, "رقم "+strconv.Itoa(pos))
+			}
+		}
+		s += " (" + strings.Join(positions, "، ") + " منها)"
+	}
+
+	if r.Count > 0 {
+		s += fmt.Sprintf(" (%d مرات)", r.Count)
+	} else if !r.Until.IsZero() {
+		s += " حتى " + r.Until.Format("2006-01-02")
+	}
+	return s
+}
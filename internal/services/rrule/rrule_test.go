@@ -0,0 +1,180 @@
+package rrule
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		want     []string
+	}{
+		{
+			"COUNT stops the series", "FREQ=DAILY;COUNT=3", day(2025, 3, 1), day(2025, 3, 1), day(2025, 3, 31),
+			[]string{"2025-03-01", "2025-03-02", "2025-03-03"},
+		},
+		{
+			"COUNT counts from the first match, not the start", "FREQ=WEEKLY;BYDAY=FR;COUNT=2", day(2025, 3, 3), day(2025, 3, 1), day(2025, 3, 31),
+			[]string{"2025-03-07", "2025-03-14"},
+		},
+		{
+			"UNTIL is inclusive", "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20250312", day(2025, 3, 3), day(2025, 3, 1), day(2025, 3, 31),
+			[]string{"2025-03-03", "2025-03-05", "2025-03-10", "2025-03-12"},
+		},
+		{
+			"weekly INTERVAL", "FREQ=WEEKLY;INTERVAL=2", day(2025, 3, 4), day(2025, 3, 1), day(2025, 4, 5),
+			[]string{"2025-03-04", "2025-03-18", "2025-04-01"},
+		},
+		{
+			"daily INTERVAL from mid-series", "FREQ=DAILY;INTERVAL=3", day(2025, 3, 1), day(2025, 3, 10), day(2025, 3, 16),
+			[]string{"2025-03-10", "2025-03-13", "2025-03-16"},
+		},
+		{
+			"BYMONTHDAY=31 skips shorter months", "FREQ=MONTHLY;BYMONTHDAY=31", day(2025, 1, 31), day(2025, 1, 1), day(2025, 6, 30),
+			[]string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			"BYMONTHDAY=-1 is the last day of each month", "FREQ=MONTHLY;BYMONTHDAY=-1", day(2024, 1, 31), day(2024, 1, 1), day(2024, 4, 30),
+			[]string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"},
+		},
+		{
+			"BYSETPOS=-1 picks the last weekday of the month", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", day(2025, 1, 1), day(2025, 1, 1), day(2025, 5, 31),
+			[]string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30", "2025-05-30"},
+		},
+		{
+			"BYSETPOS with several positions", "FREQ=MONTHLY;BYDAY=SA,SU;BYSETPOS=1,2", day(2025, 3, 1), day(2025, 3, 1), day(2025, 4, 30),
+			[]string{"2025-03-01", "2025-03-02", "2025-04-05", "2025-04-06"},
+		},
+		{
+			"monthly ordinal BYDAY", "FREQ=MONTHLY;BYDAY=-1FR", day(2025, 1, 1), day(2025, 1, 1), day(2025, 3, 31),
+			[]string{"2025-01-31", "2025-02-28", "2025-03-28"},
+		},
+		{
+			"yearly BYMONTHDAY without BYMONTH covers every month", "FREQ=YEARLY;BYMONTHDAY=15;COUNT=3", day(2025, 1, 1), day(2025, 1, 1), day(2025, 12, 31),
+			[]string{"2025-01-15", "2025-02-15", "2025-03-15"},
+		},
+		{
+			"yearly ordinal BYDAY without BYMONTH counts within the year", "FREQ=YEARLY;BYDAY=1MO", day(2025, 1, 1), day(2025, 1, 1), day(2027, 12, 31),
+			[]string{"2025-01-06", "2026-01-05", "2027-01-04"},
+		},
+		{
+			"yearly BYDAY from the end of the year", "FREQ=YEARLY;BYDAY=-1FR", day(2025, 1, 1), day(2025, 1, 1), day(2025, 12, 31),
+			[]string{"2025-12-26"},
+		},
+		{
+			"yearly plain BYDAY is every such weekday", "FREQ=YEARLY;BYDAY=SU", day(2025, 1, 1), day(2025, 1, 1), day(2025, 1, 31),
+			[]string{"2025-01-05", "2025-01-12", "2025-01-19", "2025-01-26"},
+		},
+		{
+			"yearly ordinal BYDAY within BYMONTH", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", day(2025, 1, 1), day(2025, 1, 1), day(2026, 12, 31),
+			[]string{"2025-11-27", "2026-11-26"},
+		},
+		{
+			"plain yearly keeps the start date", "FREQ=YEARLY", day(2024, 2, 29), day(2024, 1, 1), day(2028, 12, 31),
+			[]string{"2024-02-29", "2028-02-29"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range r.Between(tt.start, tt.from, tt.to) {
+				got = append(got, d.Format(time.DateOnly))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule string
+		ok   bool
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,TH", true},
+		{"RRULE:freq=monthly;byday=2mo", true},
+		{"FREQ=YEARLY;BYDAY=20MO", true},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15;BYSETPOS=-1", true},
+		{"FREQ=WEEKLY;BYDAY=1MO", false},
+		{"FREQ=DAILY;BYDAY=-1FR", false},
+		{"FREQ=MONTHLY;BYDAY=6MO", false},
+		{"FREQ=YEARLY;BYMONTH=1;BYDAY=10MO", false},
+		{"FREQ=YEARLY;BYDAY=54MO", false},
+		{"FREQ=MONTHLY;BYSETPOS=1", false},
+		{"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=0", false},
+		{"FREQ=DAILY;COUNT=3;UNTIL=20250101", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", false},
+		{"FREQ=HOURLY", false},
+		{"FREQ=DAILY;BYHOUR=9", false},
+		{"INTERVAL=2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := Parse(tt.rule)
+			if tt.ok && err != nil {
+				t.Errorf("Parse = %v, want a rule", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	rules := []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU",
+		"FREQ=MONTHLY;COUNT=10;BYDAY=-1FR",
+		"FREQ=MONTHLY;UNTIL=20251231;BYMONTHDAY=-1",
+		"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		"FREQ=YEARLY;BYDAY=1MO;BYMONTH=1,7",
+		"FREQ=WEEKLY;BYDAY=SA;WKST=SU",
+	}
+	for _, rule := range rules {
+		r, err := Parse(rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", rule, err)
+		}
+		if got := r.String(); got != rule {
+			t.Errorf("Parse(%q).String() = %q", rule, got)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU", "كل أسبوعين: الأحد، الثلاثاء"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=5", "شهرياً: آخر الجمعة (5 مرات)"},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "الأخير منها"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Describe(); !strings.Contains(got, tt.want) {
+			t.Errorf("Describe(%q) = %q, want it to contain %q", tt.rule, got, tt.want)
+		}
+	}
+}
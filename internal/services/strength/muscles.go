@@ -0,0 +1,74 @@
+package strength
+
+import (
+	"time"
+
+	"ohabits/internal/database"
+)
+
+// SecondaryMuscleWeight is how much a set counts toward its secondary muscle
+// groups (a bench press set is one chest set and half a triceps set)
+const SecondaryMuscleWeight = 0.5
+
+// MuscleWeek is the number of working sets per muscle group in one Sunday-based week
+type MuscleWeek struct {
+	WeekStart  time.Time          `json:"week_start"`
+	Sets       map[string]float64 `json:"sets"`       // Muscle group -> sets
+	Unassigned int                `json:"unassigned"` // Sets of exercises not in the library
+}
+
+// MuscleGroupSets counts weekly sets per muscle group for the `weeks` weeks
+// ending with the week containing `until`, oldest first. Weeks without
+// training are included with no sets.
+func MuscleGroupSets(logs []database.WorkoutLog, lib *database.ExerciseLibrary, weeks int, until time.Time) []MuscleWeek {
+	if weeks <= 0 {
+		return []MuscleWeek{}
+	}
+
+	last := weekStart(until)
+	first := last.AddDate(0, 0, -7*(weeks-1))
+	result := make([]MuscleWeek, weeks)
+	index := make(map[time.Time]int, weeks)
+	for i := range result {
+		start := first.AddDate(0, 0, 7*i)
+		result[i] = MuscleWeek{WeekStart: start, Sets: map[string]float64{}}
+		index[start] = i
+	}
+
+	for _, wl := range logs {
+		i, ok := index[weekStart(wl.Date.In(until.Location()))]
+		if !ok {
+			continue
+		}
+		for _, ex := range wl.CompletedExercises {
+			sets := workingSets(ex)
+			if sets == 0 {
+				continue
+			}
+			entry := lib.Lookup(ex)
+			if entry == nil {
+				result[i].Unassigned += sets
+				continue
+			}
+			result[i].Sets[entry.MuscleGroup] += float64(sets)
+			for _, m := range entry.SecondaryMuscles {
+				if m != entry.MuscleGroup {
+					result[i].Sets[m] += float64(sets) * SecondaryMuscleWeight
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// workingSets counts sets with at least one rep
+func workingSets(ex database.Exercise) int {
+	n := 0
+	for _, s := range ex.Sets {
+		if s.Reps > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package strength
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func TestMuscleGroupSets(t *testing.T) {
+	bench := database.LibraryExercise{
+		ID: uuid.New(), Name: "Bench Press", MuscleGroup: database.MuscleChest,
+		SecondaryMuscles: []string{database.MuscleTriceps, database.MuscleChest}, Aliases: []string{"بنش"},
+	}
+	squat := database.LibraryExercise{ID: uuid.New(), Name: "Squat", MuscleGroup: database.MuscleQuads}
+	lib := database.NewExerciseLibrary([]database.LibraryExercise{bench, squat})
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	sets := func(reps ...int) []database.ExerciseSet {
+		var s []database.ExerciseSet
+		for _, r := range reps {
+			s = append(s, database.ExerciseSet{Reps: r, Weight: 60})
+		}
+		return s
+	}
+	logs := []database.WorkoutLog{
+		// Before the first week
+		{Date: day(2, 28), CompletedExercises: []database.Exercise{{Name: "Squat", Sets: sets(5, 5)}}},
+		// Week of Sunday 2 March: names match case-insensitively, by alias or by link
+		{Date: day(3, 2), CompletedExercises: []database.Exercise{
+			{Name: "bench press", Sets: sets(8, 8, 0)}, // A set without reps doesn't count
+			{Name: "Squat", Sets: sets(5)},
+		}},
+		{Date: day(3, 8), CompletedExercises: []database.Exercise{
+			{Name: "بنش", Sets: sets(10)},
+			{Name: "My Squat", ExerciseID: &squat.ID, Sets: sets(5, 5)},
+			{Name: "Plank", Sets: sets(1, 1, 1)},
+			{Name: "Walk"},
+		}},
+		// Week of 16 March
+		{Date: day(3, 19), CompletedExercises: []database.Exercise{{Name: "Bench Press", Sets: sets(5)}}},
+		// After until's week
+		{Date: day(3, 23), CompletedExercises: []database.Exercise{{Name: "Squat", Sets: sets(5)}}},
+	}
+
+	got := MuscleGroupSets(logs, lib, 3, day(3, 20))
+	want := []MuscleWeek{
+		{
+			WeekStart: day(3, 2),
+			// Secondary muscles count half a set; the primary group isn't counted twice
+			Sets:       map[string]float64{database.MuscleChest: 3, database.MuscleTriceps: 1.5, database.MuscleQuads: 3},
+			Unassigned: 3,
+		},
+		{WeekStart: day(3, 9), Sets: map[string]float64{}},
+		{WeekStart: day(3, 16), Sets: map[string]float64{database.MuscleChest: 1, database.MuscleTriceps: 0.5}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MuscleGroupSets =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := MuscleGroupSets(logs, lib, 0, day(3, 20)); len(got) != 0 {
+		t.Errorf("MuscleGroupSets with no weeks = %+v, want none", got)
+	}
+}
@@ -0,0 +1,244 @@
+package strength
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Personal record kinds
+const (
+	RecordHeaviestWeight = "heaviest_weight"
+	RecordMostReps       = "most_reps" // Most reps at a given weight
+	RecordBestE1RM       = "best_e1rm"
+)
+
+// MaxE1RMReps is the rep count above which the Epley estimate becomes unreliable
+// and the set is ignored for e1RM purposes
+const MaxE1RMReps = 12
+
+// Session summarizes one exercise within one workout log
+type Session struct {
+	LogID     uuid.UUID                 `json:"log_id"`
+	Date      time.Time                 `json:"date"`
+	Sets      int                       `json:"sets"`
+	Reps      int                       `json:"reps"`
+	Volume    float64                   `json:"volume"` // Sum of reps × weight
+	TopWeight float64                   `json:"top_weight"`
+	BestE1RM  float64                   `json:"best_e1rm"`
+	Records   []database.PersonalRecord `json:"records,omitempty"`
+}
+
+// Week is the total volume for an exercise in one Sunday-based week
+type Week struct {
+	WeekStart time.Time `json:"week_start"`
+	Sessions  int       `json:"sessions"`
+	Volume    float64   `json:"volume"`
+}
+
+// Progress is the full history of one exercise
+type Progress struct {
+	Exercise string                    `json:"exercise"`
+	Sessions []Session                 `json:"sessions"`
+	Weekly   []Week                    `json:"weekly"`
+	Bests    []database.PersonalRecord `json:"bests"` // Latest record set per kind (empty until one is beaten)
+}
+
+// E1RM estimates the one-rep max with the Epley formula
+func E1RM(weight float64, reps int) float64 {
+	if weight <= 0 || reps <= 0 || reps > MaxE1RMReps {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return math.Round(weight*(1+float64(reps)/30)*10) / 10
+}
+
+// Key normalizes an exercise name for matching across logs
+func Key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ExerciseProgress builds the progress of one exercise from workout logs
+func ExerciseProgress(logs []database.WorkoutLog, exercise string) *Progress {
+	key := Key(exercise)
+	progress := &Progress{Exercise: exercise, Sessions: []Session{}, Weekly: []Week{}, Bests: []database.PersonalRecord{}}
+
+	logs = sortedByDate(logs)
+	records := DetectRecords(logs)
+
+	weekIndex := map[time.Time]int{}
+	for _, wl := range logs {
+		for _, ex := range wl.CompletedExercises {
+			if Key(ex.Name) != key || len(ex.Sets) == 0 {
+				continue
+			}
+			progress.Exercise = ex.Name
+
+			s := Session{LogID: wl.ID, Date: wl.Date, Sets: len(ex.Sets), Volume: ex.Volume()}
+			for _, set := range ex.Sets {
+				s.Reps += set.Reps
+				s.TopWeight = max(s.TopWeight, set.Weight)
+				s.BestE1RM = max(s.BestE1RM, E1RM(set.Weight, set.Reps))
+			}
+			for _, r := range records[wl.ID] {
+				if Key(r.Exercise) == key {
+					s.Records = append(s.Records, r)
+				}
+			}
+			progress.Sessions = append(progress.Sessions, s)
+
+			week := weekStart(wl.Date)
+			i, ok := weekIndex[week]
+			if !ok {
+				i = len(progress.Weekly)
+				weekIndex[week] = i
+				progress.Weekly = append(progress.Weekly, Week{WeekStart: week})
+			}
+			progress.Weekly[i].Sessions++
+			progress.Weekly[i].Volume += s.Volume
+		}
+	}
+
+	// The latest record of each kind is the current best
+	latest := map[string]database.PersonalRecord{}
+	for _, s := range progress.Sessions {
+		for _, r := range s.Records {
+			if r.Kind == RecordMostReps {
+				continue // Per-weight, so there's no single best
+			}
+			latest[r.Kind] = r
+		}
+	}
+	for _, kind := range []string{RecordHeaviestWeight, RecordBestE1RM} {
+		if r, ok := latest[kind]; ok {
+			progress.Bests = append(progress.Bests, r)
+		}
+	}
+
+	return progress
+}
+
+// DetectRecords walks the logs in date order and returns, per log ID, the
+// personal records that log set. The first session of an exercise only
+// establishes a baseline and is never flagged.
+func DetectRecords(logs []database.WorkoutLog) map[uuid.UUID][]database.PersonalRecord {
+	type best struct {
+		weight       float64
+		e1rm         float64
+		repsByWeight map[float64]int
+	}
+
+	result := map[uuid.UUID][]database.PersonalRecord{}
+	bests := map[string]*best{}
+
+	for _, wl := range sortedByDate(logs) {
+		for _, ex := range wl.CompletedExercises {
+			if len(ex.Sets) == 0 {
+				continue
+			}
+			key := Key(ex.Name)
+			prev, seen := bests[key]
+			if !seen {
+				prev = &best{repsByWeight: map[float64]int{}}
+				bests[key] = prev
+			}
+
+			// Best of this session, compared against history before it
+			var topWeight, topE1RM float64
+			var topWeightReps, topE1RMReps int
+			var topE1RMWeight float64
+			repsAtWeight := map[float64]int{}
+			for _, set := range ex.Sets {
+				if set.Reps <= 0 {
+					continue
+				}
+				if set.Weight > topWeight || (set.Weight == topWeight && set.Reps > topWeightReps) {
+					topWeight, topWeightReps = set.Weight, set.Reps
+				}
+				if e := E1RM(set.Weight, set.Reps); e > topE1RM {
+					topE1RM, topE1RMWeight, topE1RMReps = e, set.Weight, set.Reps
+				}
+				if set.Weight > 0 {
+					repsAtWeight[set.Weight] = max(repsAtWeight[set.Weight], set.Reps)
+				}
+			}
+
+			record := func(kind string, weight float64, reps int, previous float64) {
+				result[wl.ID] = append(result[wl.ID], database.PersonalRecord{
+					Exercise: ex.Name,
+					Kind:     kind,
+					Weight:   weight,
+					Reps:     reps,
+					E1RM:     E1RM(weight, reps),
+					Previous: previous,
+					Date:     wl.Date,
+					LogID:    wl.ID,
+				})
+			}
+
+			if seen {
+				if topWeight > prev.weight && prev.weight > 0 {
+					record(RecordHeaviestWeight, topWeight, topWeightReps, prev.weight)
+				}
+				if topE1RM > prev.e1rm && prev.e1rm > 0 {
+					record(RecordBestE1RM, topE1RMWeight, topE1RMReps, prev.e1rm)
+				}
+				weights := make([]float64, 0, len(repsAtWeight))
+				for w := range repsAtWeight {
+					weights = append(weights, w)
+				}
+				sort.Float64s(weights)
+				for _, w := range weights {
+					if before, ok := prev.repsByWeight[w]; ok && repsAtWeight[w] > before {
+						record(RecordMostReps, w, repsAtWeight[w], float64(before))
+					}
+				}
+			}
+
+			prev.weight = max(prev.weight, topWeight)
+			prev.e1rm = max(prev.e1rm, topE1RM)
+			for w, reps := range repsAtWeight {
+				prev.repsByWeight[w] = max(prev.repsByWeight[w], reps)
+			}
+		}
+	}
+
+	return result
+}
+
+// ExerciseNames returns the distinct exercises that have logged sets, most recent first
+func ExerciseNames(logs []database.WorkoutLog) []string {
+	logs = sortedByDate(logs)
+	seen := map[string]bool{}
+	var names []string
+	for i := len(logs) - 1; i >= 0; i-- {
+		for _, ex := range logs[i].CompletedExercises {
+			if len(ex.Sets) == 0 || seen[Key(ex.Name)] {
+				continue
+			}
+			seen[Key(ex.Name)] = true
+			names = append(names, ex.Name)
+		}
+	}
+	return names
+}
+
+// sortedByDate returns a copy of logs in ascending date order
+func sortedByDate(logs []database.WorkoutLog) []database.WorkoutLog {
+	sorted := append([]database.WorkoutLog(nil), logs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+	return sorted
+}
+
+// weekStart returns the Sunday that starts the week containing t (same as the review page)
+func weekStart(t time.Time) time.Time {
+	d := t.AddDate(0, 0, -int(t.Weekday()))
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())
+}
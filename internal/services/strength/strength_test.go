@@ -0,0 +1,173 @@
+package strength
+
+import (
+	"testing"
+	"time"
+
+	"ohabits/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func TestE1RM(t *testing.T) {
+	tests := []struct {
+		name   string
+		weight float64
+		reps   int
+		want   float64
+	}{
+		{"single is the max", 100, 1, 100},
+		{"five reps", 100, 5, 116.7},
+		{"ten reps", 60, 10, 80},
+		{"eight reps", 80, 8, 101.3},
+		{"at the rep limit", 50, MaxE1RMReps, 70},
+		{"over the rep limit", 50, MaxE1RMReps + 1, 0},
+		{"bodyweight", 0, 10, 0},
+		{"no reps", 100, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := E1RM(tt.weight, tt.reps); got != tt.want {
+				t.Errorf("E1RM(%v, %d) = %v, want %v", tt.weight, tt.reps, got, tt.want)
+			}
+		})
+	}
+}
+
+type set struct {
+	reps   int
+	weight float64
+}
+
+// session builds a workout log with one exercise
+func session(day int, exercise string, sets ...set) database.WorkoutLog {
+	ex := database.Exercise{Name: exercise}
+	for _, s := range sets {
+		ex.Sets = append(ex.Sets, database.ExerciseSet{Reps: s.reps, Weight: s.weight})
+	}
+	return database.WorkoutLog{
+		ID:                 uuid.New(),
+		Date:               time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day), // a Sunday
+		CompletedExercises: []database.Exercise{ex},
+	}
+}
+
+func TestDetectRecords(t *testing.T) {
+	type record struct {
+		kind     string
+		weight   float64
+		reps     int
+		previous float64
+	}
+	tests := []struct {
+		name string
+		logs []database.WorkoutLog
+		want []record // Records set by the last log
+	}{
+		{
+			"first session is only a baseline",
+			[]database.WorkoutLog{session(0, "Squat", set{5, 100})},
+			nil,
+		},
+		{
+			"heavier weight",
+			[]database.WorkoutLog{session(0, "Squat", set{5, 100}), session(2, "Squat", set{1, 110})},
+			[]record{{RecordHeaviestWeight, 110, 1, 100}},
+		},
+		{
+			"heavier weight and better e1RM",
+			[]database.WorkoutLog{session(0, "Squat", set{5, 100}), session(2, "Squat", set{5, 105})},
+			[]record{{RecordHeaviestWeight, 105, 5, 100}, {RecordBestE1RM, 105, 5, 116.7}},
+		},
+		{
+			"more reps at a weight already lifted",
+			[]database.WorkoutLog{session(0, "Bench", set{5, 60}, set{3, 80}), session(2, "Bench", set{8, 60})},
+			[]record{{RecordMostReps, 60, 8, 5}},
+		},
+		{
+			"more reps at a new weight is not a reps record",
+			[]database.WorkoutLog{session(0, "Bench", set{5, 60}), session(2, "Bench", set{10, 55})},
+			[]record{{RecordBestE1RM, 55, 10, 70}},
+		},
+		{
+			"matching the best is not a record",
+			[]database.WorkoutLog{session(0, "Row", set{5, 70}), session(2, "row ", set{5, 70})},
+			nil,
+		},
+		{
+			"history is compared in date order",
+			[]database.WorkoutLog{session(4, "Squat", set{5, 90}), session(0, "Squat", set{5, 100})},
+			nil,
+		},
+		{
+			"different exercises don't compete",
+			[]database.WorkoutLog{session(0, "Squat", set{5, 140}), session(2, "Deadlift", set{5, 120})},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest := sortedByDate(tt.logs)[len(tt.logs)-1]
+			got := DetectRecords(tt.logs)[latest.ID]
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d records %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Kind != w.kind || g.Weight != w.weight || g.Reps != w.reps || g.Previous != w.previous {
+					t.Errorf("record %d = %s %v×%d (was %v), want %s %v×%d (was %v)",
+						i, g.Kind, g.Weight, g.Reps, g.Previous, w.kind, w.weight, w.reps, w.previous)
+				}
+				if g.LogID != latest.ID || g.E1RM != E1RM(w.weight, w.reps) {
+					t.Errorf("record %d has log %v and e1RM %v", i, g.LogID, g.E1RM)
+				}
+			}
+		})
+	}
+}
+
+func TestExerciseProgress(t *testing.T) {
+	logs := []database.WorkoutLog{
+		session(0, "Squat", set{5, 100}, set{5, 100}),
+		session(2, "Squat", set{5, 105}),
+		session(9, "SQUAT", set{3, 110}, set{8, 60}),
+		session(10, "Bench", set{5, 80}),
+	}
+	p := ExerciseProgress(logs, "squat")
+
+	if p.Exercise != "SQUAT" {
+		t.Errorf("exercise name = %q, want the latest spelling", p.Exercise)
+	}
+	if len(p.Sessions) != 3 {
+		t.Fatalf("got %d sessions, want 3", len(p.Sessions))
+	}
+	first := p.Sessions[0]
+	if first.Sets != 2 || first.Reps != 10 || first.Volume != 1000 || first.TopWeight != 100 || first.BestE1RM != 116.7 {
+		t.Errorf("first session = %+v", first)
+	}
+	if last := p.Sessions[2]; last.TopWeight != 110 || last.BestE1RM != 121 || last.Volume != 810 {
+		t.Errorf("last session = %+v", last)
+	}
+
+	wantWeeks := []Week{
+		{WeekStart: logs[0].Date, Sessions: 2, Volume: 1525},
+		{WeekStart: logs[0].Date.AddDate(0, 0, 7), Sessions: 1, Volume: 810},
+	}
+	if len(p.Weekly) != len(wantWeeks) {
+		t.Fatalf("weekly = %+v", p.Weekly)
+	}
+	for i, w := range wantWeeks {
+		if !p.Weekly[i].WeekStart.Equal(w.WeekStart) || p.Weekly[i].Sessions != w.Sessions || p.Weekly[i].Volume != w.Volume {
+			t.Errorf("week %d = %+v, want %+v", i, p.Weekly[i], w)
+		}
+	}
+
+	// 110×3 is the heaviest, but 105×5 still has the better e1RM
+	bests := map[string]float64{}
+	for _, r := range p.Bests {
+		bests[r.Kind] = r.Weight
+	}
+	if len(p.Bests) != 2 || bests[RecordHeaviestWeight] != 110 || bests[RecordBestE1RM] != 105 {
+		t.Errorf("bests = %+v", p.Bests)
+	}
+}
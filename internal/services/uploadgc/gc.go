@@ -0,0 +1,173 @@
+// Package uploadgc finds stored upload files that no row references anymore and deletes
+// them once they've been orphaned for longer than a grace period.
+package uploadgc
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"ohabits/internal/database"
+	"ohabits/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// urlPrefix is how stored files are referenced in the DB (see handlers.uploadURL)
+const urlPrefix = "/uploads/"
+
+// DefaultGracePeriod protects uploads whose row is still being written and
+// recently deleted items the user may still restore from a synced device
+const DefaultGracePeriod = 72 * time.Hour
+
+// Collector reconciles the upload store with the database
+type Collector struct {
+	DB          *database.DB
+	Store       storage.BlobStore
+	GracePeriod time.Duration
+	DryRun      bool // Report orphans without deleting anything
+}
+
+// Report summarises one run
+type Report struct {
+	Scanned         int                  // Objects looked at in the store
+	Orphans         []storage.ObjectInfo // Unreferenced objects older than the grace period
+	OrphanBytes     int64
+	StaleBlogImages int // Blog image rows whose marker was removed from the post
+	Deleted         int
+	Failed          int
+}
+
+// Run performs one collection pass
+func (gc *Collector) Run(ctx context.Context) (*Report, error) {
+	grace := gc.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	cutoff := time.Now().Add(-grace)
+	report := &Report{}
+
+	// Blog images removed from their post keep a live row; retire those first
+	// so their files stop being referenced
+	stale, err := gc.DB.GetUnreferencedBlogImages(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	report.StaleBlogImages = len(stale)
+
+	// Their files are collected right away: the post has already been
+	// unchanged for the whole grace period
+	retired := make(map[string]bool)
+	for _, img := range stale {
+		if !gc.DryRun {
+			if _, err := gc.DB.DeleteBlogImage(ctx, img.ID, img.UserID); err != nil {
+				log.Printf("[uploadgc] failed to retire blog image %s: %v", img.ID, err)
+				report.Failed++
+				continue
+			}
+		}
+		for _, url := range append(img.Variants.URLs(), img.OriginalPath, derefString(img.ThumbnailPath)) {
+			retired[url] = true
+		}
+	}
+
+	refs, err := gc.DB.GetUploadReferences(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := findOrphans(ctx, gc.Store, refs, retired, cutoff, report); err != nil {
+		return nil, err
+	}
+
+	for _, obj := range report.Orphans {
+		if gc.DryRun {
+			continue
+		}
+		if err := gc.Store.Delete(ctx, obj.Key); err != nil {
+			log.Printf("[uploadgc] failed to delete %s: %v", obj.Key, err)
+			report.Failed++
+			continue
+		}
+		report.Deleted++
+	}
+
+	return report, nil
+}
+
+// RunEvery runs the collector on an interval until ctx is cancelled
+func (gc *Collector) RunEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := gc.Run(ctx)
+			if err != nil {
+				log.Printf("[uploadgc] run failed: %v", err)
+				continue
+			}
+			if len(report.Orphans) > 0 || report.StaleBlogImages > 0 {
+				log.Printf("[uploadgc] scanned %d, orphans %d (%d bytes), stale blog images %d, deleted %d, failed %d",
+					report.Scanned, len(report.Orphans), report.OrphanBytes, report.StaleBlogImages, report.Deleted, report.Failed)
+			}
+		}
+	}
+}
+
+// findOrphans adds the managed objects that no row references and that are
+// older than cutoff to the report; retired files are orphans at any age
+func findOrphans(ctx context.Context, store storage.BlobStore, refs, retired map[string]bool, cutoff time.Time, report *Report) error {
+	return store.List(ctx, "", func(obj storage.ObjectInfo) error {
+		if !isManagedKey(obj.Key) {
+			return nil
+		}
+		report.Scanned++
+
+		url := urlPrefix + obj.Key
+		if refs[url] && !retired[url] {
+			return nil
+		}
+		if obj.ModTime.After(cutoff) && !retired[url] {
+			return nil // Upload may still be waiting for its row
+		}
+
+		report.Orphans = append(report.Orphans, obj)
+		report.OrphanBytes += obj.Size
+		return nil
+	})
+}
+
+// isManagedKey limits the collector to the layouts the app writes:
+// <user_id>/..., blog/<user_id>/... and avatars/...
+func isManagedKey(key string) bool {
+	first, rest, ok := strings.Cut(key, "/")
+	if !ok || rest == "" {
+		return false
+	}
+	switch first {
+	case "avatars":
+		return true
+	case "blog":
+		userDir, _, ok := strings.Cut(rest, "/")
+		return ok && isUUID(userDir)
+	default:
+		return isUUID(first)
+	}
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
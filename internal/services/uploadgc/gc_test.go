@@ -0,0 +1,103 @@
+package uploadgc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"ohabits/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestIsManagedKey(t *testing.T) {
+	user := uuid.NewString()
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{user + "/2025-03-01/a.jpg", true},
+		{"blog/" + user + "/a.jpg", true},
+		{"avatars/" + user + ".jpg", true},
+		{user, false},
+		{user + "/", false},
+		{"blog/a.jpg", false},
+		{"blog/not-a-user/a.jpg", false},
+		{"backups/db.sql", false},
+		{".upload-123", false},
+		{"README", false},
+	}
+	for _, tt := range tests {
+		if got := isManagedKey(tt.key); got != tt.want {
+			t.Errorf("isManagedKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store := storage.NewLocalStore(root)
+	now := time.Now()
+	cutoff := now.Add(-DefaultGracePeriod)
+	old := cutoff.Add(-time.Hour)
+
+	user := uuid.NewString()
+	files := map[string]time.Time{
+		user + "/referenced.jpg":            old,
+		user + "/orphan.jpg":                old,
+		user + "/new.jpg":                   now, // Row may not be written yet
+		user + "/retired.jpg":               now, // Referenced, but its blog image was retired
+		"blog/" + user + "/orphan.jpg":      old,
+		"avatars/" + user + ".jpg":          old,
+		"backups/db.sql":                    old, // Not an upload layout
+		"blog/not-a-user/a.jpg":             old,
+		"avatars/" + user + "-previous.jpg": old,
+	}
+	for key, modTime := range files {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(key)), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs := map[string]bool{
+		urlPrefix + user + "/referenced.jpg":   true,
+		urlPrefix + user + "/retired.jpg":      true,
+		urlPrefix + "avatars/" + user + ".jpg": true,
+	}
+	retired := map[string]bool{urlPrefix + user + "/retired.jpg": true}
+
+	report := &Report{}
+	if err := findOrphans(ctx, store, refs, retired, cutoff, report); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, obj := range report.Orphans {
+		got = append(got, obj.Key)
+	}
+	slices.Sort(got)
+	want := []string{
+		"avatars/" + user + "-previous.jpg",
+		"blog/" + user + "/orphan.jpg",
+		user + "/orphan.jpg",
+		user + "/retired.jpg",
+	}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("orphans = %v, want %v", got, want)
+	}
+	if report.Scanned != 7 {
+		t.Errorf("Scanned = %d, want 7 managed objects", report.Scanned)
+	}
+	if report.OrphanBytes != int64(len(want)) {
+		t.Errorf("OrphanBytes = %d, want %d", report.OrphanBytes, len(want))
+	}
+}
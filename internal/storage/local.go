@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps objects as files under a root directory
+type LocalStore struct {
+	Root string
+}
+
+// NewLocalStore creates a store rooted at dir (default "uploads")
+func NewLocalStore(dir string) *LocalStore {
+	if dir == "" {
+		dir = "uploads"
+	}
+	return &LocalStore{Root: dir}
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	clean, ok := CleanKey(key)
+	if !ok {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes to a temporary file and renames it so readers never see a partial file
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ObjectInfo{}, ErrNotFound
+		}
+		return nil, ObjectInfo{}, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	return f, localInfo(key, stat), nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	stat, err := os.Stat(p)
+	if err != nil || stat.IsDir() {
+		if err == nil || errors.Is(err, fs.ErrNotExist) {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, err
+	}
+
+	return localInfo(key, stat), nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) DeletePrefix(ctx context.Context, prefix string) error {
+	// A directory prefix ("<user_id>/") can be removed in one go
+	if strings.HasSuffix(prefix, "/") {
+		p, err := s.path(prefix)
+		if err != nil {
+			return err
+		}
+		return os.RemoveAll(p)
+	}
+
+	return s.List(ctx, prefix, func(obj ObjectInfo) error {
+		return s.Delete(ctx, obj.Key)
+	})
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	// Walk only the deepest directory that can contain matching keys
+	dir := s.Root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		p, err := s.path(prefix[:i])
+		if err != nil {
+			return err
+		}
+		dir = p
+	}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		return fn(localInfo(key, stat))
+	})
+	return err
+}
+
+func localInfo(key string, stat fs.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ETag:        fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size()),
+	}
+}
@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is sha256("") for requests without a body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Store talks to any S3-compatible API (AWS S3, MinIO, R2) using SigV4 over net/http
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+// NewS3Store validates the config and creates an S3 store
+func NewS3Store(cfg Config) (*S3Store, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("storage: S3_ENDPOINT and S3_BUCKET are required for the s3 backend")
+	}
+	if cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+		return nil, errors.New("storage: S3_ACCESS_KEY and S3_SECRET_KEY are required for the s3 backend")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.S3Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", cfg.S3Endpoint)
+	}
+
+	region := cfg.S3Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    cfg.S3Bucket,
+		accessKey: cfg.S3AccessKey,
+		secretKey: cfg.S3SecretKey,
+		pathStyle: cfg.S3PathStyle,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	clean, ok := CleanKey(key)
+	if !ok {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, clean, nil, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, true)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	clean, ok := CleanKey(key)
+	if !ok {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, clean, nil, nil)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	resp, err := s.do(req, false)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	return resp.Body, s3Info(clean, resp), nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	clean, ok := CleanKey(key)
+	if !ok {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	req, err := s.newRequest(ctx, http.MethodHead, clean, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	resp, err := s.do(req, false)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+
+	return s3Info(clean, resp), nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	clean, ok := CleanKey(key)
+	if !ok {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, clean, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, false)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) DeletePrefix(ctx context.Context, prefix string) error {
+	// Collect first so deleting doesn't shift the listing pages
+	var keys []string
+	err := s.List(ctx, prefix, func(obj ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listBucketResult is the ListObjectsV2 response body
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.do(req, false)
+		if err != nil {
+			return err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("storage: decode list response: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			info := ObjectInfo{
+				Key:     obj.Key,
+				Size:    obj.Size,
+				ModTime: obj.LastModified,
+				ETag:    obj.ETag,
+			}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// newRequest builds an unsigned request for key (empty key = bucket root)
+func (s *S3Store) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = u.Path + "/" + s.bucket + "/" + key
+		u.RawPath = s.endpoint.EscapedPath() + "/" + s3Escape(s.bucket) + "/" + s3EscapePath(key)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+		u.RawPath = s.endpoint.EscapedPath() + "/" + s3EscapePath(key)
+	}
+	if query != nil {
+		u.RawQuery = s3EncodeQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// do signs and sends a request; non-2xx responses become errors (404 → ErrNotFound)
+func (s *S3Store) do(req *http.Request, unsignedPayload bool) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if unsignedPayload {
+		payloadHash = "UNSIGNED-PAYLOAD"
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("storage: s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// sign adds AWS Signature Version 4 headers
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Signed headers: host + every x-amz-* and content-type header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	sig := signV4(req.Method, req.URL.EscapedPath(), req.URL.RawQuery, headers, payloadHash,
+		s.secretKey, s.region, "s3", now)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, sig.scope, sig.signedHeaders, sig.signature,
+	))
+}
+
+// sigV4 is a Signature Version 4 signature with the intermediate strings it
+// was derived from
+type sigV4 struct {
+	canonicalRequest string
+	signedHeaders    string
+	scope            string
+	stringToSign     string
+	signature        string
+}
+
+// signV4 signs a request given its escaped path, canonical query string and
+// the headers to sign (lower-case names)
+func signV4(method, path, query string, headers map[string]string, payloadHash, secretKey, region, service string, now time.Time) sigV4 {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	var sig sigV4
+	sig.signedHeaders = strings.Join(names, ";")
+	sig.canonicalRequest = strings.Join([]string{
+		method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		sig.signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	sig.scope = day + "/" + region + "/" + service + "/aws4_request"
+	sig.stringToSign = strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		sig.scope,
+		hexSHA256(sig.canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	sig.signature = hex.EncodeToString(hmacSHA256(key, sig.stringToSign))
+	return sig
+}
+
+func s3Info(key string, resp *http.Response) ObjectInfo {
+	info := ObjectInfo{
+		Key:         key,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+	}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	return info
+}
+
+// s3EscapePath URI-encodes every path segment the way SigV4 expects (RFC 3986, "/" kept)
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = s3Escape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3EncodeQuery encodes a query string with sorted keys and RFC 3986 escaping
+func s3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
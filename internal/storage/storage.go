@@ -0,0 +1,85 @@
+// Package storage stores uploaded files (daily images, blog images, task attachments, avatars)
+// behind a BlobStore so the server isn't tied to a local disk.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Backends
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+	ETag        string
+}
+
+// BlobStore is a flat key/value store for uploaded files
+// Keys are slash-separated paths relative to the uploads root, e.g. "<user_id>/originals/x.jpg"
+type BlobStore interface {
+	// Put stores r (size bytes) under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens an object for reading; the reader is an io.ReadSeeker when the backend supports it
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Stat returns object metadata without reading it
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every object whose key starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	// List calls fn for every object whose key starts with prefix
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend string // "local" (default) or "s3"
+
+	// Local filesystem
+	LocalDir string
+
+	// S3-compatible (AWS S3, MinIO, R2, ...)
+	S3Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // Use endpoint/bucket/key instead of bucket.endpoint/key (required by MinIO)
+}
+
+// New creates the configured BlobStore
+func New(cfg Config) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocalStore(cfg.LocalDir), nil
+	case BackendS3:
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
+
+// CleanKey normalizes a key and rejects keys that would escape the uploads root
+func CleanKey(key string) (string, bool) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(clean, "..") {
+		return "", false
+	}
+	return strings.TrimPrefix(clean, "/"), true
+}
@@ -0,0 +1,39 @@
+-- Migration: 005_note_templates
+-- Description: User-defined journaling templates with structured, queryable fields
+
+-- =====================================================
+-- قوالب المذكرات (Note Templates)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS note_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    icon TEXT NOT NULL DEFAULT '📝',
+    fields JSONB NOT NULL DEFAULT '[]'::jsonb, -- [{"key": "gratitude", "label": "...", "type": "list", "count": 3}]
+    display_order INTEGER NOT NULL DEFAULT 0,
+    is_deleted BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_templates_user ON note_templates(user_id);
+
+-- =====================================================
+-- قيم القوالب اليومية (Note Template Entries)
+-- =====================================================
+-- One row per template field per day, so fields can be queried later
+-- (e.g. all gratitude entries this month)
+CREATE TABLE IF NOT EXISTS note_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    template_id UUID NOT NULL REFERENCES note_templates(id) ON DELETE CASCADE,
+    field_key TEXT NOT NULL,
+    date DATE NOT NULL,
+    value TEXT NOT NULL, -- List fields store one item per line
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT note_entries_unique UNIQUE (template_id, field_key, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_entries_user_date ON note_entries(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_note_entries_field ON note_entries(user_id, template_id, field_key);
@@ -0,0 +1,21 @@
+-- Migration: 006_revisions
+-- Description: Edit history for daily notes and blog posts
+
+-- =====================================================
+-- سجل التعديلات (Revisions)
+-- =====================================================
+-- Each row is a prior version of a note or blog post, saved right before
+-- it was overwritten, along with where the overwriting change came from
+CREATE TABLE IF NOT EXISTS revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('note', 'markdown_note')),
+    entity_id UUID NOT NULL, -- notes.id or markdown_notes.id
+    title TEXT NOT NULL DEFAULT '', -- Blog posts only
+    content TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('web', 'api', 'sync')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revisions_entity ON revisions(entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_revisions_user ON revisions(user_id);
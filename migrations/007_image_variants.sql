@@ -0,0 +1,10 @@
+-- Migration: 007_image_variants
+-- Description: Responsive variants for daily and blog images
+
+-- =====================================================
+-- أحجام الصور (Image variants)
+-- =====================================================
+-- {"thumb": {"width": 320, "height": 320, "url": "/uploads/...", "webp_url": "/uploads/..."}, "medium": {...}, "full": {...}}
+-- Images uploaded before this migration keep '{}' and use original_path / thumbnail_path
+ALTER TABLE daily_images ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE blog_images ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '{}';
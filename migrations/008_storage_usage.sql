@@ -0,0 +1,17 @@
+-- Migration: 008_storage_usage
+-- Description: Per-user storage accounting for uploads
+
+-- =====================================================
+-- مساحة التخزين (Storage usage)
+-- =====================================================
+-- Bytes actually stored for an image across all its variants
+-- (size_bytes stays the size of the full image as shown to clients)
+ALTER TABLE daily_images ADD COLUMN IF NOT EXISTS stored_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE blog_images ADD COLUMN IF NOT EXISTS stored_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- Images uploaded before this migration: best estimate is the recorded file size
+UPDATE daily_images SET stored_bytes = size_bytes WHERE stored_bytes = 0;
+UPDATE blog_images SET stored_bytes = size_bytes WHERE stored_bytes = 0;
+
+-- Size of the current avatar file
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_bytes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Migration: 009_upload_gc
+-- Description: Deletion time for task attachments, so the upload GC can wait a grace period
+
+-- =====================================================
+-- تنظيف الملفات (Upload garbage collection)
+-- =====================================================
+-- Attachments deleted before this migration have no deletion time;
+-- the GC treats them as deleted at creation
+ALTER TABLE task_attachments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_blog_images_live ON blog_images(markdown_note_id) WHERE is_deleted = false;
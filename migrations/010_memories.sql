@@ -0,0 +1,13 @@
+-- Migration: 010_memories
+-- Description: Days the user chose not to see again in "on this day" memories
+
+-- =====================================================
+-- الذكريات (Memories)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS hidden_memories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, date)
+);
@@ -0,0 +1,39 @@
+-- Migration: 011_body_metrics
+-- Description: Dedicated body weight and measurements log with unit preference and goal weight
+
+-- =====================================================
+-- قياسات الجسم (Body metrics)
+-- =====================================================
+-- All values are stored in metric units (kg / cm); the unit preference only affects display
+CREATE TABLE IF NOT EXISTS body_metrics (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    weight_kg NUMERIC(6,2),
+    body_fat_pct NUMERIC(4,1),
+    waist_cm NUMERIC(5,1),
+    chest_cm NUMERIC(5,1),
+    hips_cm NUMERIC(5,1),
+    arm_cm NUMERIC(5,1),
+    thigh_cm NUMERIC(5,1),
+    neck_cm NUMERIC(5,1),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_body_metrics_user_date ON body_metrics (user_id, date);
+
+-- One row per user: display unit and goal weight
+CREATE TABLE IF NOT EXISTS body_metric_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    unit TEXT NOT NULL DEFAULT 'kg' CHECK (unit IN ('kg', 'lb')),
+    goal_weight_kg NUMERIC(6,2),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Carry over weights recorded on workout logs
+INSERT INTO body_metrics (user_id, date, weight_kg)
+SELECT user_id, date, weight FROM workout_logs WHERE weight > 0
+ON CONFLICT (user_id, date) DO NOTHING;
@@ -0,0 +1,31 @@
+-- Migration: 012_training_programs
+-- Description: Multi-week training programs with weekday or rotation schedules and progression rules
+
+-- =====================================================
+-- البرامج التدريبية (Training programs)
+-- =====================================================
+-- slots is a JSON array of workout IDs (null = rest day):
+--   weekly   -> 7 entries, Sunday first
+--   rotation -> any length, cycled day by day from start_date (e.g. A / B / rest)
+CREATE TABLE IF NOT EXISTS training_programs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    schedule_type TEXT NOT NULL DEFAULT 'weekly' CHECK (schedule_type IN ('weekly', 'rotation')),
+    slots JSONB NOT NULL DEFAULT '[]',
+    weeks INTEGER NOT NULL DEFAULT 0,                -- Program length, 0 = open-ended
+    deload_every INTEGER NOT NULL DEFAULT 0,         -- Every Nth week is a deload, 0 = never
+    deload_percent INTEGER NOT NULL DEFAULT 60,      -- Target weight during deload weeks (% of normal)
+    progression_increment_kg NUMERIC(5,2) NOT NULL DEFAULT 0, -- Added when every set hit its target reps, 0 = no progression
+    start_date DATE NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_training_programs_user ON training_programs (user_id);
+
+-- Only one active program per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_training_programs_one_active
+    ON training_programs (user_id) WHERE is_active;
@@ -0,0 +1,30 @@
+-- Migration: 013_exercise_library
+-- Description: Per-user exercise catalogue with muscle groups, equipment and aliases
+
+-- =====================================================
+-- مكتبة التمارين (Exercise library)
+-- =====================================================
+-- Workout templates and logs link to entries through "exercise_id" inside their
+-- exercises JSON; names and aliases are matched case-insensitively
+CREATE TABLE IF NOT EXISTS exercise_library (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    name_ar TEXT NOT NULL DEFAULT '',
+    muscle_group TEXT NOT NULL,                       -- Primary muscle group (chest, back, quads, ...)
+    secondary_muscles TEXT[] NOT NULL DEFAULT '{}',
+    equipment TEXT NOT NULL DEFAULT 'other',          -- barbell, dumbbell, machine, cable, bodyweight, kettlebell, band, other
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercise_library_user_name
+    ON exercise_library (user_id, lower(name));
+
+-- Users whose library has been seeded with the default lifts, so deleting
+-- every entry doesn't bring the defaults back
+CREATE TABLE IF NOT EXISTS exercise_library_seeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    seeded_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
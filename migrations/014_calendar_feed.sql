@@ -0,0 +1,16 @@
+-- Migration: 014_calendar_feed
+-- Description: Secret-token iCalendar subscription feed per user
+
+-- =====================================================
+-- اشتراك الرزنامة (Calendar feed)
+-- =====================================================
+-- The token is the only credential for the feed URL; rotating it replaces
+-- the token so previously shared URLs stop working
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    include_medications BOOLEAN NOT NULL DEFAULT false,
+    include_tasks BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    rotated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
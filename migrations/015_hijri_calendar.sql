@@ -0,0 +1,20 @@
+-- Migration: 015_hijri_calendar
+-- Description: Hijri (Umm al-Qura) yearly recurrence for calendar events and the Islamic holidays overlay
+
+-- =====================================================
+-- التقويم الهجري (Hijri calendar)
+-- =====================================================
+-- Recurring events repeat on the Gregorian month/day by default; 'hijri'
+-- events repeat on the Hijri month/day of event_date (converted in Go)
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS calendar_type TEXT NOT NULL DEFAULT 'gregorian'
+    CHECK (calendar_type IN ('gregorian', 'hijri'));
+
+CREATE INDEX IF NOT EXISTS idx_calendar_events_hijri ON calendar_events (user_id)
+    WHERE calendar_type = 'hijri' AND is_deleted = false;
+
+-- One row per user: whether built-in Islamic holidays are shown alongside events
+CREATE TABLE IF NOT EXISTS calendar_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    show_islamic_holidays BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,25 @@
+-- Migration: 016_prayer_times
+-- Description: Prayer-time anchors for habits and medication doses, and per-user prayer time settings
+
+-- =====================================================
+-- أوقات الصلاة (Prayer times)
+-- =====================================================
+-- Prayer times are computed in Go from the stored coordinates (no network);
+-- the defaults are Kuwait City with the Kuwaiti Awqaf method
+CREATE TABLE IF NOT EXISTS prayer_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    latitude DOUBLE PRECISION NOT NULL DEFAULT 29.3759 CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION NOT NULL DEFAULT 47.9774 CHECK (longitude BETWEEN -180 AND 180),
+    method TEXT NOT NULL DEFAULT 'kuwait',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- A habit done around a prayer, e.g. "Quran after Fajr" = ('fajr', 0);
+-- prayer_offset is in minutes, negative for before the prayer
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS prayer_anchor TEXT
+    CHECK (prayer_anchor IN ('fajr', 'sunrise', 'dhuhr', 'asr', 'maghrib', 'isha'));
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS prayer_offset INTEGER NOT NULL DEFAULT 0;
+
+-- One {"prayer", "offset_minutes"} entry per dose, in dose order; "prayer" is
+-- empty for doses that are not anchored
+ALTER TABLE medications ADD COLUMN IF NOT EXISTS dose_anchors JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,34 @@
+-- Migration: 017_calendar_recurrence
+-- Description: RFC 5545 recurrence rules for calendar events with per-occurrence edits and cancellations
+
+-- =====================================================
+-- قواعد التكرار (Recurrence rules)
+-- =====================================================
+-- An RRULE such as 'FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU', expanded in Go.
+-- NULL keeps the original meaning of is_recurring: yearly on the
+-- Gregorian or Hijri month/day of event_date.
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS rrule TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_calendar_events_rrule ON calendar_events (user_id)
+    WHERE rrule IS NOT NULL AND is_deleted = false;
+
+-- =====================================================
+-- استثناءات التكرار (Occurrence exceptions)
+-- =====================================================
+-- One row per changed occurrence of a series, keyed by the date the rule
+-- puts it on: either cancelled (EXDATE) or overridden (moved, renamed, notes)
+CREATE TABLE IF NOT EXISTS calendar_event_exceptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES calendar_events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    is_cancelled BOOLEAN NOT NULL DEFAULT false,
+    title TEXT,
+    event_date DATE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (event_id, occurrence_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_event_exceptions_user ON calendar_event_exceptions (user_id);
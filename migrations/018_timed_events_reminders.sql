@@ -0,0 +1,41 @@
+-- Migration: 018_timed_events_reminders
+-- Description: Optional start/end times for calendar events, per-event reminders and their delivery queue
+
+-- =====================================================
+-- أوقات المناسبات (Event times)
+-- =====================================================
+-- An event without start_time is all-day. Times are wall-clock times in
+-- time_zone (an IANA name; NULL = Asia/Kuwait) on each occurrence's date
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS start_time TIME;
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS end_time TIME;
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS time_zone TEXT;
+
+-- Reminder offsets in minutes before the start, e.g. [60, 4320] = an hour and
+-- three days before; all-day events count from 09:00 on the day
+ALTER TABLE calendar_events ADD COLUMN IF NOT EXISTS reminders JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS idx_calendar_events_reminders ON calendar_events (user_id)
+    WHERE reminders <> '[]' AND is_deleted = false;
+
+-- =====================================================
+-- إشعارات المناسبات (Event notifications)
+-- =====================================================
+-- Reminders that came due, queued by the scheduler for delivery. One row per
+-- occurrence and offset, so a reminder is queued once however often it runs;
+-- delivered_at is set once a client has shown or dismissed it
+CREATE TABLE IF NOT EXISTS event_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES calendar_events(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    remind_minutes INTEGER NOT NULL,
+    fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (event_id, occurrence_date, remind_minutes)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_notifications_pending ON event_notifications (user_id, fire_at)
+    WHERE delivered_at IS NULL;
@@ -0,0 +1,12 @@
+-- Migration: 019_storage_reservations
+-- Description: Reserve upload bytes against the storage quota while an upload is processed
+
+-- =====================================================
+-- حجز مساحة التخزين (Storage reservations)
+-- =====================================================
+-- Bytes of uploads in progress. An upload reserves its size in one conditional
+-- UPDATE before anything is stored and releases it once its row is saved (or
+-- it failed), so parallel uploads can't overshoot the quota together.
+-- storage_reserved_at lets a reservation left by a crashed upload expire.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_reserved_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_reserved_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,16 @@
+-- Migration: 020_task_completed_at
+-- Description: Record when a project task was completed, for the weekly and monthly review
+
+-- =====================================================
+-- وقت إنجاز المهمة (Task completion time)
+-- =====================================================
+-- Set when a task is marked completed and cleared when it is reopened. Tasks
+-- completed before this migration only have their last update time, so that
+-- stands in for them.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE tasks SET completed_at = updated_at
+WHERE completed = true AND completed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_completed_at ON tasks (user_id, completed_at)
+WHERE completed_at IS NOT NULL;